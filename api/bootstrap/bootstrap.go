@@ -6,6 +6,7 @@ import (
 
     stripeapp "github.com/tbeaudouin05/stripe-trellai/api/services/stripe/app"
     stripegw "github.com/tbeaudouin05/stripe-trellai/api/services/stripe/gateway/stripe"
+    "github.com/tbeaudouin05/stripe-trellai/api/services/stripe/notifier"
     "github.com/tbeaudouin05/stripe-trellai/api/config"
     "github.com/tbeaudouin05/stripe-trellai/api/database"
 )
@@ -34,7 +35,11 @@ func Init() error {
 
     stripegw.SetKey(config.AppConfig.StripeSecretKey)
 
-    stripeService = stripeapp.NewService(stripegw.New())
+    alerts, err := notifier.New(config.AppConfig)
+    if err != nil {
+        return fmt.Errorf("failed to create alert notifier: %w", err)
+    }
+    stripeService = stripeapp.NewServiceWithNotifier(stripegw.New(), alerts)
     return nil
 }
 
@@ -2,10 +2,13 @@ package config
 
 import (
 	"fmt"
+	"math/big"
 	"os"
 	"path/filepath"
 	"reflect"
+	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
@@ -16,10 +19,67 @@ var AppConfig *Config
 // Config holds the application configuration
 type Config struct {
 	DatabaseURL         string
+	// Optional direct (unpooled) connection for LISTEN/NOTIFY between instances; defaults to DatabaseURL
+	PubSubDatabaseURL   string
 	StripeSecretKey     string
 	StripeWebhookSecret string
 	CreditUnitsPerDollar string
+	// Optional per-currency units table, e.g. "eur:2_100_000,jpy:13_500" (units per major unit)
+	CreditUnitsPerCurrency string
+	// Optional one-time credit packs, e.g. "starter:usd:500:1_000_000" (id:currency:amount in minor units:units)
+	CreditPacks string
+	// Optional per-feature weights, e.g. "image_generation:5,email_draft:1" (feature:units counted per unit)
+	FeatureWeights string
+	// Optional admin API tokens, e.g. "support:s3cret,ops:t0ken" (name:token); admin RPCs are disabled when empty
+	AdminAPITokens string
+	// What to do when a user checks out while their subscription is still active:
+	// review (default), cancel_new_refund or cancel_old
+	DuplicateSubscriptionPolicy string
+	// Allowance usage percentages alerted once per billing period, e.g. "50,80,100" (default)
+	AllowanceAlertThresholds string
+	// Where allowance alerts go: log (default), webhook, smtp or none
+	AlertNotifier string
+	// Receives allowance alerts as JSON POSTs when ALERT_NOTIFIER=webhook
+	AlertWebhookURL string
+	// SMTP server (host:port), optional credentials and sender of allowance alert emails when ALERT_NOTIFIER=smtp
+	SMTPAddr       string
+	SMTPUsername   string
+	SMTPPassword   string
+	AlertEmailFrom string
+	// Optional endpoints of our own application receiving billing events, comma-separated, and the
+	// secret their HMAC-SHA256 signatures are computed with (required with endpoints)
+	OutgoingWebhookURLs   string
+	OutgoingWebhookSecret string
 	InitialFreeCredit   int
+	// Days before the initial free credit grant expires; 0 never expires
+	FreeCreditTTLDays int
+	// Free credit balance reset at the start of each calendar month (UTC); 0 disables refills
+	FreeCreditMonthlyRefill int
+	// Interval of the job expiring and refilling free credit; 0 disables it (reads still apply the policy)
+	FreeCreditRefreshIntervalSeconds int
+	// Free credit granted to a referred user, and to the referrer, once the referred user completes
+	// a checkout; referral codes are disabled when both are 0
+	ReferralRefereeUnits  int
+	ReferralReferrerUnits int
+	// Units granted per trial period to plans without trial_units_per_period metadata; 0 keeps the plan's allowance
+	TrialUnitsPerPeriod int
+	// Grace periods during which past_due (days after the failed renewal) and incomplete (hours after
+	// creation) subscriptions stay valid; 0 disables
+	GracePastDueDays     int
+	GraceIncompleteHours int
+	// How long per-plan allowances read from Stripe metadata are cached locally
+	PlanAllowanceCacheTTLSeconds int
+	// Interval of the metered usage reporter; 0 disables it
+	UsageReportIntervalSeconds int
+	// Interval of the job invoicing overage of ended billing periods; 0 disables it
+	OverageInvoiceIntervalSeconds int
+	// Interval of the job delivering outgoing webhooks, and delivery attempts before one is given up
+	WebhookDeliveryIntervalSeconds int
+	WebhookMaxAttempts             int
+	// Spending units StreamSpendingUnits writes per micro-batch
+	SpendingUnitsStreamBatchSize int
+	// Stripe lookups BatchVerifySubscriptionValidity runs at once
+	BatchVerifyConcurrency int
 	// Optional: base URL for running remote HTTP integration tests (e.g., https://api.example.com)
 	IntegrationBaseURL  string
 	// Server ports
@@ -56,9 +116,24 @@ func LoadConfig() (*Config, error) {
 		required bool
 	}{
 		{"DatabaseURL", "DATABASE_URL", "Database URL", true},
+		{"PubSubDatabaseURL", "PUBSUB_DATABASE_URL", "PubSub Database URL", false},
 		{"StripeSecretKey", "STRIPE_SECRET_KEY", "Stripe Secret Key", true},
 		{"StripeWebhookSecret", "STRIPE_WEBHOOK_SECRET", "Stripe Webhook Secret", true},
 		{"CreditUnitsPerDollar", "CREDIT_UNITS_PER_DOLLAR", "Credit Units Per Dollar", true},
+		{"CreditUnitsPerCurrency", "CREDIT_UNITS_PER_CURRENCY", "Credit Units Per Currency", false},
+		{"CreditPacks", "CREDIT_PACKS", "Credit Packs", false},
+		{"FeatureWeights", "FEATURE_WEIGHTS", "Feature Weights", false},
+		{"AdminAPITokens", "ADMIN_API_TOKENS", "Admin API Tokens", false},
+		{"DuplicateSubscriptionPolicy", "DUPLICATE_SUBSCRIPTION_POLICY", "Duplicate Subscription Policy", false},
+		{"AllowanceAlertThresholds", "ALLOWANCE_ALERT_THRESHOLDS", "Allowance Alert Thresholds", false},
+		{"AlertNotifier", "ALERT_NOTIFIER", "Alert Notifier", false},
+		{"AlertWebhookURL", "ALERT_WEBHOOK_URL", "Alert Webhook URL", false},
+		{"SMTPAddr", "SMTP_ADDR", "SMTP Address", false},
+		{"SMTPUsername", "SMTP_USERNAME", "SMTP Username", false},
+		{"SMTPPassword", "SMTP_PASSWORD", "SMTP Password", false},
+		{"AlertEmailFrom", "ALERT_EMAIL_FROM", "Alert Email From", false},
+		{"OutgoingWebhookURLs", "OUTGOING_WEBHOOK_URLS", "Outgoing Webhook URLs", false},
+		{"OutgoingWebhookSecret", "OUTGOING_WEBHOOK_SECRET", "Outgoing Webhook Secret", false},
 		// Optional integration base URL for remote tests
 		{"IntegrationBaseURL", "INTEGRATION_BASE_URL", "Integration Base URL", false},
 		// Optional server ports
@@ -86,7 +161,97 @@ func LoadConfig() (*Config, error) {
 		config.InitialFreeCredit = n
 	}
 
+	// Parse optional non-negative integer env vars
+	optionalInts := []struct {
+		field  *int
+		envVar string
+		def    int
+	}{
+		{&config.PlanAllowanceCacheTTLSeconds, "PLAN_ALLOWANCE_CACHE_TTL_SECONDS", 3600},
+		{&config.TrialUnitsPerPeriod, "TRIAL_UNITS_PER_PERIOD", 0},
+		{&config.GracePastDueDays, "GRACE_PAST_DUE_DAYS", 0},
+		{&config.GraceIncompleteHours, "GRACE_INCOMPLETE_HOURS", 0},
+		{&config.UsageReportIntervalSeconds, "USAGE_REPORT_INTERVAL_SECONDS", 0},
+		{&config.OverageInvoiceIntervalSeconds, "OVERAGE_INVOICE_INTERVAL_SECONDS", 0},
+		{&config.FreeCreditTTLDays, "FREE_CREDIT_TTL_DAYS", 0},
+		{&config.FreeCreditMonthlyRefill, "FREE_CREDIT_MONTHLY_REFILL", 0},
+		{&config.FreeCreditRefreshIntervalSeconds, "FREE_CREDIT_REFRESH_INTERVAL_SECONDS", 0},
+		{&config.ReferralRefereeUnits, "REFERRAL_REFEREE_UNITS", 0},
+		{&config.ReferralReferrerUnits, "REFERRAL_REFERRER_UNITS", 0},
+		{&config.WebhookDeliveryIntervalSeconds, "WEBHOOK_DELIVERY_INTERVAL_SECONDS", 15},
+		{&config.WebhookMaxAttempts, "WEBHOOK_MAX_ATTEMPTS", 8},
+		{&config.SpendingUnitsStreamBatchSize, "SPENDING_UNITS_STREAM_BATCH_SIZE", 500},
+		{&config.BatchVerifyConcurrency, "BATCH_VERIFY_CONCURRENCY", 8},
+	}
+	for _, v := range optionalInts {
+		*v.field = v.def
+		raw := os.Getenv(v.envVar)
+		if raw == "" {
+			continue
+		}
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid %s, must be a non-negative integer: %q", v.envVar, raw)
+		}
+		*v.field = n
+	}
+
+	switch config.DuplicateSubscriptionPolicy {
+	case "":
+		config.DuplicateSubscriptionPolicy = "review"
+	case "review", "cancel_new_refund", "cancel_old":
+	default:
+		return nil, fmt.Errorf("invalid DUPLICATE_SUBSCRIPTION_POLICY, must be review, cancel_new_refund or cancel_old: %q", config.DuplicateSubscriptionPolicy)
+	}
+
+	if _, ok := ParseRate(config.CreditUnitsPerDollar); !ok {
+		return nil, fmt.Errorf("invalid CREDIT_UNITS_PER_DOLLAR, must be a positive number: %q", config.CreditUnitsPerDollar)
+	}
+	if _, err := ParseCurrencyRates(config.CreditUnitsPerCurrency); err != nil {
+		return nil, err
+	}
+	if _, err := ParseFeatureWeights(config.FeatureWeights); err != nil {
+		return nil, err
+	}
+
+	if config.AllowanceAlertThresholds == "" {
+		config.AllowanceAlertThresholds = "50,80,100"
+	}
+	if _, err := ParseThresholds(config.AllowanceAlertThresholds); err != nil {
+		return nil, fmt.Errorf("invalid ALLOWANCE_ALERT_THRESHOLDS: %v", err)
+	}
+	switch config.AlertNotifier {
+	case "":
+		config.AlertNotifier = "log"
+	case "log", "none":
+	case "webhook":
+		if config.AlertWebhookURL == "" {
+			return nil, fmt.Errorf("ALERT_WEBHOOK_URL is required when ALERT_NOTIFIER=webhook")
+		}
+	case "smtp":
+		if config.SMTPAddr == "" || config.AlertEmailFrom == "" {
+			return nil, fmt.Errorf("SMTP_ADDR and ALERT_EMAIL_FROM are required when ALERT_NOTIFIER=smtp")
+		}
+	default:
+		return nil, fmt.Errorf("invalid ALERT_NOTIFIER, must be log, webhook, smtp or none: %q", config.AlertNotifier)
+	}
+	if len(SplitList(config.OutgoingWebhookURLs)) > 0 && config.OutgoingWebhookSecret == "" {
+		return nil, fmt.Errorf("OUTGOING_WEBHOOK_SECRET is required when OUTGOING_WEBHOOK_URLS is set")
+	}
+	if config.WebhookMaxAttempts == 0 {
+		return nil, fmt.Errorf("invalid WEBHOOK_MAX_ATTEMPTS, must be at least 1")
+	}
+	if config.SpendingUnitsStreamBatchSize == 0 {
+		return nil, fmt.Errorf("invalid SPENDING_UNITS_STREAM_BATCH_SIZE, must be at least 1")
+	}
+	if config.BatchVerifyConcurrency == 0 {
+		return nil, fmt.Errorf("invalid BATCH_VERIFY_CONCURRENCY, must be at least 1")
+	}
+
 	// Defaults
+	if config.PubSubDatabaseURL == "" {
+		config.PubSubDatabaseURL = config.DatabaseURL
+	}
 	if config.HTTPPort == "" {
 		config.HTTPPort = "8080"
 	}
@@ -96,3 +261,92 @@ func LoadConfig() (*Config, error) {
 
 	return config, nil
 }
+
+// SplitList splits a comma-separated setting, dropping blank entries.
+func SplitList(raw string) []string {
+	var list []string
+	for _, part := range strings.Split(raw, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			list = append(list, part)
+		}
+	}
+	return list
+}
+
+// ParseThresholds parses a comma-separated list of percentages, e.g. "50,80,100", in ascending order.
+func ParseThresholds(raw string) ([]int, error) {
+	var thresholds []int
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		n, err := strconv.Atoi(strings.TrimSuffix(part, "%"))
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("%q is not a positive percentage", part)
+		}
+		thresholds = append(thresholds, n)
+	}
+	sort.Ints(thresholds)
+	return thresholds, nil
+}
+
+// ParseRate parses a positive decimal rate; underscores are allowed for readability.
+func ParseRate(raw string) (*big.Rat, bool) {
+	r, ok := new(big.Rat).SetString(strings.ReplaceAll(strings.TrimSpace(raw), "_", ""))
+	if !ok || r.Sign() <= 0 {
+		return nil, false
+	}
+	return r, true
+}
+
+// ParseCurrencyRates parses CREDIT_UNITS_PER_CURRENCY, a comma-separated "currency:rate" table,
+// e.g. "eur:2_100_000,jpy:13_500". Currencies are lowercased.
+func ParseCurrencyRates(raw string) (map[string]*big.Rat, error) {
+	rates := make(map[string]*big.Rat)
+	for _, entry := range SplitList(raw) {
+		cur, val, ok := strings.Cut(entry, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid CREDIT_UNITS_PER_CURRENCY entry %q, expected currency:rate", entry)
+		}
+		r, ok := ParseRate(val)
+		if !ok {
+			return nil, fmt.Errorf("invalid CREDIT_UNITS_PER_CURRENCY rate for %q: %q", cur, val)
+		}
+		rates[strings.ToLower(strings.TrimSpace(cur))] = r
+	}
+	return rates, nil
+}
+
+// ParseFeatureTable parses a comma-separated "feature:units" table, e.g.
+// "image_generation:5,email_draft:1_000". what names the setting in errors.
+func ParseFeatureTable(raw, what string) (map[string]int64, error) {
+	table := make(map[string]int64)
+	for _, entry := range SplitList(raw) {
+		feature, n, ok := strings.Cut(entry, ":")
+		feature = strings.TrimSpace(feature)
+		if !ok || feature == "" {
+			return nil, fmt.Errorf("invalid %s entry %q (want feature:units)", what, entry)
+		}
+		units, err := strconv.ParseInt(strings.ReplaceAll(strings.TrimSpace(n), "_", ""), 10, 64)
+		if err != nil || units < 0 {
+			return nil, fmt.Errorf("invalid %s units in %q", what, entry)
+		}
+		table[feature] = units
+	}
+	return table, nil
+}
+
+// ParseFeatureWeights parses FEATURE_WEIGHTS: how many units one unit of each feature counts for.
+func ParseFeatureWeights(raw string) (map[string]int64, error) {
+	weights, err := ParseFeatureTable(raw, "FEATURE_WEIGHTS")
+	if err != nil {
+		return nil, err
+	}
+	for feature, w := range weights {
+		if w == 0 {
+			return nil, fmt.Errorf("invalid FEATURE_WEIGHTS weight for %q: must be > 0", feature)
+		}
+	}
+	return weights, nil
+}
@@ -0,0 +1,27 @@
+package config
+
+import "testing"
+
+func TestLoadConfig_RejectsInvalidTables(t *testing.T) {
+	for _, env := range []map[string]string{
+		{"CREDIT_UNITS_PER_DOLLAR": "many"},
+		{"CREDIT_UNITS_PER_CURRENCY": "eur"},
+		{"CREDIT_UNITS_PER_CURRENCY": "eur:-1"},
+		{"FEATURE_WEIGHTS": "image_generation:0"},
+		{"FEATURE_WEIGHTS": "image_generation"},
+	} {
+		t.Setenv("DATABASE_URL", "postgres://localhost/test")
+		t.Setenv("STRIPE_SECRET_KEY", "sk_test")
+		t.Setenv("STRIPE_WEBHOOK_SECRET", "whsec_test")
+		t.Setenv("INITIAL_FREE_CREDIT", "0")
+		t.Setenv("CREDIT_UNITS_PER_DOLLAR", "1_000")
+		t.Setenv("CREDIT_UNITS_PER_CURRENCY", "")
+		t.Setenv("FEATURE_WEIGHTS", "")
+		for k, v := range env {
+			t.Setenv(k, v)
+		}
+		if _, err := LoadConfig(); err == nil {
+			t.Errorf("LoadConfig accepted %v", env)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+// Package pubsub tells in-process subscribers that a topic changed. Changes published on one
+// instance reach the others through Postgres LISTEN/NOTIFY once Listen runs.
+package pubsub
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Channel is the Postgres channel topics are fanned out on.
+const Channel = "entitlements_changed"
+
+// resync wakes every subscriber; it is published locally when the listener reconnects, since
+// notifications sent while it was down are lost.
+const resync = "*"
+
+type subscriber struct{ ch chan struct{} }
+
+var (
+	mu     sync.Mutex
+	topics = make(map[string]map[*subscriber]struct{})
+	// bridge sends NOTIFY while Listen runs
+	bridge atomic.Pointer[sql.DB]
+)
+
+// Subscribe returns a channel receiving a value after any of the topics changed, and the function
+// ending the subscription. Changes arriving before the last one was received are coalesced.
+func Subscribe(keys ...string) (<-chan struct{}, func()) {
+	sub := &subscriber{ch: make(chan struct{}, 1)}
+	mu.Lock()
+	for _, key := range keys {
+		if topics[key] == nil {
+			topics[key] = make(map[*subscriber]struct{})
+		}
+		topics[key][sub] = struct{}{}
+	}
+	mu.Unlock()
+	var once sync.Once
+	return sub.ch, func() {
+		once.Do(func() {
+			mu.Lock()
+			defer mu.Unlock()
+			for _, key := range keys {
+				delete(topics[key], sub)
+				if len(topics[key]) == 0 {
+					delete(topics, key)
+				}
+			}
+		})
+	}
+}
+
+// Publish tells the topic's subscribers on every instance that it changed. Without a running
+// listener, or when NOTIFY fails, only this instance's subscribers are told.
+func Publish(key string) {
+	if db := bridge.Load(); db != nil {
+		_, err := db.Exec("SELECT pg_notify($1, $2)", Channel, key)
+		if err == nil {
+			return
+		}
+		slog.Warn("pg_notify failed, publishing locally", "err", err)
+	}
+	publishLocal(key)
+}
+
+func publishLocal(key string) {
+	mu.Lock()
+	defer mu.Unlock()
+	wake := func(subs map[*subscriber]struct{}) {
+		for sub := range subs {
+			select {
+			case sub.ch <- struct{}{}:
+			default:
+			}
+		}
+	}
+	if key == resync {
+		for _, subs := range topics {
+			wake(subs)
+		}
+		return
+	}
+	wake(topics[key])
+}
+
+// Listen relays notifications on Channel to this instance's subscribers until ctx is done, and
+// meanwhile publishes through NOTIFY on db. dsn must reach Postgres directly: LISTEN does not work
+// through a transaction pooler.
+func Listen(ctx context.Context, dsn string, db *sql.DB) {
+	l := pq.NewListener(dsn, time.Second, time.Minute, func(ev pq.ListenerEventType, err error) {
+		if err != nil {
+			slog.Warn("pubsub listener event", "event", ev, "err", err)
+		}
+	})
+	defer l.Close()
+	if err := l.Listen(Channel); err != nil {
+		slog.Error("pubsub listen failed, changes stay on this instance", "err", err)
+		return
+	}
+	bridge.Store(db)
+	defer bridge.Store(nil)
+	slog.Info("pubsub listening", "channel", Channel)
+	ping := time.NewTicker(90 * time.Second)
+	defer ping.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case n := <-l.Notify:
+			if n == nil {
+				publishLocal(resync)
+				continue
+			}
+			publishLocal(n.Extra)
+		case <-ping.C:
+			go func() { _ = l.Ping() }()
+		}
+	}
+}
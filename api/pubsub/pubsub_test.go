@@ -0,0 +1,72 @@
+package pubsub
+
+import (
+	"testing"
+	"time"
+)
+
+func received(ch <-chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	case <-time.After(50 * time.Millisecond):
+		return false
+	}
+}
+
+func TestPublish_WakesSubscribersOfTheTopic(t *testing.T) {
+	a, cancelA := Subscribe("user-a", "org-1")
+	defer cancelA()
+	b, cancelB := Subscribe("user-b")
+	defer cancelB()
+
+	Publish("org-1")
+	if !received(a) {
+		t.Fatalf("expected subscriber of org-1 to be woken")
+	}
+	if received(b) {
+		t.Fatalf("subscriber of another topic was woken")
+	}
+}
+
+func TestPublish_CoalescesUntilReceived(t *testing.T) {
+	ch, cancel := Subscribe("user-c")
+	defer cancel()
+
+	Publish("user-c")
+	Publish("user-c")
+	if !received(ch) {
+		t.Fatalf("expected a change")
+	}
+	if received(ch) {
+		t.Fatalf("expected changes to be coalesced")
+	}
+}
+
+func TestSubscribe_CancelStopsDelivery(t *testing.T) {
+	ch, cancel := Subscribe("user-d")
+	cancel()
+	cancel()
+
+	Publish("user-d")
+	if received(ch) {
+		t.Fatalf("cancelled subscriber was woken")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := topics["user-d"]; ok {
+		t.Fatalf("expected topic without subscribers to be dropped")
+	}
+}
+
+func TestResync_WakesEveryone(t *testing.T) {
+	a, cancelA := Subscribe("user-e")
+	defer cancelA()
+	b, cancelB := Subscribe("user-f")
+	defer cancelB()
+
+	publishLocal(resync)
+	if !received(a) || !received(b) {
+		t.Fatalf("expected every subscriber to be woken")
+	}
+}
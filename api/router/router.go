@@ -49,15 +49,10 @@ func NewRouter() http.Handler {
             http.Error(w, "signature verification failed", http.StatusBadRequest)
             return
         }
-        switch event.Type {
-        case "checkout.session.completed":
-            if err := bootstrap.GetStripeService().HandleCheckoutSessionCompleted(event); err != nil {
-                slog.Error("handle checkout.session.completed failed", "err", err)
-                http.Error(w, "handler error", http.StatusInternalServerError)
-                return
-            }
-        default:
-            slog.Info("Unhandled event type", "type", event.Type)
+        if err := grpcserver.DispatchEvent(bootstrap.GetStripeService(), event); err != nil {
+            slog.Error("handle webhook event failed", "type", event.Type, "err", err)
+            http.Error(w, "handler error", http.StatusInternalServerError)
+            return
         }
         w.WriteHeader(http.StatusOK)
     }); err != nil {
@@ -0,0 +1,32 @@
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Every runs fn once per interval until ctx is cancelled.
+// Errors are logged and do not stop the loop; a non-positive interval disables the job.
+func Every(ctx context.Context, name string, interval time.Duration, fn func() error) {
+	if interval <= 0 {
+		slog.Info("scheduled job disabled", slog.String("job", name))
+		return
+	}
+	slog.Info("scheduled job started", slog.String("job", name), slog.String("interval", interval.String()))
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			start := time.Now()
+			if err := fn(); err != nil {
+				slog.Error("scheduled job failed", slog.String("job", name), slog.String("duration", time.Since(start).String()), slog.String("error", err.Error()))
+				continue
+			}
+			slog.Info("scheduled job ran", slog.String("job", name), slog.String("duration", time.Since(start).String()))
+		}
+	}
+}
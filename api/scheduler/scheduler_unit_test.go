@@ -0,0 +1,43 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestEvery_RunsUntilCancelledAndSurvivesErrors(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var runs int32
+	done := make(chan struct{})
+	go func() {
+		Every(ctx, "test", 5*time.Millisecond, func() error {
+			if atomic.AddInt32(&runs, 1) >= 3 {
+				cancel()
+			}
+			return errors.New("boom")
+		})
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("Every did not return after cancellation")
+	}
+	if got := atomic.LoadInt32(&runs); got < 3 {
+		t.Fatalf("expected at least 3 runs, got %d", got)
+	}
+}
+
+func TestEvery_DisabledWithZeroInterval(t *testing.T) {
+	called := false
+	Every(context.Background(), "disabled", 0, func() error {
+		called = true
+		return nil
+	})
+	if called {
+		t.Fatalf("expected disabled job not to run")
+	}
+}
@@ -0,0 +1,102 @@
+package app
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/stripe/stripe-go"
+	"github.com/tbeaudouin05/stripe-trellai/api/config"
+	stripedb "github.com/tbeaudouin05/stripe-trellai/api/services/stripe/db"
+	"github.com/tbeaudouin05/stripe-trellai/api/services/stripe/notifier"
+)
+
+// alertEndpointPrefix marks the webhook_delivery rows of allowance alerts, which DeliverWebhooks
+// hands to the notifier instead of posting them.
+const alertEndpointPrefix = "notifier:"
+
+// checkAllowanceAlerts queues an alert for each ALLOWANCE_ALERT_THRESHOLDS percentage of its
+// allowance the account reached in the subscription's current period, once per threshold and
+// period. count is the account's units against the allowance in that period. Alerts are
+// delivered by DeliverWebhooks, which retries them like webhooks.
+func (s serviceImpl) checkAllowanceAlerts(account string, ua stripedb.UserAccount, sub stripe.Subscription, allowance entitlement, count int) error {
+	if s.notifier == nil || config.AppConfig == nil || allowance.Units <= 0 {
+		return nil
+	}
+	if _, off := s.notifier.(notifier.Discard); off {
+		return nil
+	}
+	thresholds, err := config.ParseThresholds(config.AppConfig.AllowanceAlertThresholds)
+	if err != nil || len(thresholds) == 0 {
+		return err
+	}
+	// Stripe provides seconds; spending units are in milliseconds.
+	start, end := sub.CurrentPeriodStart*1000, sub.CurrentPeriodEnd*1000
+
+	var email string
+	for _, threshold := range thresholds {
+		if int64(count)*100 < int64(threshold)*allowance.Units {
+			break
+		}
+		if email == "" && ua.StripeCustomerID != "" {
+			if cust, err := s.gw.GetCustomer(ua.StripeCustomerID); err == nil {
+				email = cust.Email
+			} else {
+				slog.Warn("error retrieving customer email for allowance alert", "err", err)
+			}
+		}
+		alert := notifier.Alert{
+			Type:             notifier.AlertTypeAllowanceThreshold,
+			UserExternalID:   account,
+			Email:            email,
+			ThresholdPercent: threshold,
+			Units:            int64(count),
+			Allowance:        allowance.Units,
+			PeriodStart:      start,
+			PeriodEnd:        end,
+		}
+		payload, err := json.Marshal(alert)
+		if err != nil {
+			return fmt.Errorf("error encoding allowance alert: %v", err)
+		}
+		sum := sha256.Sum256([]byte(fmt.Sprintf("%s:%d:%d", account, start, threshold)))
+		now := time.Now().UnixMilli()
+		_, err = stripedb.QueueAllowanceAlert(stripedb.AllowanceAlert{
+			UserExternalID:   account,
+			PeriodStart:      start,
+			ThresholdPercent: threshold,
+			Units:            int64(count),
+			Allowance:        allowance.Units,
+		}, stripedb.WebhookDelivery{
+			EventID:     "alert_" + hex.EncodeToString(sum[:16]),
+			EventType:   notifier.AlertTypeAllowanceThreshold,
+			EndpointURL: alertEndpoint(),
+			Payload:     string(payload),
+		}, now)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrDatabase, err)
+		}
+	}
+	return nil
+}
+
+// alertEndpoint is the endpoint_url of queued alerts: the notifier picked by ALERT_NOTIFIER.
+func alertEndpoint() string {
+	name := config.AppConfig.AlertNotifier
+	if name == "" {
+		name = "log"
+	}
+	return alertEndpointPrefix + name
+}
+
+// notifyAlert hands a queued alert to the notifier.
+func (s serviceImpl) notifyAlert(payload string) error {
+	var a notifier.Alert
+	if err := json.Unmarshal([]byte(payload), &a); err != nil {
+		return fmt.Errorf("error decoding allowance alert: %v", err)
+	}
+	return s.notifier.Notify(a)
+}
@@ -0,0 +1,126 @@
+package app
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	stripe "github.com/stripe/stripe-go"
+	"github.com/tbeaudouin05/stripe-trellai/api/config"
+	stripedb "github.com/tbeaudouin05/stripe-trellai/api/services/stripe/db"
+	"github.com/tbeaudouin05/stripe-trellai/api/services/stripe/notifier"
+)
+
+const alertsBoardID = "alerts-test-board"
+
+// recordingNotifier keeps the alerts it is given, failing while fail is set.
+type recordingNotifier struct {
+	alerts *[]notifier.Alert
+	fail   *bool
+}
+
+func (r recordingNotifier) Notify(a notifier.Alert) error {
+	if *r.fail {
+		return errors.New("notifier down")
+	}
+	*r.alerts = append(*r.alerts, a)
+	return nil
+}
+
+func Test_AllowanceAlerts_OncePerThresholdAndPeriod(t *testing.T) {
+	db, _ := setupSubTestDB(t)
+	hb := stripedb.HashExternalID(alertsBoardID)
+	clean := func() {
+		_, _ = db.Exec("DELETE FROM allowance_alert WHERE user_external_id = $1", hb)
+		_, _ = db.Exec("DELETE FROM webhook_delivery WHERE event_type = $1", notifier.AlertTypeAllowanceThreshold)
+		_, _ = db.Exec("DELETE FROM spending_unit WHERE user_external_id = $1", hb)
+		_, _ = db.Exec("DELETE FROM free_credit WHERE user_external_id = $1", hb)
+		_, _ = db.Exec("DELETE FROM user_account WHERE user_external_id = $1", hb)
+	}
+	clean()
+	defer clean()
+
+	orig := *config.AppConfig
+	t.Cleanup(func() { *config.AppConfig = orig })
+	config.AppConfig.AllowanceAlertThresholds = "50,80,100"
+	config.AppConfig.AlertNotifier = "log"
+	config.AppConfig.WebhookMaxAttempts = 8
+
+	if err := stripedb.UpsertUserAccount(alertsBoardID, "sub_alerts", "plan_alerts", "cust_alerts"); err != nil {
+		t.Fatalf("UpsertUserAccount failed: %v", err)
+	}
+	if _, err := db.Exec("INSERT INTO free_credit (user_external_id, credit) VALUES ($1, 0)", hb); err != nil {
+		t.Fatalf("Failed to insert free_credit: %v", err)
+	}
+	periodStart := time.Now().Add(-time.Hour).Unix()
+	plan := &stripe.Plan{ID: "plan_alerts", Metadata: map[string]string{PlanMetadataUnitsPerPeriod: "10"}}
+	gw := fakeGateway{
+		subs: map[string]stripe.Subscription{
+			"sub_alerts": {
+				ID:                 "sub_alerts",
+				Status:             stripe.SubscriptionStatusActive,
+				CurrentPeriodStart: periodStart,
+				CurrentPeriodEnd:   time.Now().Add(time.Hour).Unix(),
+				Items:              &stripe.SubscriptionItemList{Data: []*stripe.SubscriptionItem{{ID: "si_alerts", Plan: plan, Quantity: 1}}},
+			},
+		},
+		custs: map[string]stripe.Customer{"cust_alerts": {Email: "alerts@example.com"}},
+	}
+	var alerts []notifier.Alert
+	fail := false
+	svc := NewServiceWithNotifier(gw, recordingNotifier{alerts: &alerts, fail: &fail}).(serviceImpl)
+
+	// record waits for the background check, then runs the delivery job with every queued alert due
+	record := func(id string, amount int) {
+		t.Helper()
+		_, err := svc.AddSpendingUnits([]stripedb.SpendingUnit{
+			{ExternalID: id, UserExternalID: alertsBoardID, Amount: amount, CreatedAt: (periodStart + 60) * 1000},
+		})
+		assert.NoError(t, err)
+		svc.checks.wg.Wait()
+		if _, err := db.Exec("UPDATE webhook_delivery SET next_attempt_at = 0 WHERE event_type = $1 AND status = 'pending'", notifier.AlertTypeAllowanceThreshold); err != nil {
+			t.Fatalf("Failed to make alerts due: %v", err)
+		}
+		_, err = svc.DeliverWebhooks()
+		assert.NoError(t, err)
+	}
+	thresholds := func() []int {
+		var got []int
+		for _, a := range alerts {
+			got = append(got, a.ThresholdPercent)
+		}
+		return got
+	}
+
+	record("alerts-unit-1", 4)
+	assert.Empty(t, alerts)
+
+	record("alerts-unit-2", 1)
+	assert.Equal(t, []int{50}, thresholds())
+	assert.Equal(t, notifier.Alert{
+		Type:             notifier.AlertTypeAllowanceThreshold,
+		UserExternalID:   alertsBoardID,
+		Email:            "alerts@example.com",
+		ThresholdPercent: 50,
+		Units:            5,
+		Allowance:        10,
+		PeriodStart:      alerts[0].PeriodStart,
+		PeriodEnd:        alerts[0].PeriodEnd,
+	}, alerts[0])
+
+	// crossing a threshold again in the same period doesn't repeat it
+	record("alerts-unit-3", 1)
+	assert.Equal(t, []int{50}, thresholds())
+
+	// a failed delivery doesn't fail the recording and is retried by the delivery job
+	fail = true
+	record("alerts-unit-4", 2)
+	assert.Equal(t, []int{50}, thresholds())
+	fail = false
+	record("alerts-unit-5", 0)
+	assert.Equal(t, []int{50, 80}, thresholds())
+
+	record("alerts-unit-6", 5)
+	assert.Equal(t, []int{50, 80, 100}, thresholds())
+}
@@ -0,0 +1,368 @@
+package app
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stripe/stripe-go"
+	"github.com/tbeaudouin05/stripe-trellai/api/config"
+	stripedb "github.com/tbeaudouin05/stripe-trellai/api/services/stripe/db"
+)
+
+// PlanMetadataUnitsPerPeriod is the Stripe price (or product) metadata key holding
+// the number of units a single quantity of the plan grants per billing period.
+const PlanMetadataUnitsPerPeriod = "units_per_period"
+
+// PlanMetadataOverageUnitAmount is the Stripe price (or product) metadata key that opts a plan into
+// overage billing. Its value is the price of one unit past the allowance in the plan currency's
+// minor units; decimals are allowed (e.g., "0.05" for 0.05 cents per unit).
+const PlanMetadataOverageUnitAmount = "overage_unit_amount"
+
+// PlanMetadataTrialUnitsPerPeriod is the Stripe price (or product) metadata key holding the units a
+// single quantity of the plan grants while the subscription is trialing.
+const PlanMetadataTrialUnitsPerPeriod = "trial_units_per_period"
+
+// PlanMetadataFeatureLimits is the Stripe price (or product) metadata key holding per-feature limits,
+// e.g. "image_generation:100,email_draft:1_000": the units a single quantity of the plan lets each
+// feature use per billing period, within the overall allowance.
+const PlanMetadataFeatureLimits = "feature_limits"
+
+// planTerms are the billing terms a plan declares in its metadata.
+type planTerms struct {
+	Units    int64
+	HasUnits bool
+	// OverageUnitAmount is empty unless the plan bills usage past its allowance.
+	OverageUnitAmount string
+	TrialUnits        int64
+	HasTrialUnits     bool
+	// FeatureLimits is the normalized feature_limits metadata; empty when the plan defines none.
+	FeatureLimits string
+}
+
+// trial returns the terms applying while the subscription is trialing: trial_units_per_period,
+// else TRIAL_UNITS_PER_PERIOD when set, else the regular allowance. Trials never bill overage.
+func (t planTerms) trial() planTerms {
+	switch {
+	case t.HasTrialUnits:
+		return planTerms{Units: t.TrialUnits, HasUnits: true, FeatureLimits: t.FeatureLimits}
+	case config.AppConfig.TrialUnitsPerPeriod > 0:
+		return planTerms{Units: int64(config.AppConfig.TrialUnitsPerPeriod), HasUnits: true, FeatureLimits: t.FeatureLimits}
+	default:
+		return planTerms{Units: t.Units, HasUnits: t.HasUnits, FeatureLimits: t.FeatureLimits}
+	}
+}
+
+// entitlement is the unit allowance granted by a subscription (or one of its items)
+// for the current billing period.
+type entitlement struct {
+	Units int64
+	// Unlimited is true when usage is billed in arrears (metered without a units_per_period cap).
+	Unlimited bool
+	// OverageUnitAmount and Currency are set when usage past Units is billed rather than refused.
+	OverageUnitAmount string
+	Currency          string
+	// FeatureLimits caps the units individual features use within the allowance.
+	FeatureLimits map[string]int64
+}
+
+// add sums allowances; the overage terms of the first overage-enabled item apply.
+// Feature limits are summed across the items that define them.
+func (e entitlement) add(o entitlement) entitlement {
+	sum := entitlement{Units: e.Units + o.Units, Unlimited: e.Unlimited || o.Unlimited}
+	for _, limits := range []map[string]int64{e.FeatureLimits, o.FeatureLimits} {
+		for feature, n := range limits {
+			if sum.FeatureLimits == nil {
+				sum.FeatureLimits = make(map[string]int64)
+			}
+			sum.FeatureLimits[feature] += n
+		}
+	}
+	sum.OverageUnitAmount, sum.Currency = e.OverageUnitAmount, e.Currency
+	if sum.OverageUnitAmount == "" {
+		sum.OverageUnitAmount, sum.Currency = o.OverageUnitAmount, o.Currency
+	}
+	return sum
+}
+
+// subscriptionAllowance returns how many units the subscription grants for its current period,
+// summed across its items. Plans that define units_per_period in metadata use it; others fall back
+// to the per-currency units rate applied to the (possibly tiered) item amount.
+// Trialing subscriptions get the trial allowance instead (see planTerms.trial).
+func (s serviceImpl) subscriptionAllowance(sub stripe.Subscription) (entitlement, error) {
+	trial := sub.Status == stripe.SubscriptionStatusTrialing
+	if sub.Items == nil || len(sub.Items.Data) == 0 {
+		// Legacy single-plan shape.
+		if sub.Plan == nil {
+			return entitlement{}, fmt.Errorf("plan not found for subscription")
+		}
+		if sub.Quantity == 0 && sub.Plan.UsageType != stripe.PlanUsageTypeMetered {
+			return entitlement{}, fmt.Errorf("quantity is 0 for subscription")
+		}
+		return s.itemAllowance(sub.Plan, sub.Quantity, trial)
+	}
+	var total entitlement
+	for _, it := range sub.Items.Data {
+		if it == nil || it.Deleted {
+			continue
+		}
+		if it.Plan == nil {
+			return entitlement{}, fmt.Errorf("plan not found for subscription item %q", it.ID)
+		}
+		e, err := s.itemAllowance(it.Plan, it.Quantity, trial)
+		if err != nil {
+			return entitlement{}, fmt.Errorf("subscription item %q: %w", it.ID, err)
+		}
+		total = total.add(e)
+	}
+	return total, nil
+}
+
+// itemAllowance computes the entitlement of a single subscription item.
+func (s serviceImpl) itemAllowance(plan *stripe.Plan, quantity int64, trial bool) (entitlement, error) {
+	terms, err := s.planTerms(plan)
+	if err != nil {
+		return entitlement{}, err
+	}
+	if trial {
+		terms = terms.trial()
+	}
+	e, err := s.baseAllowance(plan, quantity, terms)
+	if err != nil {
+		return entitlement{}, err
+	}
+	if terms.OverageUnitAmount != "" {
+		e.OverageUnitAmount, e.Currency = terms.OverageUnitAmount, string(plan.Currency)
+	}
+	if terms.FeatureLimits != "" {
+		limits, err := config.ParseFeatureTable(terms.FeatureLimits, PlanMetadataFeatureLimits)
+		if err != nil {
+			return entitlement{}, err
+		}
+		if plan.UsageType != stripe.PlanUsageTypeMetered {
+			// like units_per_period, limits are per quantity of licensed plans
+			for feature := range limits {
+				limits[feature] *= quantity
+			}
+		}
+		e.FeatureLimits = limits
+	}
+	return e, nil
+}
+
+func (s serviceImpl) baseAllowance(plan *stripe.Plan, quantity int64, terms planTerms) (entitlement, error) {
+	if plan.UsageType == stripe.PlanUsageTypeMetered {
+		// Metered prices have no licensed quantity: units_per_period acts as a per-period cap,
+		// otherwise usage is simply billed and never exhausts.
+		if terms.HasUnits {
+			return entitlement{Units: terms.Units}, nil
+		}
+		return entitlement{Unlimited: true}, nil
+	}
+	if terms.HasUnits {
+		return entitlement{Units: terms.Units * quantity}, nil
+	}
+	if quantity == 0 {
+		return entitlement{}, nil
+	}
+	amount, err := planAmountFor(plan, quantity)
+	if err != nil {
+		return entitlement{}, err
+	}
+	if amount.Sign() == 0 {
+		return entitlement{}, fmt.Errorf("plan amount is 0 for subscription")
+	}
+	n, err := ratAllowance(amount, string(plan.Currency))
+	if err != nil {
+		return entitlement{}, err
+	}
+	return entitlement{Units: n}, nil
+}
+
+// planTerms resolves the metadata terms of a plan, preferring the local cache.
+// On a cache miss it reads the price metadata, falling back to the product metadata for each key,
+// and caches the result (including "not defined") for PLAN_ALLOWANCE_CACHE_TTL_SECONDS.
+func (s serviceImpl) planTerms(plan *stripe.Plan) (planTerms, error) {
+	if plan.ID == "" {
+		// Nothing to cache against; read metadata directly.
+		return parsePlanTerms(plan.Metadata, nil)
+	}
+	now := time.Now().UnixMilli()
+	cached, found, err := stripedb.GetPlanAllowance(plan.ID)
+	if err != nil {
+		return planTerms{}, fmt.Errorf("%w: %v", ErrDatabase, err)
+	}
+	if found && now-cached.FetchedAt < int64(config.AppConfig.PlanAllowanceCacheTTLSeconds)*1000 {
+		return planTerms{
+			Units:             cached.UnitsPerPeriod,
+			HasUnits:          cached.HasUnits,
+			OverageUnitAmount: cached.OverageUnitAmount,
+			TrialUnits:        cached.TrialUnitsPerPeriod,
+			HasTrialUnits:     cached.HasTrialUnits,
+			FeatureLimits:     cached.FeatureLimits,
+		}, nil
+	}
+
+	var productMetadata map[string]string
+	if !hasPlanTerms(plan.Metadata) && plan.Product != nil && plan.Product.ID != "" {
+		prod, err := s.gw.GetProduct(plan.Product.ID)
+		if err != nil {
+			return planTerms{}, fmt.Errorf("%w: error getting product: %v", ErrGateway, err)
+		}
+		productMetadata = prod.Metadata
+	}
+	terms, err := parsePlanTerms(plan.Metadata, productMetadata)
+	if err != nil {
+		return planTerms{}, err
+	}
+	if err := stripedb.UpsertPlanAllowance(stripedb.PlanAllowance{
+		StripePlanID:        plan.ID,
+		UnitsPerPeriod:      terms.Units,
+		HasUnits:            terms.HasUnits,
+		OverageUnitAmount:   terms.OverageUnitAmount,
+		TrialUnitsPerPeriod: terms.TrialUnits,
+		HasTrialUnits:       terms.HasTrialUnits,
+		FeatureLimits:       terms.FeatureLimits,
+		FetchedAt:           now,
+	}); err != nil {
+		return planTerms{}, fmt.Errorf("%w: %v", ErrDatabase, err)
+	}
+	return terms, nil
+}
+
+// hasPlanTerms reports whether metadata defines every plan term, making a product lookup unnecessary.
+func hasPlanTerms(metadata map[string]string) bool {
+	return metadata[PlanMetadataUnitsPerPeriod] != "" && metadata[PlanMetadataOverageUnitAmount] != "" &&
+		metadata[PlanMetadataTrialUnitsPerPeriod] != "" && metadata[PlanMetadataFeatureLimits] != ""
+}
+
+// parsePlanTerms reads plan terms from price metadata, falling back to product metadata per key.
+func parsePlanTerms(priceMetadata, productMetadata map[string]string) (planTerms, error) {
+	var terms planTerms
+	var err error
+	if terms.Units, terms.HasUnits, err = parseUnits(priceMetadata, PlanMetadataUnitsPerPeriod); err != nil {
+		return planTerms{}, err
+	}
+	if !terms.HasUnits {
+		if terms.Units, terms.HasUnits, err = parseUnits(productMetadata, PlanMetadataUnitsPerPeriod); err != nil {
+			return planTerms{}, err
+		}
+	}
+	if terms.TrialUnits, terms.HasTrialUnits, err = parseUnits(priceMetadata, PlanMetadataTrialUnitsPerPeriod); err != nil {
+		return planTerms{}, err
+	}
+	if !terms.HasTrialUnits {
+		if terms.TrialUnits, terms.HasTrialUnits, err = parseUnits(productMetadata, PlanMetadataTrialUnitsPerPeriod); err != nil {
+			return planTerms{}, err
+		}
+	}
+	if terms.OverageUnitAmount, err = parseOverageUnitAmount(priceMetadata); err != nil {
+		return planTerms{}, err
+	}
+	if terms.OverageUnitAmount == "" {
+		if terms.OverageUnitAmount, err = parseOverageUnitAmount(productMetadata); err != nil {
+			return planTerms{}, err
+		}
+	}
+	if terms.FeatureLimits, err = parseFeatureLimits(priceMetadata); err != nil {
+		return planTerms{}, err
+	}
+	if terms.FeatureLimits == "" {
+		if terms.FeatureLimits, err = parseFeatureLimits(productMetadata); err != nil {
+			return planTerms{}, err
+		}
+	}
+	return terms, nil
+}
+
+// parseOverageUnitAmount validates overage_unit_amount and returns it normalized (underscores removed).
+func parseOverageUnitAmount(metadata map[string]string) (string, error) {
+	v := metadata[PlanMetadataOverageUnitAmount]
+	if v == "" {
+		return "", nil
+	}
+	if _, ok := config.ParseRate(v); !ok {
+		return "", fmt.Errorf("invalid %s metadata: %q", PlanMetadataOverageUnitAmount, v)
+	}
+	return strings.ReplaceAll(strings.TrimSpace(v), "_", ""), nil
+}
+
+// parseFeatureLimits validates feature_limits and returns it trimmed.
+func parseFeatureLimits(metadata map[string]string) (string, error) {
+	v := strings.TrimSpace(metadata[PlanMetadataFeatureLimits])
+	if v == "" {
+		return "", nil
+	}
+	if _, err := config.ParseFeatureTable(v, PlanMetadataFeatureLimits); err != nil {
+		return "", err
+	}
+	return v, nil
+}
+
+// parseUnits reads a unit count from Stripe metadata (underscores allowed, e.g., 2_000_000).
+func parseUnits(metadata map[string]string, key string) (int64, bool, error) {
+	v, ok := metadata[key]
+	if !ok || v == "" {
+		return 0, false, nil
+	}
+	units, err := strconv.ParseInt(strings.ReplaceAll(v, "_", ""), 10, 64)
+	if err != nil || units < 0 {
+		return 0, false, fmt.Errorf("invalid %s metadata: %q", key, v)
+	}
+	return units, true, nil
+}
+
+// planAmountFor returns what the plan charges for quantity, in the currency's minor units.
+// Tiered plans are priced per their tiers_mode (graduated or volume); per-unit plans multiply.
+func planAmountFor(plan *stripe.Plan, quantity int64) (*big.Rat, error) {
+	if plan.BillingScheme != stripe.PlanBillingSchemeTiered {
+		amount := minorAmount(plan.Amount, plan.AmountDecimal)
+		return amount.Mul(amount, new(big.Rat).SetInt64(quantity)), nil
+	}
+	if len(plan.Tiers) == 0 {
+		return nil, fmt.Errorf("tiered plan %q has no tiers (expand plan.tiers)", plan.ID)
+	}
+	total := new(big.Rat)
+	var prevUpTo int64
+	for _, tier := range plan.Tiers {
+		// up_to == 0 is the open-ended "inf" tier
+		last := tier.UpTo == 0 || quantity <= tier.UpTo
+		if plan.TiersMode == string(stripe.PlanTiersModeVolume) {
+			if !last {
+				continue
+			}
+			unit := minorAmount(tier.UnitAmount, tier.UnitAmountDecimal)
+			total.Add(total, unit.Mul(unit, new(big.Rat).SetInt64(quantity)))
+			total.Add(total, minorAmount(tier.FlatAmount, tier.FlatAmountDecimal))
+			return total, nil
+		}
+		// graduated: each tier prices only the units falling inside it
+		inTier := quantity - prevUpTo
+		if !last {
+			inTier = tier.UpTo - prevUpTo
+		}
+		if inTier > 0 {
+			unit := minorAmount(tier.UnitAmount, tier.UnitAmountDecimal)
+			total.Add(total, unit.Mul(unit, new(big.Rat).SetInt64(inTier)))
+			total.Add(total, minorAmount(tier.FlatAmount, tier.FlatAmountDecimal))
+		}
+		if last {
+			return total, nil
+		}
+		prevUpTo = tier.UpTo
+	}
+	return total, nil
+}
+
+// minorAmount prefers Stripe's *_decimal field (which may carry sub-minor-unit precision)
+// and falls back to the integer amount.
+func minorAmount(amount int64, decimal float64) *big.Rat {
+	if decimal != 0 {
+		if r, ok := new(big.Rat).SetString(strconv.FormatFloat(decimal, 'f', -1, 64)); ok {
+			return r
+		}
+	}
+	return new(big.Rat).SetInt64(amount)
+}
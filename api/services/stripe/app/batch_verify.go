@@ -0,0 +1,191 @@
+package app
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/stripe/stripe-go"
+	"github.com/tbeaudouin05/stripe-trellai/api/config"
+	stripedb "github.com/tbeaudouin05/stripe-trellai/api/services/stripe/db"
+)
+
+// VerifyResult is one user's outcome in BatchVerifySubscription: Err is set instead of Response
+// when that user could not be verified.
+type VerifyResult struct {
+	Response VerifySubscriptionResponse
+	Err      error
+}
+
+// lookup is the outcome of one gateway call in a batch.
+type lookup[T any] struct {
+	value T
+	err   error
+}
+
+// BatchVerifySubscription verifies many users at once, with the same outcome as
+// VerifySubscription for each. Memberships and account states are read with one query each, and
+// Stripe is only called for accounts that credit doesn't already validate: once per distinct
+// subscription and customer, at most BATCH_VERIFY_CONCURRENCY calls at a time. Members' caps
+// reuse the fetched subscriptions, or the service's cached ones for accounts settled by credit.
+// Some reads are still per account rather than per batch: the usage and feature limits of each
+// account checked against its subscription, the free credit of accounts seen for the first time
+// or due a refill, and the caps and usage of each capped member. Failures are reported per user;
+// the error is only set when the batch queries themselves fail.
+func (s serviceImpl) BatchVerifySubscription(userExternalIDs []string) (map[string]VerifyResult, error) {
+	users := make([]string, 0, len(userExternalIDs))
+	results := make(map[string]VerifyResult, len(userExternalIDs))
+	for _, u := range userExternalIDs {
+		if _, ok := results[u]; ok {
+			continue
+		}
+		results[u] = VerifyResult{}
+		users = append(users, u)
+	}
+
+	resolved, err := stripedb.ResolveAccounts(users)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDatabase, err)
+	}
+	billedOf := make(map[string]stripedb.BilledAccount, len(users))
+	seen := make(map[string]bool)
+	var accounts []string
+	for _, u := range users {
+		billed := resolved[u]
+		if !billed.Seated {
+			results[u] = VerifyResult{Response: VerifySubscriptionResponse{IsValidSubscription: false, InvalidityType: InvalidityTypeNoSeat}}
+			continue
+		}
+		if !seen[billed.Account] {
+			seen[billed.Account] = true
+			accounts = append(accounts, billed.Account)
+		}
+		billedOf[u] = billed
+	}
+	states, err := stripedb.ListVerifyAccounts(accounts)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDatabase, err)
+	}
+
+	// credit, or the lack of a subscription, settles most accounts without Stripe
+	verdicts := make(map[string]VerifyResult, len(accounts))
+	var subscriptionIDs, customerIDs []string
+	for _, account := range accounts {
+		st, found := states[account]
+		resp, settled, err := verifyFromDatabase(account, st, found)
+		if err != nil || settled {
+			verdicts[account] = VerifyResult{Response: resp, Err: err}
+			continue
+		}
+		subscriptionIDs = append(subscriptionIDs, st.StripeSubscriptionID)
+		customerIDs = append(customerIDs, st.StripeCustomerID)
+	}
+
+	sem := make(chan struct{}, config.AppConfig.BatchVerifyConcurrency)
+	var subs map[string]lookup[stripe.Subscription]
+	var custs map[string]lookup[stripe.Customer]
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		subs = fetchAll(sem, subscriptionIDs, s.gw.GetSubscription)
+	}()
+	go func() {
+		defer wg.Done()
+		custs = fetchAll(sem, customerIDs, s.gw.GetCustomer)
+	}()
+	wg.Wait()
+
+	for _, account := range accounts {
+		if _, ok := verdicts[account]; ok {
+			continue
+		}
+		st := states[account]
+		sub, cust := subs[st.StripeSubscriptionID], custs[st.StripeCustomerID]
+		switch {
+		case sub.err != nil:
+			verdicts[account] = VerifyResult{Err: fmt.Errorf("%w: error getting subscription: %v", ErrGateway, sub.err)}
+		case cust.err != nil:
+			verdicts[account] = VerifyResult{Err: fmt.Errorf("%w: error retrieving customer email: %v", ErrGateway, cust.err)}
+		default:
+			resp, err := s.evaluateSubscription(account, sub.value, cust.value.Email)
+			verdicts[account] = VerifyResult{Response: resp, Err: err}
+		}
+	}
+
+	for _, u := range users {
+		billed, ok := billedOf[u]
+		if !ok {
+			continue // no seat, already answered
+		}
+		account := billed.Account
+		v := verdicts[account]
+		if v.Err != nil {
+			results[u] = v
+			continue
+		}
+		billing := states[account].Billing
+		if _, found := states[account]; !found {
+			if billing, err = stripedb.GetBillingStatus(account); err != nil {
+				results[u] = VerifyResult{Err: fmt.Errorf("%w: error retrieving billing status: %v", ErrDatabase, err)}
+				continue
+			}
+		}
+		var sub *stripe.Subscription
+		if fetched, ok := subs[states[account].StripeSubscriptionID]; ok && fetched.err == nil {
+			sub = &fetched.value
+		}
+		resp, err := s.memberValidity(billed, u, v.Response, billing, sub)
+		results[u] = VerifyResult{Response: resp, Err: err}
+	}
+	return results, nil
+}
+
+// verifyFromDatabase settles an account's validity from its credit and subscription state, as
+// verifySubscription does before calling Stripe. The boolean is false when the account's Stripe
+// subscription still needs checking.
+func verifyFromDatabase(account string, st stripedb.VerifyAccount, found bool) (VerifySubscriptionResponse, bool, error) {
+	credit := st.FreeCredit
+	if !found || !st.FreeCreditCurrent {
+		// first contact, expiry or a refill: let GetFreeCredit create or update the balance
+		var err error
+		if credit, err = stripedb.GetFreeCredit(account); err != nil {
+			return VerifySubscriptionResponse{}, true, fmt.Errorf("%w: error retrieving free credit: %v", ErrDatabase, err)
+		}
+	}
+	if credit > 0 {
+		return VerifySubscriptionResponse{IsValidSubscription: true, ValidityType: ValidityTypeFreeTier}, true, nil
+	}
+	if st.PurchasedCredit > 0 {
+		return VerifySubscriptionResponse{IsValidSubscription: true, ValidityType: ValidityTypePrepaidCredit}, true, nil
+	}
+	if st.StripeSubscriptionID == "" {
+		return VerifySubscriptionResponse{IsValidSubscription: false, InvalidityType: InvalidityTypeNoSubscription}, true, nil
+	}
+	return VerifySubscriptionResponse{}, false, nil
+}
+
+// fetchAll calls fetch once per distinct id, holding a slot of sem during each call.
+func fetchAll[T any](sem chan struct{}, ids []string, fetch func(id string) (T, error)) map[string]lookup[T] {
+	out := make(map[string]lookup[T], len(ids))
+	distinct := make(map[string]bool, len(ids))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, id := range ids {
+		if distinct[id] {
+			continue
+		}
+		distinct[id] = true
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			v, err := fetch(id)
+			mu.Lock()
+			out[id] = lookup[T]{value: v, err: err}
+			mu.Unlock()
+		}(id)
+	}
+	wg.Wait()
+	return out
+}
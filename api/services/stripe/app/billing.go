@@ -0,0 +1,109 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	stripe "github.com/stripe/stripe-go"
+	"github.com/tbeaudouin05/stripe-trellai/api/pubsub"
+	stripedb "github.com/tbeaudouin05/stripe-trellai/api/services/stripe/db"
+	"github.com/tbeaudouin05/stripe-trellai/api/services/stripe/outbound"
+)
+
+// HandleInvoiceEvent maintains the user's dunning state from invoice events. invoice.payment_failed
+// puts the user in dunning; any paid invoice of the subscription takes them out, as does the
+// failed invoice itself being voided or marked uncollectible, since Stripe stops retrying it.
+// Invoices are matched to users by their subscription; invoices of unknown subscriptions are ignored.
+func (s serviceImpl) HandleInvoiceEvent(event stripe.Event) error {
+	var inv stripe.Invoice
+	if err := json.Unmarshal(event.Data.Raw, &inv); err != nil {
+		return fmt.Errorf("%w: error unmarshaling into Invoice: %v", ErrBadEvent, err)
+	}
+	if inv.ID == "" {
+		return fmt.Errorf("%w: invoice ID not found in event", ErrBadEvent)
+	}
+	if inv.Subscription == nil || inv.Subscription.ID == "" {
+		slog.Info("ignoring invoice without subscription", "event_type", event.Type, "invoice_id", inv.ID)
+		return nil
+	}
+	user, found, err := stripedb.FindUserBySubscriptionID(inv.Subscription.ID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrDatabase, err)
+	}
+	if !found {
+		slog.Info("ignoring invoice of unknown subscription", "event_type", event.Type, "invoice_id", inv.ID, "subscription_id", inv.Subscription.ID)
+		return nil
+	}
+	// Stripe provides seconds; billing_status stores milliseconds.
+	eventAt := event.Created * 1000
+
+	var applied bool
+	switch event.Type {
+	case "invoice.payment_failed":
+		applied, err = stripedb.MarkBillingDunning(user, stripedb.BillingStatus{
+			StripeInvoiceID:    inv.ID,
+			AttemptCount:       int(inv.AttemptCount),
+			NextPaymentAttempt: inv.NextPaymentAttempt * 1000,
+			LastEventAt:        eventAt,
+		})
+	case "invoice.paid":
+		applied, err = stripedb.ClearBillingDunning(user, "", eventAt)
+	case "invoice.voided", "invoice.marked_uncollectible":
+		applied, err = stripedb.ClearBillingDunning(user, inv.ID, eventAt)
+	default:
+		return fmt.Errorf("%w: unexpected event type %q", ErrBadEvent, event.Type)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrDatabase, err)
+	}
+	slog.Info("invoice event processed", "event_type", event.Type, "invoice_id", inv.ID, "attempt_count", inv.AttemptCount, "applied", applied)
+	if applied {
+		pubsub.Publish(user)
+	}
+	return nil
+}
+
+// HandleSubscriptionDeleted clears the user's dunning state and emits subscription.cancelled when
+// the subscription a user is billed on ends (customer.subscription.deleted). Subscriptions no
+// user is on, such as cancelled duplicates, are ignored.
+func (s serviceImpl) HandleSubscriptionDeleted(event stripe.Event) error {
+	var sub stripe.Subscription
+	if err := json.Unmarshal(event.Data.Raw, &sub); err != nil {
+		return fmt.Errorf("%w: error unmarshaling into Subscription: %v", ErrBadEvent, err)
+	}
+	if sub.ID == "" {
+		return fmt.Errorf("%w: subscription ID not found in event", ErrBadEvent)
+	}
+	hashed, found, err := stripedb.FindUserBySubscriptionID(sub.ID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrDatabase, err)
+	}
+	if !found {
+		slog.Info("ignoring deletion of a subscription no user is on", "stripe_subscription_id", sub.ID)
+		return nil
+	}
+	// its invoices are no longer retried; validity now follows the cancelled subscription
+	if _, err := stripedb.ClearBillingDunning(hashed, "", event.Created*1000); err != nil {
+		return fmt.Errorf("%w: %v", ErrDatabase, err)
+	}
+	data := outbound.EventData{UserHash: hashed, StripeSubscriptionID: sub.ID}
+	if sub.Customer != nil {
+		data.StripeCustomerID = sub.Customer.ID
+	}
+	pubsub.Publish(hashed)
+	return emitEvent(outbound.EventSubscriptionCancelled, sub.ID, data)
+}
+
+// GetBillingStatus returns the user's dunning state.
+func (s serviceImpl) GetBillingStatus(userExternalID string) (stripedb.BillingStatus, error) {
+	account, err := ownAccount(userExternalID)
+	if err != nil {
+		return stripedb.BillingStatus{}, err
+	}
+	st, err := stripedb.GetBillingStatus(account)
+	if err != nil {
+		return stripedb.BillingStatus{}, fmt.Errorf("%w: %v", ErrDatabase, err)
+	}
+	return st, nil
+}
@@ -0,0 +1,80 @@
+package app
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	stripe "github.com/stripe/stripe-go"
+	stripedb "github.com/tbeaudouin05/stripe-trellai/api/services/stripe/db"
+)
+
+const billingBoardID = "billing-test-board"
+
+func invoiceEvent(t *testing.T, typ string, created int64, inv map[string]interface{}) stripe.Event {
+	t.Helper()
+	raw, err := json.Marshal(inv)
+	if err != nil {
+		t.Fatalf("failed to marshal invoice: %v", err)
+	}
+	return stripe.Event{Type: typ, Created: created, Data: &stripe.EventData{Raw: raw}}
+}
+
+func Test_HandleInvoiceEvent_DunningLifecycle(t *testing.T) {
+	db, cleanup := setupSubTestDB(t)
+	defer cleanup()
+	hb := stripedb.HashExternalID(billingBoardID)
+	clean := func() {
+		_, _ = db.Exec("DELETE FROM billing_status WHERE user_external_id = $1", hb)
+		_, _ = db.Exec("DELETE FROM free_credit WHERE user_external_id = $1", hb)
+		_, _ = db.Exec("DELETE FROM user_account WHERE user_external_id = $1", hb)
+	}
+	clean()
+	defer clean()
+	if err := stripedb.UpsertUserAccount(billingBoardID, "sub_billing", "plan_billing", "cust_billing"); err != nil {
+		t.Fatalf("UpsertUserAccount failed: %v", err)
+	}
+	svc := NewService(fakeGateway{})
+	failed := map[string]interface{}{"id": "in_1", "subscription": "sub_billing", "attempt_count": 2, "next_payment_attempt": 1_700_100_000}
+
+	assert.NoError(t, svc.HandleInvoiceEvent(invoiceEvent(t, "invoice.payment_failed", 1_700_000_000, failed)))
+	st, err := svc.GetBillingStatus(billingBoardID)
+	assert.NoError(t, err)
+	assert.True(t, st.InDunning)
+	assert.Equal(t, 2, st.AttemptCount)
+	assert.Equal(t, int64(1_700_100_000_000), st.NextPaymentAttempt)
+
+	// Voiding another invoice, or a late retry of an older failure, leaves the state alone.
+	assert.NoError(t, svc.HandleInvoiceEvent(invoiceEvent(t, "invoice.voided", 1_700_000_100, map[string]interface{}{"id": "in_other", "subscription": "sub_billing"})))
+	failed["attempt_count"] = 1
+	assert.NoError(t, svc.HandleInvoiceEvent(invoiceEvent(t, "invoice.payment_failed", 1_699_999_000, failed)))
+	st, err = svc.GetBillingStatus(billingBoardID)
+	assert.NoError(t, err)
+	assert.True(t, st.InDunning)
+	assert.Equal(t, 2, st.AttemptCount)
+
+	// Any paid invoice of the subscription clears it.
+	assert.NoError(t, svc.HandleInvoiceEvent(invoiceEvent(t, "invoice.paid", 1_700_000_200, map[string]interface{}{"id": "in_other", "subscription": "sub_billing"})))
+	st, err = svc.GetBillingStatus(billingBoardID)
+	assert.NoError(t, err)
+	assert.False(t, st.InDunning)
+	assert.Equal(t, int64(0), st.NextPaymentAttempt)
+
+	// So does the failed invoice being marked uncollectible...
+	assert.NoError(t, svc.HandleInvoiceEvent(invoiceEvent(t, "invoice.payment_failed", 1_700_000_300, failed)))
+	assert.NoError(t, svc.HandleInvoiceEvent(invoiceEvent(t, "invoice.marked_uncollectible", 1_700_000_400, map[string]interface{}{"id": "in_1", "subscription": "sub_billing"})))
+	st, err = svc.GetBillingStatus(billingBoardID)
+	assert.NoError(t, err)
+	assert.False(t, st.InDunning)
+
+	// ...or the subscription being deleted.
+	assert.NoError(t, svc.HandleInvoiceEvent(invoiceEvent(t, "invoice.payment_failed", 1_700_000_500, failed)))
+	raw, _ := json.Marshal(map[string]interface{}{"id": "sub_billing"})
+	assert.NoError(t, svc.HandleSubscriptionDeleted(stripe.Event{Type: "customer.subscription.deleted", Created: 1_700_000_600, Data: &stripe.EventData{Raw: raw}}))
+	st, err = svc.GetBillingStatus(billingBoardID)
+	assert.NoError(t, err)
+	assert.False(t, st.InDunning)
+
+	// Invoices of subscriptions we don't know are ignored.
+	assert.NoError(t, svc.HandleInvoiceEvent(invoiceEvent(t, "invoice.payment_failed", 1_700_000_700, map[string]interface{}{"id": "in_2", "subscription": "sub_unknown"})))
+}
@@ -0,0 +1,106 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/tbeaudouin05/stripe-trellai/api/config"
+	stripedb "github.com/tbeaudouin05/stripe-trellai/api/services/stripe/db"
+	"github.com/tbeaudouin05/stripe-trellai/api/services/stripe/outbound"
+)
+
+// CreateCampaign creates a promo code campaign and returns its ID.
+func (s serviceImpl) CreateCampaign(c stripedb.Campaign) (int64, error) {
+	if stripedb.NormalizeCampaignCode(c.Code) == "" {
+		return 0, fmt.Errorf("code is required")
+	}
+	if c.Units <= 0 {
+		return 0, fmt.Errorf("units must be > 0")
+	}
+	if c.PerUserLimit <= 0 {
+		c.PerUserLimit = 1
+	}
+	if c.EndsAt > 0 && c.EndsAt <= c.StartsAt {
+		return 0, fmt.Errorf("ends_at must be after starts_at")
+	}
+	id, err := stripedb.CreateCampaign(c)
+	if errors.Is(err, stripedb.ErrCampaignExists) {
+		return 0, fmt.Errorf("%w: %v", ErrNotAllowed, err)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrDatabase, err)
+	}
+	return id, nil
+}
+
+// RedeemCode redeems a promo or referral code for the user. Retrying with the same
+// idempotencyKey returns the original outcome.
+func (s serviceImpl) RedeemCode(userExternalID, code, idempotencyKey string) (stripedb.Redemption, error) {
+	// members redeem for their organization's pool
+	billed, err := resolveAccount(userExternalID)
+	if err != nil {
+		return stripedb.Redemption{}, err
+	}
+	userExternalID = billed.Account
+	r, err := stripedb.RedeemCode(userExternalID, code, idempotencyKey)
+	switch {
+	case err == nil:
+		if r.Units > 0 && !r.Pending {
+			entitlementsChanged(userExternalID)
+			data := userEventData(userExternalID)
+			data.Units, data.Source = int64(r.Units), CreditSourcePromoCode
+			// a retry with the same idempotency key returns the same redemption, and emits nothing new
+			if err := emitEvent(outbound.EventCreditsGranted, stripedb.HashExternalID(userExternalID)+":"+idempotencyKey, data); err != nil {
+				slog.Error("error emitting credits.granted", "code", r.Code, "err", err)
+			}
+		}
+		return r, nil
+	case errors.Is(err, stripedb.ErrCampaignNotFound):
+		return stripedb.Redemption{}, fmt.Errorf("%w: %v", ErrNotFound, err)
+	case errors.Is(err, stripedb.ErrCampaignInactive),
+		errors.Is(err, stripedb.ErrCampaignExhausted),
+		errors.Is(err, stripedb.ErrRedemptionLimitReached),
+		errors.Is(err, stripedb.ErrSelfReferral),
+		errors.Is(err, stripedb.ErrAlreadyReferred),
+		errors.Is(err, stripedb.ErrIdempotencyKeyReused):
+		return stripedb.Redemption{}, fmt.Errorf("%w: %v", ErrNotAllowed, err)
+	default:
+		return stripedb.Redemption{}, fmt.Errorf("%w: %v", ErrDatabase, err)
+	}
+}
+
+// GetReferralCode returns the user's referral code, rewarding REFERRAL_REFEREE_UNITS to users
+// redeeming it and REFERRAL_REFERRER_UNITS to the user once they complete a checkout.
+func (s serviceImpl) GetReferralCode(userExternalID string) (string, error) {
+	if config.AppConfig == nil {
+		return "", fmt.Errorf("app config not initialized")
+	}
+	referee, referrer := config.AppConfig.ReferralRefereeUnits, config.AppConfig.ReferralReferrerUnits
+	if referee == 0 && referrer == 0 {
+		return "", fmt.Errorf("%w: referrals are disabled", ErrNotAllowed)
+	}
+	userExternalID, err := ownAccount(userExternalID)
+	if err != nil {
+		return "", err
+	}
+	code, err := stripedb.ReferralCode(userExternalID, referee, referrer)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrDatabase, err)
+	}
+	return code, nil
+}
+
+// rewardReferral credits the referee and referrer of the user's pending referral, if any,
+// after the user completed a checkout.
+func (s serviceImpl) rewardReferral(userExternalID string) error {
+	rewarded, err := stripedb.RewardReferral(userExternalID)
+	if err != nil {
+		slog.Error("error rewarding referral", "user_external_id", userExternalID, "err", err)
+		return fmt.Errorf("%w: error rewarding referral: %v", ErrDatabase, err)
+	}
+	if rewarded {
+		slog.Info("referral rewarded", "user_external_id", userExternalID)
+	}
+	return nil
+}
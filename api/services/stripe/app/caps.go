@@ -0,0 +1,268 @@
+package app
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/stripe/stripe-go"
+	stripedb "github.com/tbeaudouin05/stripe-trellai/api/services/stripe/db"
+)
+
+// SpendingCapChange sets the per-billing-period cap of one organization member or one API key.
+// Exactly one of MemberUserExternalID and APIKeyID is set. CapUnits 0 removes the cap.
+type SpendingCapChange struct {
+	MemberUserExternalID string
+	APIKeyID             string
+	CapUnits             int64
+}
+
+// SpendingUsage is what one member or API key spent from the pool in the current billing period.
+// ID is the member's hashed identifier ("" for units spent directly on the organization's
+// account), or the API key ID. CapUnits is 0 when uncapped.
+type SpendingUsage struct {
+	ID       string
+	Units    int64
+	CapUnits int64
+}
+
+// UsageBreakdown splits an organization's usage for the billing period [PeriodStart, PeriodEnd]
+// (unix ms) by member and by API key.
+type UsageBreakdown struct {
+	PeriodStart int64
+	PeriodEnd   int64
+	Members     []SpendingUsage
+	APIKeys     []SpendingUsage
+}
+
+// SetSpendingCap caps what a member or an API key can spend from the organization's pool per
+// billing period, on behalf of the owner or an admin. Only the owner caps admins and themselves.
+func (s serviceImpl) SetSpendingCap(orgExternalID, actorUserExternalID string, c SpendingCapChange) error {
+	if (c.MemberUserExternalID == "") == (c.APIKeyID == "") {
+		return fmt.Errorf("exactly one of member_user_external_id and api_key_id is required")
+	}
+	if c.CapUnits < 0 {
+		return fmt.Errorf("cap_units must be >= 0")
+	}
+	orgID, actorRole, err := organizationActor(orgExternalID, actorUserExternalID)
+	if err != nil {
+		return err
+	}
+	if actorRole != stripedb.RoleOwner && actorRole != stripedb.RoleAdmin {
+		return fmt.Errorf("%w: only the owner and admins set spending caps", ErrNotAllowed)
+	}
+	subjectType, subjectID := stripedb.CapSubjectAPIKey, c.APIKeyID
+	if c.MemberUserExternalID != "" {
+		subjectType, subjectID = stripedb.CapSubjectMember, c.MemberUserExternalID
+		role, isMember, err := memberRole(orgID, c.MemberUserExternalID)
+		if err != nil {
+			return err
+		}
+		if !isMember {
+			return organizationError(stripedb.ErrMemberNotFound)
+		}
+		if actorRole != stripedb.RoleOwner && role != stripedb.RoleMember {
+			return fmt.Errorf("%w: only the owner caps admins and the owner", ErrNotAllowed)
+		}
+	}
+	if c.CapUnits == 0 {
+		if _, err := stripedb.RemoveSpendingCap(orgID, subjectType, subjectID); err != nil {
+			return fmt.Errorf("%w: %v", ErrDatabase, err)
+		}
+	} else if err := stripedb.SetSpendingCap(orgID, subjectType, subjectID, c.CapUnits, actorUserExternalID); err != nil {
+		return fmt.Errorf("%w: %v", ErrDatabase, err)
+	}
+	if c.MemberUserExternalID != "" {
+		entitlementsChanged(c.MemberUserExternalID)
+	}
+	return nil
+}
+
+// GetUsageBreakdown returns the organization's usage in the current billing period by member and
+// by API key, with their caps, to the owner or an admin. Capped members and keys without usage
+// are listed with 0 units.
+func (s serviceImpl) GetUsageBreakdown(orgExternalID, actorUserExternalID string) (UsageBreakdown, error) {
+	orgID, actorRole, err := organizationActor(orgExternalID, actorUserExternalID)
+	if err != nil {
+		return UsageBreakdown{}, err
+	}
+	if actorRole != stripedb.RoleOwner && actorRole != stripedb.RoleAdmin {
+		return UsageBreakdown{}, fmt.Errorf("%w: only the owner and admins see the usage breakdown", ErrNotAllowed)
+	}
+	memberCaps, keyCaps, err := spendingCaps(orgID)
+	if err != nil {
+		return UsageBreakdown{}, err
+	}
+	account := stripedb.OrganizationAccount(orgExternalID)
+	start, end, err := s.billingPeriod(account, nil)
+	if err != nil {
+		return UsageBreakdown{}, err
+	}
+	byMember, err := stripedb.UnitsByMemberBetween(account, start, end)
+	if err != nil {
+		return UsageBreakdown{}, fmt.Errorf("%w: %v", ErrDatabase, err)
+	}
+	byKey, err := stripedb.UnitsByAPIKeyBetween(account, start, end)
+	if err != nil {
+		return UsageBreakdown{}, fmt.Errorf("%w: %v", ErrDatabase, err)
+	}
+	return UsageBreakdown{
+		PeriodStart: start,
+		PeriodEnd:   end,
+		Members:     usageWithCaps(byMember, memberCaps),
+		APIKeys:     usageWithCaps(byKey, keyCaps),
+	}, nil
+}
+
+// capScope prepares the check of a batch of spending units against the spending caps of the
+// organization owning the pooled account: the check refuses the batch if it would take a member or
+// an API key past their cap for the current billing period. Units already recorded (retries) and
+// units dated outside the period don't count. The boolean is false when no unit of the batch is
+// capped.
+func (s serviceImpl) capScope(account string, items []stripedb.SpendingUnit) (stripedb.CapScope, bool, error) {
+	orgID, err := stripedb.GetAccountOrganizationID(account)
+	if err != nil {
+		return stripedb.CapScope{}, false, organizationError(err)
+	}
+	memberCaps, keyCaps, err := spendingCaps(orgID)
+	if err != nil || len(memberCaps)+len(keyCaps) == 0 {
+		return stripedb.CapScope{}, false, err
+	}
+	var capped []int
+	for i, it := range items {
+		if it.UserExternalID != account {
+			continue
+		}
+		_, memberCapped := memberCaps[stripedb.HashExternalID(it.MemberExternalID)]
+		_, keyCapped := keyCaps[it.APIKeyID]
+		if (it.MemberExternalID != "" && memberCapped) || (it.APIKeyID != "" && keyCapped) {
+			capped = append(capped, i)
+		}
+	}
+	if len(capped) == 0 {
+		return stripedb.CapScope{}, false, nil
+	}
+
+	start, end, err := s.billingPeriod(account, nil)
+	if err != nil {
+		return stripedb.CapScope{}, false, err
+	}
+	check := func(byMember, byKey map[string]int64, recorded map[string]bool) error {
+		for _, i := range capped {
+			it := items[i]
+			if recorded[it.ExternalID] || it.CreatedAt < start || it.CreatedAt > end {
+				continue
+			}
+			// count each unit once, even if the batch repeats it
+			recorded[it.ExternalID] = true
+			if it.MemberExternalID != "" {
+				member := stripedb.HashExternalID(it.MemberExternalID)
+				byMember[member] += int64(it.Amount)
+				if limit, ok := memberCaps[member]; ok && byMember[member] > limit {
+					return fmt.Errorf("%w: item %d: member spending cap of %d units reached", ErrNotAllowed, i, limit)
+				}
+			}
+			if it.APIKeyID != "" {
+				byKey[it.APIKeyID] += int64(it.Amount)
+				if limit, ok := keyCaps[it.APIKeyID]; ok && byKey[it.APIKeyID] > limit {
+					return fmt.Errorf("%w: item %d: API key spending cap of %d units reached", ErrNotAllowed, i, limit)
+				}
+			}
+		}
+		return nil
+	}
+	return stripedb.CapScope{OrganizationID: orgID, Account: account, Start: start, End: end, Check: check}, true, nil
+}
+
+// memberCapReached reports whether an organization member has spent their whole cap for the
+// current billing period. Uncapped members never reach it. sub is the account's subscription if
+// the caller already has it.
+func (s serviceImpl) memberCapReached(account, memberUserExternalID string, sub *stripe.Subscription) (bool, error) {
+	orgID, err := stripedb.GetAccountOrganizationID(account)
+	if err != nil {
+		return false, organizationError(err)
+	}
+	memberCaps, _, err := spendingCaps(orgID)
+	if err != nil {
+		return false, err
+	}
+	member := stripedb.HashExternalID(memberUserExternalID)
+	limit, ok := memberCaps[member]
+	if !ok {
+		return false, nil
+	}
+	start, end, err := s.billingPeriod(account, sub)
+	if err != nil {
+		return false, err
+	}
+	byMember, err := stripedb.UnitsByMemberBetween(account, start, end)
+	if err != nil {
+		return false, fmt.Errorf("%w: %v", ErrDatabase, err)
+	}
+	return byMember[member] >= limit, nil
+}
+
+// spendingCaps returns the organization's caps by hashed member identifier and by API key ID.
+func spendingCaps(orgID int64) (map[string]int64, map[string]int64, error) {
+	caps, err := stripedb.ListSpendingCaps(orgID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrDatabase, err)
+	}
+	memberCaps := make(map[string]int64)
+	keyCaps := make(map[string]int64)
+	for _, c := range caps {
+		if c.SubjectType == stripedb.CapSubjectMember {
+			memberCaps[c.SubjectID] = c.CapUnits
+		} else {
+			keyCaps[c.SubjectID] = c.CapUnits
+		}
+	}
+	return memberCaps, keyCaps, nil
+}
+
+// billingPeriod returns the current period (unix ms) of the account's subscription, or the
+// current calendar month (UTC) when it has no live subscription. sub is the account's subscription
+// if the caller already has it; otherwise it is read through the service's cache, as a period only
+// changes at its end, when the cached subscription expires.
+func (s serviceImpl) billingPeriod(account string, sub *stripe.Subscription) (int64, int64, error) {
+	if sub == nil {
+		ua, err := stripedb.GetUserAccount(account)
+		if err != nil {
+			return 0, 0, fmt.Errorf("%w: error retrieving user account: %v", ErrDatabase, err)
+		}
+		if ua.StripeSubscriptionID != "" {
+			fetched, err := s.cachedStripe(time.Time{}).gw.GetSubscription(ua.StripeSubscriptionID)
+			if err != nil {
+				return 0, 0, fmt.Errorf("%w: error getting subscription: %v", ErrGateway, err)
+			}
+			sub = &fetched
+		}
+	}
+	if sub != nil && !IsSubscriptionCancelled(*sub) && sub.CurrentPeriodStart > 0 {
+		// Stripe provides seconds; spending units are in milliseconds.
+		return sub.CurrentPeriodStart * 1000, sub.CurrentPeriodEnd * 1000, nil
+	}
+	now := time.Now().UTC()
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return monthStart.UnixMilli(), monthStart.AddDate(0, 1, 0).UnixMilli() - 1, nil
+}
+
+// usageWithCaps merges usage and caps, listing capped subjects without usage too.
+func usageWithCaps(units map[string]int64, caps map[string]int64) []SpendingUsage {
+	usage := make([]SpendingUsage, 0, len(units)+len(caps))
+	for id, n := range units {
+		usage = append(usage, SpendingUsage{ID: id, Units: n, CapUnits: caps[id]})
+	}
+	for id, limit := range caps {
+		if _, ok := units[id]; !ok {
+			usage = append(usage, SpendingUsage{ID: id, CapUnits: limit})
+		}
+	}
+	sort.Slice(usage, func(i, j int) bool {
+		if usage[i].Units != usage[j].Units {
+			return usage[i].Units > usage[j].Units
+		}
+		return usage[i].ID < usage[j].ID
+	})
+	return usage
+}
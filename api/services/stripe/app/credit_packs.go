@@ -0,0 +1,157 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	stripe "github.com/stripe/stripe-go"
+	"github.com/tbeaudouin05/stripe-trellai/api/config"
+	stripedb "github.com/tbeaudouin05/stripe-trellai/api/services/stripe/db"
+	"github.com/tbeaudouin05/stripe-trellai/api/services/stripe/gateway"
+	"github.com/tbeaudouin05/stripe-trellai/api/services/stripe/outbound"
+)
+
+// Checkout Session metadata keys identifying a credit pack purchase. They are set when the
+// session is created, so the webhook credits exactly what was sold even if CREDIT_PACKS changes.
+const (
+	SessionMetadataCreditPackID = "credit_pack_id"
+	SessionMetadataCreditUnits  = "credit_units"
+)
+
+// CreditPack is a one-time purchase granting Units of purchased credit.
+// Amount is in the currency's minor units.
+type CreditPack struct {
+	ID       string
+	Currency string
+	Amount   int64
+	Units    int64
+}
+
+// creditPacks parses CREDIT_PACKS, a comma-separated "id:currency:amount:units" table,
+// e.g. "starter:usd:500:1_000_000,pro:usd:4000:10_000_000".
+func creditPacks() (map[string]CreditPack, error) {
+	if config.AppConfig == nil {
+		return nil, fmt.Errorf("app config not initialized")
+	}
+	packs := make(map[string]CreditPack)
+	for _, entry := range strings.Split(config.AppConfig.CreditPacks, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.Split(entry, ":")
+		if len(parts) != 4 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid CREDIT_PACKS entry %q (want id:currency:amount:units)", entry)
+		}
+		amount, err := strconv.ParseInt(strings.ReplaceAll(parts[2], "_", ""), 10, 64)
+		if err != nil || amount <= 0 {
+			return nil, fmt.Errorf("invalid CREDIT_PACKS amount in %q", entry)
+		}
+		units, err := strconv.ParseInt(strings.ReplaceAll(parts[3], "_", ""), 10, 64)
+		if err != nil || units <= 0 {
+			return nil, fmt.Errorf("invalid CREDIT_PACKS units in %q", entry)
+		}
+		packs[parts[0]] = CreditPack{ID: parts[0], Currency: strings.ToLower(parts[1]), Amount: amount, Units: units}
+	}
+	return packs, nil
+}
+
+// CreateCreditPackCheckout starts a one-time payment Checkout Session for a configured credit pack
+// and returns the session ID. The user's existing Stripe customer is reused when known.
+func (s serviceImpl) CreateCreditPackCheckout(userExternalID, packID, successURL, cancelURL string) (string, error) {
+	packs, err := creditPacks()
+	if err != nil {
+		return "", err
+	}
+	pack, ok := packs[packID]
+	if !ok {
+		return "", fmt.Errorf("%w: unknown credit pack %q", ErrNotFound, packID)
+	}
+	// members buy credit for their organization's pool
+	billed, err := resolveOrganizationAccount(userExternalID)
+	if err != nil {
+		return "", err
+	}
+	ua, err := stripedb.GetUserAccount(billed.Account)
+	if err != nil {
+		return "", fmt.Errorf("%w: error retrieving user account: %v", ErrDatabase, err)
+	}
+	id, err := s.gw.CreatePaymentCheckout(gateway.PaymentCheckout{
+		ClientReferenceID: billed.Account,
+		CustomerID:        ua.StripeCustomerID,
+		Name:              fmt.Sprintf("%d credit units", pack.Units),
+		Amount:            pack.Amount,
+		Currency:          pack.Currency,
+		SuccessURL:        successURL,
+		CancelURL:         cancelURL,
+		Metadata: map[string]string{
+			SessionMetadataCreditPackID: pack.ID,
+			SessionMetadataCreditUnits:  strconv.FormatInt(pack.Units, 10),
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("%w: error creating checkout session: %v", ErrGateway, err)
+	}
+	return id, nil
+}
+
+// paymentSessionDetails holds Checkout Session fields missing from the pinned SDK version.
+type paymentSessionDetails struct {
+	PaymentStatus string `json:"payment_status"`
+	AmountTotal   int64  `json:"amount_total"`
+	Currency      string `json:"currency"`
+}
+
+// handleCreditPackPayment credits a paid credit pack session to the user's purchased credit.
+// Sessions whose payment_status isn't "paid" are skipped: those awaiting an asynchronous
+// payment are credited on checkout.session.async_payment_succeeded instead.
+func (s serviceImpl) handleCreditPackPayment(session stripe.CheckoutSession, raw json.RawMessage) error {
+	var details paymentSessionDetails
+	if err := json.Unmarshal(raw, &details); err != nil {
+		return fmt.Errorf("%w: error unmarshaling CheckoutSession payment details: %v", ErrBadEvent, err)
+	}
+	if details.PaymentStatus != "paid" {
+		slog.Info("credit pack session not paid yet", "session_id", session.ID, "payment_status", details.PaymentStatus)
+		return nil
+	}
+	units, err := strconv.ParseInt(session.Metadata[SessionMetadataCreditUnits], 10, 64)
+	if err != nil || units <= 0 {
+		return fmt.Errorf("%w: invalid %s metadata on CheckoutSession %q", ErrBadEvent, SessionMetadataCreditUnits, session.ID)
+	}
+	billed, err := resolveAccount(session.ClientReferenceID)
+	if err != nil {
+		return err
+	}
+	userExternalID := billed.Account
+	var customerID string
+	if session.Customer != nil {
+		customerID = session.Customer.ID
+	}
+	// Make sure the account exists (and remember the customer) without touching its subscription.
+	if err := stripedb.UpsertUserAccount(userExternalID, "", "", customerID); err != nil {
+		return fmt.Errorf("%w: error upserting user_account: %v", ErrDatabase, err)
+	}
+	granted, err := stripedb.GrantCreditPurchase(userExternalID, stripedb.CreditPurchase{
+		StripeCheckoutSessionID: session.ID,
+		PackID:                  session.Metadata[SessionMetadataCreditPackID],
+		Units:                   units,
+		Amount:                  details.AmountTotal,
+		Currency:                details.Currency,
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrDatabase, err)
+	}
+	slog.Info("credit pack purchase processed", "session_id", session.ID, "units", units, "granted", granted)
+	if granted {
+		entitlementsChanged(userExternalID)
+		data := userEventData(userExternalID)
+		data.StripeCustomerID, data.Units, data.Source = customerID, units, CreditSourceCreditPack
+		if err := emitEvent(outbound.EventCreditsGranted, session.ID, data); err != nil {
+			return err
+		}
+	}
+	return s.rewardReferral(userExternalID)
+}
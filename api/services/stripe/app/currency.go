@@ -0,0 +1,110 @@
+package app
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+
+	"github.com/tbeaudouin05/stripe-trellai/api/config"
+)
+
+// zeroDecimalCurrencies are charged by Stripe in whole units (no minor unit).
+// See https://stripe.com/docs/currencies#zero-decimal.
+var zeroDecimalCurrencies = map[string]bool{
+	"bif": true, "clp": true, "djf": true, "gnf": true, "jpy": true, "kmf": true,
+	"krw": true, "mga": true, "pyg": true, "rwf": true, "ugx": true, "vnd": true,
+	"vuv": true, "xaf": true, "xof": true, "xpf": true,
+}
+
+// threeDecimalCurrencies use three minor-unit digits in Stripe amounts.
+var threeDecimalCurrencies = map[string]bool{
+	"bhd": true, "jod": true, "kwd": true, "omr": true, "tnd": true,
+}
+
+// minorUnitsPerMajor returns how many minor units make up one major unit of the currency
+// (100 for USD, 1 for JPY, 1000 for KWD).
+func minorUnitsPerMajor(currency string) int64 {
+	c := strings.ToLower(currency)
+	switch {
+	case zeroDecimalCurrencies[c]:
+		return 1
+	case threeDecimalCurrencies[c]:
+		return 1000
+	default:
+		return 100
+	}
+}
+
+// Rate settings, parsed once per value rather than on every verification.
+var (
+	parsedCurrencyRates parsedSetting[map[string]*big.Rat]
+	parsedDollarRate    parsedSetting[*big.Rat]
+)
+
+// unitsRateFor returns the number of credit units granted per major unit of currency, and how
+// many minor units the rate applies to. CREDIT_UNITS_PER_CURRENCY entries take precedence; other
+// currencies (USD included) fall back to CREDIT_UNITS_PER_DOLLAR per 100 minor units, as amounts
+// were read before per-currency rates existed. Rates may be fractional (e.g., jpy:13.5) and may use
+// underscores.
+func unitsRateFor(currency string) (*big.Rat, int64, error) {
+	if config.AppConfig == nil {
+		return nil, 0, fmt.Errorf("app config not initialized")
+	}
+	c := strings.ToLower(currency)
+	rates, err := parsedCurrencyRates.get(config.AppConfig.CreditUnitsPerCurrency, config.ParseCurrencyRates)
+	if err != nil {
+		return nil, 0, err
+	}
+	if r, ok := rates[c]; ok {
+		return r, minorUnitsPerMajor(c), nil
+	}
+	r, err := parsedDollarRate.get(config.AppConfig.CreditUnitsPerDollar, func(raw string) (*big.Rat, error) {
+		r, ok := config.ParseRate(raw)
+		if !ok {
+			return nil, fmt.Errorf("invalid CREDIT_UNITS_PER_DOLLAR: %q", raw)
+		}
+		return r, nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return r, 100, nil
+}
+
+// ratAllowance converts an exact amount in minor units into credit units. The computation is
+// exact and only floors the final result, so $9.99 grants 9.99 dollars' worth of units rather
+// than 9.
+func ratAllowance(amountMinor *big.Rat, currency string) (int64, error) {
+	rate, minorUnits, err := unitsRateFor(currency)
+	if err != nil {
+		return 0, err
+	}
+	units := new(big.Rat).Quo(amountMinor, new(big.Rat).SetInt64(minorUnits))
+	units.Mul(units, rate)
+	floor := new(big.Int).Quo(units.Num(), units.Denom())
+	if !floor.IsInt64() {
+		return 0, fmt.Errorf("allowance overflows int64")
+	}
+	return floor.Int64(), nil
+}
+
+// parsedSetting caches the value parsed from a configuration setting, keyed by its raw value so a
+// changed setting is parsed again.
+type parsedSetting[T any] struct {
+	mu     sync.Mutex
+	parsed bool
+	raw    string
+	val    T
+	err    error
+}
+
+func (p *parsedSetting[T]) get(raw string, parse func(string) (T, error)) (T, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.parsed || p.raw != raw {
+		p.val, p.err = parse(raw)
+		p.parsed, p.raw = true, raw
+	}
+	return p.val, p.err
+}
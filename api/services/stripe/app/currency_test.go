@@ -0,0 +1,42 @@
+package app
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	config "github.com/tbeaudouin05/stripe-trellai/api/config"
+)
+
+func Test_RatAllowance(t *testing.T) {
+	orig := config.AppConfig
+	t.Cleanup(func() { config.AppConfig = orig })
+	config.AppConfig = &config.Config{
+		CreditUnitsPerDollar:   "1_000",
+		CreditUnitsPerCurrency: "eur:1_100, jpy:7.5, kwd:3000",
+	}
+
+	cases := []struct {
+		name     string
+		amount   int64
+		currency string
+		quantity int64
+		want     int64
+	}{
+		{"fractional dollars are not truncated", 999, "usd", 1, 9990},
+		{"quantity multiplies before flooring", 333, "usd", 3, 9990},
+		{"empty currency uses dollar rate", 100, "", 2, 2000},
+		{"per-currency rate", 250, "eur", 1, 2750},
+		{"zero-decimal currency", 1000, "jpy", 1, 7500},
+		{"fractional rate floors the result", 1, "jpy", 1, 7},
+		{"three-decimal currency", 1500, "kwd", 1, 4500},
+		{"currency without a rate uses the dollar rate", 1000, "gbp", 1, 10000},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := ratAllowance(new(big.Rat).SetInt64(c.amount*c.quantity), c.currency)
+			assert.NoError(t, err)
+			assert.Equal(t, c.want, got)
+		})
+	}
+}
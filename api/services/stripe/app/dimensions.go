@@ -0,0 +1,83 @@
+package app
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/tbeaudouin05/stripe-trellai/api/config"
+	stripedb "github.com/tbeaudouin05/stripe-trellai/api/services/stripe/db"
+)
+
+// DimensionUsage is an account's usage in the current billing period [PeriodStart, PeriodEnd]
+// (unix ms) by feature and, when a label key was asked for, by that label's value.
+type DimensionUsage struct {
+	PeriodStart int64
+	PeriodEnd   int64
+	LabelKey    string
+	Rows        []stripedb.DimensionUnits
+}
+
+// parsedWeights caches FEATURE_WEIGHTS, which every recorded batch applies.
+var parsedWeights parsedSetting[map[string]int64]
+
+// featureWeights returns FEATURE_WEIGHTS, parsed once per value (LoadConfig validated it).
+func featureWeights() (map[string]int64, error) {
+	if config.AppConfig == nil {
+		return nil, fmt.Errorf("app config not initialized")
+	}
+	return parsedWeights.get(config.AppConfig.FeatureWeights, config.ParseFeatureWeights)
+}
+
+// applyFeatureWeights multiplies the amount of units reported under a weighted feature.
+func applyFeatureWeights(items []stripedb.SpendingUnit) error {
+	weights, err := featureWeights()
+	if err != nil || len(weights) == 0 {
+		return err
+	}
+	for i, it := range items {
+		if w, ok := weights[it.FeatureKey]; ok && it.FeatureKey != "" {
+			items[i].Amount = it.Amount * int(w)
+		}
+	}
+	return nil
+}
+
+// GetUsageByDimension returns the usage of the account the user is billed to (their
+// organization's pool for members) in the current billing period, by feature and, when labelKey
+// is given, by the value of that label. Weighted features are reported in weighted units.
+func (s serviceImpl) GetUsageByDimension(userExternalID, labelKey string) (DimensionUsage, error) {
+	billed, err := resolveAccount(userExternalID)
+	if err != nil {
+		return DimensionUsage{}, err
+	}
+	account := billed.Account
+	start, end, err := s.billingPeriod(account, nil)
+	if err != nil {
+		return DimensionUsage{}, err
+	}
+	rows, err := stripedb.UnitsByDimensionBetween(account, labelKey, start, end)
+	if err != nil {
+		return DimensionUsage{}, fmt.Errorf("%w: %v", ErrDatabase, err)
+	}
+	return DimensionUsage{PeriodStart: start, PeriodEnd: end, LabelKey: labelKey, Rows: rows}, nil
+}
+
+// exhaustedFeatures returns the features that used more than their limit between start and end
+// (unix ms), in name order. Units paid for with credit don't count, as for the allowance.
+func exhaustedFeatures(account string, limits map[string]int64, start, end int64) ([]string, error) {
+	if len(limits) == 0 {
+		return nil, nil
+	}
+	rows, err := stripedb.UnitsByDimensionBetween(account, "", start, end)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDatabase, err)
+	}
+	var exhausted []string
+	for _, r := range rows {
+		if limit, ok := limits[r.FeatureKey]; ok && r.FeatureKey != "" && r.AllowanceUnits > limit {
+			exhausted = append(exhausted, r.FeatureKey)
+		}
+	}
+	sort.Strings(exhausted)
+	return exhausted, nil
+}
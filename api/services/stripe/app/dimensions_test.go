@@ -0,0 +1,108 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	stripe "github.com/stripe/stripe-go"
+	config "github.com/tbeaudouin05/stripe-trellai/api/config"
+	stripedb "github.com/tbeaudouin05/stripe-trellai/api/services/stripe/db"
+)
+
+func Test_ParseFeatureTable(t *testing.T) {
+	table, err := config.ParseFeatureTable(" image_generation:5, email_draft:1_000,", "FEATURE_WEIGHTS")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int64{"image_generation": 5, "email_draft": 1000}, table)
+
+	for _, raw := range []string{"image_generation", ":5", "image_generation:-1", "image_generation:many"} {
+		_, err := config.ParseFeatureTable(raw, "FEATURE_WEIGHTS")
+		assert.Error(t, err, raw)
+	}
+}
+
+func Test_ApplyFeatureWeights(t *testing.T) {
+	orig := config.AppConfig
+	t.Cleanup(func() { config.AppConfig = orig })
+	config.AppConfig = &config.Config{FeatureWeights: "image_generation:5"}
+
+	items := []stripedb.SpendingUnit{
+		{ExternalID: "u1", Amount: 2, FeatureKey: "image_generation"},
+		{ExternalID: "u2", Amount: 2, FeatureKey: "email_draft"},
+		{ExternalID: "u3", Amount: 2},
+	}
+	assert.NoError(t, applyFeatureWeights(items))
+	assert.Equal(t, []int{10, 2, 2}, []int{items[0].Amount, items[1].Amount, items[2].Amount})
+
+	config.AppConfig = &config.Config{FeatureWeights: "image_generation:0"}
+	assert.Error(t, applyFeatureWeights(items), "weights must be > 0")
+}
+
+func Test_SubscriptionAllowance_FeatureLimits(t *testing.T) {
+	svc := serviceImpl{}
+	plan := func(limits string) *stripe.Plan {
+		return &stripe.Plan{Metadata: map[string]string{PlanMetadataUnitsPerPeriod: "100", PlanMetadataFeatureLimits: limits}}
+	}
+	sub := stripe.Subscription{Items: &stripe.SubscriptionItemList{Data: []*stripe.SubscriptionItem{
+		{ID: "si_1", Quantity: 3, Plan: plan("image_generation:10, email_draft:1_000")},
+		{ID: "si_2", Quantity: 1, Plan: plan("image_generation:5")},
+	}}}
+
+	e, err := svc.subscriptionAllowance(sub)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(400), e.Units)
+	// limits are per quantity and add up across items
+	assert.Equal(t, map[string]int64{"image_generation": 35, "email_draft": 3000}, e.FeatureLimits)
+
+	sub.Items.Data[1].Plan = plan("image_generation")
+	_, err = svc.subscriptionAllowance(sub)
+	assert.Error(t, err, "invalid feature_limits metadata")
+}
+
+func Test_VerifySubscription_ReportsExhaustedFeatures(t *testing.T) {
+	db, cleanup := setupSubTestDB(t)
+	defer cleanup()
+	const planID = "plan_sub_test_feature_limits"
+	_, _ = db.Exec("DELETE FROM plan_allowance WHERE stripe_plan_id = $1", planID)
+	defer db.Exec("DELETE FROM plan_allowance WHERE stripe_plan_id = $1", planID)
+	if err := stripedb.UpsertUserAccount(subBoardID, "sub_123", "plan_123", "cust_123"); err != nil {
+		t.Fatalf("UpsertUserAccount failed: %v", err)
+	}
+	if _, err := db.Exec("INSERT INTO free_credit (user_external_id, credit) VALUES ($1, 0) ON CONFLICT (user_external_id) DO UPDATE SET credit = 0", stripedb.HashExternalID(subBoardID)); err != nil {
+		t.Fatalf("Failed to upsert free_credit: %v", err)
+	}
+	now := time.Now().Unix()
+	record := func(id string, amount int) {
+		if _, err := stripedb.AddSpendingUnits([]stripedb.SpendingUnit{
+			{ExternalID: id, UserExternalID: subBoardID, Amount: amount, FeatureKey: "image_generation", CreatedAt: now * 1000},
+		}); err != nil {
+			t.Fatalf("AddSpendingUnits failed: %v", err)
+		}
+	}
+	gw := fakeGateway{
+		subs: map[string]stripe.Subscription{"sub_123": {
+			Status:             stripe.SubscriptionStatusActive,
+			Quantity:           1,
+			Plan:               &stripe.Plan{ID: planID, Metadata: map[string]string{PlanMetadataUnitsPerPeriod: "5", PlanMetadataFeatureLimits: "image_generation:2"}},
+			CurrentPeriodStart: now - 60,
+			CurrentPeriodEnd:   now + 86400,
+		}},
+		custs: map[string]stripe.Customer{"cust_123": {Email: "limits@example.com"}},
+	}
+	svc := NewService(gw)
+
+	// past the feature's limit, within the allowance
+	record("sub-limits-unit-1", 3)
+	resp, err := svc.VerifySubscription(subBoardID)
+	assert.NoError(t, err)
+	assert.True(t, resp.IsValidSubscription)
+	assert.Equal(t, []string{"image_generation"}, resp.ExhaustedFeatures)
+
+	// past the allowance too: the exhausted response still lists the feature
+	record("sub-limits-unit-2", 3)
+	resp, err = svc.VerifySubscription(subBoardID)
+	assert.NoError(t, err)
+	assert.False(t, resp.IsValidSubscription)
+	assert.Equal(t, InvalidityTypeExhausted, resp.InvalidityType)
+	assert.Equal(t, []string{"image_generation"}, resp.ExhaustedFeatures)
+}
@@ -0,0 +1,202 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+
+	config "github.com/tbeaudouin05/stripe-trellai/api/config"
+	stripedb "github.com/tbeaudouin05/stripe-trellai/api/services/stripe/db"
+	"github.com/tbeaudouin05/stripe-trellai/api/services/stripe/gateway"
+)
+
+// Duplicate subscription policies (DUPLICATE_SUBSCRIPTION_POLICY). They double as the resolutions
+// recorded on invalid_subscription entries, together with DuplicateResolutionDismiss.
+const (
+	// DuplicatePolicyReview queues the new subscription for manual review; both stay active.
+	DuplicatePolicyReview = "review"
+	// DuplicatePolicyCancelNewRefund cancels the new subscription and refunds its first invoice.
+	DuplicatePolicyCancelNewRefund = "cancel_new_refund"
+	// DuplicatePolicyCancelOld cancels the previous subscription and keeps the new one.
+	DuplicatePolicyCancelOld = "cancel_old"
+	// DuplicateResolutionDismiss resolves a queued entry without changing anything in Stripe.
+	DuplicateResolutionDismiss = "dismiss"
+)
+
+// duplicatePolicyActor is recorded as resolved_by for duplicates resolved automatically.
+const duplicatePolicyActor = "policy"
+
+func duplicatePolicy() string {
+	if config.AppConfig == nil || config.AppConfig.DuplicateSubscriptionPolicy == "" {
+		return DuplicatePolicyReview
+	}
+	return config.AppConfig.DuplicateSubscriptionPolicy
+}
+
+// handleDuplicateSubscription records newSubID, checked out while the user's existingSubID is
+// still active, in invalid_subscription and applies the duplicate subscription policy to it. The
+// entry is recorded as pending before the policy is applied and resolved after, so a failure at any
+// step leaves it either retried by the webhook or queued for review, never unrecorded. A
+// cancel_new_refund duplicate whose first invoice was not charged is left pending for review.
+func (s serviceImpl) handleDuplicateSubscription(userExternalID, existingSubID, newSubID, customerID string) error {
+	policy := duplicatePolicy()
+	entry, found, err := stripedb.FindInvalidSubscription(newSubID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrDatabase, err)
+	}
+	if found && (entry.Resolution != "" || policy == DuplicatePolicyReview) {
+		slog.Info("duplicate subscription already recorded", "stripe_subscription_id", newSubID)
+		return nil
+	}
+	if !found {
+		entry.ID, err = stripedb.RecordInvalidSubscription(stripedb.InvalidSubscription{
+			UserExternalID:       userExternalID,
+			StripeSubscriptionID: newSubID,
+			StripePlanID:         "no_need",
+			StripeCustomerID:     customerID,
+		})
+		if err != nil {
+			return fmt.Errorf("%w: error inserting invalid subscription: %v", ErrDatabase, err)
+		}
+	}
+	if policy != DuplicatePolicyReview {
+		hashed := stripedb.HashExternalID(userExternalID)
+		err := s.applyDuplicateResolution(hashed, existingSubID, newSubID, customerID, policy)
+		if errors.Is(err, gateway.ErrNothingToRefund) {
+			slog.Warn("duplicate subscription has no charge to refund, left for review", "stripe_subscription_id", newSubID, "existing_subscription_id", existingSubID)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := resolveDuplicateByPolicy(entry.ID, policy); err != nil {
+			return err
+		}
+	}
+	slog.Info("duplicate subscription handled", "stripe_subscription_id", newSubID, "existing_subscription_id", existingSubID, "policy", policy)
+	return nil
+}
+
+// resumeDuplicateResolution finishes a cancel_old policy resolution whose subscription swap was
+// saved but whose entry was not resolved: the webhook retry then finds newSubID already on the
+// account and would otherwise leave the entry pending.
+func resumeDuplicateResolution(newSubID string) error {
+	if duplicatePolicy() != DuplicatePolicyCancelOld {
+		return nil
+	}
+	entry, found, err := stripedb.FindInvalidSubscription(newSubID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrDatabase, err)
+	}
+	if !found || entry.Resolution != "" {
+		return nil
+	}
+	return resolveDuplicateByPolicy(entry.ID, DuplicatePolicyCancelOld)
+}
+
+// resolveDuplicateByPolicy marks a pending entry resolved by the automatic policy. An entry an admin
+// resolved in the meantime is left as is.
+func resolveDuplicateByPolicy(id int64, policy string) error {
+	err := stripedb.ResolveInvalidSubscription(id, policy, duplicatePolicyActor)
+	if err != nil && !errors.Is(err, stripedb.ErrInvalidSubscriptionResolved) {
+		return fmt.Errorf("%w: %v", ErrDatabase, err)
+	}
+	return nil
+}
+
+// applyDuplicateResolution carries out a resolution for newSubID against the user's existingSubID.
+// cancel_new_refund fails with ErrNotAllowed (wrapping gateway.ErrNothingToRefund) before cancelling
+// anything when newSubID's latest invoice has no charge, so the entry is not recorded as refunded.
+// Each step is safe to repeat, so a failed attempt can be retried.
+func (s serviceImpl) applyDuplicateResolution(hashedUserExternalID, existingSubID, newSubID, customerID, resolution string) error {
+	switch resolution {
+	case DuplicatePolicyCancelNewRefund:
+		refundID, err := s.gw.RefundLatestInvoice(newSubID, "duplicate-refund-"+newSubID)
+		if errors.Is(err, gateway.ErrNothingToRefund) {
+			return fmt.Errorf("%w: duplicate subscription %s: %w", ErrNotAllowed, newSubID, err)
+		}
+		if err != nil {
+			return fmt.Errorf("%w: error refunding duplicate subscription: %v", ErrGateway, err)
+		}
+		slog.Info("refunded duplicate subscription", "stripe_subscription_id", newSubID, "refund_id", refundID)
+		return s.cancelIfActive(newSubID)
+	case DuplicatePolicyCancelOld:
+		if existingSubID != "" && existingSubID != newSubID {
+			if err := s.cancelIfActive(existingSubID); err != nil {
+				return err
+			}
+		}
+		if err := stripedb.ReplaceSubscriptionHashed(hashedUserExternalID, newSubID, customerID); err != nil {
+			return fmt.Errorf("%w: %v", ErrDatabase, err)
+		}
+		return nil
+	case DuplicateResolutionDismiss:
+		return nil
+	default:
+		return fmt.Errorf("%w: invalid resolution %q (want %s, %s or %s)", ErrNotAllowed, resolution, DuplicatePolicyCancelNewRefund, DuplicatePolicyCancelOld, DuplicateResolutionDismiss)
+	}
+}
+
+// cancelIfActive cancels a subscription unless it is already cancelled.
+func (s serviceImpl) cancelIfActive(subscriptionID string) error {
+	sub, err := s.gw.GetSubscription(subscriptionID)
+	if err != nil {
+		return fmt.Errorf("%w: error getting subscription: %v", ErrGateway, err)
+	}
+	if IsSubscriptionCancelled(sub) {
+		return nil
+	}
+	if err := s.gw.CancelSubscription(subscriptionID); err != nil {
+		return fmt.Errorf("%w: error cancelling subscription: %v", ErrGateway, err)
+	}
+	return nil
+}
+
+// ListInvalidSubscriptions returns up to limit duplicate subscriptions after afterID, oldest first;
+// only those pending review unless includeResolved is set.
+func (s serviceImpl) ListInvalidSubscriptions(afterID int64, limit int, includeResolved bool) ([]stripedb.InvalidSubscription, error) {
+	list, err := stripedb.ListInvalidSubscriptions(afterID, limit, includeResolved)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDatabase, err)
+	}
+	return list, nil
+}
+
+// ResolveInvalidSubscription works a queued duplicate on behalf of an admin (actor): resolution is
+// cancel_new_refund, cancel_old (cancelling the user's current subscription) or dismiss.
+func (s serviceImpl) ResolveInvalidSubscription(id int64, resolution, actor string) (stripedb.InvalidSubscription, error) {
+	switch resolution {
+	case DuplicatePolicyCancelNewRefund, DuplicatePolicyCancelOld, DuplicateResolutionDismiss:
+	default:
+		return stripedb.InvalidSubscription{}, fmt.Errorf("%w: invalid resolution %q (want %s, %s or %s)", ErrNotAllowed, resolution, DuplicatePolicyCancelNewRefund, DuplicatePolicyCancelOld, DuplicateResolutionDismiss)
+	}
+	entry, err := stripedb.GetInvalidSubscription(id)
+	if err != nil {
+		if errors.Is(err, stripedb.ErrInvalidSubscriptionNotFound) {
+			return stripedb.InvalidSubscription{}, fmt.Errorf("%w: %v", ErrNotFound, err)
+		}
+		return stripedb.InvalidSubscription{}, fmt.Errorf("%w: %v", ErrDatabase, err)
+	}
+	if entry.Resolution != "" {
+		return stripedb.InvalidSubscription{}, fmt.Errorf("%w: %v", ErrNotAllowed, stripedb.ErrInvalidSubscriptionResolved)
+	}
+	current, err := stripedb.GetSubscriptionIDHashed(entry.UserExternalID)
+	if err != nil {
+		return stripedb.InvalidSubscription{}, fmt.Errorf("%w: %v", ErrDatabase, err)
+	}
+	if err := s.applyDuplicateResolution(entry.UserExternalID, current, entry.StripeSubscriptionID, entry.StripeCustomerID, resolution); err != nil {
+		return stripedb.InvalidSubscription{}, err
+	}
+	if err := stripedb.ResolveInvalidSubscription(id, resolution, actor); err != nil {
+		if errors.Is(err, stripedb.ErrInvalidSubscriptionResolved) {
+			return stripedb.InvalidSubscription{}, fmt.Errorf("%w: %v", ErrNotAllowed, err)
+		}
+		return stripedb.InvalidSubscription{}, fmt.Errorf("%w: %v", ErrDatabase, err)
+	}
+	slog.Info("duplicate subscription resolved", "id", id, "resolution", resolution, "actor", actor)
+	entry, err = stripedb.GetInvalidSubscription(id)
+	if err != nil {
+		return stripedb.InvalidSubscription{}, fmt.Errorf("%w: %v", ErrDatabase, err)
+	}
+	return entry, nil
+}
@@ -0,0 +1,182 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"time"
+
+	"github.com/tbeaudouin05/stripe-trellai/api/pubsub"
+	stripedb "github.com/tbeaudouin05/stripe-trellai/api/services/stripe/db"
+)
+
+// entitlementsResync is how often watched entitlements are recomputed without a change
+// notification, to catch what no event announces (period rollover, trial or grace end).
+const entitlementsResync = time.Minute
+
+// entitlementsDebounce is how long a watcher waits after a change is announced before recomputing,
+// so a burst of writes (e.g. pooled usage) costs one recomputation.
+const entitlementsDebounce = 500 * time.Millisecond
+
+// EntitlementState is what a user can currently use: their validity as VerifySubscription
+// reports it, and the units left from free credit, purchased credit and the subscription's
+// allowance for the billing period [PeriodStart, PeriodEnd] (unix ms, 0 without a live
+// subscription). RemainingUnits doesn't include the allowance when Unlimited.
+type EntitlementState struct {
+	Validity        VerifySubscriptionResponse
+	FreeCredit      int64
+	PurchasedCredit int64
+	AllowanceUnits  int64
+	UsedUnits       int64
+	Unlimited       bool
+	RemainingUnits  int64
+	PeriodStart     int64
+	PeriodEnd       int64
+}
+
+// GetEntitlements returns the user's entitlement state. Members get their organization's pool.
+func (s serviceImpl) GetEntitlements(userExternalID string) (EntitlementState, error) {
+	validity, billed, sub, err := s.verifyUser(userExternalID)
+	if err != nil {
+		return EntitlementState{}, err
+	}
+	account := billed.Account
+	st := EntitlementState{Validity: validity}
+	free, err := stripedb.GetFreeCredit(account)
+	if err != nil {
+		return EntitlementState{}, fmt.Errorf("%w: error retrieving free credit: %v", ErrDatabase, err)
+	}
+	if st.PurchasedCredit, err = stripedb.GetPurchasedCredit(account); err != nil {
+		return EntitlementState{}, fmt.Errorf("%w: error retrieving purchased credit: %v", ErrDatabase, err)
+	}
+	st.FreeCredit = int64(free)
+	st.RemainingUnits = st.FreeCredit + st.PurchasedCredit
+
+	// reuse the subscription verification fetched; it skips Stripe when credit settles validity
+	if sub == nil {
+		ua, err := stripedb.GetUserAccount(account)
+		if err != nil {
+			return EntitlementState{}, fmt.Errorf("%w: error retrieving user account: %v", ErrDatabase, err)
+		}
+		if ua.StripeSubscriptionID == "" {
+			return st, nil
+		}
+		fetched, err := s.gw.GetSubscription(ua.StripeSubscriptionID)
+		if err != nil {
+			return EntitlementState{}, fmt.Errorf("%w: error getting subscription: %v", ErrGateway, err)
+		}
+		sub = &fetched
+	}
+	if IsSubscriptionCancelled(*sub) || IsSubscriptionPaused(*sub) || sub.CurrentPeriodStart == 0 {
+		return st, nil
+	}
+	allowance, err := s.subscriptionAllowance(*sub)
+	if err != nil {
+		return EntitlementState{}, err
+	}
+	// Stripe provides seconds; spending units are in milliseconds.
+	st.PeriodStart, st.PeriodEnd = sub.CurrentPeriodStart*1000, sub.CurrentPeriodEnd*1000
+	used, err := stripedb.CountUnitsBetween(account, st.PeriodStart, st.PeriodEnd)
+	if err != nil {
+		return EntitlementState{}, fmt.Errorf("%w: error counting units: %v", ErrDatabase, err)
+	}
+	st.UsedUnits, st.AllowanceUnits, st.Unlimited = int64(used), allowance.Units, allowance.Unlimited
+	if !st.Unlimited && st.AllowanceUnits > st.UsedUnits {
+		st.RemainingUnits += st.AllowanceUnits - st.UsedUnits
+	}
+	return st, nil
+}
+
+// WatchEntitlements sends the user's entitlement state, then the new state each time it changes,
+// until ctx is done. Changes are announced by entitlementsChanged and usageChanged on any instance,
+// and the state is also recomputed every entitlementsResync. A burst of announcements is recomputed
+// once, entitlementsDebounce after the first. Subscriptions and customers are read from the
+// service's cache, shared with the other watchers: only entitlementsChanged fetches them again,
+// while new usage is recomputed from the database alone. Errors after the first state are logged
+// and the watch goes on.
+func (s serviceImpl) WatchEntitlements(ctx context.Context, userExternalID string, send func(EntitlementState) error) error {
+	var (
+		last       *EntitlementState
+		subscribed string
+		changed    <-chan struct{}
+		used       <-chan struct{}
+		cancel     = func() {}
+		// subscriptions fetched before the last change announced are fetched again
+		notBefore time.Time
+	)
+	defer func() { cancel() }()
+	resync := time.NewTicker(entitlementsResync)
+	defer resync.Stop()
+	for {
+		// follow the user into and out of organizations
+		billed, err := resolveAccount(userExternalID)
+		if err == nil && billed.Account != subscribed {
+			cancel()
+			hashed := stripedb.HashExternalID(billed.Account)
+			var cancelChanged, cancelUsed func()
+			changed, cancelChanged = pubsub.Subscribe(stripedb.HashExternalID(userExternalID), hashed)
+			used, cancelUsed = pubsub.Subscribe(usageTopic(hashed))
+			cancel = func() { cancelChanged(); cancelUsed() }
+			subscribed = billed.Account
+		}
+		var st EntitlementState
+		if err == nil {
+			st, err = s.cachedStripe(notBefore).GetEntitlements(userExternalID)
+		}
+		switch {
+		case err != nil && last == nil:
+			return err
+		case err != nil:
+			slog.Warn("error recomputing watched entitlements", "err", err)
+		case last == nil || !reflect.DeepEqual(st, *last):
+			if err := send(st); err != nil {
+				return err
+			}
+			last = &st
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-changed:
+			notBefore = time.Now()
+		case <-used:
+		case <-resync.C:
+			continue
+		}
+		// let the burst settle
+		settle := time.NewTimer(entitlementsDebounce)
+	debounce:
+		for {
+			select {
+			case <-ctx.Done():
+				settle.Stop()
+				return nil
+			case <-changed:
+				notBefore = time.Now()
+			case <-used:
+			case <-settle.C:
+				break debounce
+			}
+		}
+	}
+}
+
+// entitlementsChanged tells watchers of the users (raw identifiers) that their entitlements may
+// have changed.
+func entitlementsChanged(userExternalIDs ...string) {
+	for _, id := range userExternalIDs {
+		pubsub.Publish(stripedb.HashExternalID(id))
+	}
+}
+
+// usageChanged tells watchers of the accounts (hashed identifiers) that units were recorded or
+// refunded. Unlike entitlementsChanged, it says nothing changed in Stripe.
+func usageChanged(hashedAccounts ...string) {
+	for _, account := range hashedAccounts {
+		pubsub.Publish(usageTopic(account))
+	}
+}
+
+// usageTopic is the pubsub topic usageChanged announces an account's usage on.
+func usageTopic(hashedAccount string) string { return "usage:" + hashedAccount }
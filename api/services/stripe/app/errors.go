@@ -12,4 +12,8 @@ var (
 	ErrDatabase = errors.New("database error")
 	// ErrGateway indicates a failure from the Stripe gateway / API calls.
 	ErrGateway = errors.New("gateway error")
+	// ErrNotFound indicates a referenced record does not exist.
+	ErrNotFound = errors.New("not found")
+	// ErrNotAllowed indicates the request is valid but refused by a business rule.
+	ErrNotAllowed = errors.New("not allowed")
 )
@@ -0,0 +1,44 @@
+package app
+
+import (
+	"time"
+
+	"github.com/stripe/stripe-go"
+	"github.com/tbeaudouin05/stripe-trellai/api/config"
+)
+
+// graceDeadline returns until when (unix ms) a subscription with a payment problem stays valid,
+// and false once that deadline has passed at now.
+//
+//   - past_due: GRACE_PAST_DUE_DAYS after the renewal that failed. Stripe advances the billing
+//     period when it attempts the renewal, so that is the end of the unpaid period, i.e. the
+//     current period's start.
+//   - incomplete: GRACE_INCOMPLETE_HOURS after the subscription was created (its first payment failed
+//     or needs authentication).
+//
+// Other statuses, and statuses whose grace is configured as 0, get no grace period.
+func graceDeadline(sub stripe.Subscription, now time.Time) (int64, bool) {
+	if config.AppConfig == nil {
+		return 0, false
+	}
+	var from int64
+	var grace time.Duration
+	switch sub.Status {
+	case stripe.SubscriptionStatusPastDue:
+		from = sub.CurrentPeriodStart
+		grace = time.Duration(config.AppConfig.GracePastDueDays) * 24 * time.Hour
+	case stripe.SubscriptionStatusIncomplete:
+		from = sub.Created
+		grace = time.Duration(config.AppConfig.GraceIncompleteHours) * time.Hour
+	default:
+		return 0, false
+	}
+	if grace == 0 || from == 0 {
+		return 0, false
+	}
+	deadline := time.Unix(from, 0).Add(grace)
+	if !now.Before(deadline) {
+		return 0, false
+	}
+	return deadline.UnixMilli(), true
+}
@@ -0,0 +1,42 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	stripe "github.com/stripe/stripe-go"
+	config "github.com/tbeaudouin05/stripe-trellai/api/config"
+)
+
+func Test_GraceDeadline(t *testing.T) {
+	orig := config.AppConfig
+	t.Cleanup(func() { config.AppConfig = orig })
+	config.AppConfig = &config.Config{GracePastDueDays: 3, GraceIncompleteHours: 12}
+
+	now := time.Unix(1_700_000_000, 0)
+	day := int64(86400)
+	cases := []struct {
+		name   string
+		sub    stripe.Subscription
+		want   int64
+		within bool
+	}{
+		{"past_due within grace", stripe.Subscription{Status: stripe.SubscriptionStatusPastDue, CurrentPeriodStart: now.Unix() - day}, (now.Unix() + 2*day) * 1000, true},
+		{"past_due after grace", stripe.Subscription{Status: stripe.SubscriptionStatusPastDue, CurrentPeriodStart: now.Unix() - 3*day}, 0, false},
+		{"incomplete within grace", stripe.Subscription{Status: stripe.SubscriptionStatusIncomplete, Created: now.Unix() - 3600}, (now.Unix() + 11*3600) * 1000, true},
+		{"incomplete after grace", stripe.Subscription{Status: stripe.SubscriptionStatusIncomplete, Created: now.Unix() - 13*3600}, 0, false},
+		{"unpaid has no grace", stripe.Subscription{Status: stripe.SubscriptionStatusUnpaid, CurrentPeriodStart: now.Unix()}, 0, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, ok := graceDeadline(c.sub, now)
+			assert.Equal(t, c.within, ok)
+			assert.Equal(t, c.want, got)
+		})
+	}
+
+	config.AppConfig.GracePastDueDays = 0
+	_, ok := graceDeadline(stripe.Subscription{Status: stripe.SubscriptionStatusPastDue, CurrentPeriodStart: now.Unix()}, now)
+	assert.False(t, ok, "grace disabled when configured as 0")
+}
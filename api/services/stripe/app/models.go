@@ -8,12 +8,19 @@ const (
     InvalidityTypeNoSubscription InvalidityType = "noSubscription"
     InvalidityTypeCancelled      InvalidityType = "cancelled"
     InvalidityTypeExhausted      InvalidityType = "exhausted"
+    InvalidityTypePaused         InvalidityType = "paused"
+    InvalidityTypeNoSeat         InvalidityType = "noSeat"
+    InvalidityTypeCapReached     InvalidityType = "capReached"
     InvalidityTypeOther          InvalidityType = "other"
 )
 
 const (
     ValidityTypeFreeTier       ValidityType = "freeTier"
     ValidityTypePayingCustomer ValidityType = "payingCustomer"
+    ValidityTypeOverage        ValidityType = "overage"
+    ValidityTypePrepaidCredit  ValidityType = "prepaidCredit"
+    ValidityTypeTrial          ValidityType = "trial"
+    ValidityTypeGracePeriod    ValidityType = "gracePeriod"
 )
 
 // VerifySubscriptionResponse is the domain response returned by the app layer
@@ -24,4 +31,18 @@ type VerifySubscriptionResponse struct {
     InvalidityType      InvalidityType `json:"invalidityType"`
     ValidityType        ValidityType   `json:"validityType"`
     StripeCustomerEmail string         `json:"stripeCustomerEmail"`
+    // TrialEnd is the end of the trial in unix ms while the subscription is trialing, else 0.
+    TrialEnd            int64          `json:"trialEnd"`
+    // GraceDeadline is when a past_due or incomplete subscription stops being valid (unix ms), else 0.
+    GraceDeadline       int64          `json:"graceDeadline"`
+    // ResumesAt is when a paused subscription resumes (unix ms); 0 when paused without an end date.
+    ResumesAt           int64          `json:"resumesAt"`
+    // InDunning is set after a failed invoice payment until the invoice is paid; Stripe's next
+    // automatic retry (unix ms, 0 when none) and the attempts so far come with it.
+    InDunning           bool           `json:"inDunning"`
+    PaymentAttemptCount int            `json:"paymentAttemptCount"`
+    NextPaymentAttempt  int64          `json:"nextPaymentAttempt"`
+    // ExhaustedFeatures lists the features past their plan's feature_limits this period; the
+    // subscription itself can still be valid for other features.
+    ExhaustedFeatures   []string       `json:"exhaustedFeatures"`
 }
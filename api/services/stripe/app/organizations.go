@@ -0,0 +1,207 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+
+	stripedb "github.com/tbeaudouin05/stripe-trellai/api/services/stripe/db"
+)
+
+// organizationError maps organization refusals from the db layer to app errors.
+func organizationError(err error) error {
+	switch {
+	case errors.Is(err, stripedb.ErrOrganizationNotFound), errors.Is(err, stripedb.ErrMemberNotFound):
+		return fmt.Errorf("%w: %v", ErrNotFound, err)
+	case errors.Is(err, stripedb.ErrNoSeat):
+		return fmt.Errorf("%w: %v", ErrNotFound, err)
+	case errors.Is(err, stripedb.ErrOrganizationExists), errors.Is(err, stripedb.ErrAccountExists),
+		errors.Is(err, stripedb.ErrAlreadyInOrganization):
+		return fmt.Errorf("%w: %v", ErrNotAllowed, err)
+	default:
+		return fmt.Errorf("%w: %v", ErrDatabase, err)
+	}
+}
+
+// resolveAccount returns the account a user's subscription checks and spending are billed to:
+// their organization's pooled account when they belong to one, else their own. Organization
+// identifiers are taken as user identifiers here; see resolveOrganizationAccount.
+func resolveAccount(userExternalID string) (stripedb.BilledAccount, error) {
+	b, err := stripedb.ResolveAccount(userExternalID)
+	if err != nil {
+		return stripedb.BilledAccount{}, fmt.Errorf("%w: %v", ErrDatabase, err)
+	}
+	return b, nil
+}
+
+// resolveOrganizationAccount is resolveAccount for calls that manage an account, such as
+// checkouts: an organization identifier also resolves to the organization's pooled account.
+// A membership wins over an organization that happens to share the identifier.
+func resolveOrganizationAccount(userExternalID string) (stripedb.BilledAccount, error) {
+	b, err := resolveAccount(userExternalID)
+	if err != nil || b.Member {
+		return b, err
+	}
+	if _, err := stripedb.GetOrganizationID(userExternalID); err == nil {
+		return stripedb.BilledAccount{Account: stripedb.OrganizationAccount(userExternalID), Seated: true}, nil
+	} else if !errors.Is(err, stripedb.ErrOrganizationNotFound) {
+		return stripedb.BilledAccount{}, fmt.Errorf("%w: %v", ErrDatabase, err)
+	}
+	return b, nil
+}
+
+// ownAccount returns the account holding the subscription userExternalID manages: the pooled
+// account for an organization identifier, else the user's own, even for organization members.
+func ownAccount(userExternalID string) (string, error) {
+	b, err := resolveOrganizationAccount(userExternalID)
+	if err != nil {
+		return "", err
+	}
+	if b.Member {
+		return userExternalID, nil
+	}
+	return b.Account, nil
+}
+
+// organizationActor returns the organization and the role actorUserExternalID has in it.
+// Users who are not members of the organization are refused.
+func organizationActor(orgExternalID, actorUserExternalID string) (int64, string, error) {
+	orgID, err := stripedb.GetOrganizationID(orgExternalID)
+	if err != nil {
+		return 0, "", organizationError(err)
+	}
+	m, ok, err := stripedb.GetMembership(actorUserExternalID)
+	if err != nil {
+		return 0, "", fmt.Errorf("%w: %v", ErrDatabase, err)
+	}
+	if !ok || m.OrganizationID != orgID {
+		return 0, "", fmt.Errorf("%w: actor is not a member of the organization", ErrNotAllowed)
+	}
+	return orgID, m.Role, nil
+}
+
+// CreateOrganization creates a team account owned by ownerUserExternalID. The organization's
+// subscription is bought with orgExternalID as the checkout's client_reference_id.
+func (s serviceImpl) CreateOrganization(orgExternalID, ownerUserExternalID string) (int64, error) {
+	id, err := stripedb.CreateOrganization(orgExternalID, ownerUserExternalID)
+	if err != nil {
+		return 0, organizationError(err)
+	}
+	return id, nil
+}
+
+// AddOrganizationMember adds a user to the organization as admin or member, or changes the role of
+// an existing member, on behalf of the organization's owner or an admin. Only the owner manages
+// admins, and the owner's own role can't be changed.
+func (s serviceImpl) AddOrganizationMember(orgExternalID, actorUserExternalID, userExternalID, role string) error {
+	if role == "" {
+		role = stripedb.RoleMember
+	}
+	if role != stripedb.RoleAdmin && role != stripedb.RoleMember {
+		return fmt.Errorf("invalid role %q (want %s or %s)", role, stripedb.RoleAdmin, stripedb.RoleMember)
+	}
+	orgID, actorRole, err := organizationActor(orgExternalID, actorUserExternalID)
+	if err != nil {
+		return err
+	}
+	current, isMember, err := memberRole(orgID, userExternalID)
+	if err != nil {
+		return err
+	}
+	if err := canManage(actorRole, current, role, isMember); err != nil {
+		return err
+	}
+	if err := stripedb.UpsertOrganizationMember(orgID, userExternalID, role); err != nil {
+		return organizationError(err)
+	}
+	entitlementsChanged(userExternalID)
+	return nil
+}
+
+// RemoveOrganizationMember removes a user from the organization on behalf of its owner or an admin
+// (with the same rules as AddOrganizationMember). Members other than the owner may also leave.
+// A seated member's seat goes with them, and the subscription quantity follows.
+func (s serviceImpl) RemoveOrganizationMember(orgExternalID, actorUserExternalID, userExternalID string) error {
+	orgID, actorRole, err := organizationActor(orgExternalID, actorUserExternalID)
+	if err != nil {
+		return err
+	}
+	m, isMember, err := stripedb.GetMembership(userExternalID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrDatabase, err)
+	}
+	if !isMember || m.OrganizationID != orgID {
+		return fmt.Errorf("%w: %v", ErrNotFound, stripedb.ErrMemberNotFound)
+	}
+	current := m.Role
+	leaving := stripedb.HashExternalID(actorUserExternalID) == stripedb.HashExternalID(userExternalID)
+	if current == stripedb.RoleOwner {
+		return fmt.Errorf("%w: the owner can't be removed", ErrNotAllowed)
+	}
+	if !leaving {
+		if err := canManage(actorRole, current, current, true); err != nil {
+			return err
+		}
+	}
+	if err := stripedb.RemoveOrganizationMember(orgID, userExternalID); err != nil {
+		return organizationError(err)
+	}
+	if m.Seated {
+		if err := s.syncSeats(orgExternalID, orgID); err != nil {
+			// bring the member and their seat back so seats and the billed quantity stay in line
+			if undoErr := stripedb.UpsertOrganizationMember(orgID, userExternalID, current); undoErr != nil {
+				slog.Error("error restoring member after failed quantity update", "organization_id", orgID, "err", undoErr)
+			} else if _, undoErr := stripedb.AssignSeat(orgID, userExternalID); undoErr != nil {
+				slog.Error("error reassigning seat after failed quantity update", "organization_id", orgID, "err", undoErr)
+			}
+			return err
+		}
+	}
+	entitlementsChanged(userExternalID)
+	return nil
+}
+
+// ListOrganizationMembers returns the organization's members to one of its members.
+// Member identifiers are returned hashed, as stored.
+func (s serviceImpl) ListOrganizationMembers(orgExternalID, actorUserExternalID string) ([]stripedb.OrganizationMember, error) {
+	orgID, _, err := organizationActor(orgExternalID, actorUserExternalID)
+	if err != nil {
+		return nil, err
+	}
+	members, err := stripedb.ListOrganizationMembers(orgID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDatabase, err)
+	}
+	return members, nil
+}
+
+// memberRole returns the user's role in organization orgID, and false when they are not a member
+// of it. Members of another organization are refused.
+func memberRole(orgID int64, userExternalID string) (string, bool, error) {
+	m, ok, err := stripedb.GetMembership(userExternalID)
+	if err != nil {
+		return "", false, fmt.Errorf("%w: %v", ErrDatabase, err)
+	}
+	if !ok {
+		return "", false, nil
+	}
+	if m.OrganizationID != orgID {
+		return "", false, fmt.Errorf("%w: %v", ErrNotAllowed, stripedb.ErrAlreadyInOrganization)
+	}
+	return m.Role, true, nil
+}
+
+// canManage reports whether a member with actorRole may move a user from role current
+// (when isMember) to role next.
+func canManage(actorRole, current, next string, isMember bool) error {
+	if actorRole != stripedb.RoleOwner && actorRole != stripedb.RoleAdmin {
+		return fmt.Errorf("%w: only the owner and admins manage members", ErrNotAllowed)
+	}
+	if isMember && current == stripedb.RoleOwner {
+		return fmt.Errorf("%w: the owner's role can't be changed", ErrNotAllowed)
+	}
+	if actorRole != stripedb.RoleOwner && (next == stripedb.RoleAdmin || (isMember && current == stripedb.RoleAdmin)) {
+		return fmt.Errorf("%w: only the owner manages admins", ErrNotAllowed)
+	}
+	return nil
+}
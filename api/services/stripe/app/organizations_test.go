@@ -0,0 +1,467 @@
+package app
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	stripe "github.com/stripe/stripe-go"
+	stripedb "github.com/tbeaudouin05/stripe-trellai/api/services/stripe/db"
+)
+
+const (
+	orgTestID   = "org-test-team"
+	orgOwnerID  = "org-test-owner"
+	orgAdminID  = "org-test-admin"
+	orgMemberID = "org-test-member"
+)
+
+// setupOrganizationTest removes what earlier runs left of the test organization, its pooled
+// account and its members' accounts, and returns the cleanup doing the same.
+func setupOrganizationTest(t *testing.T) (*sql.DB, func()) {
+	db, subCleanup := setupSubTestDB(t)
+	clean := func() {
+		// the pooled account takes the organization, its members, caps and usage with it
+		_, _ = db.Exec("DELETE FROM user_account WHERE user_external_id = $1",
+			stripedb.HashExternalID(stripedb.OrganizationAccount(orgTestID)))
+		_, _ = db.Exec("DELETE FROM organization WHERE external_id = $1", stripedb.OrganizationAccount(orgTestID))
+		_, _ = db.Exec("DELETE FROM organization_member WHERE user_external_id IN ($1, $2, $3)",
+			stripedb.HashExternalID(orgOwnerID), stripedb.HashExternalID(orgAdminID), stripedb.HashExternalID(orgMemberID))
+		_, _ = db.Exec("DELETE FROM user_account WHERE user_external_id IN ($1, $2, $3, $4)",
+			stripedb.HashExternalID(orgTestID), stripedb.HashExternalID(orgOwnerID), stripedb.HashExternalID(orgAdminID), stripedb.HashExternalID(orgMemberID))
+		_, _ = db.Exec("DELETE FROM plan_allowance WHERE stripe_plan_id = 'plan_team'")
+	}
+	clean()
+	return db, func() {
+		clean()
+		subCleanup()
+	}
+}
+
+// setupTeamAccount puts the test organization's pooled account on sub_team, without free credit.
+func setupTeamAccount(t *testing.T, db *sql.DB) {
+	if err := stripedb.UpsertUserAccount(stripedb.OrganizationAccount(orgTestID), "sub_team", "no_need", "cust_team"); err != nil {
+		t.Fatalf("UpsertUserAccount failed: %v", err)
+	}
+	if _, err := db.Exec("INSERT INTO free_credit (user_external_id, credit) VALUES ($1, 0) ON CONFLICT (user_external_id) DO UPDATE SET credit = 0",
+		stripedb.HashExternalID(stripedb.OrganizationAccount(orgTestID))); err != nil {
+		t.Fatalf("Failed to reset free_credit: %v", err)
+	}
+}
+
+func Test_Organization_MembersShareSubscriptionAndPool(t *testing.T) {
+	db, cleanup := setupOrganizationTest(t)
+	defer cleanup()
+
+	now := time.Now().Unix()
+	svc := NewService(fakeGateway{subs: map[string]stripe.Subscription{
+		"sub_team": {
+			ID:     "sub_team",
+			Status: stripe.SubscriptionStatusActive,
+			Items: &stripe.SubscriptionItemList{Data: []*stripe.SubscriptionItem{{
+				ID:       "si_team",
+				Quantity: 1,
+				Plan:     &stripe.Plan{ID: "plan_team", Metadata: map[string]string{PlanMetadataUnitsPerPeriod: "10"}},
+			}}},
+			CurrentPeriodStart: now - 60,
+			CurrentPeriodEnd:   now + 86400,
+		},
+	}})
+
+	_, err := svc.CreateOrganization(orgTestID, orgOwnerID)
+	assert.NoError(t, err)
+	_, err = svc.CreateOrganization(orgTestID, orgAdminID)
+	assert.ErrorIs(t, err, ErrNotAllowed, "organization already exists")
+	setupTeamAccount(t, db)
+
+	assert.NoError(t, svc.AddOrganizationMember(orgTestID, orgOwnerID, orgAdminID, "admin"))
+	assert.NoError(t, svc.AddOrganizationMember(orgTestID, orgAdminID, orgMemberID, ""))
+	// admins manage members, not admins or the owner
+	assert.ErrorIs(t, svc.AddOrganizationMember(orgTestID, orgAdminID, orgMemberID, "admin"), ErrNotAllowed)
+	assert.ErrorIs(t, svc.RemoveOrganizationMember(orgTestID, orgAdminID, orgOwnerID), ErrNotAllowed)
+	assert.ErrorIs(t, svc.AddOrganizationMember(orgTestID, orgMemberID, "org-test-other", ""), ErrNotAllowed)
+
+	members, err := svc.ListOrganizationMembers(orgTestID, orgMemberID)
+	assert.NoError(t, err)
+	assert.Len(t, members, 3)
+
+	// members need a seat; seats drive the subscription quantity
+	resp, err := svc.VerifySubscription(orgMemberID)
+	assert.NoError(t, err)
+	assert.False(t, resp.IsValidSubscription)
+	assert.Equal(t, InvalidityTypeNoSeat, resp.InvalidityType)
+	_, err = svc.AddSeat(orgTestID, orgMemberID, orgAdminID)
+	assert.ErrorIs(t, err, ErrNotAllowed, "members don't manage seats")
+	_, err = svc.AddSeat(orgTestID, orgOwnerID, orgAdminID)
+	assert.NoError(t, err)
+	seats, err := svc.AddSeat(orgTestID, orgAdminID, orgMemberID)
+	assert.NoError(t, err)
+	assert.Len(t, seats.Seats, 3)
+	assert.Equal(t, int64(3), seats.Quantity)
+	seats, err = svc.AddSeat(orgTestID, orgAdminID, orgMemberID)
+	assert.NoError(t, err, "assigning a seat again is a no-op")
+	assert.Equal(t, int64(3), seats.Quantity)
+
+	// members verify against the organization's subscription: 3 seats x 10 units
+	resp, err = svc.VerifySubscription(orgMemberID)
+	assert.NoError(t, err)
+	assert.True(t, resp.IsValidSubscription)
+	assert.Equal(t, ValidityTypePayingCustomer, resp.ValidityType)
+
+	n, err := svc.AddSpendingUnits([]stripedb.SpendingUnit{
+		{ExternalID: "org-test-unit-1", UserExternalID: orgMemberID, Amount: 12, CreatedAt: now * 1000},
+		{ExternalID: "org-test-unit-2", UserExternalID: orgAdminID, Amount: 19, CreatedAt: now * 1000},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	var member string
+	err = db.QueryRow("SELECT member_external_id FROM spending_unit WHERE user_external_id = $1 AND external_id = $2",
+		stripedb.HashExternalID(stripedb.OrganizationAccount(orgTestID)), stripedb.HashExternalID("org-test-unit-1")).Scan(&member)
+	assert.NoError(t, err)
+	assert.Equal(t, stripedb.HashExternalID(orgMemberID), member)
+
+	// the pool is exhausted for every member
+	resp, err = svc.VerifySubscription(orgOwnerID)
+	assert.NoError(t, err)
+	assert.False(t, resp.IsValidSubscription)
+	assert.Equal(t, InvalidityTypeExhausted, resp.InvalidityType)
+
+	// seats can be taken back, but not the last one
+	seats, err = svc.RemoveSeat(orgTestID, orgOwnerID, orgAdminID)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), seats.Quantity)
+	_, err = svc.RemoveSeat(orgTestID, orgOwnerID, orgAdminID)
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = svc.RemoveSeat(orgTestID, orgOwnerID, orgMemberID)
+	assert.NoError(t, err)
+	_, err = svc.RemoveSeat(orgTestID, orgOwnerID, orgMemberID)
+	assert.ErrorIs(t, err, ErrNotFound, "no seat to remove, even with one seat left")
+	_, err = svc.RemoveSeat(orgTestID, orgOwnerID, orgOwnerID)
+	assert.ErrorIs(t, err, ErrNotAllowed)
+	seats, err = svc.ListSeats(orgTestID, orgMemberID)
+	assert.NoError(t, err)
+	assert.Len(t, seats.Seats, 1)
+	assert.Equal(t, int64(1), seats.Quantity)
+
+	// credit packs bought by a member go to the pool
+	session := map[string]interface{}{
+		"id":                  "cs_org_test_pack",
+		"mode":                string(stripe.CheckoutSessionModePayment),
+		"client_reference_id": orgAdminID,
+		"payment_status":      "paid",
+		"amount_total":        500,
+		"currency":            "usd",
+		"metadata":            map[string]string{SessionMetadataCreditPackID: "starter", SessionMetadataCreditUnits: "50"},
+	}
+	raw, _ := json.Marshal(session)
+	assert.NoError(t, svc.HandleCheckoutSessionCompleted(stripe.Event{Type: "checkout.session.completed", Data: &stripe.EventData{Raw: raw}}))
+	credit, err := stripedb.GetPurchasedCredit(stripedb.OrganizationAccount(orgTestID))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(50), credit)
+
+	// a member who leaves takes their seat with them, and is back on their own account
+	seats, err = svc.AddSeat(orgTestID, orgOwnerID, orgMemberID)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), seats.Quantity)
+	assert.NoError(t, svc.RemoveOrganizationMember(orgTestID, orgMemberID, orgMemberID))
+	seats, err = svc.ListSeats(orgTestID, orgOwnerID)
+	assert.NoError(t, err)
+	assert.Len(t, seats.Seats, 1)
+	assert.Equal(t, int64(1), seats.Quantity)
+	resp, err = svc.VerifySubscription(orgMemberID)
+	assert.NoError(t, err)
+	assert.NotEqual(t, InvalidityTypeExhausted, resp.InvalidityType)
+}
+
+func Test_Organization_KeepsExistingAccounts(t *testing.T) {
+	db, cleanup := setupOrganizationTest(t)
+	defer cleanup()
+	svc := NewService(fakeGateway{})
+
+	// a user whose identifier is the organization's keeps their own account
+	if err := stripedb.UpsertUserAccount(orgTestID, "sub_user", "no_need", "cust_user"); err != nil {
+		t.Fatalf("UpsertUserAccount failed: %v", err)
+	}
+	_, err := svc.CreateOrganization(orgTestID, orgOwnerID)
+	assert.NoError(t, err)
+	ua, err := stripedb.GetUserAccount(orgTestID)
+	assert.NoError(t, err)
+	assert.Equal(t, "sub_user", ua.StripeSubscriptionID)
+	pooled, err := stripedb.GetUserAccount(stripedb.OrganizationAccount(orgTestID))
+	assert.NoError(t, err)
+	assert.Equal(t, "", pooled.StripeSubscriptionID)
+
+	// an existing pooled account is never taken over
+	if _, err := db.Exec("DELETE FROM organization WHERE external_id = $1", stripedb.OrganizationAccount(orgTestID)); err != nil {
+		t.Fatalf("Failed to delete organization: %v", err)
+	}
+	_, err = svc.CreateOrganization(orgTestID, orgOwnerID)
+	assert.ErrorIs(t, err, ErrNotAllowed)
+}
+
+func Test_Organization_SpendingCaps(t *testing.T) {
+	db, cleanup := setupOrganizationTest(t)
+	defer cleanup()
+
+	now := time.Now().Unix()
+	svc := NewService(fakeGateway{subs: map[string]stripe.Subscription{
+		"sub_team": {
+			ID:     "sub_team",
+			Status: stripe.SubscriptionStatusActive,
+			Items: &stripe.SubscriptionItemList{Data: []*stripe.SubscriptionItem{{
+				ID:       "si_team",
+				Quantity: 2,
+				Plan:     &stripe.Plan{ID: "plan_team", Metadata: map[string]string{PlanMetadataUnitsPerPeriod: "50"}},
+			}}},
+			CurrentPeriodStart: now - 60,
+			CurrentPeriodEnd:   now + 86400,
+		},
+	}})
+	_, err := svc.CreateOrganization(orgTestID, orgOwnerID)
+	assert.NoError(t, err)
+	setupTeamAccount(t, db)
+	_, err = svc.AddSeat(orgTestID, orgOwnerID, orgMemberID)
+	assert.NoError(t, err)
+
+	assert.ErrorIs(t, svc.SetSpendingCap(orgTestID, orgMemberID, SpendingCapChange{MemberUserExternalID: orgMemberID, CapUnits: 100}), ErrNotAllowed)
+	assert.ErrorIs(t, svc.SetSpendingCap(orgTestID, orgOwnerID, SpendingCapChange{MemberUserExternalID: "org-test-other", CapUnits: 5}), ErrNotFound)
+	assert.NoError(t, svc.SetSpendingCap(orgTestID, orgOwnerID, SpendingCapChange{MemberUserExternalID: orgMemberID, CapUnits: 5}))
+	assert.NoError(t, svc.SetSpendingCap(orgTestID, orgOwnerID, SpendingCapChange{APIKeyID: "org-test-key", CapUnits: 3}))
+
+	unit := func(id, user, key string, amount int) stripedb.SpendingUnit {
+		return stripedb.SpendingUnit{ExternalID: id, UserExternalID: user, APIKeyID: key, Amount: amount, CreatedAt: now * 1000}
+	}
+	n, err := svc.AddSpendingUnits([]stripedb.SpendingUnit{unit("org-cap-unit-1", orgMemberID, "", 4)})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	// the whole batch is refused when one unit goes past a cap
+	_, err = svc.AddSpendingUnits([]stripedb.SpendingUnit{unit("org-cap-unit-2", orgOwnerID, "", 1), unit("org-cap-unit-3", orgMemberID, "", 2)})
+	assert.ErrorIs(t, err, ErrNotAllowed)
+	_, err = svc.AddSpendingUnits([]stripedb.SpendingUnit{unit("org-cap-unit-4", orgOwnerID, "org-test-key", 4)})
+	assert.ErrorIs(t, err, ErrNotAllowed, "API key cap")
+
+	resp, err := svc.VerifySubscription(orgMemberID)
+	assert.NoError(t, err)
+	assert.True(t, resp.IsValidSubscription)
+	n, err = svc.AddSpendingUnits([]stripedb.SpendingUnit{unit("org-cap-unit-1", orgMemberID, "", 4), unit("org-cap-unit-5", orgMemberID, "", 1)})
+	assert.NoError(t, err, "retried units don't count twice")
+	assert.Equal(t, 1, n)
+	resp, err = svc.VerifySubscription(orgMemberID)
+	assert.NoError(t, err)
+	assert.False(t, resp.IsValidSubscription)
+	assert.Equal(t, InvalidityTypeCapReached, resp.InvalidityType)
+	resp, err = svc.VerifySubscription(orgOwnerID)
+	assert.NoError(t, err)
+	assert.True(t, resp.IsValidSubscription, "other members are not capped")
+
+	_, err = svc.GetUsageBreakdown(orgTestID, orgMemberID)
+	assert.ErrorIs(t, err, ErrNotAllowed)
+	b, err := svc.GetUsageBreakdown(orgTestID, orgOwnerID)
+	assert.NoError(t, err)
+	assert.Equal(t, (now-60)*1000, b.PeriodStart)
+	assert.Equal(t, []SpendingUsage{{ID: stripedb.HashExternalID(orgMemberID), Units: 5, CapUnits: 5}}, b.Members)
+	assert.Equal(t, []SpendingUsage{{ID: "org-test-key", CapUnits: 3}}, b.APIKeys)
+
+	// removing the cap lifts it
+	assert.NoError(t, svc.SetSpendingCap(orgTestID, orgOwnerID, SpendingCapChange{MemberUserExternalID: orgMemberID}))
+	resp, err = svc.VerifySubscription(orgMemberID)
+	assert.NoError(t, err)
+	assert.True(t, resp.IsValidSubscription)
+}
+
+func Test_Organization_ConcurrentBatchesKeepUnderCap(t *testing.T) {
+	db, cleanup := setupOrganizationTest(t)
+	defer cleanup()
+
+	now := time.Now().Unix()
+	svc := NewService(fakeGateway{subs: map[string]stripe.Subscription{
+		"sub_team": {
+			ID:     "sub_team",
+			Status: stripe.SubscriptionStatusActive,
+			Items: &stripe.SubscriptionItemList{Data: []*stripe.SubscriptionItem{{
+				ID:       "si_team",
+				Quantity: 2,
+				Plan:     &stripe.Plan{ID: "plan_team", Metadata: map[string]string{PlanMetadataUnitsPerPeriod: "50"}},
+			}}},
+			CurrentPeriodStart: now - 60,
+			CurrentPeriodEnd:   now + 86400,
+		},
+	}})
+	_, err := svc.CreateOrganization(orgTestID, orgOwnerID)
+	assert.NoError(t, err)
+	setupTeamAccount(t, db)
+	_, err = svc.AddSeat(orgTestID, orgOwnerID, orgMemberID)
+	assert.NoError(t, err)
+	assert.NoError(t, svc.SetSpendingCap(orgTestID, orgOwnerID, SpendingCapChange{MemberUserExternalID: orgMemberID, CapUnits: 5}))
+
+	// each batch fits under the cap alone, but not both: only one may be recorded
+	errs := make([]error, 2)
+	var wg sync.WaitGroup
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = svc.AddSpendingUnits([]stripedb.SpendingUnit{
+				{ExternalID: fmt.Sprintf("org-race-unit-%d", i), UserExternalID: orgMemberID, Amount: 3, CreatedAt: now * 1000},
+			})
+		}(i)
+	}
+	wg.Wait()
+	refused := 0
+	for _, err := range errs {
+		if err != nil {
+			assert.ErrorIs(t, err, ErrNotAllowed)
+			refused++
+		}
+	}
+	assert.Equal(t, 1, refused)
+	b, err := svc.GetUsageBreakdown(orgTestID, orgOwnerID)
+	assert.NoError(t, err)
+	assert.Equal(t, []SpendingUsage{{ID: stripedb.HashExternalID(orgMemberID), Units: 3, CapUnits: 5}}, b.Members)
+}
+
+func Test_Organization_BatchVerifyReusesSubscriptionForCaps(t *testing.T) {
+	db, cleanup := setupOrganizationTest(t)
+	defer cleanup()
+
+	now := time.Now().Unix()
+	var lookups atomic.Int32
+	svc := NewService(countingGateway{fakeGateway: fakeGateway{subs: map[string]stripe.Subscription{
+		"sub_team": {
+			ID:     "sub_team",
+			Status: stripe.SubscriptionStatusActive,
+			Items: &stripe.SubscriptionItemList{Data: []*stripe.SubscriptionItem{{
+				ID:       "si_team",
+				Quantity: 2,
+				Plan:     &stripe.Plan{ID: "plan_team", Metadata: map[string]string{PlanMetadataUnitsPerPeriod: "50"}},
+			}}},
+			CurrentPeriodStart: now - 60,
+			CurrentPeriodEnd:   now + 86400,
+		},
+	}}, lookups: &lookups})
+	_, err := svc.CreateOrganization(orgTestID, orgOwnerID)
+	assert.NoError(t, err)
+	setupTeamAccount(t, db)
+	for _, user := range []string{orgAdminID, orgMemberID} {
+		_, err = svc.AddSeat(orgTestID, orgOwnerID, user)
+		assert.NoError(t, err)
+		assert.NoError(t, svc.SetSpendingCap(orgTestID, orgOwnerID, SpendingCapChange{MemberUserExternalID: user, CapUnits: 5}))
+	}
+
+	// the capped members' period comes from the subscription the batch already fetched
+	lookups.Store(0)
+	batch, err := svc.BatchVerifySubscription([]string{orgOwnerID, orgAdminID, orgMemberID})
+	assert.NoError(t, err)
+	for _, user := range []string{orgOwnerID, orgAdminID, orgMemberID} {
+		assert.NoError(t, batch[user].Err, user)
+		assert.True(t, batch[user].Response.IsValidSubscription, user)
+	}
+	assert.Equal(t, int32(1), lookups.Load())
+}
+
+func Test_Organization_IdentifierIsNotResolvedForUserCalls(t *testing.T) {
+	db, cleanup := setupOrganizationTest(t)
+	defer cleanup()
+	hashedOrg := stripedb.HashExternalID(orgTestID)
+	clean := func() {
+		_, _ = db.Exec("DELETE FROM spending_unit WHERE user_external_id = $1", hashedOrg)
+		_, _ = db.Exec("DELETE FROM free_credit WHERE user_external_id = $1", hashedOrg)
+	}
+	clean()
+	defer clean()
+
+	now := time.Now().Unix()
+	svc := NewService(fakeGateway{subs: map[string]stripe.Subscription{
+		"sub_team": {
+			ID:     "sub_team",
+			Status: stripe.SubscriptionStatusActive,
+			Items: &stripe.SubscriptionItemList{Data: []*stripe.SubscriptionItem{{
+				ID:       "si_team",
+				Quantity: 1,
+				Plan:     &stripe.Plan{ID: "plan_team", Metadata: map[string]string{PlanMetadataUnitsPerPeriod: "10"}},
+			}}},
+			CurrentPeriodStart: now - 60,
+			CurrentPeriodEnd:   now + 86400,
+		},
+	}})
+	_, err := svc.CreateOrganization(orgTestID, orgOwnerID)
+	assert.NoError(t, err)
+	setupTeamAccount(t, db)
+	// a user whose identifier is the organization's, without a subscription
+	if err := stripedb.UpsertUserAccount(orgTestID, "", "", ""); err != nil {
+		t.Fatalf("UpsertUserAccount failed: %v", err)
+	}
+	if _, err := db.Exec("INSERT INTO free_credit (user_external_id, credit) VALUES ($1, 0)", hashedOrg); err != nil {
+		t.Fatalf("Failed to insert free_credit: %v", err)
+	}
+
+	// the pooled account's subscription is valid for its members...
+	resp, err := svc.VerifySubscription(orgOwnerID)
+	assert.NoError(t, err)
+	assert.True(t, resp.IsValidSubscription)
+
+	// ...but the organization identifier is just another user to the per-user calls
+	resp, err = svc.VerifySubscription(orgTestID)
+	assert.NoError(t, err)
+	assert.False(t, resp.IsValidSubscription)
+	assert.Equal(t, InvalidityTypeNoSubscription, resp.InvalidityType)
+	batch, err := svc.BatchVerifySubscription([]string{orgTestID})
+	assert.NoError(t, err)
+	assert.False(t, batch[orgTestID].Response.IsValidSubscription)
+
+	n, err := svc.AddSpendingUnits([]stripedb.SpendingUnit{
+		{ExternalID: "org-test-direct-unit", UserExternalID: orgTestID, Amount: 1, CreatedAt: now * 1000},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	var pooled int
+	if err := db.QueryRow("SELECT COUNT(*) FROM spending_unit WHERE user_external_id = $1",
+		stripedb.HashExternalID(stripedb.OrganizationAccount(orgTestID))).Scan(&pooled); err != nil {
+		t.Fatalf("Failed to count pooled units: %v", err)
+	}
+	assert.Equal(t, 0, pooled)
+
+	// organization-scoped calls still take it
+	account, err := ownAccount(orgTestID)
+	assert.NoError(t, err)
+	assert.Equal(t, stripedb.OrganizationAccount(orgTestID), account)
+}
+
+func Test_Organization_ConcurrentRemovalsKeepLastSeat(t *testing.T) {
+	_, cleanup := setupOrganizationTest(t)
+	defer cleanup()
+	svc := NewService(fakeGateway{})
+
+	_, err := svc.CreateOrganization(orgTestID, orgOwnerID)
+	assert.NoError(t, err)
+	_, err = svc.AddSeat(orgTestID, orgOwnerID, orgMemberID)
+	assert.NoError(t, err)
+
+	// both seats are removed at once: only one removal may go through
+	errs := make([]error, 2)
+	var wg sync.WaitGroup
+	for i, user := range []string{orgOwnerID, orgMemberID} {
+		wg.Add(1)
+		go func(i int, user string) {
+			defer wg.Done()
+			_, errs[i] = svc.RemoveSeat(orgTestID, orgOwnerID, user)
+		}(i, user)
+	}
+	wg.Wait()
+	refused := 0
+	for _, err := range errs {
+		if err != nil {
+			assert.ErrorIs(t, err, ErrNotAllowed)
+			refused++
+		}
+	}
+	assert.Equal(t, 1, refused)
+	seats, err := svc.ListSeats(orgTestID, orgOwnerID)
+	assert.NoError(t, err)
+	assert.Len(t, seats.Seats, 1)
+}
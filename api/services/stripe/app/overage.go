@@ -0,0 +1,146 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"time"
+
+	"github.com/stripe/stripe-go"
+	"github.com/tbeaudouin05/stripe-trellai/api/config"
+	stripedb "github.com/tbeaudouin05/stripe-trellai/api/services/stripe/db"
+	"github.com/tbeaudouin05/stripe-trellai/api/services/stripe/gateway"
+)
+
+// InvoiceOverages bills the overage of every ended billing period as a Stripe invoice item
+// on the subscription and returns how many invoice items were created. Each run first records
+// the current period of every overage-enabled subscription. Recording units records it too, so a
+// period with usage is known even if the job never ran during it. Overage is computed from spending
+// units at invoicing time, so refunds made during the period are honoured.
+// Items carry an idempotency key derived from the period, so a crash before the period is
+// marked invoiced cannot bill it twice. Failures for one period do not stop the others.
+func (s serviceImpl) InvoiceOverages() (int, error) {
+	var errs []error
+	accounts, err := stripedb.ListSubscribedAccounts()
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrDatabase, err)
+	}
+	for _, acct := range accounts {
+		if err := s.trackOveragePeriod(acct); err != nil {
+			slog.Error("error recording overage period", "stripe_subscription_id", acct.StripeSubscriptionID, "err", err)
+			errs = append(errs, err)
+		}
+	}
+
+	periods, err := stripedb.ListUninvoicedOveragePeriods(time.Now().UnixMilli())
+	if err != nil {
+		return 0, errors.Join(append(errs, fmt.Errorf("%w: %v", ErrDatabase, err))...)
+	}
+	var created int
+	for _, p := range periods {
+		n, err := s.invoiceOveragePeriod(p)
+		created += n
+		if err != nil {
+			slog.Error("error invoicing overage", "stripe_subscription_id", p.StripeSubscriptionID, "period_start", p.PeriodStart, "err", err)
+			errs = append(errs, err)
+		}
+	}
+	return created, errors.Join(errs...)
+}
+
+// trackOveragePeriod records the account's current billing period if its subscription bills
+// overage, with the overage observed so far.
+func (s serviceImpl) trackOveragePeriod(acct stripedb.SubscribedAccount) error {
+	sub, err := s.gw.GetSubscription(acct.StripeSubscriptionID)
+	if err != nil {
+		return fmt.Errorf("%w: error getting subscription: %v", ErrGateway, err)
+	}
+	if IsSubscriptionCancelled(sub) || IsSubscriptionPaused(sub) {
+		return nil
+	}
+	allowance, err := s.subscriptionAllowance(sub)
+	if err != nil {
+		return err
+	}
+	if allowance.Unlimited || allowance.OverageUnitAmount == "" {
+		return nil
+	}
+	count, err := stripedb.CountStoredUnitsBetween(acct.UserExternalID, sub.CurrentPeriodStart*1000, sub.CurrentPeriodEnd*1000)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrDatabase, err)
+	}
+	return recordOveragePeriod(acct, sub, allowance, count)
+}
+
+// recordOveragePeriod records the subscription's current period for an overage-enabled allowance,
+// count being the account's units against the allowance in that period.
+func recordOveragePeriod(acct stripedb.SubscribedAccount, sub stripe.Subscription, allowance entitlement, count int) error {
+	p := stripedb.OveragePeriod{
+		UserExternalID:       acct.UserExternalID,
+		StripeSubscriptionID: acct.StripeSubscriptionID,
+		StripeCustomerID:     acct.StripeCustomerID,
+		PeriodStart:          sub.CurrentPeriodStart * 1000,
+		PeriodEnd:            sub.CurrentPeriodEnd * 1000,
+		Allowance:            allowance.Units,
+		OverageUnitAmount:    allowance.OverageUnitAmount,
+		Currency:             allowance.Currency,
+		OverageUnits:         max(int64(count)-allowance.Units, 0),
+	}
+	if err := stripedb.RecordOverage(p); err != nil {
+		return fmt.Errorf("%w: %v", ErrDatabase, err)
+	}
+	return nil
+}
+
+func (s serviceImpl) invoiceOveragePeriod(p stripedb.OveragePeriod) (int, error) {
+	count, err := stripedb.CountStoredUnitsBetween(p.UserExternalID, p.PeriodStart, p.PeriodEnd)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrDatabase, err)
+	}
+	overage := int64(count) - p.Allowance
+	if overage <= 0 {
+		// refunded back under the allowance: nothing to bill
+		if err := stripedb.MarkOveragePeriodInvoiced(p.ID, 0, "", time.Now().UnixMilli()); err != nil {
+			return 0, fmt.Errorf("%w: %v", ErrDatabase, err)
+		}
+		return 0, nil
+	}
+	amount, err := overageAmount(overage, p.OverageUnitAmount)
+	if err != nil {
+		return 0, err
+	}
+	itemID, err := s.gw.CreateInvoiceItem(gateway.InvoiceItem{
+		CustomerID:     p.StripeCustomerID,
+		SubscriptionID: p.StripeSubscriptionID,
+		Amount:         amount,
+		Currency:       p.Currency,
+		Description:    fmt.Sprintf("Overage: %d units beyond the %d included", overage, p.Allowance),
+		PeriodStart:    p.PeriodStart / 1000,
+		PeriodEnd:      p.PeriodEnd / 1000,
+		IdempotencyKey: fmt.Sprintf("overage-%s-%d", p.StripeSubscriptionID, p.PeriodStart),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("%w: error creating invoice item: %v", ErrGateway, err)
+	}
+	if err := stripedb.MarkOveragePeriodInvoiced(p.ID, overage, itemID, time.Now().UnixMilli()); err != nil {
+		return 1, fmt.Errorf("%w: %v", ErrDatabase, err)
+	}
+	return 1, nil
+}
+
+// overageAmount prices units at unitAmount (minor units, possibly fractional) and rounds
+// the total half up to a whole minor unit.
+func overageAmount(units int64, unitAmount string) (int64, error) {
+	rate, ok := config.ParseRate(unitAmount)
+	if !ok {
+		return 0, fmt.Errorf("invalid overage unit amount %q", unitAmount)
+	}
+	total := new(big.Rat).Mul(rate, new(big.Rat).SetInt64(units))
+	total.Add(total, big.NewRat(1, 2))
+	rounded := new(big.Int).Quo(total.Num(), total.Denom())
+	if !rounded.IsInt64() {
+		return 0, fmt.Errorf("overage amount overflows int64")
+	}
+	return rounded.Int64(), nil
+}
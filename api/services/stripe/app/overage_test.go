@@ -0,0 +1,241 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	stripe "github.com/stripe/stripe-go"
+	stripedb "github.com/tbeaudouin05/stripe-trellai/api/services/stripe/db"
+	"github.com/tbeaudouin05/stripe-trellai/api/services/stripe/gateway"
+	"github.com/tbeaudouin05/stripe-trellai/api/services/stripe/notifier"
+)
+
+const overageBoardID = "overage-test-board"
+
+func Test_Overage_ValidPastAllowanceAndInvoicedAtPeriodEnd(t *testing.T) {
+	db, cleanup := setupSubTestDB(t)
+	defer cleanup()
+	hb := stripedb.HashExternalID(overageBoardID)
+	clean := func() {
+		_, _ = db.Exec("DELETE FROM overage_period WHERE user_external_id = $1", hb)
+		_, _ = db.Exec("DELETE FROM spending_unit WHERE user_external_id = $1", hb)
+		_, _ = db.Exec("DELETE FROM free_credit WHERE user_external_id = $1", hb)
+		_, _ = db.Exec("DELETE FROM user_account WHERE user_external_id = $1", hb)
+		_, _ = db.Exec("DELETE FROM plan_allowance WHERE stripe_plan_id = $1", "plan_overage")
+	}
+	clean()
+	defer clean()
+
+	if err := stripedb.UpsertUserAccount(overageBoardID, "sub_overage", "plan_overage", "cust_overage"); err != nil {
+		t.Fatalf("UpsertUserAccount failed: %v", err)
+	}
+	if _, err := db.Exec("INSERT INTO free_credit (user_external_id, credit) VALUES ($1, 0)", hb); err != nil {
+		t.Fatalf("Failed to insert free_credit: %v", err)
+	}
+	// A period that has just ended, so the invoicing job picks it up.
+	periodStart := time.Now().Add(-2 * time.Hour).Unix()
+	periodEnd := time.Now().Add(-time.Minute).Unix()
+	if _, err := stripedb.AddSpendingUnits([]stripedb.SpendingUnit{
+		{ExternalID: "overage-unit-1", UserExternalID: overageBoardID, Amount: 8, CreatedAt: (periodStart + 60) * 1000},
+	}); err != nil {
+		t.Fatalf("AddSpendingUnits failed: %v", err)
+	}
+
+	plan := &stripe.Plan{
+		ID:       "plan_overage",
+		Currency: stripe.CurrencyUSD,
+		Metadata: map[string]string{PlanMetadataUnitsPerPeriod: "5", PlanMetadataOverageUnitAmount: "0.5"},
+	}
+	gw := fakeGateway{
+		subs: map[string]stripe.Subscription{
+			"sub_overage": {
+				ID:                 "sub_overage",
+				Status:             stripe.SubscriptionStatusActive,
+				CurrentPeriodStart: periodStart,
+				CurrentPeriodEnd:   periodEnd,
+				Items:              &stripe.SubscriptionItemList{Data: []*stripe.SubscriptionItem{{ID: "si_overage", Plan: plan, Quantity: 1}}},
+			},
+		},
+		custs:        map[string]stripe.Customer{"cust_overage": {Email: "overage@example.com"}},
+		invoiceItems: map[string]gateway.InvoiceItem{},
+	}
+	svc := NewService(gw)
+
+	resp, err := svc.VerifySubscription(overageBoardID)
+	assert.NoError(t, err)
+	assert.True(t, resp.IsValidSubscription)
+	assert.Equal(t, ValidityTypeOverage, resp.ValidityType)
+
+	// Verifying is read-only: the period is recorded by the invoicing job itself.
+	var periods int
+	if err := db.QueryRow("SELECT COUNT(*) FROM overage_period WHERE user_external_id = $1", hb).Scan(&periods); err != nil {
+		t.Fatalf("Failed to count overage periods: %v", err)
+	}
+	assert.Equal(t, 0, periods)
+
+	n, err := svc.InvoiceOverages()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	if assert.Len(t, gw.invoiceItems, 1) {
+		for _, item := range gw.invoiceItems {
+			// 3 units past the allowance at 0.5 cents each, rounded half up
+			assert.Equal(t, int64(2), item.Amount)
+			assert.Equal(t, "cust_overage", item.CustomerID)
+			assert.Equal(t, "sub_overage", item.SubscriptionID)
+		}
+	}
+
+	// Invoiced periods are not billed again.
+	n, err = svc.InvoiceOverages()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, n)
+}
+
+func Test_Overage_FreeCreditIsNotInvoiced(t *testing.T) {
+	db, cleanup := setupSubTestDB(t)
+	defer cleanup()
+	const boardID = "overage-credit-test-board"
+	hb := stripedb.HashExternalID(boardID)
+	clean := func() {
+		_, _ = db.Exec("DELETE FROM overage_period WHERE user_external_id = $1", hb)
+		_, _ = db.Exec("DELETE FROM spending_unit WHERE user_external_id = $1", hb)
+		_, _ = db.Exec("DELETE FROM free_credit WHERE user_external_id = $1", hb)
+		_, _ = db.Exec("DELETE FROM user_account WHERE user_external_id = $1", hb)
+		_, _ = db.Exec("DELETE FROM plan_allowance WHERE stripe_plan_id = $1", "plan_overage_credit")
+	}
+	clean()
+	defer clean()
+
+	if err := stripedb.UpsertUserAccount(boardID, "sub_overage_credit", "plan_overage_credit", "cust_overage_credit"); err != nil {
+		t.Fatalf("UpsertUserAccount failed: %v", err)
+	}
+	// Free credit pays for part of the period's units.
+	if _, err := db.Exec("INSERT INTO free_credit (user_external_id, credit) VALUES ($1, 1)", hb); err != nil {
+		t.Fatalf("Failed to insert free_credit: %v", err)
+	}
+	periodStart := time.Now().Add(-2 * time.Hour).Unix()
+	periodEnd := time.Now().Add(-time.Minute).Unix()
+	if _, err := stripedb.AddSpendingUnits([]stripedb.SpendingUnit{
+		{ExternalID: "overage-credit-unit-1", UserExternalID: boardID, Amount: 8, CreatedAt: (periodStart + 60) * 1000},
+	}); err != nil {
+		t.Fatalf("AddSpendingUnits failed: %v", err)
+	}
+
+	plan := &stripe.Plan{
+		ID:       "plan_overage_credit",
+		Currency: stripe.CurrencyUSD,
+		Metadata: map[string]string{PlanMetadataUnitsPerPeriod: "5", PlanMetadataOverageUnitAmount: "0.5"},
+	}
+	gw := fakeGateway{
+		subs: map[string]stripe.Subscription{
+			"sub_overage_credit": {
+				ID:                 "sub_overage_credit",
+				Status:             stripe.SubscriptionStatusActive,
+				CurrentPeriodStart: periodStart,
+				CurrentPeriodEnd:   periodEnd,
+				Items:              &stripe.SubscriptionItemList{Data: []*stripe.SubscriptionItem{{ID: "si_overage_credit", Plan: plan, Quantity: 1}}},
+			},
+		},
+		custs:        map[string]stripe.Customer{"cust_overage_credit": {Email: "overage-credit@example.com"}},
+		invoiceItems: map[string]gateway.InvoiceItem{},
+	}
+	svc := NewService(gw)
+
+	n, err := svc.InvoiceOverages()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	if assert.Len(t, gw.invoiceItems, 1) {
+		for _, item := range gw.invoiceItems {
+			// 8 units, 1 paid with free credit: 2 past the allowance at 0.5 cents each
+			assert.Equal(t, int64(1), item.Amount)
+		}
+	}
+	var overage int64
+	if err := db.QueryRow("SELECT overage_units FROM overage_period WHERE user_external_id = $1", hb).Scan(&overage); err != nil {
+		t.Fatalf("Failed to read overage period: %v", err)
+	}
+	assert.Equal(t, int64(2), overage)
+}
+
+func Test_Overage_AllowanceCrossedPartlyOnFreeCredit(t *testing.T) {
+	db, cleanup := setupSubTestDB(t)
+	defer cleanup()
+	const boardID = "overage-partial-test-board"
+	hb := stripedb.HashExternalID(boardID)
+	clean := func() {
+		_, _ = db.Exec("DELETE FROM overage_period WHERE user_external_id = $1", hb)
+		_, _ = db.Exec("DELETE FROM allowance_alert WHERE user_external_id = $1", hb)
+		_, _ = db.Exec("DELETE FROM webhook_delivery WHERE event_type = $1", notifier.AlertTypeAllowanceThreshold)
+		_, _ = db.Exec("DELETE FROM spending_unit WHERE user_external_id = $1", hb)
+		_, _ = db.Exec("DELETE FROM free_credit WHERE user_external_id = $1", hb)
+		_, _ = db.Exec("DELETE FROM user_account WHERE user_external_id = $1", hb)
+		_, _ = db.Exec("DELETE FROM plan_allowance WHERE stripe_plan_id = $1", "plan_overage_partial")
+	}
+	clean()
+	defer clean()
+
+	if err := stripedb.UpsertUserAccount(boardID, "sub_overage_partial", "plan_overage_partial", "cust_overage_partial"); err != nil {
+		t.Fatalf("UpsertUserAccount failed: %v", err)
+	}
+	if _, err := db.Exec("INSERT INTO free_credit (user_external_id, credit) VALUES ($1, 3)", hb); err != nil {
+		t.Fatalf("Failed to insert free_credit: %v", err)
+	}
+	periodStart := time.Now().Add(-time.Hour).Unix()
+	plan := &stripe.Plan{
+		ID:       "plan_overage_partial",
+		Currency: stripe.CurrencyUSD,
+		Metadata: map[string]string{PlanMetadataUnitsPerPeriod: "5", PlanMetadataOverageUnitAmount: "0.5"},
+	}
+	gw := fakeGateway{
+		subs: map[string]stripe.Subscription{
+			"sub_overage_partial": {
+				ID:                 "sub_overage_partial",
+				Status:             stripe.SubscriptionStatusActive,
+				CurrentPeriodStart: periodStart,
+				CurrentPeriodEnd:   time.Now().Add(time.Hour).Unix(),
+				Items:              &stripe.SubscriptionItemList{Data: []*stripe.SubscriptionItem{{ID: "si_overage_partial", Plan: plan, Quantity: 1}}},
+			},
+		},
+		custs: map[string]stripe.Customer{"cust_overage_partial": {Email: "overage-partial@example.com"}},
+	}
+	svc := NewService(gw).(serviceImpl)
+	record := func(id string, amount int) {
+		t.Helper()
+		_, err := svc.AddSpendingUnits([]stripedb.SpendingUnit{
+			{ExternalID: id, UserExternalID: boardID, Amount: amount, CreatedAt: (periodStart + 60) * 1000},
+		})
+		assert.NoError(t, err)
+		svc.checks.wg.Wait()
+	}
+	overageUnits := func() int64 {
+		t.Helper()
+		var units int64
+		if err := db.QueryRow("SELECT overage_units FROM overage_period WHERE user_external_id = $1", hb).Scan(&units); err != nil {
+			t.Fatalf("Failed to read overage period: %v", err)
+		}
+		return units
+	}
+
+	// 6 units, 3 paid with free credit: 3 of the 5 allowed are used
+	record("overage-partial-unit-1", 6)
+	resp, err := svc.VerifySubscription(boardID)
+	assert.NoError(t, err)
+	assert.Equal(t, ValidityTypePayingCustomer, resp.ValidityType)
+	st, err := svc.GetEntitlements(boardID)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), st.UsedUnits)
+	assert.Equal(t, int64(2), st.RemainingUnits)
+	// recording units records the period, without waiting for the invoicing job
+	assert.Equal(t, int64(0), overageUnits())
+
+	// 4 more cross the allowance: 2 past it
+	record("overage-partial-unit-2", 4)
+	resp, err = svc.VerifySubscription(boardID)
+	assert.NoError(t, err)
+	assert.Equal(t, ValidityTypeOverage, resp.ValidityType)
+	st, err = svc.GetEntitlements(boardID)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), st.UsedUnits)
+	assert.Equal(t, int64(2), overageUnits())
+}
@@ -0,0 +1,84 @@
+package app
+
+import (
+	"fmt"
+	"time"
+
+	stripe "github.com/stripe/stripe-go"
+)
+
+// PauseState describes payment collection on the user's subscription after a pause or resume.
+// ResumesAt is unix ms, 0 when the pause has no end date.
+type PauseState struct {
+	SubscriptionID string
+	Paused         bool
+	Behavior       string
+	ResumesAt      int64
+}
+
+func pauseState(sub stripe.Subscription) PauseState {
+	st := PauseState{SubscriptionID: sub.ID, Paused: IsSubscriptionPaused(sub)}
+	if st.Paused {
+		st.Behavior = string(sub.PauseCollection.Behavior)
+		st.ResumesAt = sub.PauseCollection.ResumesAt * 1000
+	}
+	return st
+}
+
+// PauseSubscription pauses payment collection on the user's subscription, e.g. over a summer.
+// behavior says what happens to invoices created while paused: void (default), keep_as_draft or
+// mark_uncollectible. resumesAt (unix ms) is optional; without it the pause lasts until
+// ResumeSubscription. VerifySubscription reports the user as paused meanwhile.
+func (s serviceImpl) PauseSubscription(userExternalID, behavior string, resumesAt int64) (PauseState, error) {
+	if behavior == "" {
+		behavior = string(stripe.SubscriptionPauseCollectionBehaviorVoid)
+	}
+	switch stripe.SubscriptionPauseCollectionBehavior(behavior) {
+	case stripe.SubscriptionPauseCollectionBehaviorVoid,
+		stripe.SubscriptionPauseCollectionBehaviorKeepAsDraft,
+		stripe.SubscriptionPauseCollectionBehaviorMarkUncollectible:
+	default:
+		return PauseState{}, fmt.Errorf("invalid behavior %q (want void, keep_as_draft or mark_uncollectible)", behavior)
+	}
+	if resumesAt < 0 || (resumesAt > 0 && resumesAt <= time.Now().UnixMilli()) {
+		return PauseState{}, fmt.Errorf("resumes_at must be in the future")
+	}
+
+	account, err := ownAccount(userExternalID)
+	if err != nil {
+		return PauseState{}, err
+	}
+	_, sub, err := s.currentSubscription(account)
+	if err != nil {
+		return PauseState{}, err
+	}
+	// Stripe takes seconds; round up so the pause never ends before the requested time
+	sub, err = s.gw.PauseSubscription(sub.ID, behavior, (resumesAt+999)/1000)
+	if err != nil {
+		return PauseState{}, fmt.Errorf("%w: error pausing subscription: %v", ErrGateway, err)
+	}
+	entitlementsChanged(account)
+	return pauseState(sub), nil
+}
+
+// ResumeSubscription resumes payment collection on the user's paused subscription. Resuming a
+// subscription that isn't paused does nothing.
+func (s serviceImpl) ResumeSubscription(userExternalID string) (PauseState, error) {
+	account, err := ownAccount(userExternalID)
+	if err != nil {
+		return PauseState{}, err
+	}
+	_, sub, err := s.currentSubscription(account)
+	if err != nil {
+		return PauseState{}, err
+	}
+	if sub.PauseCollection.Behavior == "" {
+		return pauseState(sub), nil
+	}
+	sub, err = s.gw.ResumeSubscription(sub.ID)
+	if err != nil {
+		return PauseState{}, fmt.Errorf("%w: error resuming subscription: %v", ErrGateway, err)
+	}
+	entitlementsChanged(account)
+	return pauseState(sub), nil
+}
@@ -0,0 +1,62 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	stripe "github.com/stripe/stripe-go"
+	stripedb "github.com/tbeaudouin05/stripe-trellai/api/services/stripe/db"
+)
+
+func Test_PauseSubscription_VerifyReportsPausedUntilResumed(t *testing.T) {
+	db, cleanup := setupSubTestDB(t)
+	defer cleanup()
+	_, _ = db.Exec("DELETE FROM plan_allowance WHERE stripe_plan_id = 'plan_pause'")
+	defer db.Exec("DELETE FROM plan_allowance WHERE stripe_plan_id = 'plan_pause'")
+	if err := stripedb.UpsertUserAccount(subBoardID, "sub_pause", "no_need", "cust_pause"); err != nil {
+		t.Fatalf("UpsertUserAccount failed: %v", err)
+	}
+	if _, err := db.Exec("INSERT INTO free_credit (user_external_id, credit) VALUES ($1, 0) ON CONFLICT (user_external_id) DO UPDATE SET credit = 0", stripedb.HashExternalID(subBoardID)); err != nil {
+		t.Fatalf("Failed to upsert free_credit: %v", err)
+	}
+	now := time.Now()
+	gw := fakeGateway{subs: map[string]stripe.Subscription{
+		"sub_pause": {
+			ID:                 "sub_pause",
+			Status:             stripe.SubscriptionStatusActive,
+			Quantity:           1,
+			Plan:               &stripe.Plan{ID: "plan_pause", Metadata: map[string]string{PlanMetadataUnitsPerPeriod: "1_000"}},
+			CurrentPeriodStart: now.Unix() - 60,
+			CurrentPeriodEnd:   now.Unix() + 86400,
+		},
+	}}
+	svc := NewService(gw)
+
+	_, err := svc.PauseSubscription(subBoardID, "refund", 0)
+	assert.Error(t, err)
+	_, err = svc.PauseSubscription(subBoardID, "", now.Add(-time.Hour).UnixMilli())
+	assert.Error(t, err, "resume date in the past")
+
+	resumesAt := now.Add(30 * 24 * time.Hour).UnixMilli()
+	st, err := svc.PauseSubscription(subBoardID, "", resumesAt)
+	assert.NoError(t, err)
+	assert.True(t, st.Paused)
+	assert.Equal(t, "void", st.Behavior)
+	assert.GreaterOrEqual(t, st.ResumesAt, resumesAt)
+
+	resp, err := svc.VerifySubscription(subBoardID)
+	assert.NoError(t, err)
+	assert.False(t, resp.IsValidSubscription)
+	assert.Equal(t, InvalidityTypePaused, resp.InvalidityType)
+	assert.Equal(t, st.ResumesAt, resp.ResumesAt)
+
+	st, err = svc.ResumeSubscription(subBoardID)
+	assert.NoError(t, err)
+	assert.False(t, st.Paused)
+
+	resp, err = svc.VerifySubscription(subBoardID)
+	assert.NoError(t, err)
+	assert.True(t, resp.IsValidSubscription)
+	assert.Equal(t, ValidityTypePayingCustomer, resp.ValidityType)
+}
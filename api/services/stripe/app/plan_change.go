@@ -0,0 +1,169 @@
+package app
+
+import (
+	"fmt"
+	"time"
+
+	stripe "github.com/stripe/stripe-go"
+	stripedb "github.com/tbeaudouin05/stripe-trellai/api/services/stripe/db"
+	"github.com/tbeaudouin05/stripe-trellai/api/services/stripe/gateway"
+)
+
+// PlanChange is a request to move the user's subscription to another plan (price) in place.
+// SubscriptionItemID is only needed for subscriptions with several items; Quantity 0 keeps the
+// item's current quantity. ProrationBehavior defaults to create_prorations.
+type PlanChange struct {
+	UserExternalID     string
+	SubscriptionItemID string
+	PlanID             string
+	Quantity           int64
+	ProrationBehavior  string
+	// ProrationDate (unix ms) pins the proration to a previous preview.
+	ProrationDate int64
+}
+
+// PlanChangeResult describes the subscription after a plan change.
+type PlanChangeResult struct {
+	SubscriptionID string
+	PlanID         string
+	Quantity       int64
+	Status         string
+}
+
+// PreviewPlanChange returns the upcoming invoice the plan change would produce, without changing
+// anything. Pass the returned ProrationDate to ChangePlan to be billed exactly what was previewed.
+func (s serviceImpl) PreviewPlanChange(c PlanChange) (gateway.PlanChangePreview, error) {
+	account, err := ownAccount(c.UserExternalID)
+	if err != nil {
+		return gateway.PlanChangePreview{}, err
+	}
+	c.UserExternalID = account
+	change, err := s.resolvePlanChange(c)
+	if err != nil {
+		return gateway.PlanChangePreview{}, err
+	}
+	if change.ProrationDate == 0 {
+		change.ProrationDate = time.Now().UnixMilli()
+	}
+	preview, err := s.gw.PreviewPlanChange(change)
+	if err != nil {
+		return gateway.PlanChangePreview{}, fmt.Errorf("%w: error previewing plan change: %v", ErrGateway, err)
+	}
+	if preview.ProrationDate == 0 {
+		preview.ProrationDate = change.ProrationDate
+	}
+	return preview, nil
+}
+
+// ChangePlan moves the user's existing subscription to another plan and/or quantity, so upgrades
+// and downgrades don't go through a second checkout.
+func (s serviceImpl) ChangePlan(c PlanChange) (PlanChangeResult, error) {
+	account, err := ownAccount(c.UserExternalID)
+	if err != nil {
+		return PlanChangeResult{}, err
+	}
+	c.UserExternalID = account
+	change, err := s.resolvePlanChange(c)
+	if err != nil {
+		return PlanChangeResult{}, err
+	}
+	sub, err := s.gw.ChangeSubscriptionPlan(change)
+	if err != nil {
+		return PlanChangeResult{}, fmt.Errorf("%w: error changing plan: %v", ErrGateway, err)
+	}
+	entitlementsChanged(c.UserExternalID)
+	result := PlanChangeResult{SubscriptionID: sub.ID, PlanID: change.PlanID, Quantity: change.Quantity, Status: string(sub.Status)}
+	if sub.Items != nil {
+		for _, it := range sub.Items.Data {
+			if it != nil && it.ID == change.SubscriptionItemID {
+				result.Quantity = it.Quantity
+			}
+		}
+	}
+	return result, nil
+}
+
+// resolvePlanChange validates a plan change against the user's current subscription and fills in
+// the Stripe identifiers it applies to.
+func (s serviceImpl) resolvePlanChange(c PlanChange) (gateway.PlanChange, error) {
+	behavior := c.ProrationBehavior
+	if behavior == "" {
+		behavior = string(stripe.SubscriptionProrationBehaviorCreateProrations)
+	}
+	switch stripe.SubscriptionProrationBehavior(behavior) {
+	case stripe.SubscriptionProrationBehaviorCreateProrations,
+		stripe.SubscriptionProrationBehaviorAlwaysInvoice,
+		stripe.SubscriptionProrationBehaviorNone:
+	default:
+		return gateway.PlanChange{}, fmt.Errorf("invalid proration_behavior %q (want create_prorations, always_invoice or none)", behavior)
+	}
+	if c.Quantity < 0 {
+		return gateway.PlanChange{}, fmt.Errorf("quantity must be >= 0")
+	}
+
+	ua, sub, err := s.currentSubscription(c.UserExternalID)
+	if err != nil {
+		return gateway.PlanChange{}, err
+	}
+
+	item, err := planChangeItem(sub, c.SubscriptionItemID)
+	if err != nil {
+		return gateway.PlanChange{}, err
+	}
+	quantity := c.Quantity
+	if quantity == 0 {
+		quantity = item.Quantity
+	}
+	return gateway.PlanChange{
+		CustomerID:         ua.StripeCustomerID,
+		SubscriptionID:     sub.ID,
+		SubscriptionItemID: item.ID,
+		PlanID:             c.PlanID,
+		Quantity:           quantity,
+		ProrationBehavior:  behavior,
+		ProrationDate:      c.ProrationDate,
+	}, nil
+}
+
+// currentSubscription returns the user's account and its subscription, which must not be cancelled.
+func (s serviceImpl) currentSubscription(userExternalID string) (stripedb.UserAccount, stripe.Subscription, error) {
+	ua, err := stripedb.GetUserAccount(userExternalID)
+	if err != nil {
+		return stripedb.UserAccount{}, stripe.Subscription{}, fmt.Errorf("%w: error retrieving user account: %v", ErrDatabase, err)
+	}
+	if ua.StripeSubscriptionID == "" {
+		return stripedb.UserAccount{}, stripe.Subscription{}, fmt.Errorf("%w: user has no subscription", ErrNotFound)
+	}
+	sub, err := s.gw.GetSubscription(ua.StripeSubscriptionID)
+	if err != nil {
+		return stripedb.UserAccount{}, stripe.Subscription{}, fmt.Errorf("%w: error getting subscription: %v", ErrGateway, err)
+	}
+	if IsSubscriptionCancelled(sub) {
+		return stripedb.UserAccount{}, stripe.Subscription{}, fmt.Errorf("%w: subscription is cancelled", ErrNotAllowed)
+	}
+	return ua, sub, nil
+}
+
+// planChangeItem picks the subscription item to change: itemID when given, else the only item.
+func planChangeItem(sub stripe.Subscription, itemID string) (*stripe.SubscriptionItem, error) {
+	var items []*stripe.SubscriptionItem
+	if sub.Items != nil {
+		for _, it := range sub.Items.Data {
+			if it != nil && !it.Deleted {
+				items = append(items, it)
+			}
+		}
+	}
+	if itemID != "" {
+		for _, it := range items {
+			if it.ID == itemID {
+				return it, nil
+			}
+		}
+		return nil, fmt.Errorf("%w: subscription item %q not found on subscription", ErrNotFound, itemID)
+	}
+	if len(items) != 1 {
+		return nil, fmt.Errorf("subscription has %d items; subscription_item_id is required", len(items))
+	}
+	return items[0], nil
+}
@@ -0,0 +1,53 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	stripe "github.com/stripe/stripe-go"
+	stripedb "github.com/tbeaudouin05/stripe-trellai/api/services/stripe/db"
+	"github.com/tbeaudouin05/stripe-trellai/api/services/stripe/gateway"
+)
+
+func Test_ChangePlan_UpdatesExistingSubscriptionItem(t *testing.T) {
+	_, cleanup := setupSubTestDB(t)
+	defer cleanup()
+	if err := stripedb.UpsertUserAccount(subBoardID, "sub_change", "no_need", "cust_change"); err != nil {
+		t.Fatalf("UpsertUserAccount failed: %v", err)
+	}
+	var changes []gateway.PlanChange
+	gw := fakeGateway{
+		subs: map[string]stripe.Subscription{
+			"sub_change": {
+				ID:     "sub_change",
+				Status: stripe.SubscriptionStatusActive,
+				Items:  &stripe.SubscriptionItemList{Data: []*stripe.SubscriptionItem{{ID: "si_basic", Plan: &stripe.Plan{ID: "plan_basic"}, Quantity: 2}}},
+			},
+		},
+		planChanges: &changes,
+	}
+	svc := NewService(gw)
+
+	preview, err := svc.PreviewPlanChange(PlanChange{UserExternalID: subBoardID, PlanID: "plan_pro"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1500), preview.AmountDue)
+	assert.NotZero(t, preview.ProrationDate, "preview pins a proration date to reuse")
+
+	_, err = svc.ChangePlan(PlanChange{UserExternalID: subBoardID, PlanID: "plan_pro", ProrationDate: preview.ProrationDate})
+	assert.NoError(t, err)
+	if assert.Len(t, changes, 2) {
+		applied := changes[1]
+		assert.Equal(t, "sub_change", applied.SubscriptionID)
+		assert.Equal(t, "si_basic", applied.SubscriptionItemID)
+		assert.Equal(t, "cust_change", applied.CustomerID)
+		// quantity 0 keeps the current quantity; default proration behaviour
+		assert.Equal(t, int64(2), applied.Quantity)
+		assert.Equal(t, "create_prorations", applied.ProrationBehavior)
+		assert.Equal(t, preview.ProrationDate, applied.ProrationDate)
+	}
+
+	_, err = svc.ChangePlan(PlanChange{UserExternalID: subBoardID, PlanID: "plan_pro", ProrationBehavior: "sometimes"})
+	assert.Error(t, err)
+	_, err = svc.ChangePlan(PlanChange{UserExternalID: subBoardID, PlanID: "plan_pro", SubscriptionItemID: "si_other"})
+	assert.ErrorIs(t, err, ErrNotFound)
+}
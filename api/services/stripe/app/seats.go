@@ -0,0 +1,158 @@
+package app
+
+import (
+	"fmt"
+	"log/slog"
+
+	stripe "github.com/stripe/stripe-go"
+	stripedb "github.com/tbeaudouin05/stripe-trellai/api/services/stripe/db"
+)
+
+// SeatState lists an organization's seated members. Quantity is the subscription's quantity,
+// 0 when the organization has no subscription (or a metered one).
+type SeatState struct {
+	Seats    []stripedb.OrganizationMember
+	Quantity int64
+}
+
+// AddSeat gives a user a seat in the organization, adding them as a member if needed, on behalf
+// of the organization's owner or an admin. The subscription quantity follows the number of seats.
+func (s serviceImpl) AddSeat(orgExternalID, actorUserExternalID, userExternalID string) (SeatState, error) {
+	orgID, actorRole, err := organizationActor(orgExternalID, actorUserExternalID)
+	if err != nil {
+		return SeatState{}, err
+	}
+	if err := canManage(actorRole, "", "", false); err != nil {
+		return SeatState{}, err
+	}
+	if _, isMember, err := memberRole(orgID, userExternalID); err != nil {
+		return SeatState{}, err
+	} else if !isMember {
+		if err := stripedb.UpsertOrganizationMember(orgID, userExternalID, stripedb.RoleMember); err != nil {
+			return SeatState{}, organizationError(err)
+		}
+	}
+	assigned, err := stripedb.AssignSeat(orgID, userExternalID)
+	if err != nil {
+		return SeatState{}, fmt.Errorf("%w: %v", ErrDatabase, err)
+	}
+	if assigned {
+		if err := s.syncSeats(orgExternalID, orgID); err != nil {
+			// give the seat back so seats and the billed quantity stay in line
+			if _, undoErr := stripedb.UnassignSeat(orgID, userExternalID); undoErr != nil {
+				slog.Error("error unassigning seat after failed quantity update", "organization_id", orgID, "err", undoErr)
+			}
+			return SeatState{}, err
+		}
+		entitlementsChanged(userExternalID)
+	}
+	return s.seatState(orgExternalID, orgID)
+}
+
+// RemoveSeat takes a member's seat back, on behalf of the organization's owner or an admin, and
+// lowers the subscription quantity. They stay a member. The last seat can't be removed.
+func (s serviceImpl) RemoveSeat(orgExternalID, actorUserExternalID, userExternalID string) (SeatState, error) {
+	orgID, actorRole, err := organizationActor(orgExternalID, actorUserExternalID)
+	if err != nil {
+		return SeatState{}, err
+	}
+	if err := canManage(actorRole, "", "", false); err != nil {
+		return SeatState{}, err
+	}
+	m, isMember, err := stripedb.GetMembership(userExternalID)
+	if err != nil {
+		return SeatState{}, fmt.Errorf("%w: %v", ErrDatabase, err)
+	}
+	if !isMember || m.OrganizationID != orgID || !m.Seated {
+		return SeatState{}, organizationError(stripedb.ErrNoSeat)
+	}
+	// checked and removed under a lock on the organization, so concurrent removals can't both pass
+	unassigned, err := stripedb.UnassignSeatUnlessLast(orgID, userExternalID)
+	if err != nil {
+		return SeatState{}, organizationError(err)
+	}
+	if !unassigned {
+		return SeatState{}, fmt.Errorf("%w: an organization keeps at least one seat", ErrNotAllowed)
+	}
+	if err := s.syncSeats(orgExternalID, orgID); err != nil {
+		if _, undoErr := stripedb.AssignSeat(orgID, userExternalID); undoErr != nil {
+			slog.Error("error reassigning seat after failed quantity update", "organization_id", orgID, "err", undoErr)
+		}
+		return SeatState{}, err
+	}
+	entitlementsChanged(userExternalID)
+	return s.seatState(orgExternalID, orgID)
+}
+
+// ListSeats returns the organization's seats to one of its members.
+func (s serviceImpl) ListSeats(orgExternalID, actorUserExternalID string) (SeatState, error) {
+	orgID, _, err := organizationActor(orgExternalID, actorUserExternalID)
+	if err != nil {
+		return SeatState{}, err
+	}
+	return s.seatState(orgExternalID, orgID)
+}
+
+// syncSeats sets the quantity of the organization's subscription to its number of seats.
+// Organizations without a (licensed) subscription yet have nothing to sync.
+func (s serviceImpl) syncSeats(orgExternalID string, orgID int64) error {
+	sub, item, ok, err := s.seatItem(orgExternalID)
+	if err != nil || !ok {
+		return err
+	}
+	seats, err := stripedb.CountSeats(orgID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrDatabase, err)
+	}
+	if item.Quantity == int64(seats) {
+		return nil
+	}
+	if _, err := s.gw.UpdateSubscriptionQuantity(sub.ID, item.ID, int64(seats)); err != nil {
+		return fmt.Errorf("%w: error updating subscription quantity: %v", ErrGateway, err)
+	}
+	slog.Info("subscription quantity synced to seats", "subscription_id", sub.ID, "seats", seats)
+	return nil
+}
+
+// seatItem returns the organization's subscription and the item whose quantity counts seats.
+// The boolean is false when the organization has no active subscription, or a metered one.
+func (s serviceImpl) seatItem(orgExternalID string) (stripe.Subscription, *stripe.SubscriptionItem, bool, error) {
+	ua, err := stripedb.GetUserAccount(stripedb.OrganizationAccount(orgExternalID))
+	if err != nil {
+		return stripe.Subscription{}, nil, false, fmt.Errorf("%w: error retrieving user account: %v", ErrDatabase, err)
+	}
+	if ua.StripeSubscriptionID == "" {
+		return stripe.Subscription{}, nil, false, nil
+	}
+	sub, err := s.gw.GetSubscription(ua.StripeSubscriptionID)
+	if err != nil {
+		return stripe.Subscription{}, nil, false, fmt.Errorf("%w: error getting subscription: %v", ErrGateway, err)
+	}
+	if IsSubscriptionCancelled(sub) {
+		return stripe.Subscription{}, nil, false, nil
+	}
+	item, err := planChangeItem(sub, "")
+	if err != nil {
+		return stripe.Subscription{}, nil, false, err
+	}
+	if item.Plan != nil && item.Plan.UsageType == stripe.PlanUsageTypeMetered {
+		return stripe.Subscription{}, nil, false, nil
+	}
+	return sub, item, true, nil
+}
+
+func (s serviceImpl) seatState(orgExternalID string, orgID int64) (SeatState, error) {
+	seats, err := stripedb.ListSeats(orgID)
+	if err != nil {
+		return SeatState{}, fmt.Errorf("%w: %v", ErrDatabase, err)
+	}
+	st := SeatState{Seats: seats}
+	_, item, ok, err := s.seatItem(orgExternalID)
+	if err != nil {
+		return SeatState{}, err
+	}
+	if ok {
+		st.Quantity = item.Quantity
+	}
+	return st, nil
+}
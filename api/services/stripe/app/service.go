@@ -1,13 +1,17 @@
 package app
 
 import (
+    "context"
     "encoding/json"
+    "errors"
     "fmt"
     "log/slog"
 
     stripe "github.com/stripe/stripe-go"
     stripedb "github.com/tbeaudouin05/stripe-trellai/api/services/stripe/db"
     gw "github.com/tbeaudouin05/stripe-trellai/api/services/stripe/gateway"
+    "github.com/tbeaudouin05/stripe-trellai/api/services/stripe/notifier"
+    "github.com/tbeaudouin05/stripe-trellai/api/services/stripe/outbound"
 )
 
 // Service defines the business operations for the Stripe domain.
@@ -15,17 +19,69 @@ import (
 type Service interface {
     CancelSubscription(subscriptionID string) error
     VerifySubscription(userExternalID string) (VerifySubscriptionResponse, error)
+    BatchVerifySubscription(userExternalIDs []string) (map[string]VerifyResult, error)
+    GetEntitlements(userExternalID string) (EntitlementState, error)
+    WatchEntitlements(ctx context.Context, userExternalID string, send func(EntitlementState) error) error
     HandleCheckoutSessionCompleted(event stripe.Event) error
     AddSpendingUnits(items []stripedb.SpendingUnit) (int, error)
+    RefundSpendingUnits(externalIDs []string) (int, error)
+    ReportMeteredUsage() (int, error)
+    InvoiceOverages() (int, error)
+    DeliverWebhooks() (int, error)
+    ListWebhookDeliveries(beforeID int64, status, eventType string, limit int) ([]stripedb.WebhookDelivery, error)
+    CreateCreditPackCheckout(userExternalID, packID, successURL, cancelURL string) (string, error)
+    RefreshFreeCredits() (int, error)
+    GrantCredits(userExternalID string, amount int, reason, actor string, expiresAt int64) (stripedb.CreditAdjustment, error)
+    RevokeCredits(userExternalID string, amount int, reason, actor string) (stripedb.CreditAdjustment, error)
+    CreateCampaign(c stripedb.Campaign) (int64, error)
+    RedeemCode(userExternalID, code, idempotencyKey string) (stripedb.Redemption, error)
+    GetReferralCode(userExternalID string) (string, error)
+    HandleInvoiceEvent(event stripe.Event) error
+    HandleSubscriptionDeleted(event stripe.Event) error
+    GetBillingStatus(userExternalID string) (stripedb.BillingStatus, error)
+    PreviewPlanChange(c PlanChange) (gw.PlanChangePreview, error)
+    ChangePlan(c PlanChange) (PlanChangeResult, error)
+    CreateOrganization(orgExternalID, ownerUserExternalID string) (int64, error)
+    AddOrganizationMember(orgExternalID, actorUserExternalID, userExternalID, role string) error
+    RemoveOrganizationMember(orgExternalID, actorUserExternalID, userExternalID string) error
+    ListOrganizationMembers(orgExternalID, actorUserExternalID string) ([]stripedb.OrganizationMember, error)
+    AddSeat(orgExternalID, actorUserExternalID, userExternalID string) (SeatState, error)
+    RemoveSeat(orgExternalID, actorUserExternalID, userExternalID string) (SeatState, error)
+    ListSeats(orgExternalID, actorUserExternalID string) (SeatState, error)
+    SetSpendingCap(orgExternalID, actorUserExternalID string, c SpendingCapChange) error
+    GetUsageBreakdown(orgExternalID, actorUserExternalID string) (UsageBreakdown, error)
+    GetUsageByDimension(userExternalID, labelKey string) (DimensionUsage, error)
+    PauseSubscription(userExternalID, behavior string, resumesAt int64) (PauseState, error)
+    ResumeSubscription(userExternalID string) (PauseState, error)
+    ListInvalidSubscriptions(afterID int64, limit int, includeResolved bool) ([]stripedb.InvalidSubscription, error)
+    ResolveInvalidSubscription(id int64, resolution, actor string) (stripedb.InvalidSubscription, error)
 }
 
 // serviceImpl is a concrete implementation.
 // No fields needed yet since we rely on package-level database funcs.
-type serviceImpl struct{ gw gw.StripeGateway }
+type serviceImpl struct {
+    gw       gw.StripeGateway
+    notifier notifier.Notifier
+    // cache shares recently fetched subscriptions and customers, see cachedStripe
+    cache *stripeCache
+    // checks follow recorded units in the background, see queueUsageChecks
+    checks *usageChecks
+}
+
+// NewService returns the service, logging allowance alerts.
+func NewService(g gw.StripeGateway) Service { return NewServiceWithNotifier(g, nil) }
 
-func NewService(g gw.StripeGateway) Service { return serviceImpl{gw: g} }
+// NewServiceWithNotifier returns the service, sending allowance alerts through n (logged if nil).
+func NewServiceWithNotifier(g gw.StripeGateway, n notifier.Notifier) Service {
+    if n == nil {
+        n = notifier.Log{}
+    }
+    return serviceImpl{gw: g, notifier: n, cache: newStripeCache(), checks: &usageChecks{}}
+}
 
-// HandleCheckoutSessionCompleted processes the checkout.session.completed event
+// HandleCheckoutSessionCompleted processes the checkout.session.completed event.
+// It also handles checkout.session.async_payment_succeeded, which only concerns
+// credit pack (payment mode) sessions.
 func (s serviceImpl) HandleCheckoutSessionCompleted(event stripe.Event) error {
     slog.Info("HandleCheckoutSessionCompleted: start", "event_type", event.Type, "event_id", event.ID)
     var session stripe.CheckoutSession
@@ -37,6 +93,13 @@ func (s serviceImpl) HandleCheckoutSessionCompleted(event stripe.Event) error {
         slog.Error("client reference ID not found in CheckoutSession")
         return fmt.Errorf("%w: client reference ID not found in CheckoutSession", ErrBadEvent)
     }
+    if session.Mode == stripe.CheckoutSessionModePayment {
+        return s.handleCreditPackPayment(session, event.Data.Raw)
+    }
+    if event.Type == "checkout.session.async_payment_succeeded" {
+        slog.Info("ignoring async payment for non-payment session", "session_id", session.ID, "mode", session.Mode)
+        return nil
+    }
     if session.Customer == nil || session.Customer.ID == "" {
         slog.Error("customer ID not found in CheckoutSession")
         return fmt.Errorf("%w: customer ID not found in CheckoutSession", ErrBadEvent)
@@ -45,10 +108,15 @@ func (s serviceImpl) HandleCheckoutSessionCompleted(event stripe.Event) error {
         slog.Error("subscription ID not found in CheckoutSession")
         return fmt.Errorf("%w: subscription ID not found in CheckoutSession", ErrBadEvent)
     }
-    userExternalID := session.ClientReferenceID
+    // an organization's subscription is bought for its pooled account
+    userExternalID, err := ownAccount(session.ClientReferenceID)
+    if err != nil {
+        return err
+    }
     stripeCustomerID := session.Customer.ID
     newStripeSubscriptionID := session.Subscription.ID
 
+    duplicate := false
     exists, existingSubID, err := stripedb.CheckUserAccount(userExternalID)
     if err != nil {
         slog.Error("error checking user account", "user_external_id", userExternalID, "err", err)
@@ -69,6 +137,12 @@ func (s serviceImpl) HandleCheckoutSessionCompleted(event stripe.Event) error {
                 slog.Error("error upserting user_account when no previous subscription id", "user_external_id", userExternalID, "stripe_subscription_id", newStripeSubscriptionID, "stripe_customer_id", stripeCustomerID, "err", err)
                 return fmt.Errorf("%w: error upserting user_account: %v", ErrDatabase, err)
             }
+        } else if existingSubID == newStripeSubscriptionID {
+            slog.Info("subscription already recorded on user account", "user_external_id", userExternalID, "stripe_subscription_id", newStripeSubscriptionID)
+            if err := resumeDuplicateResolution(newStripeSubscriptionID); err != nil {
+                slog.Error("error resuming duplicate subscription resolution", "stripe_subscription_id", newStripeSubscriptionID, "err", err)
+                return err
+            }
         } else {
             prevSub, err := s.gw.GetSubscription(existingSubID)
             if err != nil {
@@ -82,10 +156,11 @@ func (s serviceImpl) HandleCheckoutSessionCompleted(event stripe.Event) error {
                     return fmt.Errorf("%w: error upserting user_account: %v", ErrDatabase, err)
                 }
             } else {
-                slog.Info("previous subscription active, recording new subscription as invalid", "user_external_id", userExternalID)
-                if err := stripedb.InsertInvalidSubscription(userExternalID, newStripeSubscriptionID, "no_need", stripeCustomerID); err != nil {
-                    slog.Error("error inserting invalid subscription", "user_external_id", userExternalID, "stripe_subscription_id", newStripeSubscriptionID, "stripe_customer_id", stripeCustomerID, "err", err)
-                    return fmt.Errorf("%w: error inserting invalid subscription: %v", ErrDatabase, err)
+                slog.Info("previous subscription active, handling new subscription as duplicate", "user_external_id", userExternalID)
+                duplicate = true
+                if err := s.handleDuplicateSubscription(userExternalID, existingSubID, newStripeSubscriptionID, stripeCustomerID); err != nil {
+                    slog.Error("error handling duplicate subscription", "user_external_id", userExternalID, "stripe_subscription_id", newStripeSubscriptionID, "stripe_customer_id", stripeCustomerID, "err", err)
+                    return err
                 }
             }
         }
@@ -95,14 +170,150 @@ func (s serviceImpl) HandleCheckoutSessionCompleted(event stripe.Event) error {
         slog.Error("error initializing free credit", "user_external_id", userExternalID, "err", err)
         return fmt.Errorf("%w: error initializing free credit: %v", ErrDatabase, err)
     }
-    return nil
+    if !duplicate {
+        entitlementsChanged(userExternalID)
+        data := userEventData(userExternalID)
+        data.StripeSubscriptionID, data.StripeCustomerID = newStripeSubscriptionID, stripeCustomerID
+        if err := emitEvent(outbound.EventSubscriptionActivated, newStripeSubscriptionID, data); err != nil {
+            return err
+        }
+    }
+    return s.rewardReferral(userExternalID)
 }
 
 // AddSpendingUnits inserts a batch of spending units and returns how many were inserted.
+// Units of organization members are billed to the organization's pooled account, and units of
+// weighted features (FEATURE_WEIGHTS) count for their weight.
 func (s serviceImpl) AddSpendingUnits(items []stripedb.SpendingUnit) (int, error) {
-    n, err := stripedb.AddSpendingUnits(items)
+    accounts := make(map[string]string)
+    billedOf := make(map[string]stripedb.BilledAccount)
+    pooled := make(map[string]bool)
+    if err := applyFeatureWeights(items); err != nil {
+        return 0, err
+    }
+    for i, it := range items {
+        billed, ok := billedOf[it.UserExternalID]
+        if !ok {
+            var err error
+            if billed, err = resolveAccount(it.UserExternalID); err != nil {
+                return 0, err
+            }
+            billedOf[it.UserExternalID] = billed
+            accounts[it.UserExternalID] = billed.Account
+        }
+        if billed.Member {
+            items[i].MemberExternalID = it.UserExternalID
+            pooled[billed.Account] = true
+        }
+        items[i].UserExternalID = billed.Account
+    }
+    // caps are checked against the usage read under the organization's lock, in the transaction
+    // recording the batch, so a refused batch records nothing and can be retried as a whole
+    var scopes []stripedb.CapScope
+    for account := range pooled {
+        scope, capped, err := s.capScope(account, items)
+        if err != nil {
+            return 0, err
+        }
+        if capped {
+            scopes = append(scopes, scope)
+        }
+    }
+    balances, err := creditBalances(accounts)
+    if err != nil {
+        return 0, err
+    }
+    var n int
+    if len(scopes) > 0 {
+        n, err = stripedb.AddSpendingUnitsWithinCaps(items, scopes)
+    } else {
+        n, err = stripedb.AddSpendingUnits(items)
+    }
+    if errors.Is(err, ErrNotAllowed) {
+        return 0, err
+    }
     if err != nil {
         return 0, fmt.Errorf("%w: %v", ErrDatabase, err)
     }
+    if len(items) > 0 && len(balances) > 0 {
+        if err := emitCreditsExhausted(balances, items[len(items)-1].ExternalID); err != nil {
+            slog.Error("error emitting credits.exhausted", "err", err)
+        }
+    }
+    // watchers hear about the new usage; overage periods and alerts are checked in the background
+    // and never fail the recording, missed ones are caught up when more units are recorded
+    checked := make(map[string]bool)
+    for _, account := range accounts {
+        if checked[account] {
+            continue
+        }
+        checked[account] = true
+        if n > 0 {
+            usageChanged(stripedb.HashExternalID(account))
+        }
+        s.queueUsageChecks(account)
+    }
     return n, nil
 }
+
+// RefundSpendingUnits reverses previously recorded spending units by their original external IDs
+// and returns how many were refunded. Already-refunded units are skipped. Each unit is refunded on
+// its own, so on error the count still tells how many were refunded before it.
+func (s serviceImpl) RefundSpendingUnits(externalIDs []string) (int, error) {
+    n, accounts, err := stripedb.RefundSpendingUnits(externalIDs)
+    usageChanged(accounts...)
+    if err != nil {
+        if errors.Is(err, stripedb.ErrSpendingUnitNotFound) {
+            return n, fmt.Errorf("%w: %v", ErrNotFound, err)
+        }
+        return n, fmt.Errorf("%w: %v", ErrDatabase, err)
+    }
+    return n, nil
+}
+
+// RefreshFreeCredits expires free credit past its expires_at and applies pending monthly
+// refills for all users, returning how many balances changed.
+func (s serviceImpl) RefreshFreeCredits() (int, error) {
+    n, err := stripedb.RefreshFreeCredits()
+    if err != nil {
+        return 0, fmt.Errorf("%w: %v", ErrDatabase, err)
+    }
+    return int(n), nil
+}
+
+// GrantCredits adds free credit to a user on behalf of an admin (actor), with an optional
+// expiry in unix ms, and records it in the audit trail.
+func (s serviceImpl) GrantCredits(userExternalID string, amount int, reason, actor string, expiresAt int64) (stripedb.CreditAdjustment, error) {
+    userExternalID, err := ownAccount(userExternalID)
+    if err != nil {
+        return stripedb.CreditAdjustment{}, err
+    }
+    adj, err := stripedb.GrantFreeCredits(userExternalID, amount, reason, actor, expiresAt)
+    if err != nil {
+        return stripedb.CreditAdjustment{}, fmt.Errorf("%w: %v", ErrDatabase, err)
+    }
+    slog.Info("credits granted", "actor", actor, "amount", amount, "grant_id", adj.GrantID)
+    entitlementsChanged(userExternalID)
+    data := userEventData(userExternalID)
+    data.Units, data.Source = int64(amount), CreditSourceAdmin
+    if err := emitEvent(outbound.EventCreditsGranted, fmt.Sprint(adj.GrantID), data); err != nil {
+        slog.Error("error emitting credits.granted", "grant_id", adj.GrantID, "err", err)
+    }
+    return adj, nil
+}
+
+// RevokeCredits removes up to amount of free credit from a user on behalf of an admin (actor)
+// and records the amount actually removed in the audit trail.
+func (s serviceImpl) RevokeCredits(userExternalID string, amount int, reason, actor string) (stripedb.CreditAdjustment, error) {
+    userExternalID, err := ownAccount(userExternalID)
+    if err != nil {
+        return stripedb.CreditAdjustment{}, err
+    }
+    adj, err := stripedb.RevokeFreeCredits(userExternalID, amount, reason, actor)
+    if err != nil {
+        return stripedb.CreditAdjustment{}, fmt.Errorf("%w: %v", ErrDatabase, err)
+    }
+    slog.Info("credits revoked", "actor", actor, "amount", -adj.Applied, "grant_id", adj.GrantID)
+    entitlementsChanged(userExternalID)
+    return adj, nil
+}
@@ -11,6 +11,7 @@ import (
 	config "github.com/tbeaudouin05/stripe-trellai/api/config"
 	database "github.com/tbeaudouin05/stripe-trellai/api/database"
 	stripedb "github.com/tbeaudouin05/stripe-trellai/api/services/stripe/db"
+	"github.com/tbeaudouin05/stripe-trellai/api/services/stripe/gateway"
 )
 
 // Deprecated local hash helper removed; use stripedb.HashExternalID instead
@@ -23,6 +24,22 @@ const (
 type fakeGateway struct {
 	subs  map[string]stripe.Subscription
 	custs map[string]stripe.Customer
+	prods map[string]stripe.Product
+	// usage records posted, keyed by idempotency key
+	usage map[string]int64
+	// usage records timestamped before this (unix seconds) are rejected
+	usageRejectedBefore int64
+	// invoice items created, keyed by idempotency key
+	invoiceItems map[string]gateway.InvoiceItem
+	// payment checkouts created, in order
+	checkouts *[]gateway.PaymentCheckout
+	// plan changes previewed or applied, in order
+	planChanges *[]gateway.PlanChange
+	// subscriptions cancelled and refunded, in order
+	cancelled *[]string
+	refunds   *[]string
+	// subscriptions whose latest invoice has no charge to refund
+	uncharged map[string]bool
 }
 
 func (f fakeGateway) GetSubscription(id string) (stripe.Subscription, error) {
@@ -32,7 +49,23 @@ func (f fakeGateway) GetSubscription(id string) (stripe.Subscription, error) {
 	return f.subs[id], nil
 }
 
-func (f fakeGateway) CancelSubscription(id string) error { return nil }
+func (f fakeGateway) CancelSubscription(id string) error {
+	if f.cancelled != nil {
+		*f.cancelled = append(*f.cancelled, id)
+	}
+	return nil
+}
+
+func (f fakeGateway) RefundLatestInvoice(subscriptionID, idempotencyKey string) (string, error) {
+	if f.uncharged[subscriptionID] {
+		return "", gateway.ErrNothingToRefund
+	}
+	if f.refunds != nil {
+		*f.refunds = append(*f.refunds, subscriptionID)
+	}
+	return "re_" + subscriptionID, nil
+}
+
 func (f fakeGateway) GetCustomer(id string) (stripe.Customer, error) {
 	if f.custs == nil {
 		return stripe.Customer{ID: id}, nil
@@ -40,6 +73,86 @@ func (f fakeGateway) GetCustomer(id string) (stripe.Customer, error) {
 	return f.custs[id], nil
 }
 
+func (f fakeGateway) CreateInvoiceItem(item gateway.InvoiceItem) (string, error) {
+	if f.invoiceItems != nil {
+		f.invoiceItems[item.IdempotencyKey] = item
+	}
+	return "ii_" + item.IdempotencyKey, nil
+}
+
+func (f fakeGateway) CreatePaymentCheckout(checkout gateway.PaymentCheckout) (string, error) {
+	if f.checkouts != nil {
+		*f.checkouts = append(*f.checkouts, checkout)
+	}
+	return "cs_fake", nil
+}
+
+func (f fakeGateway) ChangeSubscriptionPlan(change gateway.PlanChange) (stripe.Subscription, error) {
+	if f.planChanges != nil {
+		*f.planChanges = append(*f.planChanges, change)
+	}
+	s := f.subs[change.SubscriptionID]
+	s.ID = change.SubscriptionID
+	return s, nil
+}
+
+func (f fakeGateway) UpdateSubscriptionQuantity(subscriptionID, subscriptionItemID string, quantity int64) (stripe.Subscription, error) {
+	s := f.subs[subscriptionID]
+	s.ID = subscriptionID
+	if s.Items != nil {
+		for _, it := range s.Items.Data {
+			if it != nil && it.ID == subscriptionItemID {
+				it.Quantity = quantity
+			}
+		}
+	}
+	return s, nil
+}
+
+func (f fakeGateway) PauseSubscription(id, behavior string, resumesAt int64) (stripe.Subscription, error) {
+	s := f.subs[id]
+	s.ID = id
+	s.PauseCollection = stripe.SubscriptionPauseCollection{Behavior: stripe.SubscriptionPauseCollectionBehavior(behavior), ResumesAt: resumesAt}
+	if f.subs != nil {
+		f.subs[id] = s
+	}
+	return s, nil
+}
+
+func (f fakeGateway) ResumeSubscription(id string) (stripe.Subscription, error) {
+	s := f.subs[id]
+	s.ID = id
+	s.PauseCollection = stripe.SubscriptionPauseCollection{}
+	if f.subs != nil {
+		f.subs[id] = s
+	}
+	return s, nil
+}
+
+func (f fakeGateway) PreviewPlanChange(change gateway.PlanChange) (gateway.PlanChangePreview, error) {
+	if f.planChanges != nil {
+		*f.planChanges = append(*f.planChanges, change)
+	}
+	return gateway.PlanChangePreview{Currency: "usd", AmountDue: 1500, ProrationAmount: 500}, nil
+}
+
+func (f fakeGateway) CreateUsageRecord(itemID string, quantity int64, timestamp int64, idempotencyKey string) error {
+	if timestamp < f.usageRejectedBefore {
+		return gateway.ErrUsageRejected
+	}
+	if f.usage != nil {
+		f.usage[idempotencyKey] = quantity
+	}
+	return nil
+}
+
+func (f fakeGateway) GetProduct(id string) (stripe.Product, error) {
+	if f.prods == nil {
+		return stripe.Product{ID: id}, nil
+	}
+	return f.prods[id], nil
+}
+
 // setupTestDB sets up the test database and returns the DB instance and a cleanup function
 func setupTestDB(t *testing.T) (*sql.DB, func()) {
 	// Prevent tests from running against production database
@@ -170,3 +283,240 @@ func Test_HandleCheckoutSessionCompleted_ExistingBoard_NonCanceledPrevSub(t *tes
 	assert.NoError(t, err)
 	assert.Equal(t, config.AppConfig.InitialFreeCredit, credit)
 }
+
+func Test_HandleCheckoutSessionCompleted_DuplicatePolicies(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+	prev := config.AppConfig.DuplicateSubscriptionPolicy
+	defer func() { config.AppConfig.DuplicateSubscriptionPolicy = prev }()
+
+	session := stripe.CheckoutSession{ClientReferenceID: checkoutBoardID, Customer: &stripe.Customer{ID: "cust-new"}, Subscription: &stripe.Subscription{ID: "sub-new"}}
+	raw, _ := json.Marshal(session)
+	evt := stripe.Event{Type: "checkout.session.completed", Data: &stripe.EventData{Raw: raw}}
+	resolution := func() string {
+		var r sql.NullString
+		err := db.QueryRow("SELECT resolution FROM invalid_subscription WHERE stripe_subscription_id = 'sub-new'").Scan(&r)
+		assert.NoError(t, err)
+		return r.String
+	}
+
+	t.Run("cancel_new_refund", func(t *testing.T) {
+		_, _ = db.Exec("DELETE FROM invalid_subscription WHERE stripe_subscription_id = 'sub-new'")
+		if err := stripedb.UpsertUserAccount(checkoutBoardID, "old-sub", "plan-old", "cust-old"); err != nil {
+			t.Fatalf("failed to insert existing board: %v", err)
+		}
+		config.AppConfig.DuplicateSubscriptionPolicy = DuplicatePolicyCancelNewRefund
+		var cancelled, refunds []string
+		svc := NewService(fakeGateway{
+			subs:      map[string]stripe.Subscription{"old-sub": {Status: stripe.SubscriptionStatusActive}, "sub-new": {Status: stripe.SubscriptionStatusActive}},
+			cancelled: &cancelled,
+			refunds:   &refunds,
+		})
+
+		assert.NoError(t, svc.HandleCheckoutSessionCompleted(evt))
+		// a webhook retry does not handle the duplicate again
+		assert.NoError(t, svc.HandleCheckoutSessionCompleted(evt))
+
+		assert.Equal(t, []string{"sub-new"}, refunds)
+		assert.Equal(t, []string{"sub-new"}, cancelled)
+		account, err := stripedb.GetUserAccount(checkoutBoardID)
+		assert.NoError(t, err)
+		assert.Equal(t, "old-sub", account.StripeSubscriptionID)
+		assert.Equal(t, DuplicatePolicyCancelNewRefund, resolution())
+	})
+
+	t.Run("cancel_new_refund without a charge", func(t *testing.T) {
+		_, _ = db.Exec("DELETE FROM invalid_subscription WHERE stripe_subscription_id = 'sub-new'")
+		if err := stripedb.UpsertUserAccount(checkoutBoardID, "old-sub", "plan-old", "cust-old"); err != nil {
+			t.Fatalf("failed to insert existing board: %v", err)
+		}
+		config.AppConfig.DuplicateSubscriptionPolicy = DuplicatePolicyCancelNewRefund
+		var cancelled, refunds []string
+		svc := NewService(fakeGateway{
+			subs:      map[string]stripe.Subscription{"old-sub": {Status: stripe.SubscriptionStatusActive}, "sub-new": {Status: stripe.SubscriptionStatusActive}},
+			cancelled: &cancelled,
+			refunds:   &refunds,
+			uncharged: map[string]bool{"sub-new": true},
+		})
+
+		assert.NoError(t, svc.HandleCheckoutSessionCompleted(evt))
+
+		// nothing is cancelled and the entry waits for an admin
+		assert.Empty(t, refunds)
+		assert.Empty(t, cancelled)
+		assert.Equal(t, "", resolution())
+
+		var id int64
+		assert.NoError(t, db.QueryRow("SELECT id FROM invalid_subscription WHERE stripe_subscription_id = 'sub-new'").Scan(&id))
+		_, err := svc.ResolveInvalidSubscription(id, DuplicatePolicyCancelNewRefund, "support")
+		assert.ErrorIs(t, err, ErrNotAllowed)
+		assert.ErrorIs(t, err, gateway.ErrNothingToRefund)
+		assert.Empty(t, cancelled)
+		assert.Equal(t, "", resolution())
+	})
+
+	t.Run("cancel_old", func(t *testing.T) {
+		_, _ = db.Exec("DELETE FROM invalid_subscription WHERE stripe_subscription_id = 'sub-new'")
+		if err := stripedb.UpsertUserAccount(checkoutBoardID, "old-sub", "plan-old", "cust-old"); err != nil {
+			t.Fatalf("failed to insert existing board: %v", err)
+		}
+		config.AppConfig.DuplicateSubscriptionPolicy = DuplicatePolicyCancelOld
+		var cancelled, refunds []string
+		svc := NewService(fakeGateway{
+			subs:      map[string]stripe.Subscription{"old-sub": {Status: stripe.SubscriptionStatusActive}, "sub-new": {Status: stripe.SubscriptionStatusActive}},
+			cancelled: &cancelled,
+			refunds:   &refunds,
+		})
+
+		assert.NoError(t, svc.HandleCheckoutSessionCompleted(evt))
+		assert.NoError(t, svc.HandleCheckoutSessionCompleted(evt))
+
+		assert.Empty(t, refunds)
+		assert.Equal(t, []string{"old-sub"}, cancelled)
+		account, err := stripedb.GetUserAccount(checkoutBoardID)
+		assert.NoError(t, err)
+		assert.Equal(t, "sub-new", account.StripeSubscriptionID)
+		assert.Equal(t, DuplicatePolicyCancelOld, resolution())
+	})
+
+	t.Run("cancel_old retried after the swap was saved", func(t *testing.T) {
+		_, _ = db.Exec("DELETE FROM invalid_subscription WHERE stripe_subscription_id = 'sub-new'")
+		// the account already moved to sub-new, but the entry was left pending
+		if err := stripedb.UpsertUserAccount(checkoutBoardID, "sub-new", "no_need", "cust-new"); err != nil {
+			t.Fatalf("failed to insert existing board: %v", err)
+		}
+		if _, err := stripedb.RecordInvalidSubscription(stripedb.InvalidSubscription{UserExternalID: checkoutBoardID, StripeSubscriptionID: "sub-new", StripePlanID: "no_need", StripeCustomerID: "cust-new"}); err != nil {
+			t.Fatalf("failed to record pending duplicate: %v", err)
+		}
+		config.AppConfig.DuplicateSubscriptionPolicy = DuplicatePolicyCancelOld
+		svc := NewService(fakeGateway{subs: map[string]stripe.Subscription{"sub-new": {Status: stripe.SubscriptionStatusActive}}})
+
+		assert.NoError(t, svc.HandleCheckoutSessionCompleted(evt))
+		assert.Equal(t, DuplicatePolicyCancelOld, resolution())
+	})
+}
+
+func Test_ResolveInvalidSubscription(t *testing.T) {
+	_, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	if err := stripedb.UpsertUserAccount(checkoutBoardID, "old-sub", "plan-old", "cust-old"); err != nil {
+		t.Fatalf("failed to insert existing board: %v", err)
+	}
+	id, err := stripedb.RecordInvalidSubscription(stripedb.InvalidSubscription{UserExternalID: checkoutBoardID, StripeSubscriptionID: "sub-dup", StripeCustomerID: "cust-old"})
+	if err != nil {
+		t.Fatalf("failed to record invalid subscription: %v", err)
+	}
+	var cancelled []string
+	svc := NewService(fakeGateway{
+		subs:      map[string]stripe.Subscription{"old-sub": {Status: stripe.SubscriptionStatusActive}},
+		cancelled: &cancelled,
+	})
+
+	_, err = svc.ResolveInvalidSubscription(id, "refund_everything", "support")
+	assert.ErrorIs(t, err, ErrNotAllowed)
+
+	pending, err := svc.ListInvalidSubscriptions(id-1, 10, false)
+	assert.NoError(t, err)
+	if assert.Len(t, pending, 1) {
+		assert.Equal(t, "sub-dup", pending[0].StripeSubscriptionID)
+	}
+
+	_, err = svc.ResolveInvalidSubscription(id, "refund_everything", "support")
+	assert.Error(t, err)
+
+	entry, err := svc.ResolveInvalidSubscription(id, DuplicatePolicyCancelOld, "support")
+	assert.NoError(t, err)
+	assert.Equal(t, DuplicatePolicyCancelOld, entry.Resolution)
+	assert.Equal(t, "support", entry.ResolvedBy)
+	assert.Equal(t, []string{"old-sub"}, cancelled)
+	account, err := stripedb.GetUserAccount(checkoutBoardID)
+	assert.NoError(t, err)
+	assert.Equal(t, "sub-dup", account.StripeSubscriptionID)
+
+	_, err = svc.ResolveInvalidSubscription(id, DuplicateResolutionDismiss, "support")
+	assert.ErrorIs(t, err, ErrNotAllowed)
+
+	pending, err = svc.ListInvalidSubscriptions(id-1, 10, false)
+	assert.NoError(t, err)
+	assert.Empty(t, pending)
+	all, err := svc.ListInvalidSubscriptions(id-1, 10, true)
+	assert.NoError(t, err)
+	assert.Len(t, all, 1)
+}
+
+func Test_HandleCheckoutSessionCompleted_CreditPackPayment(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	svc := NewService(fakeGateway{})
+	session := stripe.CheckoutSession{
+		ID:                "cs_pack_1",
+		Mode:              stripe.CheckoutSessionModePayment,
+		ClientReferenceID: checkoutBoardID,
+		Customer:          &stripe.Customer{ID: "cust-pack"},
+		Metadata:          map[string]string{SessionMetadataCreditPackID: "starter", SessionMetadataCreditUnits: "50"},
+	}
+	raw, _ := json.Marshal(session)
+	// payment_status/amount_total are not modelled by the pinned SDK; add them to the raw payload
+	var payload map[string]interface{}
+	_ = json.Unmarshal(raw, &payload)
+	payload["payment_status"] = "paid"
+	payload["amount_total"] = 500
+	payload["currency"] = "usd"
+	raw, _ = json.Marshal(payload)
+	evt := stripe.Event{Type: "checkout.session.completed", Data: &stripe.EventData{Raw: raw}}
+
+	// Webhook retries must not credit twice.
+	assert.NoError(t, svc.HandleCheckoutSessionCompleted(evt))
+	assert.NoError(t, svc.HandleCheckoutSessionCompleted(evt))
+
+	credit, err := stripedb.GetPurchasedCredit(checkoutBoardID)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(50), credit)
+
+	account, err := stripedb.GetUserAccount(checkoutBoardID)
+	assert.NoError(t, err)
+	assert.Equal(t, "", account.StripeSubscriptionID)
+	assert.Equal(t, "cust-pack", account.StripeCustomerID)
+
+	// With free credit exhausted, purchased credit keeps the user valid.
+	if _, err := db.Exec("UPDATE free_credit SET credit = 0 WHERE user_external_id = $1", stripedb.HashExternalID(checkoutBoardID)); err != nil {
+		t.Fatalf("failed to reset free_credit: %v", err)
+	}
+	resp, err := svc.VerifySubscription(checkoutBoardID)
+	assert.NoError(t, err)
+	assert.True(t, resp.IsValidSubscription)
+	assert.Equal(t, ValidityTypePrepaidCredit, resp.ValidityType)
+
+	if _, err := stripedb.AddSpendingUnits([]stripedb.SpendingUnit{
+		{ExternalID: "pack-unit-1", UserExternalID: checkoutBoardID, Amount: 30, CreatedAt: time.Now().UnixMilli()},
+	}); err != nil {
+		t.Fatalf("AddSpendingUnits failed: %v", err)
+	}
+	credit, err = stripedb.GetPurchasedCredit(checkoutBoardID)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(20), credit)
+}
+
+func Test_HandleCheckoutSessionCompleted_CreditPackWithoutPaymentStatus(t *testing.T) {
+	_, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	svc := NewService(fakeGateway{})
+	session := stripe.CheckoutSession{
+		ID:                "cs_pack_unpaid",
+		Mode:              stripe.CheckoutSessionModePayment,
+		ClientReferenceID: checkoutBoardID,
+		Customer:          &stripe.Customer{ID: "cust-pack"},
+		Metadata:          map[string]string{SessionMetadataCreditPackID: "starter", SessionMetadataCreditUnits: "50"},
+	}
+	// No payment_status: the session can't be shown to be paid, so nothing is credited.
+	raw, _ := json.Marshal(session)
+	evt := stripe.Event{Type: "checkout.session.completed", Data: &stripe.EventData{Raw: raw}}
+	assert.NoError(t, svc.HandleCheckoutSessionCompleted(evt))
+
+	credit, err := stripedb.GetPurchasedCredit(checkoutBoardID)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), credit)
+}
@@ -0,0 +1,142 @@
+package app
+
+import (
+	"sync"
+	"time"
+
+	"github.com/stripe/stripe-go"
+	gw "github.com/tbeaudouin05/stripe-trellai/api/services/stripe/gateway"
+)
+
+// subscriptionCacheTTL is how long readers that don't need Stripe's latest word (entitlement
+// watchers, spending caps, allowance alerts) reuse a fetched subscription or customer. A cached
+// subscription also expires when its period, trial or pause ends.
+const subscriptionCacheTTL = 2 * time.Minute
+
+// stripeCache holds the subscriptions and customers fetched for those readers, shared by every
+// caller of the service.
+type stripeCache struct {
+	subs  ttlCache[stripe.Subscription]
+	custs ttlCache[stripe.Customer]
+}
+
+func newStripeCache() *stripeCache {
+	return &stripeCache{
+		subs:  ttlCache[stripe.Subscription]{ttl: subscriptionCacheTTL},
+		custs: ttlCache[stripe.Customer]{ttl: subscriptionCacheTTL},
+	}
+}
+
+// ttlCache keeps fetched values for ttl. Callers missing the same key at the same time share one
+// fetch; failed fetches are not kept.
+type ttlCache[T any] struct {
+	ttl     time.Duration
+	mu      sync.Mutex
+	entries map[string]*ttlEntry[T]
+}
+
+type ttlEntry[T any] struct {
+	done      chan struct{} // closed once fetched
+	val       T
+	err       error
+	fetchedAt time.Time
+	expires   time.Time
+}
+
+// get returns the value of key, fetching it unless it was fetched at or after notBefore and has
+// not expired. until, if set, can expire a value before ttl.
+func (c *ttlCache[T]) get(key string, notBefore time.Time, fetch func(string) (T, error), until func(T) time.Time) (T, error) {
+	now := time.Now()
+	c.mu.Lock()
+	e, ok := c.entries[key]
+	if ok && e.fetchedAt.Before(notBefore) {
+		ok = false
+	} else if ok {
+		select {
+		case <-e.done:
+			ok = e.err == nil && now.Before(e.expires)
+		default:
+		}
+	}
+	if ok {
+		c.mu.Unlock()
+		<-e.done
+		return e.val, e.err
+	}
+	if c.entries == nil {
+		c.entries = make(map[string]*ttlEntry[T])
+	}
+	c.sweep(now)
+	e = &ttlEntry[T]{done: make(chan struct{}), fetchedAt: now}
+	c.entries[key] = e
+	c.mu.Unlock()
+
+	e.val, e.err = fetch(key)
+	e.expires = now.Add(c.ttl)
+	if until != nil && e.err == nil {
+		if u := until(e.val); !u.IsZero() && u.Before(e.expires) {
+			e.expires = u
+		}
+	}
+	close(e.done)
+	return e.val, e.err
+}
+
+// sweep drops the fetched entries that expired or failed; c.mu must be held.
+func (c *ttlCache[T]) sweep(now time.Time) {
+	for key, e := range c.entries {
+		select {
+		case <-e.done:
+			if e.err != nil || !now.Before(e.expires) {
+				delete(c.entries, key)
+			}
+		default:
+		}
+	}
+}
+
+// subscriptionValidUntil is when what a subscription grants next changes on its own: the end of
+// its period, trial or pause, or its scheduled cancellation, whichever comes first.
+func subscriptionValidUntil(sub stripe.Subscription) time.Time {
+	now := time.Now().Unix()
+	var until int64
+	for _, at := range []int64{sub.CurrentPeriodEnd, sub.TrialEnd, sub.CancelAt, sub.PauseCollection.ResumesAt} {
+		if at > now && (until == 0 || at < until) {
+			until = at
+		}
+	}
+	if until == 0 {
+		return time.Time{}
+	}
+	return time.Unix(until, 0)
+}
+
+// cachedGateway reads subscriptions and customers through the service's cache, fetching again
+// those fetched before notBefore. Every other call goes to Stripe.
+type cachedGateway struct {
+	gw.StripeGateway
+	cache     *stripeCache
+	notBefore time.Time
+}
+
+func (g cachedGateway) GetSubscription(id string) (stripe.Subscription, error) {
+	return g.cache.subs.get(id, g.notBefore, g.StripeGateway.GetSubscription, subscriptionValidUntil)
+}
+
+func (g cachedGateway) GetCustomer(id string) (stripe.Customer, error) {
+	return g.cache.custs.get(id, g.notBefore, g.StripeGateway.GetCustomer, nil)
+}
+
+// cachedStripe returns the service reading subscriptions and customers from the cache, refreshed
+// if fetched before notBefore (the zero time accepts any cached value). Use it where a
+// subscription change may be seen up to subscriptionCacheTTL late.
+func (s serviceImpl) cachedStripe(notBefore time.Time) serviceImpl {
+	if s.cache == nil {
+		return s
+	}
+	if c, ok := s.gw.(cachedGateway); ok {
+		s.gw = c.StripeGateway
+	}
+	s.gw = cachedGateway{StripeGateway: s.gw, cache: s.cache, notBefore: notBefore}
+	return s
+}
@@ -0,0 +1,71 @@
+package app
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	stripe "github.com/stripe/stripe-go"
+)
+
+func Test_TTLCache_SharesFetches(t *testing.T) {
+	c := ttlCache[int]{ttl: time.Minute}
+	var fetches atomic.Int32
+	release := make(chan struct{})
+	fetch := func(string) (int, error) {
+		<-release
+		return int(fetches.Add(1)), nil
+	}
+
+	// callers missing the same key together share one fetch
+	var wg sync.WaitGroup
+	got := make([]int, 5)
+	for i := range got {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			got[i], _ = c.get("k", time.Time{}, fetch, nil)
+		}(i)
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	assert.Equal(t, []int{1, 1, 1, 1, 1}, got)
+
+	// and later ones get the cached value, unless they want one fetched after a change
+	v, err := c.get("k", time.Time{}, fetch, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, v)
+	v, err = c.get("k", time.Now().Add(time.Millisecond), fetch, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, v)
+
+	// values expire after ttl, or earlier if until says so
+	v, _ = c.get("short", time.Time{}, fetch, func(int) time.Time { return time.Now().Add(-time.Second) })
+	assert.Equal(t, 3, v)
+	v, _ = c.get("short", time.Time{}, fetch, nil)
+	assert.Equal(t, 4, v)
+
+	// failures are not kept
+	failing := func(string) (int, error) { return 0, errors.New("stripe down") }
+	_, err = c.get("fail", time.Time{}, failing, nil)
+	assert.Error(t, err)
+	v, err = c.get("fail", time.Time{}, fetch, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, v)
+}
+
+func Test_SubscriptionValidUntil(t *testing.T) {
+	now := time.Now().Unix()
+	sub := stripe.Subscription{CurrentPeriodEnd: now + 3600, TrialEnd: now - 60}
+	// a trial that already ended doesn't expire the subscription
+	assert.Equal(t, time.Unix(now+3600, 0), subscriptionValidUntil(sub))
+
+	sub.PauseCollection.ResumesAt = now + 60
+	assert.Equal(t, time.Unix(now+60, 0), subscriptionValidUntil(sub))
+
+	assert.True(t, subscriptionValidUntil(stripe.Subscription{}).IsZero())
+}
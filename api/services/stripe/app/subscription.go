@@ -2,59 +2,141 @@ package app
 
 import (
 	"fmt"
-	"strconv"
-	"strings"
+	"time"
 
 	"github.com/stripe/stripe-go"
-	"github.com/tbeaudouin05/stripe-trellai/api/config"
 	stripedb "github.com/tbeaudouin05/stripe-trellai/api/services/stripe/db"
 )
 
-// VerifySubscription checks if a subscription is valid for a given user external id
+// VerifySubscription checks if a subscription is valid for a given user external id.
+// Organization members are checked against their organization's subscription and pooled credit,
+// and need a seat and to be under their spending cap. Users with a failed invoice payment are flagged as in dunning whatever their validity.
 func (s serviceImpl) VerifySubscription(userExternalID string) (VerifySubscriptionResponse, error) {
+	resp, _, _, err := s.verifyUser(userExternalID)
+	return resp, err
+}
+
+// verifyUser is VerifySubscription, also returning the account checked and the subscription
+// fetched from Stripe to check it (nil when the check didn't need it), so callers don't fetch
+// it again.
+func (s serviceImpl) verifyUser(userExternalID string) (VerifySubscriptionResponse, stripedb.BilledAccount, *stripe.Subscription, error) {
+	billed, err := resolveAccount(userExternalID)
+	if err != nil {
+		return VerifySubscriptionResponse{}, stripedb.BilledAccount{}, nil, err
+	}
+	if !billed.Seated {
+		return VerifySubscriptionResponse{IsValidSubscription: false, InvalidityType: InvalidityTypeNoSeat}, billed, nil, nil
+	}
+	resp, sub, err := s.verifySubscription(billed.Account)
+	if err != nil {
+		return VerifySubscriptionResponse{}, stripedb.BilledAccount{}, nil, err
+	}
+	billing, err := stripedb.GetBillingStatus(billed.Account)
+	if err != nil {
+		return VerifySubscriptionResponse{}, stripedb.BilledAccount{}, nil, fmt.Errorf("%w: error retrieving billing status: %v", ErrDatabase, err)
+	}
+	resp, err = s.memberValidity(billed, userExternalID, resp, billing, sub)
+	if err != nil {
+		return VerifySubscriptionResponse{}, stripedb.BilledAccount{}, nil, err
+	}
+	return resp, billed, sub, nil
+}
+
+// memberValidity applies what depends on the user rather than on their account's subscription:
+// a member's spending cap, then the account's dunning state. sub is the account's subscription if
+// the caller already fetched it.
+func (s serviceImpl) memberValidity(billed stripedb.BilledAccount, userExternalID string, resp VerifySubscriptionResponse, billing stripedb.BillingStatus, sub *stripe.Subscription) (VerifySubscriptionResponse, error) {
+	if resp.IsValidSubscription && billed.Member {
+		reached, err := s.memberCapReached(billed.Account, userExternalID, sub)
+		if err != nil {
+			return VerifySubscriptionResponse{}, err
+		}
+		if reached {
+			resp = VerifySubscriptionResponse{IsValidSubscription: false, InvalidityType: InvalidityTypeCapReached, StripeCustomerEmail: resp.StripeCustomerEmail}
+		}
+	}
+	if billing.InDunning {
+		resp.InDunning = true
+		resp.PaymentAttemptCount = billing.AttemptCount
+		resp.NextPaymentAttempt = billing.NextPaymentAttempt
+	}
+	return resp, nil
+}
+
+// verifySubscription checks the account's credit, then its subscription. The subscription is
+// returned when it was fetched from Stripe.
+func (s serviceImpl) verifySubscription(userExternalID string) (VerifySubscriptionResponse, *stripe.Subscription, error) {
 	// if there is enough free credit, then it is valid
 	credit, err := stripedb.GetFreeCredit(userExternalID)
 	if err != nil {
-		return VerifySubscriptionResponse{}, fmt.Errorf("%w: error retrieving free credit: %v", ErrDatabase, err)
+		return VerifySubscriptionResponse{}, nil, fmt.Errorf("%w: error retrieving free credit: %v", ErrDatabase, err)
 	}
 	if credit > 0 {
-		return VerifySubscriptionResponse{IsValidSubscription: true, ValidityType: ValidityTypeFreeTier}, nil
+		return VerifySubscriptionResponse{IsValidSubscription: true, ValidityType: ValidityTypeFreeTier}, nil, nil
+	}
+
+	// then purchased credit packs, before touching the subscription allowance
+	purchased, err := stripedb.GetPurchasedCredit(userExternalID)
+	if err != nil {
+		return VerifySubscriptionResponse{}, nil, fmt.Errorf("%w: error retrieving purchased credit: %v", ErrDatabase, err)
+	}
+	if purchased > 0 {
+		return VerifySubscriptionResponse{IsValidSubscription: true, ValidityType: ValidityTypePrepaidCredit}, nil, nil
 	}
 
 	// if not enough free credit, fetch user account and customer ID
 	ua, err := stripedb.GetUserAccount(userExternalID)
 	if err != nil {
-		return VerifySubscriptionResponse{}, fmt.Errorf("%w: error retrieving user account: %v", ErrDatabase, err)
+		return VerifySubscriptionResponse{}, nil, fmt.Errorf("%w: error retrieving user account: %v", ErrDatabase, err)
 	}
 	if ua.UserExternalID == stripedb.AccountWithoutSubscriptionID {
-		return VerifySubscriptionResponse{IsValidSubscription: false, InvalidityType: InvalidityTypeNoSubscription}, nil
+		return VerifySubscriptionResponse{IsValidSubscription: false, InvalidityType: InvalidityTypeNoSubscription}, nil, nil
 	}
 	if ua.StripeSubscriptionID == "" {
-		return VerifySubscriptionResponse{IsValidSubscription: false, InvalidityType: InvalidityTypeNoSubscription}, nil
+		return VerifySubscriptionResponse{IsValidSubscription: false, InvalidityType: InvalidityTypeNoSubscription}, nil, nil
 	}
 
 	// fetch subscription
 	subRetrieved, err := s.gw.GetSubscription(ua.StripeSubscriptionID)
 	if err != nil {
-		return VerifySubscriptionResponse{}, fmt.Errorf("%w: error getting subscription: %v", ErrGateway, err)
+		return VerifySubscriptionResponse{}, nil, fmt.Errorf("%w: error getting subscription: %v", ErrGateway, err)
 	}
 
 	// get customer email
 	cust, err := s.gw.GetCustomer(ua.StripeCustomerID)
 	if err != nil {
-		return VerifySubscriptionResponse{}, fmt.Errorf("%w: error retrieving customer email: %v", ErrGateway, err)
+		return VerifySubscriptionResponse{}, nil, fmt.Errorf("%w: error retrieving customer email: %v", ErrGateway, err)
+	}
+	resp, err := s.evaluateSubscription(userExternalID, subRetrieved, cust.Email)
+	if err != nil {
+		return VerifySubscriptionResponse{}, nil, err
 	}
-	email := cust.Email
+	return resp, &subRetrieved, nil
+}
 
+// evaluateSubscription checks the account's subscription, as retrieved from Stripe, against its
+// status and its allowance for the current period.
+func (s serviceImpl) evaluateSubscription(userExternalID string, subRetrieved stripe.Subscription, email string) (VerifySubscriptionResponse, error) {
 	// if subscription is cancelled, then it is not valid
 	if IsSubscriptionCancelled(subRetrieved) {
 		return VerifySubscriptionResponse{IsValidSubscription: false, InvalidityType: InvalidityTypeCancelled, StripeCustomerEmail: email}, nil
 	}
 
+	// a paused subscription isn't billed, so it doesn't grant its allowance either
+	if IsSubscriptionPaused(subRetrieved) {
+		return VerifySubscriptionResponse{IsValidSubscription: false, InvalidityType: InvalidityTypePaused, StripeCustomerEmail: email, ResumesAt: subRetrieved.PauseCollection.ResumesAt * 1000}, nil
+	}
+
 	// if subscription is not valid, then it is not valid :)
-	if subRetrieved.Status != stripe.SubscriptionStatusActive {
+	trialing := subRetrieved.Status == stripe.SubscriptionStatusTrialing
+	graceUntil, inGrace := graceDeadline(subRetrieved, time.Now())
+	if subRetrieved.Status != stripe.SubscriptionStatusActive && !trialing && !inGrace {
 		return VerifySubscriptionResponse{IsValidSubscription: false, InvalidityType: InvalidityTypeOther, StripeCustomerEmail: email}, nil
 	}
+	var trialEnd int64
+	if trialing {
+		trialEnd = subRetrieved.TrialEnd * 1000
+	}
 
 	// if subscription is exhausted (not enough units remaining), then it is not valid
 	// Stripe provides seconds; our DB stores milliseconds, so convert bounds to ms.
@@ -66,35 +148,52 @@ func (s serviceImpl) VerifySubscription(userExternalID string) (VerifySubscripti
 	if err != nil {
 		return VerifySubscriptionResponse{}, fmt.Errorf("%w: error counting units: %v", ErrDatabase, err)
 	}
-	if subRetrieved.Plan == nil {
-		return VerifySubscriptionResponse{}, fmt.Errorf("plan not found for subscription")
-	}
-	if subRetrieved.Plan.Amount == 0 {
-		return VerifySubscriptionResponse{}, fmt.Errorf("plan amount is 0 for subscription")
+	allowance, err := s.subscriptionAllowance(subRetrieved)
+	if err != nil {
+		return VerifySubscriptionResponse{}, err
 	}
-	if subRetrieved.Quantity == 0 {
-		return VerifySubscriptionResponse{}, fmt.Errorf("quantity is 0 for subscription")
+	exhausted, err := exhaustedFeatures(userExternalID, allowance.FeatureLimits, subRetrieved.CurrentPeriodStart*1000, subRetrieved.CurrentPeriodEnd*1000)
+	if err != nil {
+		return VerifySubscriptionResponse{}, err
+	}
+	if !allowance.Unlimited && int64(count) > allowance.Units {
+		if allowance.OverageUnitAmount == "" {
+			return VerifySubscriptionResponse{IsValidSubscription: false, InvalidityType: InvalidityTypeExhausted, StripeCustomerEmail: email, TrialEnd: trialEnd, GraceDeadline: graceUntil, ExhaustedFeatures: exhausted}, nil
+		}
+		// overage-enabled plan: stay valid, the excess is invoiced once the period ends
+		return VerifySubscriptionResponse{
+			IsValidSubscription: true,
+			ValidityType:        ValidityTypeOverage,
+			StripeCustomerEmail: email,
+			TrialEnd:            trialEnd,
+			GraceDeadline:       graceUntil,
+			ExhaustedFeatures:   exhausted,
+		}, nil
 	}
 
-	// parse CREDIT_UNITS_PER_DOLLAR from config (underscores allowed, e.g., 2_000_000)
-	if config.AppConfig == nil {
-		return VerifySubscriptionResponse{}, fmt.Errorf("app config not initialized")
+	if inGrace {
+		return VerifySubscriptionResponse{
+			IsValidSubscription: true,
+			ValidityType:        ValidityTypeGracePeriod,
+			StripeCustomerEmail: email,
+			GraceDeadline:       graceUntil,
+			ExhaustedFeatures:   exhausted,
+		}, nil
+	}
+	if trialing {
+		return VerifySubscriptionResponse{
+			IsValidSubscription: true,
+			ValidityType:        ValidityTypeTrial,
+			StripeCustomerEmail: email,
+			TrialEnd:            trialEnd,
+			ExhaustedFeatures:   exhausted,
+		}, nil
 	}
-	raw := strings.ReplaceAll(config.AppConfig.CreditUnitsPerDollar, "_", "")
-	unitsPerDollar, err := strconv.ParseInt(raw, 10, 64)
-	if err != nil || unitsPerDollar <= 0 {
-		return VerifySubscriptionResponse{}, fmt.Errorf("invalid CREDIT_UNITS_PER_DOLLAR: %q", config.AppConfig.CreditUnitsPerDollar)
-	}
-	// Stripe Amount is in cents; convert to dollars before multiplying
-	dollars := subRetrieved.Plan.Amount / 100
-	if int64(count) > dollars*subRetrieved.Quantity*unitsPerDollar {
-		return VerifySubscriptionResponse{IsValidSubscription: false, InvalidityType: InvalidityTypeExhausted, StripeCustomerEmail: email}, nil
-	}
-
 	return VerifySubscriptionResponse{
 		IsValidSubscription: true,
 		ValidityType:        ValidityTypePayingCustomer,
 		StripeCustomerEmail: email,
+		ExhaustedFeatures:   exhausted,
 	}, nil
 }
 
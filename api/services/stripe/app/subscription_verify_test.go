@@ -1,9 +1,11 @@
 package app
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -11,6 +13,7 @@ import (
 	stripe "github.com/stripe/stripe-go"
 	config "github.com/tbeaudouin05/stripe-trellai/api/config"
 	database "github.com/tbeaudouin05/stripe-trellai/api/database"
+	"github.com/tbeaudouin05/stripe-trellai/api/pubsub"
 	stripedb "github.com/tbeaudouin05/stripe-trellai/api/services/stripe/db"
 )
 
@@ -213,3 +216,362 @@ func Test_VerifySubscription_Cancelled(t *testing.T) {
 	assert.Equal(t, InvalidityTypeCancelled, resp.InvalidityType)
 	assert.Equal(t, "cancelled@example.com", resp.StripeCustomerEmail)
 }
+
+func Test_VerifySubscription_ExhaustedWithProductMetadataAllowance(t *testing.T) {
+	db, cleanup := setupSubTestDB(t)
+	defer cleanup()
+	const planID = "plan_sub_test_meta"
+	_, _ = db.Exec("DELETE FROM plan_allowance WHERE stripe_plan_id = $1", planID)
+	defer db.Exec("DELETE FROM plan_allowance WHERE stripe_plan_id = $1", planID)
+	if err := stripedb.UpsertUserAccount(subBoardID, "sub_123", "plan_123", "cust_123"); err != nil {
+		t.Fatalf("UpsertUserAccount failed: %v", err)
+	}
+	if _, err := db.Exec("INSERT INTO free_credit (user_external_id, credit) VALUES ($1, 0) ON CONFLICT (user_external_id) DO UPDATE SET credit = 0", stripedb.HashExternalID(subBoardID)); err != nil {
+		t.Fatalf("Failed to upsert free_credit: %v", err)
+	}
+	now := time.Now().Unix()
+	if _, err := db.Exec("INSERT INTO spending_unit (user_external_id, external_id, amount, created_at) VALUES ($1, $2, 5, $3)", stripedb.HashExternalID(subBoardID), "sub-meta-card", now*1000); err != nil {
+		t.Fatalf("Failed to insert spending_unit: %v", err)
+	}
+
+	// Plan amount alone would grant plenty of units; product metadata caps it at 2 per period.
+	config.AppConfig.CreditUnitsPerDollar = "1_000"
+	gw := fakeGateway{
+		subs: map[string]stripe.Subscription{
+			"sub_123": {Status: stripe.SubscriptionStatusActive, Quantity: 2, Plan: &stripe.Plan{ID: planID, Amount: 10000, Product: &stripe.Product{ID: "prod_meta"}}, CurrentPeriodStart: now - 60, CurrentPeriodEnd: now + 86400},
+		},
+		custs: map[string]stripe.Customer{"cust_123": {Email: "meta@example.com"}},
+		prods: map[string]stripe.Product{"prod_meta": {ID: "prod_meta", Metadata: map[string]string{PlanMetadataUnitsPerPeriod: "2"}}},
+	}
+	resp, err := NewService(gw).VerifySubscription(subBoardID)
+	assert.NoError(t, err)
+	assert.False(t, resp.IsValidSubscription)
+	assert.Equal(t, InvalidityTypeExhausted, resp.InvalidityType)
+
+	cached, found, err := stripedb.GetPlanAllowance(planID)
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, int64(2), cached.UnitsPerPeriod)
+}
+
+func Test_VerifySubscription_MultiItemTieredAndMetered(t *testing.T) {
+	db, cleanup := setupSubTestDB(t)
+	defer cleanup()
+	planIDs := []string{"plan_sub_test_tiered", "plan_sub_test_metered"}
+	for _, id := range planIDs {
+		_, _ = db.Exec("DELETE FROM plan_allowance WHERE stripe_plan_id = $1", id)
+		defer db.Exec("DELETE FROM plan_allowance WHERE stripe_plan_id = $1", id)
+	}
+	if err := stripedb.UpsertUserAccount(subBoardID, "sub_123", "plan_123", "cust_123"); err != nil {
+		t.Fatalf("UpsertUserAccount failed: %v", err)
+	}
+	if _, err := db.Exec("INSERT INTO free_credit (user_external_id, credit) VALUES ($1, 0) ON CONFLICT (user_external_id) DO UPDATE SET credit = 0", stripedb.HashExternalID(subBoardID)); err != nil {
+		t.Fatalf("Failed to upsert free_credit: %v", err)
+	}
+	now := time.Now().Unix()
+	if _, err := db.Exec("INSERT INTO spending_unit (user_external_id, external_id, amount, created_at) VALUES ($1, $2, 25, $3)", stripedb.HashExternalID(subBoardID), "sub-tiered-card", now*1000); err != nil {
+		t.Fatalf("Failed to insert spending_unit: %v", err)
+	}
+	config.AppConfig.CreditUnitsPerDollar = "1"
+
+	// Graduated tiers: first 2 seats at $10, the rest at $5 => 3 seats = $25 => 25 units.
+	tiered := &stripe.Plan{
+		ID:            planIDs[0],
+		BillingScheme: stripe.PlanBillingSchemeTiered,
+		TiersMode:     string(stripe.PlanTiersModeGraduated),
+		Tiers:         []*stripe.PlanTier{{UpTo: 2, UnitAmount: 1000}, {UnitAmount: 500}},
+	}
+	items := &stripe.SubscriptionItemList{Data: []*stripe.SubscriptionItem{{ID: "si_tiered", Plan: tiered, Quantity: 3}}}
+	gw := fakeGateway{
+		subs: map[string]stripe.Subscription{
+			"sub_123": {Status: stripe.SubscriptionStatusActive, Items: items, CurrentPeriodStart: now - 60, CurrentPeriodEnd: now + 86400},
+		},
+		custs: map[string]stripe.Customer{"cust_123": {Email: "tiered@example.com"}},
+	}
+	resp, err := NewService(gw).VerifySubscription(subBoardID)
+	assert.NoError(t, err)
+	assert.True(t, resp.IsValidSubscription, "25 units used of a 25 unit tiered allowance")
+
+	// Consuming one more unit exhausts the licensed allowance...
+	if _, err := db.Exec("INSERT INTO spending_unit (user_external_id, external_id, amount, created_at) VALUES ($1, $2, 1, $3)", stripedb.HashExternalID(subBoardID), "sub-tiered-card-2", now*1000); err != nil {
+		t.Fatalf("Failed to insert spending_unit: %v", err)
+	}
+	resp, err = NewService(gw).VerifySubscription(subBoardID)
+	assert.NoError(t, err)
+	assert.Equal(t, InvalidityTypeExhausted, resp.InvalidityType)
+
+	// ...unless a metered item bills the extra usage in arrears.
+	metered := &stripe.Plan{ID: planIDs[1], UsageType: stripe.PlanUsageTypeMetered, Amount: 1}
+	items.Data = append(items.Data, &stripe.SubscriptionItem{ID: "si_metered", Plan: metered})
+	resp, err = NewService(gw).VerifySubscription(subBoardID)
+	assert.NoError(t, err)
+	assert.True(t, resp.IsValidSubscription)
+}
+
+func Test_VerifySubscription_TrialingUsesTrialAllowance(t *testing.T) {
+	db, cleanup := setupSubTestDB(t)
+	defer cleanup()
+	const planID = "plan_sub_test_trial"
+	_, _ = db.Exec("DELETE FROM plan_allowance WHERE stripe_plan_id = $1", planID)
+	defer db.Exec("DELETE FROM plan_allowance WHERE stripe_plan_id = $1", planID)
+	if err := stripedb.UpsertUserAccount(subBoardID, "sub_123", "plan_123", "cust_123"); err != nil {
+		t.Fatalf("UpsertUserAccount failed: %v", err)
+	}
+	if _, err := db.Exec("INSERT INTO free_credit (user_external_id, credit) VALUES ($1, 0) ON CONFLICT (user_external_id) DO UPDATE SET credit = 0", stripedb.HashExternalID(subBoardID)); err != nil {
+		t.Fatalf("Failed to upsert free_credit: %v", err)
+	}
+	now := time.Now().Unix()
+	if _, err := db.Exec("INSERT INTO spending_unit (user_external_id, external_id, amount, created_at) VALUES ($1, $2, 5, $3)", stripedb.HashExternalID(subBoardID), "sub-trial-card", now*1000); err != nil {
+		t.Fatalf("Failed to insert spending_unit: %v", err)
+	}
+
+	sub := stripe.Subscription{
+		Status:             stripe.SubscriptionStatusTrialing,
+		Quantity:           1,
+		Plan:               &stripe.Plan{ID: planID, Metadata: map[string]string{PlanMetadataUnitsPerPeriod: "1_000", PlanMetadataTrialUnitsPerPeriod: "10"}},
+		CurrentPeriodStart: now - 60,
+		CurrentPeriodEnd:   now + 86400,
+		TrialEnd:           now + 86400,
+	}
+	gw := fakeGateway{
+		subs:  map[string]stripe.Subscription{"sub_123": sub},
+		custs: map[string]stripe.Customer{"cust_123": {Email: "trial@example.com"}},
+	}
+	resp, err := NewService(gw).VerifySubscription(subBoardID)
+	assert.NoError(t, err)
+	assert.True(t, resp.IsValidSubscription)
+	assert.Equal(t, ValidityTypeTrial, resp.ValidityType)
+	assert.Equal(t, (now+86400)*1000, resp.TrialEnd)
+
+	// 11 units exceed the 10-unit trial allowance even though the plan grants 1000
+	if _, err := db.Exec("INSERT INTO spending_unit (user_external_id, external_id, amount, created_at) VALUES ($1, $2, 6, $3)", stripedb.HashExternalID(subBoardID), "sub-trial-card-2", now*1000); err != nil {
+		t.Fatalf("Failed to insert spending_unit: %v", err)
+	}
+	resp, err = NewService(gw).VerifySubscription(subBoardID)
+	assert.NoError(t, err)
+	assert.False(t, resp.IsValidSubscription)
+	assert.Equal(t, InvalidityTypeExhausted, resp.InvalidityType)
+}
+
+func Test_VerifySubscription_TrialingOnOveragePlan(t *testing.T) {
+	db, cleanup := setupSubTestDB(t)
+	defer cleanup()
+	const planID = "plan_sub_test_trial_overage"
+	_, _ = db.Exec("DELETE FROM plan_allowance WHERE stripe_plan_id = $1", planID)
+	defer db.Exec("DELETE FROM plan_allowance WHERE stripe_plan_id = $1", planID)
+	if err := stripedb.UpsertUserAccount(subBoardID, "sub_123", "plan_123", "cust_123"); err != nil {
+		t.Fatalf("UpsertUserAccount failed: %v", err)
+	}
+	if _, err := db.Exec("INSERT INTO free_credit (user_external_id, credit) VALUES ($1, 0) ON CONFLICT (user_external_id) DO UPDATE SET credit = 0", stripedb.HashExternalID(subBoardID)); err != nil {
+		t.Fatalf("Failed to upsert free_credit: %v", err)
+	}
+	now := time.Now().Unix()
+	if _, err := db.Exec("INSERT INTO spending_unit (user_external_id, external_id, amount, created_at) VALUES ($1, $2, 11, $3)", stripedb.HashExternalID(subBoardID), "sub-trial-overage-card", now*1000); err != nil {
+		t.Fatalf("Failed to insert spending_unit: %v", err)
+	}
+
+	sub := stripe.Subscription{
+		Status:   stripe.SubscriptionStatusTrialing,
+		Quantity: 1,
+		Plan: &stripe.Plan{ID: planID, Currency: stripe.CurrencyUSD, Metadata: map[string]string{
+			PlanMetadataUnitsPerPeriod:      "1_000",
+			PlanMetadataTrialUnitsPerPeriod: "10",
+			PlanMetadataOverageUnitAmount:   "0.5",
+		}},
+		CurrentPeriodStart: now - 60,
+		CurrentPeriodEnd:   now + 86400,
+		TrialEnd:           now + 86400,
+	}
+	gw := fakeGateway{
+		subs:  map[string]stripe.Subscription{"sub_123": sub},
+		custs: map[string]stripe.Customer{"cust_123": {Email: "trial@example.com"}},
+	}
+	// past the trial allowance of an overage-enabled plan: the trial end is reported whatever
+	// the outcome, and trials never bill overage
+	resp, err := NewService(gw).VerifySubscription(subBoardID)
+	assert.NoError(t, err)
+	assert.Equal(t, (now+86400)*1000, resp.TrialEnd)
+	assert.False(t, resp.IsValidSubscription)
+	assert.Equal(t, InvalidityTypeExhausted, resp.InvalidityType)
+}
+
+// countingGateway counts subscription lookups.
+type countingGateway struct {
+	fakeGateway
+	lookups *atomic.Int32
+}
+
+func (g countingGateway) GetSubscription(id string) (stripe.Subscription, error) {
+	g.lookups.Add(1)
+	return g.fakeGateway.GetSubscription(id)
+}
+
+func Test_BatchVerifySubscription_MatchesSingleVerification(t *testing.T) {
+	db, cleanup := setupSubTestDB(t)
+	defer cleanup()
+	if err := stripedb.UpsertUserAccount(subBoardID, "sub_123", "plan_123", "cust_123"); err != nil {
+		t.Fatalf("UpsertUserAccount failed: %v", err)
+	}
+	if err := stripedb.UpsertUserAccount(subNoneBoardID, "", "", ""); err != nil {
+		t.Fatalf("UpsertUserAccount (for subNoneBoardID) failed: %v", err)
+	}
+	for _, id := range []string{subBoardID, subNoneBoardID} {
+		if _, err := db.Exec("INSERT INTO free_credit (user_external_id, credit) VALUES ($1, 0) ON CONFLICT (user_external_id) DO UPDATE SET credit = 0", stripedb.HashExternalID(id)); err != nil {
+			t.Fatalf("Failed to upsert free_credit: %v", err)
+		}
+	}
+
+	now := time.Now().Unix()
+	var lookups atomic.Int32
+	gw := countingGateway{fakeGateway: fakeGateway{
+		subs: map[string]stripe.Subscription{
+			"sub_123": {CancelAt: 0, Quantity: 1, Status: stripe.SubscriptionStatusActive, Plan: &stripe.Plan{Amount: 1400}, CurrentPeriodStart: now - 86400, CurrentPeriodEnd: now + 86400},
+		},
+		custs: map[string]stripe.Customer{
+			"cust_123": {Email: "valid@example.com"},
+		},
+	}, lookups: &lookups}
+	svc := NewService(gw)
+
+	results, err := svc.BatchVerifySubscription([]string{subBoardID, subNoneBoardID, subBoardID})
+	assert.NoError(t, err)
+	assert.Len(t, results, 2)
+	assert.Equal(t, int32(1), lookups.Load(), "one Stripe lookup per distinct subscription")
+	for _, id := range []string{subBoardID, subNoneBoardID} {
+		single, err := svc.VerifySubscription(id)
+		assert.NoError(t, err)
+		assert.NoError(t, results[id].Err)
+		assert.Equal(t, single, results[id].Response, id)
+	}
+	assert.True(t, results[subBoardID].Response.IsValidSubscription)
+	assert.Equal(t, "valid@example.com", results[subBoardID].Response.StripeCustomerEmail)
+	assert.Equal(t, InvalidityTypeNoSubscription, results[subNoneBoardID].Response.InvalidityType)
+}
+
+func Test_GetEntitlements_FetchesSubscriptionOnce(t *testing.T) {
+	db, cleanup := setupSubTestDB(t)
+	defer cleanup()
+	if err := stripedb.UpsertUserAccount(subBoardID, "sub_123", "plan_123", "cust_123"); err != nil {
+		t.Fatalf("UpsertUserAccount failed: %v", err)
+	}
+	if _, err := db.Exec("INSERT INTO free_credit (user_external_id, credit) VALUES ($1, 0) ON CONFLICT (user_external_id) DO UPDATE SET credit = 0", stripedb.HashExternalID(subBoardID)); err != nil {
+		t.Fatalf("Failed to upsert free_credit: %v", err)
+	}
+
+	now := time.Now().Unix()
+	var lookups atomic.Int32
+	gw := countingGateway{fakeGateway: fakeGateway{
+		subs: map[string]stripe.Subscription{
+			"sub_123": {Quantity: 1, Status: stripe.SubscriptionStatusActive, Plan: &stripe.Plan{Amount: 1400}, CurrentPeriodStart: now - 86400, CurrentPeriodEnd: now + 86400},
+		},
+		custs: map[string]stripe.Customer{
+			"cust_123": {Email: "valid@example.com"},
+		},
+	}, lookups: &lookups}
+
+	st, err := NewService(gw).GetEntitlements(subBoardID)
+	assert.NoError(t, err)
+	assert.True(t, st.Validity.IsValidSubscription)
+	assert.Equal(t, (now-86400)*1000, st.PeriodStart)
+	// verification and the allowance share one subscription lookup
+	assert.Equal(t, int32(1), lookups.Load())
+}
+
+func Test_RefundSpendingUnits_NotifiesWatchers(t *testing.T) {
+	_, cleanup := setupSubTestDB(t)
+	defer cleanup()
+	if err := stripedb.UpsertUserAccount(subBoardID, "", "", ""); err != nil {
+		t.Fatalf("UpsertUserAccount failed: %v", err)
+	}
+	if _, err := stripedb.AddSpendingUnits([]stripedb.SpendingUnit{
+		{ExternalID: "sub-refund-unit", UserExternalID: subBoardID, Amount: 3, CreatedAt: time.Now().UnixMilli()},
+	}); err != nil {
+		t.Fatalf("AddSpendingUnits failed: %v", err)
+	}
+
+	changed, cancel := pubsub.Subscribe(usageTopic(stripedb.HashExternalID(subBoardID)))
+	defer cancel()
+	n, err := NewService(fakeGateway{}).RefundSpendingUnits([]string{"sub-refund-unit"})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	select {
+	case <-changed:
+	case <-time.After(time.Second):
+		t.Fatal("refund did not notify the account's watchers")
+	}
+
+	// a failing unit doesn't hide the refunds committed before it
+	if _, err := stripedb.AddSpendingUnits([]stripedb.SpendingUnit{
+		{ExternalID: "sub-refund-unit-2", UserExternalID: subBoardID, Amount: 1, CreatedAt: time.Now().UnixMilli()},
+	}); err != nil {
+		t.Fatalf("AddSpendingUnits failed: %v", err)
+	}
+	n, err = NewService(fakeGateway{}).RefundSpendingUnits([]string{"sub-refund-unit-2", "sub-refund-unknown"})
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.Equal(t, 1, n)
+}
+
+func Test_WatchEntitlements_RefetchesSubscriptionOnlyOnChanges(t *testing.T) {
+	db, cleanup := setupSubTestDB(t)
+	defer cleanup()
+	if err := stripedb.UpsertUserAccount(subBoardID, "sub_123", "plan_123", "cust_123"); err != nil {
+		t.Fatalf("UpsertUserAccount failed: %v", err)
+	}
+	if _, err := db.Exec("INSERT INTO free_credit (user_external_id, credit) VALUES ($1, 0) ON CONFLICT (user_external_id) DO UPDATE SET credit = 0", stripedb.HashExternalID(subBoardID)); err != nil {
+		t.Fatalf("Failed to upsert free_credit: %v", err)
+	}
+
+	now := time.Now().Unix()
+	var lookups atomic.Int32
+	gw := countingGateway{fakeGateway: fakeGateway{
+		subs: map[string]stripe.Subscription{
+			"sub_123": {Quantity: 1, Status: stripe.SubscriptionStatusActive, Plan: &stripe.Plan{Amount: 1400}, CurrentPeriodStart: now - 86400, CurrentPeriodEnd: now + 86400},
+		},
+		custs: map[string]stripe.Customer{"cust_123": {Email: "valid@example.com"}},
+	}, lookups: &lookups}
+	svc := NewService(gw)
+
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+	states := make(chan EntitlementState, 10)
+	go func() {
+		_ = svc.WatchEntitlements(ctx, subBoardID, func(st EntitlementState) error {
+			states <- st
+			return nil
+		})
+	}()
+	next := func() EntitlementState {
+		t.Helper()
+		select {
+		case st := <-states:
+			return st
+		case <-time.After(5 * time.Second):
+			t.Fatal("no entitlement state sent")
+			return EntitlementState{}
+		}
+	}
+	first := next()
+	assert.Equal(t, int32(1), lookups.Load())
+
+	// a burst of usage is recomputed once, from the database alone
+	for i := 1; i <= 3; i++ {
+		if _, err := stripedb.AddSpendingUnits([]stripedb.SpendingUnit{
+			{ExternalID: fmt.Sprintf("sub-watch-unit-%d", i), UserExternalID: subBoardID, Amount: 1, CreatedAt: time.Now().UnixMilli()},
+		}); err != nil {
+			t.Fatalf("AddSpendingUnits failed: %v", err)
+		}
+		usageChanged(stripedb.HashExternalID(subBoardID))
+	}
+	st := next()
+	assert.Equal(t, first.UsedUnits+3, st.UsedUnits)
+	assert.Equal(t, int32(1), lookups.Load())
+
+	// a change announced by entitlementsChanged fetches the subscription again
+	sub := gw.subs["sub_123"]
+	sub.Quantity = 2
+	gw.subs["sub_123"] = sub
+	entitlementsChanged(subBoardID)
+	st = next()
+	assert.Equal(t, 2*first.AllowanceUnits, st.AllowanceUnits)
+	assert.Equal(t, int32(2), lookups.Load())
+}
@@ -0,0 +1,110 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	stripedb "github.com/tbeaudouin05/stripe-trellai/api/services/stripe/db"
+)
+
+// usageChecks runs the checks that follow recorded units off the recording path, one at a time
+// per account.
+type usageChecks struct {
+	mu sync.Mutex
+	// pending holds the accounts with a check running; true when units were recorded since it
+	// started, so it runs again
+	pending map[string]bool
+	wg      sync.WaitGroup
+}
+
+// queueUsageChecks runs checkRecordedUsage for the account in the background. A check already
+// running for the account runs once more instead, so bursts of recordings cost one or two checks.
+func (s serviceImpl) queueUsageChecks(account string) {
+	c := s.checks
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	if _, running := c.pending[account]; running {
+		c.pending[account] = true
+		c.mu.Unlock()
+		return
+	}
+	if c.pending == nil {
+		c.pending = make(map[string]bool)
+	}
+	c.pending[account] = false
+	c.wg.Add(1)
+	c.mu.Unlock()
+
+	go func() {
+		defer c.wg.Done()
+		for {
+			if err := s.checkRecordedUsage(account); err != nil {
+				slog.Error("error checking recorded usage", "err", err)
+			}
+			c.mu.Lock()
+			if !c.pending[account] {
+				delete(c.pending, account)
+				c.mu.Unlock()
+				return
+			}
+			c.pending[account] = false
+			c.mu.Unlock()
+		}
+	}()
+}
+
+// checkRecordedUsage follows units recorded for the account: it records the current period of an
+// overage-enabled subscription with the overage so far, so the period is billed even if the
+// invoicing job never ran during it, then queues the allowance alerts the usage reached. The
+// subscription and customer are read through the service's cache. Accounts without a live
+// subscription or with an unlimited allowance are left alone.
+func (s serviceImpl) checkRecordedUsage(account string) error {
+	ua, err := stripedb.GetUserAccount(account)
+	if err != nil {
+		return fmt.Errorf("%w: error retrieving user account: %v", ErrDatabase, err)
+	}
+	if ua.StripeSubscriptionID == "" {
+		return nil
+	}
+	cached := s.cachedStripe(time.Time{})
+	sub, err := cached.gw.GetSubscription(ua.StripeSubscriptionID)
+	if err != nil {
+		return fmt.Errorf("%w: error getting subscription: %v", ErrGateway, err)
+	}
+	if IsSubscriptionCancelled(sub) || IsSubscriptionPaused(sub) || sub.CurrentPeriodStart == 0 {
+		return nil
+	}
+	allowance, err := s.subscriptionAllowance(sub)
+	if err != nil {
+		return err
+	}
+	if allowance.Unlimited {
+		return nil
+	}
+	// Stripe provides seconds; spending units are in milliseconds.
+	count, err := stripedb.CountUnitsBetween(account, sub.CurrentPeriodStart*1000, sub.CurrentPeriodEnd*1000)
+	if err != nil {
+		return fmt.Errorf("%w: error counting units: %v", ErrDatabase, err)
+	}
+
+	var errs []error
+	if allowance.OverageUnitAmount != "" {
+		acct := stripedb.SubscribedAccount{
+			UserExternalID:       stripedb.HashExternalID(account),
+			StripeSubscriptionID: ua.StripeSubscriptionID,
+			StripeCustomerID:     ua.StripeCustomerID,
+		}
+		if err := recordOveragePeriod(acct, sub, allowance, count); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := cached.checkAllowanceAlerts(account, ua, sub, allowance, count); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
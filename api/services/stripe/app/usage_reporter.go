@@ -0,0 +1,103 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/stripe/stripe-go"
+	stripedb "github.com/tbeaudouin05/stripe-trellai/api/services/stripe/db"
+	"github.com/tbeaudouin05/stripe-trellai/api/services/stripe/gateway"
+)
+
+// ReportMeteredUsage posts unreported spending units as Stripe usage records for every
+// subscription with a metered item and returns how many usage records were posted.
+// Units covered by free credit are not billed, nor are units recorded before the metered item
+// was created. Each run tags the account's unreported units with a batch, persisted before it
+// is sent, so a crash between the Stripe call and the commit re-sends the same batch with the
+// same idempotency key. A pending batch Stripe rejects because its period has since closed is
+// re-dated into the current period under a new key, so it doesn't hold up later batches. Refunds
+// net out in the batch they are reported in; a batch netting to zero or less is carried into the
+// next one. Failures for one account are logged and do not stop the others.
+func (s serviceImpl) ReportMeteredUsage() (int, error) {
+	accounts, err := stripedb.ListSubscribedAccounts()
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrDatabase, err)
+	}
+	var posted int
+	var errs []error
+	for _, acct := range accounts {
+		n, err := s.reportAccountUsage(acct)
+		posted += n
+		if err != nil {
+			slog.Error("error reporting metered usage", "stripe_subscription_id", acct.StripeSubscriptionID, "err", err)
+			errs = append(errs, err)
+		}
+	}
+	return posted, errors.Join(errs...)
+}
+
+func (s serviceImpl) reportAccountUsage(acct stripedb.SubscribedAccount) (int, error) {
+	sub, err := s.gw.GetSubscription(acct.StripeSubscriptionID)
+	if err != nil {
+		return 0, fmt.Errorf("%w: error getting subscription: %v", ErrGateway, err)
+	}
+	if IsSubscriptionCancelled(sub) {
+		return 0, nil
+	}
+	items := meteredItems(sub)
+	if len(items) == 0 {
+		return 0, nil
+	}
+	if len(items) > 1 {
+		// the same units can't be billed twice, and nothing says which item they belong to
+		return 0, fmt.Errorf("subscription %s has %d metered items, usage can only be reported against one", sub.ID, len(items))
+	}
+	item := items[0]
+	since := item.Created * 1000
+	if since == 0 {
+		since = sub.CurrentPeriodStart * 1000
+	}
+	b, err := stripedb.NextUsageBatch(item.ID, acct.UserExternalID, since, time.Now().Unix())
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrDatabase, err)
+	}
+	if b.Empty() {
+		return 0, nil
+	}
+	posted := 0
+	if b.Units > 0 {
+		err := s.gw.CreateUsageRecord(b.SubscriptionItemID, b.Units, b.Timestamp, b.IdempotencyKey())
+		if errors.Is(err, gateway.ErrUsageRejected) && b.Timestamp < sub.CurrentPeriodStart {
+			// the batch's period closed before Stripe accepted it: bill it in the current one
+			slog.Warn("usage batch rejected after its period closed, re-dating it", "stripe_subscription_id", sub.ID, "batch", b.Batch, "timestamp", b.Timestamp, "err", err)
+			if b, err = stripedb.RedateUsageBatch(b, acct.UserExternalID, time.Now().Unix()); err != nil {
+				return 0, fmt.Errorf("%w: %v", ErrDatabase, err)
+			}
+			err = s.gw.CreateUsageRecord(b.SubscriptionItemID, b.Units, b.Timestamp, b.IdempotencyKey())
+		}
+		if err != nil {
+			return 0, fmt.Errorf("%w: error creating usage record: %v", ErrGateway, err)
+		}
+		posted = 1
+	}
+	if err := stripedb.CommitUsageBatch(b.SubscriptionItemID); err != nil {
+		return posted, fmt.Errorf("%w: %v", ErrDatabase, err)
+	}
+	return posted, nil
+}
+
+// meteredItems returns the subscription's live items with a metered price.
+func meteredItems(sub stripe.Subscription) []*stripe.SubscriptionItem {
+	if sub.Items == nil {
+		return nil
+	}
+	var items []*stripe.SubscriptionItem
+	for _, it := range sub.Items.Data {
+		if it != nil && !it.Deleted && it.Plan != nil && it.Plan.UsageType == stripe.PlanUsageTypeMetered {
+			items = append(items, it)
+		}
+	}
+	return items
+}
@@ -0,0 +1,188 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	stripe "github.com/stripe/stripe-go"
+	stripedb "github.com/tbeaudouin05/stripe-trellai/api/services/stripe/db"
+)
+
+const usageBoardID = "usage-test-board"
+
+// setupUsageTest creates a subscribed account with no free credit, so every unit is billable.
+func setupUsageTest(t *testing.T) func() {
+	db, cleanup := setupSubTestDB(t)
+	hb := stripedb.HashExternalID(usageBoardID)
+	clean := func() {
+		_, _ = db.Exec("DELETE FROM usage_report WHERE user_external_id = $1", hb)
+		_, _ = db.Exec("DELETE FROM spending_unit WHERE user_external_id = $1", hb)
+		_, _ = db.Exec("DELETE FROM free_credit WHERE user_external_id = $1", hb)
+		_, _ = db.Exec("DELETE FROM user_account WHERE user_external_id = $1", hb)
+	}
+	clean()
+
+	if err := stripedb.UpsertUserAccount(usageBoardID, "sub_usage", "plan_usage", "cust_usage"); err != nil {
+		t.Fatalf("UpsertUserAccount failed: %v", err)
+	}
+	// No free credit so every unit is billable.
+	if _, err := db.Exec("INSERT INTO free_credit (user_external_id, credit) VALUES ($1, 0)", hb); err != nil {
+		t.Fatalf("Failed to insert free_credit: %v", err)
+	}
+	return func() {
+		clean()
+		cleanup()
+	}
+}
+
+func usageGateway(items ...*stripe.SubscriptionItem) fakeGateway {
+	return fakeGateway{
+		subs: map[string]stripe.Subscription{
+			"sub_usage": {ID: "sub_usage", Status: stripe.SubscriptionStatusActive, Items: &stripe.SubscriptionItemList{Data: items}},
+		},
+		usage: map[string]int64{},
+	}
+}
+
+func totalUsage(gw fakeGateway) int64 {
+	var total int64
+	for _, q := range gw.usage {
+		total += q
+	}
+	return total
+}
+
+var meteredPlan = &stripe.Plan{ID: "plan_usage_metered", UsageType: stripe.PlanUsageTypeMetered}
+
+func Test_ReportMeteredUsage_ReportsOnce(t *testing.T) {
+	defer setupUsageTest(t)()
+
+	now := time.Now().UnixMilli()
+	if _, err := stripedb.AddSpendingUnits([]stripedb.SpendingUnit{
+		{ExternalID: "usage-unit-1", UserExternalID: usageBoardID, Amount: 3, CreatedAt: now},
+		{ExternalID: "usage-unit-2", UserExternalID: usageBoardID, Amount: 4, CreatedAt: now},
+	}); err != nil {
+		t.Fatalf("AddSpendingUnits failed: %v", err)
+	}
+
+	gw := usageGateway(&stripe.SubscriptionItem{ID: "si_usage", Plan: meteredPlan})
+	svc := NewService(gw)
+
+	_, err := svc.ReportMeteredUsage()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), totalUsage(gw))
+
+	// A second run finds nothing new to report.
+	_, err = svc.ReportMeteredUsage()
+	assert.NoError(t, err)
+	assert.Len(t, gw.usage, 1)
+}
+
+func Test_ReportMeteredUsage_CarriesRefundDeficit(t *testing.T) {
+	defer setupUsageTest(t)()
+
+	now := time.Now().UnixMilli()
+	if _, err := stripedb.AddSpendingUnits([]stripedb.SpendingUnit{
+		{ExternalID: "usage-unit-1", UserExternalID: usageBoardID, Amount: 3, CreatedAt: now},
+	}); err != nil {
+		t.Fatalf("AddSpendingUnits failed: %v", err)
+	}
+	gw := usageGateway(&stripe.SubscriptionItem{ID: "si_usage", Plan: meteredPlan})
+	svc := NewService(gw)
+	_, err := svc.ReportMeteredUsage()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), totalUsage(gw))
+
+	// The refund of an already reported unit can't be posted as negative usage...
+	if _, _, err := stripedb.RefundSpendingUnits([]string{"usage-unit-1"}); err != nil {
+		t.Fatalf("RefundSpendingUnits failed: %v", err)
+	}
+	posted, err := svc.ReportMeteredUsage()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, posted)
+
+	// ...so it is deducted from the next batch.
+	if _, err := stripedb.AddSpendingUnits([]stripedb.SpendingUnit{
+		{ExternalID: "usage-unit-2", UserExternalID: usageBoardID, Amount: 5, CreatedAt: now},
+	}); err != nil {
+		t.Fatalf("AddSpendingUnits failed: %v", err)
+	}
+	posted, err = svc.ReportMeteredUsage()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, posted)
+	assert.Equal(t, int64(3+2), totalUsage(gw))
+}
+
+func Test_ReportMeteredUsage_SkipsUnitsBeforeItemCreation(t *testing.T) {
+	defer setupUsageTest(t)()
+
+	created := time.Now().Add(-time.Hour)
+	if _, err := stripedb.AddSpendingUnits([]stripedb.SpendingUnit{
+		{ExternalID: "usage-unit-1", UserExternalID: usageBoardID, Amount: 3, CreatedAt: created.Add(-time.Hour).UnixMilli()},
+		{ExternalID: "usage-unit-2", UserExternalID: usageBoardID, Amount: 4, CreatedAt: created.Add(time.Minute).UnixMilli()},
+	}); err != nil {
+		t.Fatalf("AddSpendingUnits failed: %v", err)
+	}
+	gw := usageGateway(&stripe.SubscriptionItem{ID: "si_usage", Plan: meteredPlan, Created: created.Unix()})
+	_, err := NewService(gw).ReportMeteredUsage()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), totalUsage(gw))
+}
+
+func Test_ReportMeteredUsage_RejectsSeveralMeteredItems(t *testing.T) {
+	defer setupUsageTest(t)()
+
+	if _, err := stripedb.AddSpendingUnits([]stripedb.SpendingUnit{
+		{ExternalID: "usage-unit-1", UserExternalID: usageBoardID, Amount: 3, CreatedAt: time.Now().UnixMilli()},
+	}); err != nil {
+		t.Fatalf("AddSpendingUnits failed: %v", err)
+	}
+	gw := usageGateway(
+		&stripe.SubscriptionItem{ID: "si_usage", Plan: meteredPlan},
+		&stripe.SubscriptionItem{ID: "si_usage_2", Plan: meteredPlan},
+	)
+	_, err := NewService(gw).ReportMeteredUsage()
+	assert.ErrorContains(t, err, "metered items")
+	assert.Empty(t, gw.usage)
+}
+
+func Test_ReportMeteredUsage_RedatesBatchRejectedAfterItsPeriod(t *testing.T) {
+	defer setupUsageTest(t)()
+
+	now := time.Now()
+	if _, err := stripedb.AddSpendingUnits([]stripedb.SpendingUnit{
+		{ExternalID: "usage-unit-1", UserExternalID: usageBoardID, Amount: 3, CreatedAt: now.Add(-2 * time.Hour).UnixMilli()},
+	}); err != nil {
+		t.Fatalf("AddSpendingUnits failed: %v", err)
+	}
+	// a batch claimed in the previous period and never sent
+	stale, err := stripedb.NextUsageBatch("si_usage", stripedb.HashExternalID(usageBoardID), 0, now.Add(-2*time.Hour).Unix())
+	if err != nil {
+		t.Fatalf("NextUsageBatch failed: %v", err)
+	}
+	periodStart := now.Add(-time.Hour).Unix()
+	gw := usageGateway(&stripe.SubscriptionItem{ID: "si_usage", Plan: meteredPlan})
+	sub := gw.subs["sub_usage"]
+	sub.CurrentPeriodStart = periodStart
+	gw.subs["sub_usage"] = sub
+	gw.usageRejectedBefore = periodStart
+	svc := NewService(gw)
+
+	n, err := svc.ReportMeteredUsage()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, int64(3), totalUsage(gw))
+	assert.NotContains(t, gw.usage, stale.IdempotencyKey())
+
+	// later units go out in their own batch
+	if _, err := stripedb.AddSpendingUnits([]stripedb.SpendingUnit{
+		{ExternalID: "usage-unit-2", UserExternalID: usageBoardID, Amount: 4, CreatedAt: now.UnixMilli()},
+	}); err != nil {
+		t.Fatalf("AddSpendingUnits failed: %v", err)
+	}
+	_, err = svc.ReportMeteredUsage()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), totalUsage(gw))
+	assert.Len(t, gw.usage, 2)
+}
@@ -17,3 +17,12 @@ func IsSubscriptionCancelled(sub stripe.Subscription) bool {
     }
     return false
 }
+
+// IsSubscriptionPaused returns true while payment collection is paused on the subscription
+// (until its resume date, when it has one)
+func IsSubscriptionPaused(sub stripe.Subscription) bool {
+    if sub.PauseCollection.Behavior == "" {
+        return false
+    }
+    return sub.PauseCollection.ResumesAt == 0 || time.Now().Unix() < sub.PauseCollection.ResumesAt
+}
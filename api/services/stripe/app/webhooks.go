@@ -0,0 +1,191 @@
+package app
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/tbeaudouin05/stripe-trellai/api/config"
+	stripedb "github.com/tbeaudouin05/stripe-trellai/api/services/stripe/db"
+	"github.com/tbeaudouin05/stripe-trellai/api/services/stripe/outbound"
+)
+
+const (
+	// webhookLease keeps a claimed delivery from other workers while it is being sent.
+	webhookLease = 5 * time.Minute
+	// webhookBatchSize is how many due deliveries one DeliverWebhooks run claims at most. Sent one
+	// after the other, they all fit in the lease even if every endpoint times out.
+	webhookBatchSize = int(webhookLease/outbound.SendTimeout) / 2
+)
+
+// Sources of credits.granted events.
+const (
+	CreditSourceAdmin      = "admin"
+	CreditSourceCreditPack = "credit_pack"
+	CreditSourcePromoCode  = "promo_code"
+)
+
+// webhookEndpoints returns OUTGOING_WEBHOOK_URLS; events are not emitted when it is empty.
+func webhookEndpoints() []string {
+	if config.AppConfig == nil {
+		return nil
+	}
+	return config.SplitList(config.AppConfig.OutgoingWebhookURLs)
+}
+
+// emitEvent queues an event for every endpoint. key identifies the occurrence within eventType,
+// so emitting the same occurrence again (e.g. from a retried Stripe webhook) queues nothing.
+func emitEvent(eventType, key string, data outbound.EventData) error {
+	endpoints := webhookEndpoints()
+	if len(endpoints) == 0 {
+		return nil
+	}
+	sum := sha256.Sum256([]byte(eventType + ":" + key))
+	event := outbound.Event{
+		ID:      "evt_" + hex.EncodeToString(sum[:16]),
+		Type:    eventType,
+		Created: time.Now().UnixMilli(),
+		Data:    data,
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("error encoding %s event: %v", eventType, err)
+	}
+	for _, url := range endpoints {
+		if _, err := stripedb.QueueWebhookDelivery(stripedb.WebhookDelivery{
+			EventID:     event.ID,
+			EventType:   eventType,
+			EndpointURL: url,
+			Payload:     string(payload),
+		}, event.Created); err != nil {
+			return fmt.Errorf("%w: %v", ErrDatabase, err)
+		}
+	}
+	return nil
+}
+
+// userEventData returns the event data of a user known by their raw identifier.
+func userEventData(userExternalID string) outbound.EventData {
+	return outbound.EventData{UserExternalID: userExternalID, UserHash: stripedb.HashExternalID(userExternalID)}
+}
+
+// DeliverWebhooks sends due outgoing webhooks, and hands due allowance alerts to the notifier, and
+// returns how many were delivered. Failed attempts are retried with exponential backoff until
+// WEBHOOK_MAX_ATTEMPTS, then marked failed.
+// Deliveries are only sent while their lease leaves time for a full attempt, and an attempt is
+// only recorded while the lease holds, so a delivery reclaimed by another run is not counted twice.
+func (s serviceImpl) DeliverWebhooks() (int, error) {
+	if config.AppConfig == nil {
+		return 0, fmt.Errorf("app config not initialized")
+	}
+	now := time.Now()
+	leaseUntil := now.Add(webhookLease)
+	due, err := stripedb.ClaimDueWebhookDeliveries(now.UnixMilli(), leaseUntil.UnixMilli(), webhookBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrDatabase, err)
+	}
+	sender := outbound.Sender{Secret: config.AppConfig.OutgoingWebhookSecret}
+	delivered := 0
+	for i, d := range due {
+		if time.Now().Add(outbound.SendTimeout).After(leaseUntil) {
+			// the rest are sent once their lease ends
+			slog.Warn("webhook lease running out, leaving deliveries for the next run", "remaining", len(due)-i)
+			break
+		}
+		var code int
+		var sendErr error
+		if strings.HasPrefix(d.EndpointURL, alertEndpointPrefix) {
+			sendErr = s.notifyAlert(d.Payload)
+		} else {
+			code, sendErr = sender.Send(d.EndpointURL, d.EventID, []byte(d.Payload))
+		}
+		attempts := d.Attempts + 1
+		at := time.Now()
+		status, next, deliveredAt, lastErr := stripedb.WebhookDelivered, at.UnixMilli(), at.UnixMilli(), ""
+		if sendErr != nil {
+			status, deliveredAt, lastErr = stripedb.WebhookPending, 0, sendErr.Error()
+			next = at.Add(outbound.Backoff(attempts)).UnixMilli()
+			if attempts >= config.AppConfig.WebhookMaxAttempts {
+				status = stripedb.WebhookFailed
+			}
+			slog.Warn("webhook delivery failed", "event_id", d.EventID, "endpoint", d.EndpointURL, "attempts", attempts, "status", status, "err", sendErr)
+		} else {
+			delivered++
+		}
+		recorded, err := stripedb.RecordWebhookAttempt(d.ID, leaseUntil.UnixMilli(), status, next, code, lastErr, deliveredAt)
+		if err != nil {
+			return delivered, fmt.Errorf("%w: %v", ErrDatabase, err)
+		}
+		if !recorded {
+			slog.Warn("webhook lease ended before the attempt was recorded", "event_id", d.EventID, "endpoint", d.EndpointURL)
+		}
+	}
+	return delivered, nil
+}
+
+// ListWebhookDeliveries returns the outgoing webhook delivery log, newest first.
+func (s serviceImpl) ListWebhookDeliveries(beforeID int64, status, eventType string, limit int) ([]stripedb.WebhookDelivery, error) {
+	switch status {
+	case "", stripedb.WebhookPending, stripedb.WebhookDelivered, stripedb.WebhookFailed:
+	default:
+		return nil, fmt.Errorf("status must be pending, delivered or failed")
+	}
+	list, err := stripedb.ListWebhookDeliveries(beforeID, status, eventType, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDatabase, err)
+	}
+	return list, nil
+}
+
+// creditBalances returns the free plus purchased credit of each account, when events are emitted.
+func creditBalances(accounts map[string]string) (map[string]int64, error) {
+	if len(webhookEndpoints()) == 0 {
+		return nil, nil
+	}
+	balances := make(map[string]int64)
+	for _, account := range accounts {
+		if _, ok := balances[account]; ok {
+			continue
+		}
+		free, err := stripedb.GetFreeCredit(account)
+		if err != nil {
+			return nil, fmt.Errorf("%w: error retrieving free credit: %v", ErrDatabase, err)
+		}
+		purchased, err := stripedb.GetPurchasedCredit(account)
+		if err != nil {
+			return nil, fmt.Errorf("%w: error retrieving purchased credit: %v", ErrDatabase, err)
+		}
+		balances[account] = int64(free) + purchased
+	}
+	return balances, nil
+}
+
+// emitCreditsExhausted emits credits.exhausted for each account whose credit the batch used up.
+// lastUnit is the external ID of the batch's last unit, which tells exhaustions apart.
+func emitCreditsExhausted(before map[string]int64, lastUnit string) error {
+	after, err := creditBalances(accountsOf(before))
+	if err != nil {
+		return err
+	}
+	for account, balance := range before {
+		if balance > 0 && after[account] <= 0 {
+			if err := emitEvent(outbound.EventCreditsExhausted, account+":"+lastUnit, userEventData(account)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// accountsOf returns the keys of balances in the shape creditBalances takes.
+func accountsOf(balances map[string]int64) map[string]string {
+	accounts := make(map[string]string, len(balances))
+	for account := range balances {
+		accounts[account] = account
+	}
+	return accounts
+}
@@ -0,0 +1,173 @@
+package app
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tbeaudouin05/stripe-trellai/api/config"
+	stripedb "github.com/tbeaudouin05/stripe-trellai/api/services/stripe/db"
+	"github.com/tbeaudouin05/stripe-trellai/api/services/stripe/notifier"
+	"github.com/tbeaudouin05/stripe-trellai/api/services/stripe/outbound"
+)
+
+const webhooksBoardID = "webhooks-test-board"
+
+func Test_Webhooks_SignedDeliveryWithRetries(t *testing.T) {
+	db, _ := setupSubTestDB(t)
+	hb := stripedb.HashExternalID(webhooksBoardID)
+
+	var received []outbound.Event
+	failing := true
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		assert.NoError(t, outbound.Verify("whsec_test", r.Header.Get(outbound.SignatureHeader), body, time.Minute, time.Now()))
+		if failing {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var e outbound.Event
+		assert.NoError(t, json.Unmarshal(body, &e))
+		assert.Equal(t, e.ID, r.Header.Get(outbound.IDHeader))
+		received = append(received, e)
+	}))
+	defer srv.Close()
+
+	clean := func() {
+		_, _ = db.Exec("DELETE FROM webhook_delivery WHERE endpoint_url = $1", srv.URL)
+		// alerts other tests queued would be delivered with ours
+		_, _ = db.Exec("DELETE FROM webhook_delivery WHERE event_type = $1", notifier.AlertTypeAllowanceThreshold)
+		_, _ = db.Exec("DELETE FROM spending_unit WHERE user_external_id = $1", hb)
+		_, _ = db.Exec("DELETE FROM credit_grant WHERE user_external_id = $1", hb)
+		_, _ = db.Exec("DELETE FROM free_credit WHERE user_external_id = $1", hb)
+		_, _ = db.Exec("DELETE FROM user_account WHERE user_external_id = $1", hb)
+	}
+	clean()
+	defer clean()
+
+	orig := *config.AppConfig
+	t.Cleanup(func() { *config.AppConfig = orig })
+	config.AppConfig.OutgoingWebhookURLs = srv.URL
+	config.AppConfig.OutgoingWebhookSecret = "whsec_test"
+	config.AppConfig.WebhookMaxAttempts = 2
+
+	if err := stripedb.UpsertUserAccount(webhooksBoardID, "", "", ""); err != nil {
+		t.Fatalf("UpsertUserAccount failed: %v", err)
+	}
+	if _, err := db.Exec("INSERT INTO free_credit (user_external_id, credit) VALUES ($1, 0)", hb); err != nil {
+		t.Fatalf("Failed to insert free_credit: %v", err)
+	}
+	svc := NewService(fakeGateway{})
+	dueNow := func() {
+		t.Helper()
+		if _, err := db.Exec("UPDATE webhook_delivery SET next_attempt_at = 0 WHERE endpoint_url = $1 AND status = 'pending'", srv.URL); err != nil {
+			t.Fatalf("Failed to make deliveries due: %v", err)
+		}
+	}
+	log := func() []stripedb.WebhookDelivery {
+		t.Helper()
+		list, err := svc.ListWebhookDeliveries(0, "", "", 10)
+		assert.NoError(t, err)
+		var ours []stripedb.WebhookDelivery
+		for _, d := range list {
+			if d.EndpointURL == srv.URL {
+				ours = append(ours, d)
+			}
+		}
+		return ours
+	}
+
+	// granting then using up the credit emits credits.granted and credits.exhausted
+	_, err := svc.GrantCredits(webhooksBoardID, 3, "support", "ops", 0)
+	assert.NoError(t, err)
+	_, err = svc.AddSpendingUnits([]stripedb.SpendingUnit{{ExternalID: "webhooks-unit-1", UserExternalID: webhooksBoardID, Amount: 3, CreatedAt: time.Now().UnixMilli()}})
+	assert.NoError(t, err)
+	// a retried batch consumes nothing and emits nothing
+	_, err = svc.AddSpendingUnits([]stripedb.SpendingUnit{{ExternalID: "webhooks-unit-1", UserExternalID: webhooksBoardID, Amount: 3, CreatedAt: time.Now().UnixMilli()}})
+	assert.NoError(t, err)
+	if deliveries := log(); assert.Len(t, deliveries, 2) {
+		assert.Equal(t, outbound.EventCreditsExhausted, deliveries[0].EventType)
+		assert.Equal(t, outbound.EventCreditsGranted, deliveries[1].EventType)
+	}
+
+	// a failed attempt is logged and backs off
+	dueNow()
+	n, err := svc.DeliverWebhooks()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, n)
+	for _, d := range log() {
+		assert.Equal(t, stripedb.WebhookPending, d.Status)
+		assert.Equal(t, 1, d.Attempts)
+		assert.Equal(t, http.StatusServiceUnavailable, d.LastStatusCode)
+		assert.Greater(t, d.NextAttemptAt, time.Now().Add(20*time.Second).UnixMilli())
+	}
+	n, err = svc.DeliverWebhooks()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, n, "not due yet")
+
+	failing = false
+	dueNow()
+	n, err = svc.DeliverWebhooks()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.ElementsMatch(t, []string{outbound.EventCreditsGranted, outbound.EventCreditsExhausted}, []string{received[0].Type, received[1].Type})
+	for _, e := range received {
+		assert.Equal(t, webhooksBoardID, e.Data.UserExternalID)
+		assert.Equal(t, hb, e.Data.UserHash)
+	}
+	delivered, err := svc.ListWebhookDeliveries(0, stripedb.WebhookDelivered, outbound.EventCreditsGranted, 10)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, delivered)
+
+	// deliveries failing WEBHOOK_MAX_ATTEMPTS times are given up
+	failing = true
+	_, err = svc.GrantCredits(webhooksBoardID, 1, "support", "ops", 0)
+	assert.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		dueNow()
+		_, err = svc.DeliverWebhooks()
+		assert.NoError(t, err)
+	}
+	failed, err := svc.ListWebhookDeliveries(0, stripedb.WebhookFailed, "", 10)
+	assert.NoError(t, err)
+	if assert.NotEmpty(t, failed) {
+		assert.Equal(t, 2, failed[0].Attempts)
+	}
+}
+
+func Test_Webhooks_AttemptOnlyRecordedUnderLease(t *testing.T) {
+	db, _ := setupSubTestDB(t)
+	const endpoint = "http://webhooks-lease.invalid"
+	clean := func() { _, _ = db.Exec("DELETE FROM webhook_delivery WHERE endpoint_url = $1", endpoint) }
+	clean()
+	defer clean()
+
+	if _, err := stripedb.QueueWebhookDelivery(stripedb.WebhookDelivery{EventID: "evt_lease", EventType: outbound.EventCreditsGranted, EndpointURL: endpoint, Payload: "{}"}, 0); err != nil {
+		t.Fatalf("QueueWebhookDelivery failed: %v", err)
+	}
+	var id int64
+	if err := db.QueryRow("SELECT id FROM webhook_delivery WHERE endpoint_url = $1", endpoint).Scan(&id); err != nil {
+		t.Fatalf("Failed to read delivery: %v", err)
+	}
+	now := time.Now().UnixMilli()
+	first := now + webhookLease.Milliseconds()
+	// the first lease ends and another run claims the delivery again
+	if _, err := db.Exec("UPDATE webhook_delivery SET next_attempt_at = $2 WHERE id = $1", id, first+1); err != nil {
+		t.Fatalf("Failed to reclaim delivery: %v", err)
+	}
+
+	recorded, err := stripedb.RecordWebhookAttempt(id, first, stripedb.WebhookPending, now, 0, "timeout", 0)
+	assert.NoError(t, err)
+	assert.False(t, recorded, "the stale run records nothing")
+	recorded, err = stripedb.RecordWebhookAttempt(id, first+1, stripedb.WebhookDelivered, now, http.StatusOK, "", now)
+	assert.NoError(t, err)
+	assert.True(t, recorded)
+
+	var attempts int
+	assert.NoError(t, db.QueryRow("SELECT attempts FROM webhook_delivery WHERE id = $1", id).Scan(&attempts))
+	assert.Equal(t, 1, attempts)
+}
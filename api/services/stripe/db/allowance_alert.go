@@ -0,0 +1,69 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/tbeaudouin05/stripe-trellai/api/database"
+	sqldb "github.com/tbeaudouin05/stripe-trellai/internal/autogenerated/sqldb"
+)
+
+// AllowanceAlert identifies a threshold alert of an account for one billing period.
+// PeriodStart is unix ms; Units and Allowance are the usage when the threshold was crossed.
+type AllowanceAlert struct {
+	UserExternalID   string
+	PeriodStart      int64
+	ThresholdPercent int
+	Units            int64
+	Allowance        int64
+}
+
+// QueueAllowanceAlert claims the alert and queues its delivery, due at dueAt, in one transaction.
+// It returns false when the alert was already claimed for the period, so each threshold is
+// notified once per account and billing period.
+func QueueAllowanceAlert(a AllowanceAlert, d WebhookDelivery, dueAt int64) (bool, error) {
+	ctx := context.Background()
+	tx, err := database.GetDB().BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin allowance_alert transaction: %w", err)
+	}
+	defer tx.Rollback()
+	qtx := q.WithTx(tx)
+
+	n, err := qtx.ClaimAllowanceAlert(ctx, sqldb.ClaimAllowanceAlertParams{
+		UserExternalID:   HashExternalID(a.UserExternalID),
+		PeriodStart:      a.PeriodStart,
+		ThresholdPercent: int32(a.ThresholdPercent),
+		Units:            a.Units,
+		Allowance:        a.Allowance,
+	})
+	if err != nil {
+		return false, fmt.Errorf("error inserting allowance_alert: %w", err)
+	}
+	if n == 0 {
+		return false, nil
+	}
+	if _, err := qtx.InsertWebhookDelivery(ctx, sqldb.InsertWebhookDeliveryParams{
+		EventID:       d.EventID,
+		EventType:     d.EventType,
+		EndpointUrl:   d.EndpointURL,
+		Payload:       d.Payload,
+		NextAttemptAt: dueAt,
+	}); err != nil {
+		return false, fmt.Errorf("error inserting webhook_delivery: %w", err)
+	}
+	if err := qtx.MarkAllowanceAlertNotified(ctx, sqldb.MarkAllowanceAlertNotifiedParams{
+		UserExternalID:   HashExternalID(a.UserExternalID),
+		PeriodStart:      a.PeriodStart,
+		ThresholdPercent: int32(a.ThresholdPercent),
+		NotifiedAt:       sql.NullInt64{Int64: time.Now().UnixMilli(), Valid: true},
+	}); err != nil {
+		return false, fmt.Errorf("error updating allowance_alert: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit allowance_alert: %w", err)
+	}
+	return true, nil
+}
@@ -0,0 +1,86 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	sqldb "github.com/tbeaudouin05/stripe-trellai/internal/autogenerated/sqldb"
+)
+
+// BillingStatus is a user's dunning state, maintained from invoice webhooks.
+// NextPaymentAttempt and LastEventAt are unix ms; NextPaymentAttempt is 0 when Stripe
+// has no retry scheduled.
+type BillingStatus struct {
+	InDunning          bool   `json:"in_dunning"`
+	StripeInvoiceID    string `json:"stripe_invoice_id"`
+	AttemptCount       int    `json:"attempt_count"`
+	NextPaymentAttempt int64  `json:"next_payment_attempt"`
+	LastEventAt        int64  `json:"last_event_at"`
+}
+
+// FindUserBySubscriptionID returns the stored (already hashed) identifier of the user whose
+// current subscription is stripeSubscriptionID. The boolean is false when no user matches.
+func FindUserBySubscriptionID(stripeSubscriptionID string) (string, bool, error) {
+	ctx := context.Background()
+	hashed, err := q.GetUserExternalIDBySubscriptionID(ctx, toNullString(stripeSubscriptionID))
+	if err == sql.ErrNoRows {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("error reading user_account by subscription: %w", err)
+	}
+	return hashed, true, nil
+}
+
+// MarkBillingDunning records a failed invoice payment for an already hashed user identifier,
+// as returned by FindUserBySubscriptionID. It returns false when a newer event was already applied.
+func MarkBillingDunning(hashedUserExternalID string, s BillingStatus) (bool, error) {
+	ctx := context.Background()
+	n, err := q.MarkBillingDunning(ctx, sqldb.MarkBillingDunningParams{
+		UserExternalID:     hashedUserExternalID,
+		StripeInvoiceID:    toNullString(s.StripeInvoiceID),
+		AttemptCount:       int32(s.AttemptCount),
+		NextPaymentAttempt: sql.NullInt64{Int64: s.NextPaymentAttempt, Valid: s.NextPaymentAttempt > 0},
+		LastEventAt:        s.LastEventAt,
+	})
+	if err != nil {
+		return false, fmt.Errorf("error upserting billing_status: %w", err)
+	}
+	return n > 0, nil
+}
+
+// ClearBillingDunning takes an already hashed user out of dunning. When stripeInvoiceID is set,
+// only dunning caused by that invoice is cleared; when empty, any dunning is. It returns false
+// when the user was not in dunning for that invoice or a newer event was already applied.
+func ClearBillingDunning(hashedUserExternalID, stripeInvoiceID string, eventAt int64) (bool, error) {
+	ctx := context.Background()
+	n, err := q.ClearBillingDunning(ctx, sqldb.ClearBillingDunningParams{
+		UserExternalID:  hashedUserExternalID,
+		StripeInvoiceID: toNullString(stripeInvoiceID),
+		LastEventAt:     eventAt,
+	})
+	if err != nil {
+		return false, fmt.Errorf("error clearing billing_status: %w", err)
+	}
+	return n > 0, nil
+}
+
+// GetBillingStatus returns the user's dunning state (the zero value when no invoice event was seen).
+func GetBillingStatus(userExternalID string) (BillingStatus, error) {
+	ctx := context.Background()
+	row, err := q.GetBillingStatus(ctx, HashExternalID(userExternalID))
+	if err == sql.ErrNoRows {
+		return BillingStatus{}, nil
+	}
+	if err != nil {
+		return BillingStatus{}, fmt.Errorf("error reading billing_status: %w", err)
+	}
+	return BillingStatus{
+		InDunning:          row.InDunning,
+		StripeInvoiceID:    row.StripeInvoiceID.String,
+		AttemptCount:       int(row.AttemptCount),
+		NextPaymentAttempt: row.NextPaymentAttempt.Int64,
+		LastEventAt:        row.LastEventAt,
+	}, nil
+}
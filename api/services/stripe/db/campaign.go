@@ -0,0 +1,261 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tbeaudouin05/stripe-trellai/api/database"
+	sqldb "github.com/tbeaudouin05/stripe-trellai/internal/autogenerated/sqldb"
+)
+
+// Campaign redemption errors. They describe why a code was refused and are safe to show users.
+var (
+	ErrCampaignNotFound       = errors.New("unknown code")
+	ErrCampaignInactive       = errors.New("code is not active")
+	ErrCampaignExhausted      = errors.New("code has no redemptions left")
+	ErrRedemptionLimitReached = errors.New("code already redeemed")
+	ErrSelfReferral           = errors.New("cannot redeem your own referral code")
+	ErrAlreadyReferred        = errors.New("a referral code was already redeemed")
+	ErrCampaignExists         = errors.New("campaign code already exists")
+	ErrIdempotencyKeyReused   = errors.New("idempotency key already used for another code")
+)
+
+// Campaign is a promo code granting Units of free credit on redemption.
+// MaxRedemptions (0 = unlimited) caps redemptions across all users, PerUserLimit per user.
+// StartsAt/EndsAt are optional unix ms bounds of the validity window.
+type Campaign struct {
+	ID             int64  `json:"id"`
+	Code           string `json:"code"`
+	Units          int    `json:"units"`
+	MaxRedemptions int    `json:"max_redemptions"`
+	PerUserLimit   int    `json:"per_user_limit"`
+	StartsAt       int64  `json:"starts_at"`
+	EndsAt         int64  `json:"ends_at"`
+}
+
+// Redemption is the outcome of redeeming a code. Referral redemptions are Pending until the
+// referee completes a checkout; Units are credited then.
+type Redemption struct {
+	Code    string `json:"code"`
+	Units   int    `json:"units"`
+	Pending bool   `json:"pending"`
+	Balance int    `json:"balance"`
+}
+
+// NormalizeCampaignCode returns the canonical (trimmed, upper-case) form of a code.
+func NormalizeCampaignCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// campaignActor is the credit_grant actor recorded for credit granted by a campaign.
+func campaignActor(code string) string {
+	return "campaign:" + code
+}
+
+// CreateCampaign inserts a promo code campaign and returns its ID.
+func CreateCampaign(c Campaign) (int64, error) {
+	ctx := context.Background()
+	id, err := q.InsertCampaign(ctx, sqldb.InsertCampaignParams{
+		Code:           NormalizeCampaignCode(c.Code),
+		Units:          int32(c.Units),
+		MaxRedemptions: sql.NullInt32{Int32: int32(c.MaxRedemptions), Valid: c.MaxRedemptions > 0},
+		PerUserLimit:   int32(c.PerUserLimit),
+		StartsAt:       sql.NullInt64{Int64: c.StartsAt, Valid: c.StartsAt > 0},
+		EndsAt:         sql.NullInt64{Int64: c.EndsAt, Valid: c.EndsAt > 0},
+	})
+	if err == sql.ErrNoRows {
+		return 0, ErrCampaignExists
+	}
+	if err != nil {
+		return 0, fmt.Errorf("error inserting campaign: %w", err)
+	}
+	return id, nil
+}
+
+// ReferralCode returns the user's referral code, creating it on first use. A referee redeeming it
+// gets refereeUnits and the user gets referrerUnits once the referee completes a checkout.
+func ReferralCode(userExternalID string, refereeUnits, referrerUnits int) (string, error) {
+	ctx := context.Background()
+	// ensure the referrer's account exists (campaign references it)
+	if _, err := GetFreeCredit(userExternalID); err != nil {
+		return "", err
+	}
+	hashed := HashExternalID(userExternalID)
+	code, err := q.UpsertReferralCampaign(ctx, sqldb.UpsertReferralCampaignParams{
+		Code:                   "REF" + strings.ToUpper(hashed[:12]),
+		Units:                  int32(refereeUnits),
+		ReferrerUserExternalID: toNullString(hashed),
+		ReferrerUnits:          int32(referrerUnits),
+	})
+	if err != nil {
+		return "", fmt.Errorf("error upserting referral campaign: %w", err)
+	}
+	return code, nil
+}
+
+// RedeemCode redeems a campaign code for the user. Promo codes credit the user's free credit
+// immediately; referral codes are recorded and rewarded by RewardReferral. Redeeming again
+// with the same idempotencyKey returns the original outcome without crediting twice.
+func RedeemCode(userExternalID, code, idempotencyKey string) (Redemption, error) {
+	ctx := context.Background()
+	// ensure the account and free_credit row exist before taking the row lock
+	if _, err := GetFreeCredit(userExternalID); err != nil {
+		return Redemption{}, err
+	}
+	hashed := HashExternalID(userExternalID)
+	code = NormalizeCampaignCode(code)
+	now := time.Now().UnixMilli()
+
+	tx, err := database.GetDB().BeginTx(ctx, nil)
+	if err != nil {
+		return Redemption{}, fmt.Errorf("failed to begin redemption transaction: %w", err)
+	}
+	defer tx.Rollback()
+	qtx := q.WithTx(tx)
+
+	// serialize redemptions by the same user
+	balance, err := qtx.LockFreeCredit(ctx, hashed)
+	if err != nil {
+		return Redemption{}, fmt.Errorf("failed to lock free_credit: %w", err)
+	}
+	prev, err := qtx.GetCampaignRedemptionByKey(ctx, sqldb.GetCampaignRedemptionByKeyParams{
+		UserExternalID: hashed,
+		IdempotencyKey: idempotencyKey,
+	})
+	if err == nil {
+		c, err := qtx.GetCampaignByCodeForUpdate(ctx, code)
+		if err != nil || c.ID != prev.CampaignID {
+			return Redemption{}, ErrIdempotencyKeyReused
+		}
+		return Redemption{Code: code, Units: int(prev.Units), Pending: !prev.RewardedAt.Valid, Balance: int(balance)}, nil
+	}
+	if err != sql.ErrNoRows {
+		return Redemption{}, fmt.Errorf("error reading campaign_redemption: %w", err)
+	}
+
+	c, err := qtx.GetCampaignByCodeForUpdate(ctx, code)
+	if err == sql.ErrNoRows {
+		return Redemption{}, ErrCampaignNotFound
+	}
+	if err != nil {
+		return Redemption{}, fmt.Errorf("error reading campaign: %w", err)
+	}
+	if (c.StartsAt.Valid && now < c.StartsAt.Int64) || (c.EndsAt.Valid && now >= c.EndsAt.Int64) {
+		return Redemption{}, ErrCampaignInactive
+	}
+	if c.MaxRedemptions.Valid && c.Redemptions >= c.MaxRedemptions.Int32 {
+		return Redemption{}, ErrCampaignExhausted
+	}
+	used, err := qtx.CountUserCampaignRedemptions(ctx, sqldb.CountUserCampaignRedemptionsParams{
+		CampaignID:     c.ID,
+		UserExternalID: hashed,
+	})
+	if err != nil {
+		return Redemption{}, fmt.Errorf("error counting campaign redemptions: %w", err)
+	}
+	if used >= c.PerUserLimit {
+		return Redemption{}, ErrRedemptionLimitReached
+	}
+	referral := c.ReferrerUserExternalID.Valid
+	if referral {
+		if c.ReferrerUserExternalID.String == hashed {
+			return Redemption{}, ErrSelfReferral
+		}
+		referred, err := qtx.CountUserReferralRedemptions(ctx, hashed)
+		if err != nil {
+			return Redemption{}, fmt.Errorf("error counting referral redemptions: %w", err)
+		}
+		if referred > 0 {
+			return Redemption{}, ErrAlreadyReferred
+		}
+	}
+
+	var rewardedAt sql.NullInt64
+	if !referral {
+		rewardedAt = sql.NullInt64{Int64: now, Valid: true}
+	}
+	if err := qtx.InsertCampaignRedemption(ctx, sqldb.InsertCampaignRedemptionParams{
+		CampaignID:     c.ID,
+		UserExternalID: hashed,
+		IdempotencyKey: idempotencyKey,
+		Units:          c.Units,
+		RewardedAt:     rewardedAt,
+	}); err != nil {
+		return Redemption{}, fmt.Errorf("failed to insert campaign_redemption: %w", err)
+	}
+	if err := qtx.IncrementCampaignRedemptions(ctx, c.ID); err != nil {
+		return Redemption{}, fmt.Errorf("failed to count campaign redemption: %w", err)
+	}
+	if !referral && c.Units > 0 {
+		adj, err := adjustFreeCreditsTx(ctx, qtx, hashed, int(c.Units), "promo code "+code, campaignActor(code), sql.NullInt64{})
+		if err != nil {
+			return Redemption{}, err
+		}
+		balance = int32(adj.Balance)
+	}
+	if err := tx.Commit(); err != nil {
+		return Redemption{}, fmt.Errorf("failed to commit redemption: %w", err)
+	}
+	return Redemption{Code: code, Units: int(c.Units), Pending: referral, Balance: int(balance)}, nil
+}
+
+// RewardReferral credits the referee and the referrer for the user's pending referral redemption,
+// if any. It returns false when there was nothing to reward, so repeated calls are safe.
+func RewardReferral(userExternalID string) (bool, error) {
+	ctx := context.Background()
+	// ensure the referee's free_credit row exists before crediting it
+	if _, err := GetFreeCredit(userExternalID); err != nil {
+		return false, err
+	}
+	hashed := HashExternalID(userExternalID)
+	now := time.Now()
+
+	tx, err := database.GetDB().BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin referral reward transaction: %w", err)
+	}
+	defer tx.Rollback()
+	qtx := q.WithTx(tx)
+
+	r, err := qtx.GetPendingReferralRedemption(ctx, hashed)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("error reading pending referral: %w", err)
+	}
+	n, err := qtx.MarkCampaignRedemptionRewarded(ctx, sqldb.MarkCampaignRedemptionRewardedParams{
+		ID:         r.ID,
+		RewardedAt: sql.NullInt64{Int64: now.UnixMilli(), Valid: true},
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to mark referral rewarded: %w", err)
+	}
+	if n == 0 {
+		return false, nil
+	}
+	actor := campaignActor(r.Code)
+	if r.Units > 0 {
+		if _, err := adjustFreeCreditsTx(ctx, qtx, hashed, int(r.Units), "referral bonus", actor, sql.NullInt64{}); err != nil {
+			return false, err
+		}
+	}
+	if r.ReferrerUnits > 0 {
+		referrer := r.ReferrerUserExternalID.String
+		// the referrer's account exists (campaign references it); make sure its free_credit row does too
+		if _, err := qtx.UpsertAndGetFreeCredit(ctx, freeCreditPolicy(referrer, now)); err != nil {
+			return false, fmt.Errorf("error getting/creating referrer free_credit: %w", err)
+		}
+		if _, err := adjustFreeCreditsTx(ctx, qtx, referrer, int(r.ReferrerUnits), "referral reward", actor, sql.NullInt64{}); err != nil {
+			return false, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit referral reward: %w", err)
+	}
+	return true, nil
+}
@@ -0,0 +1,159 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/tbeaudouin05/stripe-trellai/api/database"
+	sqldb "github.com/tbeaudouin05/stripe-trellai/internal/autogenerated/sqldb"
+)
+
+// CreditAdjustment is the outcome of an admin grant or revocation of free credit.
+type CreditAdjustment struct {
+	GrantID int64 `json:"grant_id"`
+	// Applied is the change actually made to the balance (revocations are clamped at 0).
+	Applied int `json:"applied"`
+	Balance int `json:"balance"`
+}
+
+// GrantFreeCredits adds amount to the user's free credit and records it in the credit_grant
+// audit trail. expiresAt (unix ms) is optional; when non-zero, whatever is left of the grant
+// is removed from the balance at that time.
+func GrantFreeCredits(userExternalID string, amount int, reason, actor string, expiresAt int64) (CreditAdjustment, error) {
+	if amount <= 0 {
+		return CreditAdjustment{}, fmt.Errorf("amount must be > 0")
+	}
+	return adjustFreeCredits(userExternalID, amount, reason, actor, sql.NullInt64{Int64: expiresAt, Valid: expiresAt > 0})
+}
+
+// RevokeFreeCredits removes up to amount from the user's free credit and records the amount
+// actually removed (as a negative entry) in the credit_grant audit trail.
+func RevokeFreeCredits(userExternalID string, amount int, reason, actor string) (CreditAdjustment, error) {
+	if amount <= 0 {
+		return CreditAdjustment{}, fmt.Errorf("amount must be > 0")
+	}
+	return adjustFreeCredits(userExternalID, -amount, reason, actor, sql.NullInt64{})
+}
+
+func adjustFreeCredits(userExternalID string, delta int, reason, actor string, expiresAt sql.NullInt64) (CreditAdjustment, error) {
+	ctx := context.Background()
+	// ensure the account and free_credit row exist, with expiry and refills applied,
+	// before taking the row lock
+	if _, err := GetFreeCredit(userExternalID); err != nil {
+		return CreditAdjustment{}, err
+	}
+	hashed := HashExternalID(userExternalID)
+	tx, err := database.GetDB().BeginTx(ctx, nil)
+	if err != nil {
+		return CreditAdjustment{}, fmt.Errorf("failed to begin credit grant transaction: %w", err)
+	}
+	defer tx.Rollback()
+	adj, err := adjustFreeCreditsTx(ctx, q.WithTx(tx), hashed, delta, reason, actor, expiresAt)
+	if err != nil {
+		return CreditAdjustment{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return CreditAdjustment{}, fmt.Errorf("failed to commit credit grant: %w", err)
+	}
+	return adj, nil
+}
+
+// adjustFreeCreditsTx applies delta to a (hashed) user's free credit and records the change in
+// credit_grant, using the caller's transaction. The free_credit row must already exist.
+func adjustFreeCreditsTx(ctx context.Context, qtx *sqldb.Queries, hashedUserExternalID string, delta int, reason, actor string, expiresAt sql.NullInt64) (CreditAdjustment, error) {
+	row, err := qtx.AdjustFreeCredit(ctx, sqldb.AdjustFreeCreditParams{
+		UserExternalID: hashedUserExternalID,
+		Delta:          int32(delta),
+	})
+	if err != nil {
+		return CreditAdjustment{}, fmt.Errorf("failed to adjust free_credit: %w", err)
+	}
+	if row.Applied < 0 {
+		if err := qtx.DrawDownCreditGrants(ctx, hashedUserExternalID); err != nil {
+			return CreditAdjustment{}, fmt.Errorf("failed to draw down credit grants: %w", err)
+		}
+	}
+	id, err := qtx.InsertCreditGrant(ctx, sqldb.InsertCreditGrantParams{
+		UserExternalID: hashedUserExternalID,
+		Amount:         row.Applied,
+		Reason:         reason,
+		Actor:          actor,
+		ExpiresAt:      expiresAt,
+	})
+	if err != nil {
+		return CreditAdjustment{}, fmt.Errorf("failed to insert credit_grant: %w", err)
+	}
+	return CreditAdjustment{GrantID: id, Applied: int(row.Applied), Balance: int(row.Credit)}, nil
+}
+
+// ExpireCreditGrants removes what is left of every admin grant past its expires_at from the
+// grantee's free credit and returns how many grants expired. GetFreeCredit does the same
+// lazily for the user it reads.
+func ExpireCreditGrants() (int, error) {
+	return expireCreditGrants(sql.NullString{}, time.Now().UnixMilli())
+}
+
+// expireCreditGrants expires due grants, for one (hashed) user when hashedUserExternalID is set.
+// Each grant expires in its own transaction; marking it expired first makes this safe to run
+// concurrently with itself.
+func expireCreditGrants(hashedUserExternalID sql.NullString, now int64) (int, error) {
+	ctx := context.Background()
+	due, err := q.ListDueCreditGrants(ctx, sqldb.ListDueCreditGrantsParams{
+		Now:            now,
+		UserExternalID: hashedUserExternalID,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("error listing due credit grants: %w", err)
+	}
+	var expired int
+	for _, g := range due {
+		ok, err := expireCreditGrant(g, now)
+		if err != nil {
+			return expired, err
+		}
+		if ok {
+			expired++
+		}
+	}
+	return expired, nil
+}
+
+func expireCreditGrant(g sqldb.ListDueCreditGrantsRow, now int64) (bool, error) {
+	ctx := context.Background()
+	tx, err := database.GetDB().BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin grant expiry transaction: %w", err)
+	}
+	defer tx.Rollback()
+	qtx := q.WithTx(tx)
+
+	// lock the balance first, in the same order as spending, which draws grants down
+	if _, err := qtx.LockFreeCredit(ctx, g.UserExternalID); err != nil && err != sql.ErrNoRows {
+		return false, fmt.Errorf("failed to lock free_credit: %w", err)
+	}
+	remaining, err := qtx.MarkCreditGrantExpired(ctx, sqldb.MarkCreditGrantExpiredParams{
+		ID:        g.ID,
+		ExpiredAt: sql.NullInt64{Int64: now, Valid: true},
+	})
+	if err == sql.ErrNoRows {
+		// expired concurrently
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to mark credit_grant expired: %w", err)
+	}
+	if remaining > 0 {
+		if _, err := qtx.AdjustFreeCredit(ctx, sqldb.AdjustFreeCreditParams{
+			UserExternalID: g.UserExternalID,
+			Delta:          -remaining,
+		}); err != nil && err != sql.ErrNoRows {
+			return false, fmt.Errorf("failed to remove expired grant from free_credit: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit grant expiry: %w", err)
+	}
+	return true, nil
+}
@@ -5,8 +5,11 @@ import (
 	"crypto/sha256"
 	"database/sql"
 	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"strconv"
+	"time"
 
 	"github.com/lib/pq"
 	config "github.com/tbeaudouin05/stripe-trellai/api/config"
@@ -28,6 +31,14 @@ type SpendingUnit struct {
 	UserExternalID string
 	Amount         int
 	CreatedAt      int64
+	// MemberExternalID is the organization member who spent the units when UserExternalID
+	// is their organization's pooled account; empty otherwise.
+	MemberExternalID string
+	// APIKeyID identifies the API key the units were spent with, as given; optional.
+	APIKeyID string
+	// FeatureKey and Labels are the optional dimensions the units are reported under.
+	FeatureKey string
+	Labels     map[string]string
 }
 
 // hashExternalID returns a deterministic SHA-256 hex digest of the provided
@@ -66,77 +77,276 @@ func isForeignKeyViolation(err error) bool {
 // created_at is expected to be in unix milliseconds.
 func AddSpendingUnits(items []SpendingUnit) (int, error) {
 	ctx := context.Background()
+	params, err := spendingUnitParams(items)
+	if err != nil {
+		return 0, err
+	}
+	var total int
+	for i, p := range params {
+		insertedInt, err := insertSpendingUnit(ctx, p)
+		if err != nil {
+			return 0, fmt.Errorf("item %d: %w", i, err)
+		}
+		total += insertedInt
+	}
+	return total, nil
+}
+
+// spendingUnitParams validates items and returns their insert parameters, with identifiers
+// hashed. It makes sure every user has a free_credit row first.
+func spendingUnitParams(items []SpendingUnit) ([]sqldb.InsertSpendingUnitParams, error) {
 	// Ensure we only initialize free_credit once per user in this batch
 	ensured := make(map[string]bool)
-	var total int
+	params := make([]sqldb.InsertSpendingUnitParams, 0, len(items))
 	for i, it := range items {
 		if it.ExternalID == "" || it.UserExternalID == "" {
-			return 0, fmt.Errorf("item %d: missing external_id or user_external_id", i)
+			return nil, fmt.Errorf("item %d: missing external_id or user_external_id", i)
 		}
 		if it.Amount <= 0 {
-			return 0, fmt.Errorf("item %d: amount must be > 0", i)
+			return nil, fmt.Errorf("item %d: amount must be > 0", i)
 		}
 		// created_at must be provided (unix ms)
 		if it.CreatedAt == 0 {
-			return 0, fmt.Errorf("item %d: created_at is required", i)
+			return nil, fmt.Errorf("item %d: created_at is required", i)
 		}
 		// lazily ensure a free_credit row exists for this user.
 		// Pass RAW user ID here because GetFreeCredit hashes internally.
 		if !ensured[it.UserExternalID] {
 			if _, err := GetFreeCredit(it.UserExternalID); err != nil {
-				return 0, fmt.Errorf("failed to ensure free credit for user %q: %w", it.UserExternalID, err)
+				return nil, fmt.Errorf("failed to ensure free credit for user %q: %w", it.UserExternalID, err)
 			}
 			ensured[it.UserExternalID] = true
 		}
 
-		// Always hash external_id before persisting to avoid storing raw identifiers.
-		hashedExternalID := HashExternalID(it.ExternalID)
-		// Hash user ID for direct SQL inserts below
-		hashedUserID := HashExternalID(it.UserExternalID)
+		labels := []byte("{}")
+		if len(it.Labels) > 0 {
+			var err error
+			if labels, err = json.Marshal(it.Labels); err != nil {
+				return nil, fmt.Errorf("item %d: invalid labels: %w", i, err)
+			}
+		}
+
+		var hashedMemberID sql.NullString
+		if it.MemberExternalID != "" {
+			hashedMemberID = toNullString(HashExternalID(it.MemberExternalID))
+		}
+
+		// Always hash identifiers before persisting to avoid storing raw identifiers.
+		params = append(params, sqldb.InsertSpendingUnitParams{
+			ExternalID:       HashExternalID(it.ExternalID),
+			UserExternalID:   HashExternalID(it.UserExternalID),
+			Amount:           int32(it.Amount),
+			CreatedAt:        it.CreatedAt,
+			MemberExternalID: hashedMemberID,
+			ApiKeyID:         toNullString(it.APIKeyID),
+			FeatureKey:       toNullString(it.FeatureKey),
+			Labels:           labels,
+		})
+	}
+	return params, nil
+}
+
+// insertSpendingUnit inserts one spending unit and consumes the credit it is paid with in a
+// single transaction, so the unit is never visible (e.g. to metered usage batches) without its
+// credit consumption. It returns 0 when the unit was already recorded.
+func insertSpendingUnit(ctx context.Context, p sqldb.InsertSpendingUnitParams) (int, error) {
+	tx, err := database.GetDB().BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin spending_unit transaction: %w", err)
+	}
+	defer tx.Rollback()
+	n, err := insertSpendingUnitTx(ctx, q.WithTx(tx), p)
+	if err != nil || n == 0 {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit spending_unit: %w", err)
+	}
+	return n, nil
+}
+
+// insertSpendingUnitTx is insertSpendingUnit within the caller's transaction.
+func insertSpendingUnitTx(ctx context.Context, qtx *sqldb.Queries, p sqldb.InsertSpendingUnitParams) (int, error) {
+	inserted, err := qtx.InsertSpendingUnit(ctx, p)
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert spending_unit: %w", err)
+	}
+	// Normalize the inserted value and detect whether this row was actually inserted
+	n, err := toInt(inserted)
+	if err != nil {
+		return 0, err
+	}
+	if n == 0 {
+		return 0, nil
+	}
 
-		inserted, err := q.InsertSpendingUnit(ctx, sqldb.InsertSpendingUnitParams{
-			ExternalID:     hashedExternalID,
-			UserExternalID: hashedUserID,
-			Amount:         int32(it.Amount),
-			CreatedAt:      it.CreatedAt,
+	// Consume free credit for this user by 'amount', then purchased credit for the remainder,
+	// and remember how much of each was consumed so a later refund can restore it.
+	consumed, err := qtx.ConsumeFreeCredit(ctx, sqldb.ConsumeFreeCreditParams{
+		UserExternalID: p.UserExternalID,
+		Amount:         p.Amount,
+	})
+	if err != nil && err != sql.ErrNoRows {
+		return 0, fmt.Errorf("failed to consume free credit: %w", err)
+	}
+	if consumed > 0 {
+		if err := qtx.DrawDownCreditGrants(ctx, p.UserExternalID); err != nil {
+			return 0, fmt.Errorf("failed to draw down credit grants: %w", err)
+		}
+	}
+	var purchasedConsumed int32
+	if remaining := p.Amount - consumed; remaining > 0 {
+		purchasedConsumed, err = qtx.ConsumePurchasedCredit(ctx, sqldb.ConsumePurchasedCreditParams{
+			UserExternalID: p.UserExternalID,
+			Amount:         int64(remaining),
 		})
+		if err != nil && err != sql.ErrNoRows {
+			return 0, fmt.Errorf("failed to consume purchased credit: %w", err)
+		}
+	}
+	if consumed > 0 || purchasedConsumed > 0 {
+		if err := qtx.SetSpendingUnitCreditConsumed(ctx, sqldb.SetSpendingUnitCreditConsumedParams{
+			ExternalID:              p.ExternalID,
+			FreeCreditConsumed:      consumed,
+			PurchasedCreditConsumed: purchasedConsumed,
+		}); err != nil {
+			return 0, fmt.Errorf("failed to record credit consumption: %w", err)
+		}
+	}
+	return n, nil
+}
+
+// ErrSpendingUnitNotFound is returned when a refund references an unknown spending unit.
+var ErrSpendingUnitNotFound = errors.New("spending unit not found")
+
+// RefundSpendingUnits records a compensating entry for each referenced spending unit.
+// Each refund runs in its own transaction and restores any free or purchased credit
+// the original entry consumed. Units that were already refunded are skipped, so retries are safe.
+// Returns the number of spending units actually refunded and the accounts they were refunded to
+// (hashed, as stored). On error, both cover the refunds committed before it.
+func RefundSpendingUnits(externalIDs []string) (int, []string, error) {
+	var (
+		total    int
+		accounts []string
+	)
+	seen := make(map[string]bool)
+	for i, externalID := range externalIDs {
+		if externalID == "" {
+			return total, accounts, fmt.Errorf("item %d: missing external_id", i)
+		}
+		account, refunded, err := refundSpendingUnit(HashExternalID(externalID))
 		if err != nil {
-			return 0, fmt.Errorf("failed to insert spending_unit (item %d): %w", i, err)
+			return total, accounts, fmt.Errorf("item %d: %w", i, err)
 		}
-		// Normalize the inserted value and detect whether this row was actually inserted
-		insertedInt := 0
-		switch v := inserted.(type) {
-		case int:
-			insertedInt = v
-		case int32:
-			insertedInt = int(v)
-		case int64:
-			insertedInt = int(v)
-		case float64:
-			insertedInt = int(v)
-		case []uint8:
-			// some drivers may return numeric aggregates as []byte
-			n, perr := strconv.ParseInt(string(v), 10, 64)
-			if perr != nil {
-				return 0, fmt.Errorf("unexpected inserted type []uint8 parse error: %w", perr)
-			}
-			insertedInt = int(n)
-		default:
-			return 0, fmt.Errorf("unexpected inserted type %T", v)
+		if !refunded {
+			continue
 		}
-		total += insertedInt
+		total++
+		if !seen[account] {
+			seen[account] = true
+			accounts = append(accounts, account)
+		}
+	}
+	return total, accounts, nil
+}
 
-		// If we actually inserted the spending unit, consume free credit for this user by 'amount'
-		if insertedInt > 0 {
-			if err := q.ConsumeFreeCredit(ctx, sqldb.ConsumeFreeCreditParams{
-				UserExternalID: hashedUserID,
-				Credit:         int32(it.Amount),
-			}); err != nil {
-				return 0, fmt.Errorf("failed to consume free credit (item %d): %w", i, err)
-			}
+// refundSpendingUnit refunds a single spending unit identified by its hashed external ID.
+// It returns the (hashed) account the unit was spent from, and false when a compensating entry
+// already exists.
+func refundSpendingUnit(hashedExternalID string) (string, bool, error) {
+	ctx := context.Background()
+	tx, err := database.GetDB().BeginTx(ctx, nil)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to begin refund transaction: %w", err)
+	}
+	defer tx.Rollback()
+	qtx := q.WithTx(tx)
+
+	orig, err := qtx.GetSpendingUnitByExternalID(ctx, hashedExternalID)
+	if err == sql.ErrNoRows {
+		return "", false, ErrSpendingUnitNotFound
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed to load spending_unit: %w", err)
+	}
+	if orig.RefundOfExternalID.Valid {
+		return "", false, fmt.Errorf("spending unit is itself a refund and cannot be refunded")
+	}
+	inserted, err := qtx.InsertSpendingUnitRefund(ctx, sqldb.InsertSpendingUnitRefundParams{
+		// Derive the compensating entry's ID from the original so repeated refunds collide. Client
+		// IDs are stored as hex digests, so the ':' keeps refund IDs out of their namespace.
+		ExternalID:              refundExternalID(hashedExternalID),
+		UserExternalID:          orig.UserExternalID,
+		Amount:                  -orig.Amount,
+		FreeCreditConsumed:      -orig.FreeCreditConsumed,
+		PurchasedCreditConsumed: -orig.PurchasedCreditConsumed,
+		RefundOfExternalID:      toNullString(hashedExternalID),
+		CreatedAt:               orig.CreatedAt,
+		MemberExternalID:        orig.MemberExternalID,
+		ApiKeyID:                orig.ApiKeyID,
+		FeatureKey:              orig.FeatureKey,
+		Labels:                  orig.Labels,
+	})
+	if err != nil {
+		return "", false, fmt.Errorf("failed to insert refund spending_unit: %w", err)
+	}
+	n, err := toInt(inserted)
+	if err != nil {
+		return "", false, err
+	}
+	if n == 0 {
+		return orig.UserExternalID, false, nil
+	}
+	if orig.FreeCreditConsumed > 0 {
+		if err := qtx.RestoreFreeCredit(ctx, sqldb.RestoreFreeCreditParams{
+			UserExternalID: orig.UserExternalID,
+			Credit:         orig.FreeCreditConsumed,
+		}); err != nil {
+			return "", false, fmt.Errorf("failed to restore free credit: %w", err)
 		}
 	}
-	return total, nil
+	if orig.PurchasedCreditConsumed > 0 {
+		if err := qtx.AddPurchasedCredit(ctx, sqldb.AddPurchasedCreditParams{
+			UserExternalID: orig.UserExternalID,
+			Credit:         int64(orig.PurchasedCreditConsumed),
+		}); err != nil {
+			return "", false, fmt.Errorf("failed to restore purchased credit: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return "", false, fmt.Errorf("failed to commit refund: %w", err)
+	}
+	return orig.UserExternalID, true, nil
+}
+
+// refundExternalID is the external_id of the compensating entry of a spending unit.
+func refundExternalID(hashedExternalID string) string {
+	return "refund:" + hashedExternalID
+}
+
+// toInt normalizes numeric aggregates returned by sqlc as interface{}.
+func toInt(v interface{}) (int, error) {
+	switch n := v.(type) {
+	case int:
+		return n, nil
+	case int32:
+		return int(n), nil
+	case int64:
+		return int(n), nil
+	case float64:
+		return int(n), nil
+	case []uint8:
+		// some drivers may return numeric aggregates as []byte
+		i, err := strconv.ParseInt(string(n), 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("unexpected numeric type []uint8 parse error: %w", err)
+		}
+		return int(i), nil
+	case nil:
+		return 0, nil
+	default:
+		return 0, fmt.Errorf("unexpected numeric type %T", n)
+	}
 }
 
 // toNullString converts empty strings to NULLs to match nullable columns
@@ -165,36 +375,15 @@ func CheckUserAccount(userExternalID string) (bool, string, error) {
 	return true, "", nil
 }
 
-// InsertInvalidSubscription inserts a record into invalid_subscription table
+// InsertInvalidSubscription records a duplicate subscription queued for manual review.
 func InsertInvalidSubscription(userExternalID, stripeSubscriptionID, stripePlanID, stripeCustomerID string) error {
-	ctx := context.Background()
-	// hash the user ID before inserting
-	hashed := HashExternalID(userExternalID)
-	err := q.InsertInvalidSubscription(ctx, sqldb.InsertInvalidSubscriptionParams{
-		UserExternalID:       hashed,
-		StripeSubscriptionID: toNullString(stripeSubscriptionID),
-		StripePlanID:         toNullString(stripePlanID),
-		StripeCustomerID:     toNullString(stripeCustomerID),
+	_, err := RecordInvalidSubscription(InvalidSubscription{
+		UserExternalID:       userExternalID,
+		StripeSubscriptionID: stripeSubscriptionID,
+		StripePlanID:         stripePlanID,
+		StripeCustomerID:     stripeCustomerID,
 	})
-	if err != nil {
-		if isForeignKeyViolation(err) {
-			if upErr := UpsertUserAccount(userExternalID, "", "", ""); upErr != nil {
-				return fmt.Errorf("failed to ensure user_account after FK violation: %w", upErr)
-			}
-			// retry once
-			if err2 := q.InsertInvalidSubscription(ctx, sqldb.InsertInvalidSubscriptionParams{
-				UserExternalID:       hashed,
-				StripeSubscriptionID: toNullString(stripeSubscriptionID),
-				StripePlanID:         toNullString(stripePlanID),
-				StripeCustomerID:     toNullString(stripeCustomerID),
-			}); err2 != nil {
-				return fmt.Errorf("failed to insert invalid_subscription after ensuring user: %w", err2)
-			}
-			return nil
-		}
-		return fmt.Errorf("failed to insert invalid_subscription: %w", err)
-	}
-	return nil
+	return err
 }
 
 // UpsertUserAccount upserts a record into user_account table
@@ -260,16 +449,34 @@ func GetUserAccount(userExternalID string) (UserAccount, error) {
 	return ua, nil
 }
 
+// freeCreditPolicy returns the upsert parameters implementing the configured expiry
+// (FREE_CREDIT_TTL_DAYS) and monthly refill (FREE_CREDIT_MONTHLY_REFILL) policy at now.
+func freeCreditPolicy(hashedUserExternalID string, now time.Time) sqldb.UpsertAndGetFreeCreditParams {
+	now = now.UTC()
+	p := sqldb.UpsertAndGetFreeCreditParams{
+		UserExternalID: hashedUserExternalID,
+		Credit:         int32(config.AppConfig.InitialFreeCredit),
+		PeriodStart:    time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).UnixMilli(),
+		Refill:         int32(config.AppConfig.FreeCreditMonthlyRefill),
+		Now:            now.UnixMilli(),
+	}
+	if days := config.AppConfig.FreeCreditTTLDays; days > 0 {
+		p.ExpiresAt = sql.NullInt64{Int64: now.AddDate(0, 0, days).UnixMilli(), Valid: true}
+	}
+	return p
+}
+
 // GetFreeCredit retrieves free_credit amount for a user.
 // If no record exists, it creates one with the default InitialFreeCredit value.
+// Expired credit and grants, and pending monthly refills, are applied before the balance is returned.
 func GetFreeCredit(userExternalID string) (int, error) {
 	ctx := context.Background()
 	// hash the user ID before querying/upserting
 	hashed := HashExternalID(userExternalID)
-	credit, err := q.UpsertAndGetFreeCredit(ctx, sqldb.UpsertAndGetFreeCreditParams{
-		UserExternalID: hashed,
-		Credit:         int32(config.AppConfig.InitialFreeCredit),
-	})
+	if _, err := expireCreditGrants(toNullString(hashed), time.Now().UnixMilli()); err != nil {
+		return 0, err
+	}
+	credit, err := q.UpsertAndGetFreeCredit(ctx, freeCreditPolicy(hashed, time.Now()))
 	if err != nil {
 		// If the free_credit upsert fails due to FK violation (missing user_account),
 		// create the user on-demand and retry once.
@@ -277,10 +484,7 @@ func GetFreeCredit(userExternalID string) (int, error) {
 			if upErr := UpsertUserAccount(userExternalID, "", "", ""); upErr != nil {
 				return 0, fmt.Errorf("error ensuring user_account after FK violation: %w", upErr)
 			}
-			credit, err = q.UpsertAndGetFreeCredit(ctx, sqldb.UpsertAndGetFreeCreditParams{
-				UserExternalID: hashed,
-				Credit:         int32(config.AppConfig.InitialFreeCredit),
-			})
+			credit, err = q.UpsertAndGetFreeCredit(ctx, freeCreditPolicy(hashed, time.Now()))
 		}
 		if err != nil {
 			return 0, fmt.Errorf("error getting/creating free_credit: %w", err)
@@ -289,38 +493,31 @@ func GetFreeCredit(userExternalID string) (int, error) {
 	return int(credit), nil
 }
 
-// CountUnitsBetween sums spending units for a given user between start and end (inclusive).
-func CountUnitsBetween(userExternalID string, start, end int64) (int, error) {
+// RefreshFreeCredits expires due admin grants, then applies the expiry and monthly refill
+// policy to every free_credit row that is due, and returns how many rows changed.
+// GetFreeCredit applies the same policy lazily; this keeps balances current for users
+// who are not active.
+func RefreshFreeCredits() (int64, error) {
 	ctx := context.Background()
-	// hash the user ID before querying
-	hashed := HashExternalID(userExternalID)
-	c, err := q.CountUnitsBetween(ctx, sqldb.CountUnitsBetweenParams{
-		UserExternalID: hashed,
-		CreatedAt:      start,
-		CreatedAt_2:    end,
-	})
+	expired, err := ExpireCreditGrants()
 	if err != nil {
-		return 0, fmt.Errorf("error summing spending units: %w", err)
+		return 0, err
 	}
-	switch v := c.(type) {
-	case int64:
-		return int(v), nil
-	case int32:
-		return int(v), nil
-	case int:
-		return v, nil
-	case float64:
-		return int(v), nil
-	case []uint8:
-		// some drivers may return numeric aggregates as []byte
-		i, perr := strconv.ParseInt(string(v), 10, 64)
-		if perr != nil {
-			return 0, fmt.Errorf("unexpected count type []uint8 parse error: %w", perr)
-		}
-		return int(i), nil
-	case nil:
-		return 0, nil
-	default:
-		return 0, fmt.Errorf("unexpected count type %T", v)
+	p := freeCreditPolicy("", time.Now())
+	n, err := q.RefreshFreeCredits(ctx, sqldb.RefreshFreeCreditsParams{
+		Refill:      p.Refill,
+		PeriodStart: p.PeriodStart,
+		Now:         p.Now,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("error refreshing free_credit: %w", err)
 	}
+	return n + int64(expired), nil
+}
+
+// CountUnitsBetween sums the units a user spent between start and end (inclusive) that count
+// against the subscription allowance: those credit did not pay for. Verification, allowance
+// alerts, entitlements and overage all count units this way.
+func CountUnitsBetween(userExternalID string, start, end int64) (int, error) {
+	return CountStoredUnitsBetween(HashExternalID(userExternalID), start, end)
 }
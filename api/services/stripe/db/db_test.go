@@ -4,8 +4,10 @@ import (
     "crypto/sha256"
     "database/sql"
     "encoding/hex"
+    "errors"
     "fmt"
     "testing"
+    "time"
 
     config "github.com/tbeaudouin05/stripe-trellai/api/config"
     database "github.com/tbeaudouin05/stripe-trellai/api/database"
@@ -40,7 +42,7 @@ func TestMain(m *testing.M) {
     }
     // Pre-test cleanup for IDs used in this package
     dbc := database.GetDB()
-    ids := []string{"db-test-board", "db-test-ticket-board", "db-test-free-credit", "dup-board", "test-check-board"}
+    ids := []string{"db-test-board", "db-test-ticket-board", "db-test-free-credit", "dup-board", "test-check-board", "db-test-refund", "db-test-free-credit-policy", "db-test-credit-grant"}
     for _, id := range ids {
         hid := hash(id)
         _, _ = dbc.Exec("DELETE FROM spending_unit WHERE user_external_id = $1", hid)
@@ -273,3 +275,292 @@ func TestCheckUserAccount(t *testing.T) {
         t.Fatalf("CheckUserAccount expected (true, sub1), got (%v, %v), err %v", exists, subID, err)
     }
 }
+
+func TestRefundSpendingUnits(t *testing.T) {
+    id := "db-test-refund"
+    hid := hash(id)
+    // cleanup
+    defer database.GetDB().Exec("DELETE FROM user_account WHERE user_external_id = $1", hid)
+    defer database.GetDB().Exec("DELETE FROM free_credit WHERE user_external_id = $1", hid)
+    defer database.GetDB().Exec("DELETE FROM spending_unit WHERE user_external_id = $1", hid)
+
+    initial, err := stripedb.GetFreeCredit(id)
+    if err != nil {
+        t.Fatalf("GetFreeCredit failed: %v", err)
+    }
+    now := int64(1713800000000)
+    if _, err := stripedb.AddSpendingUnits([]stripedb.SpendingUnit{{ExternalID: "db-refund-unit", UserExternalID: id, Amount: 3, CreatedAt: now}}); err != nil {
+        t.Fatalf("AddSpendingUnits failed: %v", err)
+    }
+
+    // first refund compensates the unit and restores the consumed free credit
+    n, _, err := stripedb.RefundSpendingUnits([]string{"db-refund-unit"})
+    if err != nil {
+        t.Fatalf("RefundSpendingUnits failed: %v", err)
+    }
+    if n != 1 {
+        t.Errorf("Expected 1 refunded unit, got %d", n)
+    }
+    credit, err := stripedb.GetFreeCredit(id)
+    if err != nil {
+        t.Fatalf("GetFreeCredit failed: %v", err)
+    }
+    if credit != initial {
+        t.Errorf("Expected free credit restored to %d, got %d", initial, credit)
+    }
+    count, err := stripedb.CountUnitsBetween(id, now, now)
+    if err != nil {
+        t.Fatalf("CountUnitsBetween failed: %v", err)
+    }
+    if count != 0 {
+        t.Errorf("Expected refunded units to net to 0, got %d", count)
+    }
+
+    // second refund is a no-op
+    n, _, err = stripedb.RefundSpendingUnits([]string{"db-refund-unit"})
+    if err != nil {
+        t.Fatalf("RefundSpendingUnits (retry) failed: %v", err)
+    }
+    if n != 0 {
+        t.Errorf("Expected retry to refund 0 units, got %d", n)
+    }
+
+    // a client ID that merely starts with "refund" is an ordinary unit
+    lookalike := "refund" + hash("db-refund-unit")
+    inserted, err := stripedb.AddSpendingUnits([]stripedb.SpendingUnit{{ExternalID: lookalike, UserExternalID: id, Amount: 1, CreatedAt: now}})
+    if err != nil {
+        t.Fatalf("AddSpendingUnits (lookalike) failed: %v", err)
+    }
+    if inserted != 1 {
+        t.Errorf("Expected the lookalike unit to be inserted, got %d", inserted)
+    }
+
+    // unknown units are reported
+    if _, _, err := stripedb.RefundSpendingUnits([]string{"db-refund-unknown"}); err == nil {
+        t.Errorf("Expected error refunding unknown unit")
+    }
+}
+
+func TestFreeCreditExpiryAndRefill(t *testing.T) {
+    id := "db-test-free-credit-policy"
+    hid := hash(id)
+    defer database.GetDB().Exec("DELETE FROM free_credit WHERE user_external_id = $1", hid)
+    defer database.GetDB().Exec("DELETE FROM user_account WHERE user_external_id = $1", hid)
+    prevRefill := config.AppConfig.FreeCreditMonthlyRefill
+    defer func() { config.AppConfig.FreeCreditMonthlyRefill = prevRefill }()
+    config.AppConfig.FreeCreditMonthlyRefill = 0
+
+    if _, err := stripedb.GetFreeCredit(id); err != nil {
+        t.Fatalf("GetFreeCredit failed: %v", err)
+    }
+
+    // Expired credit is forfeited on read.
+    if _, err := database.GetDB().Exec("UPDATE free_credit SET credit = 7, expires_at = 1 WHERE user_external_id = $1", hid); err != nil {
+        t.Fatalf("Failed to expire credit: %v", err)
+    }
+    credit, err := stripedb.GetFreeCredit(id)
+    if err != nil {
+        t.Fatalf("GetFreeCredit failed: %v", err)
+    }
+    if credit != 0 {
+        t.Errorf("Expected expired credit to be 0, got %d", credit)
+    }
+
+    // A refill from a previous month resets the balance, once per month.
+    config.AppConfig.FreeCreditMonthlyRefill = 25
+    if _, err := database.GetDB().Exec("UPDATE free_credit SET refilled_at = 1 WHERE user_external_id = $1", hid); err != nil {
+        t.Fatalf("Failed to backdate refill: %v", err)
+    }
+    if _, err := stripedb.RefreshFreeCredits(); err != nil {
+        t.Fatalf("RefreshFreeCredits failed: %v", err)
+    }
+    var refilled int
+    if err := database.GetDB().QueryRow("SELECT credit FROM free_credit WHERE user_external_id = $1", hid).Scan(&refilled); err != nil {
+        t.Fatalf("Failed to read credit: %v", err)
+    }
+    if refilled != 25 {
+        t.Errorf("Expected refilled credit 25, got %d", refilled)
+    }
+    if _, err := database.GetDB().Exec("UPDATE free_credit SET credit = 3 WHERE user_external_id = $1", hid); err != nil {
+        t.Fatalf("Failed to consume credit: %v", err)
+    }
+    credit, err = stripedb.GetFreeCredit(id)
+    if err != nil {
+        t.Fatalf("GetFreeCredit failed: %v", err)
+    }
+    if credit != 3 {
+        t.Errorf("Expected no second refill within the month, got %d", credit)
+    }
+
+    // A refill tops the balance up; unexpired credit above the refill amount is kept.
+    if _, err := database.GetDB().Exec("UPDATE free_credit SET credit = 40, refilled_at = 1 WHERE user_external_id = $1", hid); err != nil {
+        t.Fatalf("Failed to backdate refill: %v", err)
+    }
+    credit, err = stripedb.GetFreeCredit(id)
+    if err != nil {
+        t.Fatalf("GetFreeCredit failed: %v", err)
+    }
+    if credit != 40 {
+        t.Errorf("Expected unused credit 40 to be kept, got %d", credit)
+    }
+}
+
+func TestGrantAndRevokeFreeCredits(t *testing.T) {
+    id := "db-test-credit-grant"
+    hid := hash(id)
+    defer database.GetDB().Exec("DELETE FROM credit_grant WHERE user_external_id = $1", hid)
+    defer database.GetDB().Exec("DELETE FROM free_credit WHERE user_external_id = $1", hid)
+    defer database.GetDB().Exec("DELETE FROM user_account WHERE user_external_id = $1", hid)
+
+    initial, err := stripedb.GetFreeCredit(id)
+    if err != nil {
+        t.Fatalf("GetFreeCredit failed: %v", err)
+    }
+    adj, err := stripedb.GrantFreeCredits(id, 40, "goodwill", "support", 0)
+    if err != nil {
+        t.Fatalf("GrantFreeCredits failed: %v", err)
+    }
+    if adj.Balance != initial+40 {
+        t.Errorf("Expected balance %d after grant, got %d", initial+40, adj.Balance)
+    }
+
+    // Revocations are clamped at 0 and audit the amount actually removed.
+    adj, err = stripedb.RevokeFreeCredits(id, initial+1000, "abuse", "support")
+    if err != nil {
+        t.Fatalf("RevokeFreeCredits failed: %v", err)
+    }
+    if adj.Balance != 0 || adj.Applied != -(initial+40) {
+        t.Errorf("Expected full revocation to 0, got applied=%d balance=%d", adj.Applied, adj.Balance)
+    }
+
+    // An expiring grant is removed once past its expiry.
+    if _, err := stripedb.GrantFreeCredits(id, 15, "trial extension", "support", 1); err != nil {
+        t.Fatalf("GrantFreeCredits failed: %v", err)
+    }
+    credit, err := stripedb.GetFreeCredit(id)
+    if err != nil {
+        t.Fatalf("GetFreeCredit failed: %v", err)
+    }
+    if credit != 0 {
+        t.Errorf("Expected expired grant to be removed, got %d", credit)
+    }
+
+    var entries int
+    if err := database.GetDB().QueryRow("SELECT COUNT(1) FROM credit_grant WHERE user_external_id = $1", hid).Scan(&entries); err != nil {
+        t.Fatalf("Failed to count credit_grant rows: %v", err)
+    }
+    if entries != 3 {
+        t.Errorf("Expected 3 audit entries, got %d", entries)
+    }
+}
+
+func TestExpiredGrantRemovesWhatIsLeft(t *testing.T) {
+    id := "db-test-credit-grant-remaining"
+    hid := hash(id)
+    defer database.GetDB().Exec("DELETE FROM credit_grant WHERE user_external_id = $1", hid)
+    defer database.GetDB().Exec("DELETE FROM spending_unit WHERE user_external_id = $1", hid)
+    defer database.GetDB().Exec("DELETE FROM free_credit WHERE user_external_id = $1", hid)
+    defer database.GetDB().Exec("DELETE FROM user_account WHERE user_external_id = $1", hid)
+
+    if _, err := stripedb.GetFreeCredit(id); err != nil {
+        t.Fatalf("GetFreeCredit failed: %v", err)
+    }
+    if _, err := database.GetDB().Exec("UPDATE free_credit SET credit = 0 WHERE user_external_id = $1", hid); err != nil {
+        t.Fatalf("Failed to reset credit: %v", err)
+    }
+    future := time.Now().Add(time.Hour).UnixMilli()
+    if _, err := stripedb.GrantFreeCredits(id, 20, "trial extension", "support", future); err != nil {
+        t.Fatalf("GrantFreeCredits failed: %v", err)
+    }
+    // Spending draws the grant down to 5...
+    if _, err := stripedb.AddSpendingUnits([]stripedb.SpendingUnit{
+        {ExternalID: "db-test-grant-unit-1", UserExternalID: id, Amount: 15, CreatedAt: time.Now().UnixMilli()},
+    }); err != nil {
+        t.Fatalf("AddSpendingUnits failed: %v", err)
+    }
+    // ...so its expiry leaves a later grant untouched.
+    if _, err := stripedb.GrantFreeCredits(id, 10, "goodwill", "support", 0); err != nil {
+        t.Fatalf("GrantFreeCredits failed: %v", err)
+    }
+    if _, err := database.GetDB().Exec("UPDATE credit_grant SET expires_at = 1 WHERE user_external_id = $1 AND expires_at IS NOT NULL", hid); err != nil {
+        t.Fatalf("Failed to backdate grant: %v", err)
+    }
+    credit, err := stripedb.GetFreeCredit(id)
+    if err != nil {
+        t.Fatalf("GetFreeCredit failed: %v", err)
+    }
+    if credit != 10 {
+        t.Errorf("Expected only what was left of the expired grant to be removed, got %d", credit)
+    }
+}
+
+func TestRedeemCodeAndReferral(t *testing.T) {
+    code := "DBTESTPROMO"
+    referrer, referee := "db-test-referrer", "db-test-referee"
+    users := []string{hash(referrer), hash(referee)}
+    defer database.GetDB().Exec("DELETE FROM campaign WHERE code = $1", code)
+    for _, h := range users {
+        defer database.GetDB().Exec("DELETE FROM user_account WHERE user_external_id = $1", h)
+        defer database.GetDB().Exec("DELETE FROM free_credit WHERE user_external_id = $1", h)
+        defer database.GetDB().Exec("DELETE FROM credit_grant WHERE user_external_id = $1", h)
+        defer database.GetDB().Exec("DELETE FROM campaign_redemption WHERE user_external_id = $1", h)
+        defer database.GetDB().Exec("DELETE FROM campaign WHERE referrer_user_external_id = $1", h)
+    }
+
+    if _, err := stripedb.CreateCampaign(stripedb.Campaign{Code: code, Units: 25, MaxRedemptions: 10, PerUserLimit: 1}); err != nil {
+        t.Fatalf("CreateCampaign failed: %v", err)
+    }
+    initial, err := stripedb.GetFreeCredit(referee)
+    if err != nil {
+        t.Fatalf("GetFreeCredit failed: %v", err)
+    }
+    r, err := stripedb.RedeemCode(referee, "dbtestpromo", "k1")
+    if err != nil {
+        t.Fatalf("RedeemCode failed: %v", err)
+    }
+    if r.Pending || r.Balance != initial+25 {
+        t.Errorf("Expected immediate credit to %d, got %+v", initial+25, r)
+    }
+    // Retrying with the same key is a no-op; a new key hits the per-user limit.
+    if again, err := stripedb.RedeemCode(referee, code, "k1"); err != nil || again.Balance != initial+25 {
+        t.Errorf("Expected idempotent retry, got %+v err=%v", again, err)
+    }
+    if _, err := stripedb.RedeemCode(referee, code, "k2"); !errors.Is(err, stripedb.ErrRedemptionLimitReached) {
+        t.Errorf("Expected ErrRedemptionLimitReached, got %v", err)
+    }
+
+    // Referral: pending until the referee completes a checkout, then both users are credited.
+    refCode, err := stripedb.ReferralCode(referrer, 10, 30)
+    if err != nil {
+        t.Fatalf("ReferralCode failed: %v", err)
+    }
+    if _, err := stripedb.RedeemCode(referrer, refCode, "self"); !errors.Is(err, stripedb.ErrSelfReferral) {
+        t.Errorf("Expected ErrSelfReferral, got %v", err)
+    }
+    if _, err := stripedb.RedeemCode(referee, refCode, "k1"); !errors.Is(err, stripedb.ErrIdempotencyKeyReused) {
+        t.Errorf("Expected ErrIdempotencyKeyReused, got %v", err)
+    }
+    r, err = stripedb.RedeemCode(referee, refCode, "ref")
+    if err != nil {
+        t.Fatalf("RedeemCode(referral) failed: %v", err)
+    }
+    if !r.Pending || r.Balance != initial+25 {
+        t.Errorf("Expected pending referral without credit, got %+v", r)
+    }
+    referrerBefore, _ := stripedb.GetFreeCredit(referrer)
+    for i := 0; i < 2; i++ {
+        rewarded, err := stripedb.RewardReferral(referee)
+        if err != nil {
+            t.Fatalf("RewardReferral failed: %v", err)
+        }
+        if rewarded != (i == 0) {
+            t.Errorf("RewardReferral call %d: rewarded=%v", i, rewarded)
+        }
+    }
+    if c, _ := stripedb.GetFreeCredit(referee); c != initial+35 {
+        t.Errorf("Expected referee credit %d, got %d", initial+35, c)
+    }
+    if c, _ := stripedb.GetFreeCredit(referrer); c != referrerBefore+30 {
+        t.Errorf("Expected referrer credit %d, got %d", referrerBefore+30, c)
+    }
+}
@@ -0,0 +1,169 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	sqldb "github.com/tbeaudouin05/stripe-trellai/internal/autogenerated/sqldb"
+)
+
+// ErrInvalidSubscriptionNotFound is returned when resolving an unknown invalid_subscription entry.
+var ErrInvalidSubscriptionNotFound = errors.New("invalid subscription not found")
+
+// ErrInvalidSubscriptionResolved is returned when resolving an entry that is no longer pending.
+var ErrInvalidSubscriptionResolved = errors.New("invalid subscription already resolved")
+
+// InvalidSubscription is a subscription checked out while the user already had an active one.
+// Resolution is empty while the entry is queued for manual review. When read back, UserExternalID
+// is the stored (hashed) identifier; ResolvedAt and CreatedAt are unix ms.
+type InvalidSubscription struct {
+	ID                   int64  `json:"id"`
+	UserExternalID       string `json:"user_external_id"`
+	StripeSubscriptionID string `json:"stripe_subscription_id"`
+	StripePlanID         string `json:"stripe_plan_id"`
+	StripeCustomerID     string `json:"stripe_customer_id"`
+	Resolution           string `json:"resolution"`
+	ResolvedBy           string `json:"resolved_by"`
+	ResolvedAt           int64  `json:"resolved_at"`
+	CreatedAt            int64  `json:"created_at"`
+}
+
+// RecordInvalidSubscription inserts s (with a raw user identifier) into invalid_subscription and
+// returns its ID. A non-empty Resolution records a duplicate that was already handled.
+func RecordInvalidSubscription(s InvalidSubscription) (int64, error) {
+	ctx := context.Background()
+	// hash the user ID before inserting
+	hashed := HashExternalID(s.UserExternalID)
+	params := sqldb.InsertInvalidSubscriptionParams{
+		UserExternalID:       hashed,
+		StripeSubscriptionID: toNullString(s.StripeSubscriptionID),
+		StripePlanID:         toNullString(s.StripePlanID),
+		StripeCustomerID:     toNullString(s.StripeCustomerID),
+	}
+	if s.Resolution != "" {
+		params.Resolution = toNullString(s.Resolution)
+		params.ResolvedBy = toNullString(s.ResolvedBy)
+		params.ResolvedAt = sql.NullInt64{Int64: time.Now().UnixMilli(), Valid: true}
+	}
+	id, err := q.InsertInvalidSubscription(ctx, params)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			if upErr := UpsertUserAccount(s.UserExternalID, "", "", ""); upErr != nil {
+				return 0, fmt.Errorf("failed to ensure user_account after FK violation: %w", upErr)
+			}
+			// retry once
+			id, err = q.InsertInvalidSubscription(ctx, params)
+			if err != nil {
+				return 0, fmt.Errorf("failed to insert invalid_subscription after ensuring user: %w", err)
+			}
+			return id, nil
+		}
+		return 0, fmt.Errorf("failed to insert invalid_subscription: %w", err)
+	}
+	return id, nil
+}
+
+// FindInvalidSubscription returns the entry stripeSubscriptionID was recorded with, so that webhook
+// retries don't handle the same duplicate twice. The boolean is false when it was never recorded.
+func FindInvalidSubscription(stripeSubscriptionID string) (InvalidSubscription, bool, error) {
+	row, err := q.GetInvalidSubscriptionBySubscriptionID(context.Background(), toNullString(stripeSubscriptionID))
+	if err == sql.ErrNoRows {
+		return InvalidSubscription{}, false, nil
+	}
+	if err != nil {
+		return InvalidSubscription{}, false, fmt.Errorf("error reading invalid_subscription: %w", err)
+	}
+	return invalidSubscriptionFromRow(sqldb.ListInvalidSubscriptionsRow(row)), true, nil
+}
+
+// GetInvalidSubscription returns an invalid_subscription entry by ID.
+func GetInvalidSubscription(id int64) (InvalidSubscription, error) {
+	row, err := q.GetInvalidSubscription(context.Background(), id)
+	if err == sql.ErrNoRows {
+		return InvalidSubscription{}, ErrInvalidSubscriptionNotFound
+	}
+	if err != nil {
+		return InvalidSubscription{}, fmt.Errorf("error reading invalid_subscription: %w", err)
+	}
+	return invalidSubscriptionFromRow(sqldb.ListInvalidSubscriptionsRow(row)), nil
+}
+
+// ListInvalidSubscriptions returns up to limit entries with an ID greater than afterID, oldest first.
+// Only entries pending review are returned unless includeResolved is set.
+func ListInvalidSubscriptions(afterID int64, limit int, includeResolved bool) ([]InvalidSubscription, error) {
+	rows, err := q.ListInvalidSubscriptions(context.Background(), sqldb.ListInvalidSubscriptionsParams{
+		AfterID:         afterID,
+		IncludeResolved: includeResolved,
+		RowLimit:        int32(limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing invalid_subscription: %w", err)
+	}
+	out := make([]InvalidSubscription, 0, len(rows))
+	for _, row := range rows {
+		out = append(out, invalidSubscriptionFromRow(row))
+	}
+	return out, nil
+}
+
+// ResolveInvalidSubscription marks a pending entry as handled. It returns
+// ErrInvalidSubscriptionResolved when the entry was already resolved.
+func ResolveInvalidSubscription(id int64, resolution, actor string) error {
+	n, err := q.ResolveInvalidSubscription(context.Background(), sqldb.ResolveInvalidSubscriptionParams{
+		ID:         id,
+		Resolution: toNullString(resolution),
+		ResolvedBy: toNullString(actor),
+		ResolvedAt: sql.NullInt64{Int64: time.Now().UnixMilli(), Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("error resolving invalid_subscription: %w", err)
+	}
+	if n == 0 {
+		return ErrInvalidSubscriptionResolved
+	}
+	return nil
+}
+
+// GetSubscriptionIDHashed returns the current subscription of an already hashed user identifier,
+// or "" when the user has none.
+func GetSubscriptionIDHashed(hashedUserExternalID string) (string, error) {
+	id, err := q.GetSubscriptionIDByUserExternalID(context.Background(), hashedUserExternalID)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("error reading user_account: %w", err)
+	}
+	return id.String, nil
+}
+
+// ReplaceSubscriptionHashed points an already hashed user's account at another subscription.
+func ReplaceSubscriptionHashed(hashedUserExternalID, stripeSubscriptionID, stripeCustomerID string) error {
+	err := q.UpsertUserAccount(context.Background(), sqldb.UpsertUserAccountParams{
+		UserExternalID:       hashedUserExternalID,
+		StripeSubscriptionID: toNullString(stripeSubscriptionID),
+		StripePlanID:         toNullString("no_need"),
+		StripeCustomerID:     toNullString(stripeCustomerID),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upsert user_account: %w", err)
+	}
+	return nil
+}
+
+func invalidSubscriptionFromRow(row sqldb.ListInvalidSubscriptionsRow) InvalidSubscription {
+	return InvalidSubscription{
+		ID:                   row.ID,
+		UserExternalID:       row.UserExternalID,
+		StripeSubscriptionID: row.StripeSubscriptionID.String,
+		StripePlanID:         row.StripePlanID.String,
+		StripeCustomerID:     row.StripeCustomerID.String,
+		Resolution:           row.Resolution.String,
+		ResolvedBy:           row.ResolvedBy.String,
+		ResolvedAt:           row.ResolvedAt.Int64,
+		CreatedAt:            row.CreatedAt,
+	}
+}
@@ -0,0 +1,317 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/tbeaudouin05/stripe-trellai/api/database"
+	sqldb "github.com/tbeaudouin05/stripe-trellai/internal/autogenerated/sqldb"
+)
+
+// Organization roles. Owners and admins manage members; only the owner manages admins.
+const (
+	RoleOwner  = "owner"
+	RoleAdmin  = "admin"
+	RoleMember = "member"
+)
+
+// Organization errors.
+var (
+	ErrOrganizationExists    = errors.New("organization already exists")
+	ErrAccountExists         = errors.New("an account already exists for the organization's pooled account")
+	ErrOrganizationNotFound  = errors.New("organization not found")
+	ErrAlreadyInOrganization = errors.New("user already belongs to another organization")
+	ErrMemberNotFound        = errors.New("user is not a member of the organization")
+	ErrNoSeat                = errors.New("user has no seat")
+)
+
+// Membership is the organization a user belongs to and their role in it.
+type Membership struct {
+	OrganizationID int64  `json:"organization_id"`
+	Role           string `json:"role"`
+	// Seated is true when the member was assigned a seat.
+	Seated bool `json:"seated"`
+}
+
+// BilledAccount is the account a user's subscription checks and spending are billed to.
+// Account is the identifier the functions of this package take for it (they hash it, as any
+// user identifier): an organization's pooled account, or the user's own.
+type BilledAccount struct {
+	Account string `json:"account"`
+	// Member is true when Account is the pooled account of an organization the user belongs to.
+	Member bool `json:"member"`
+	// Seated is false for organization members without a seat.
+	Seated bool `json:"seated"`
+}
+
+// OrganizationAccount returns the identifier of the organization's pooled account, as the
+// functions of this package take it. It is the hashed organization identifier, as stored in
+// organization.external_id, so the pooled account (whose key hashes it once more) lives apart
+// from the account of a user with the same identifier as the organization.
+func OrganizationAccount(orgExternalID string) string {
+	return HashExternalID(orgExternalID)
+}
+
+// OrganizationMember is a member as stored: UserExternalID is hashed; CreatedAt is unix ms.
+type OrganizationMember struct {
+	UserExternalID string `json:"user_external_id"`
+	Role           string `json:"role"`
+	CreatedAt      int64  `json:"created_at"`
+}
+
+// CreateOrganization creates an organization with ownerUserExternalID as its owner, seated, along
+// with its pooled account, and returns its ID. It returns ErrAccountExists when the pooled
+// account already exists, so an organization never takes over an existing account.
+func CreateOrganization(orgExternalID, ownerUserExternalID string) (int64, error) {
+	ctx := context.Background()
+	account := OrganizationAccount(orgExternalID)
+	pooled := HashExternalID(account)
+
+	tx, err := database.GetDB().BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin organization transaction: %w", err)
+	}
+	defer tx.Rollback()
+	qtx := q.WithTx(tx)
+
+	if _, err := qtx.GetOrganizationByExternalID(ctx, account); err == nil {
+		return 0, ErrOrganizationExists
+	} else if err != sql.ErrNoRows {
+		return 0, fmt.Errorf("error reading organization: %w", err)
+	}
+	if _, err := qtx.GetSubscriptionIDByUserExternalID(ctx, pooled); err == nil {
+		return 0, ErrAccountExists
+	} else if err != sql.ErrNoRows {
+		return 0, fmt.Errorf("failed to check user_account: %w", err)
+	}
+	if err := qtx.UpsertUserAccount(ctx, sqldb.UpsertUserAccountParams{UserExternalID: pooled}); err != nil {
+		return 0, fmt.Errorf("failed to upsert user_account: %w", err)
+	}
+	id, err := qtx.InsertOrganization(ctx, sqldb.InsertOrganizationParams{
+		ExternalID:     account,
+		UserExternalID: pooled,
+	})
+	if err == sql.ErrNoRows {
+		return 0, ErrOrganizationExists
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert organization: %w", err)
+	}
+	n, err := qtx.UpsertOrganizationMember(ctx, sqldb.UpsertOrganizationMemberParams{
+		OrganizationID: id,
+		UserExternalID: HashExternalID(ownerUserExternalID),
+		Role:           RoleOwner,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert organization_member: %w", err)
+	}
+	if n == 0 {
+		return 0, ErrAlreadyInOrganization
+	}
+	if _, err := qtx.AssignSeat(ctx, sqldb.AssignSeatParams{
+		OrganizationID: id,
+		UserExternalID: HashExternalID(ownerUserExternalID),
+		SeatAssignedAt: sql.NullInt64{Int64: time.Now().UnixMilli(), Valid: true},
+	}); err != nil {
+		return 0, fmt.Errorf("failed to assign owner seat: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit organization: %w", err)
+	}
+	return id, nil
+}
+
+// GetOrganizationID returns the ID of the organization identified by orgExternalID.
+func GetOrganizationID(orgExternalID string) (int64, error) {
+	return GetAccountOrganizationID(OrganizationAccount(orgExternalID))
+}
+
+// GetAccountOrganizationID returns the ID of the organization whose pooled account is account
+// (see OrganizationAccount).
+func GetAccountOrganizationID(account string) (int64, error) {
+	row, err := q.GetOrganizationByExternalID(context.Background(), account)
+	if err == sql.ErrNoRows {
+		return 0, ErrOrganizationNotFound
+	}
+	if err != nil {
+		return 0, fmt.Errorf("error reading organization: %w", err)
+	}
+	return row.ID, nil
+}
+
+// GetMembership returns the organization the user belongs to. The boolean is false when the user
+// is not a member of any organization.
+func GetMembership(userExternalID string) (Membership, bool, error) {
+	row, err := q.GetMemberOrganization(context.Background(), HashExternalID(userExternalID))
+	if err == sql.ErrNoRows {
+		return Membership{}, false, nil
+	}
+	if err != nil {
+		return Membership{}, false, fmt.Errorf("error reading organization_member: %w", err)
+	}
+	return Membership{OrganizationID: row.ID, Role: row.Role, Seated: row.SeatAssignedAt.Valid}, true, nil
+}
+
+// ResolveAccount returns the account userExternalID is billed to: the pooled account of the
+// organization the user belongs to, else their own. Organization identifiers are not resolved:
+// they are only taken by organization-scoped calls (see GetOrganizationID).
+func ResolveAccount(userExternalID string) (BilledAccount, error) {
+	accounts, err := ResolveAccounts([]string{userExternalID})
+	if err != nil {
+		return BilledAccount{}, err
+	}
+	return accounts[userExternalID], nil
+}
+
+// ResolveAccounts is ResolveAccount for many identifiers at once, keyed by the given (raw)
+// identifiers, with one query.
+func ResolveAccounts(userExternalIDs []string) (map[string]BilledAccount, error) {
+	hashed := make([]string, 0, len(userExternalIDs))
+	for _, id := range userExternalIDs {
+		hashed = append(hashed, HashExternalID(id))
+	}
+	rows, err := q.ListBilledOrganizations(context.Background(), hashed)
+	if err != nil {
+		return nil, fmt.Errorf("error listing organization_member: %w", err)
+	}
+	byHash := make(map[string]BilledAccount, len(rows))
+	for _, row := range rows {
+		byHash[row.BilledExternalID] = BilledAccount{Account: row.ExternalID, Member: true, Seated: row.Seated}
+	}
+	out := make(map[string]BilledAccount, len(userExternalIDs))
+	for i, id := range userExternalIDs {
+		if b, ok := byHash[hashed[i]]; ok {
+			out[id] = b
+		} else {
+			out[id] = BilledAccount{Account: id, Seated: true}
+		}
+	}
+	return out, nil
+}
+
+// UpsertOrganizationMember adds the user to the organization with role, or changes their role if
+// they already are a member. It returns ErrAlreadyInOrganization for members of another organization.
+func UpsertOrganizationMember(organizationID int64, userExternalID, role string) error {
+	n, err := q.UpsertOrganizationMember(context.Background(), sqldb.UpsertOrganizationMemberParams{
+		OrganizationID: organizationID,
+		UserExternalID: HashExternalID(userExternalID),
+		Role:           role,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upsert organization_member: %w", err)
+	}
+	if n == 0 {
+		return ErrAlreadyInOrganization
+	}
+	return nil
+}
+
+// RemoveOrganizationMember removes the user from the organization.
+func RemoveOrganizationMember(organizationID int64, userExternalID string) error {
+	n, err := q.DeleteOrganizationMember(context.Background(), sqldb.DeleteOrganizationMemberParams{
+		OrganizationID: organizationID,
+		UserExternalID: HashExternalID(userExternalID),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete organization_member: %w", err)
+	}
+	if n == 0 {
+		return ErrMemberNotFound
+	}
+	return nil
+}
+
+// ListOrganizationMembers returns the organization's members in the order they joined.
+func ListOrganizationMembers(organizationID int64) ([]OrganizationMember, error) {
+	rows, err := q.ListOrganizationMembers(context.Background(), organizationID)
+	if err != nil {
+		return nil, fmt.Errorf("error listing organization_member: %w", err)
+	}
+	members := make([]OrganizationMember, 0, len(rows))
+	for _, r := range rows {
+		members = append(members, OrganizationMember{UserExternalID: r.UserExternalID, Role: r.Role, CreatedAt: r.CreatedAt})
+	}
+	return members, nil
+}
+
+// AssignSeat gives a member of the organization a seat. It returns false when they already had one.
+func AssignSeat(organizationID int64, userExternalID string) (bool, error) {
+	n, err := q.AssignSeat(context.Background(), sqldb.AssignSeatParams{
+		OrganizationID: organizationID,
+		UserExternalID: HashExternalID(userExternalID),
+		SeatAssignedAt: sql.NullInt64{Int64: time.Now().UnixMilli(), Valid: true},
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to assign seat: %w", err)
+	}
+	return n > 0, nil
+}
+
+// UnassignSeat takes a member's seat back. It returns false when they had none.
+func UnassignSeat(organizationID int64, userExternalID string) (bool, error) {
+	n, err := q.UnassignSeat(context.Background(), sqldb.UnassignSeatParams{
+		OrganizationID: organizationID,
+		UserExternalID: HashExternalID(userExternalID),
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to unassign seat: %w", err)
+	}
+	return n > 0, nil
+}
+
+// UnassignSeatUnlessLast takes a member's seat back, unless it is the organization's last one.
+// Concurrent removals are serialized on the organization, so they can't free its last seat
+// together. It returns false when nothing was unassigned: the member had no seat, or held the
+// last one.
+func UnassignSeatUnlessLast(organizationID int64, userExternalID string) (bool, error) {
+	ctx := context.Background()
+	tx, err := database.GetDB().BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin seat transaction: %w", err)
+	}
+	defer tx.Rollback()
+	qtx := q.WithTx(tx)
+
+	if _, err := qtx.LockOrganization(ctx, organizationID); err == sql.ErrNoRows {
+		return false, ErrOrganizationNotFound
+	} else if err != nil {
+		return false, fmt.Errorf("failed to lock organization: %w", err)
+	}
+	n, err := qtx.UnassignSeatUnlessLast(ctx, sqldb.UnassignSeatUnlessLastParams{
+		OrganizationID: organizationID,
+		UserExternalID: HashExternalID(userExternalID),
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to unassign seat: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit seat: %w", err)
+	}
+	return n > 0, nil
+}
+
+// CountSeats returns the number of seats assigned in the organization.
+func CountSeats(organizationID int64) (int, error) {
+	n, err := q.CountSeats(context.Background(), organizationID)
+	if err != nil {
+		return 0, fmt.Errorf("error counting seats: %w", err)
+	}
+	return int(n), nil
+}
+
+// ListSeats returns the seated members of the organization, in the order they were seated.
+// CreatedAt is when the seat was assigned.
+func ListSeats(organizationID int64) ([]OrganizationMember, error) {
+	rows, err := q.ListSeats(context.Background(), organizationID)
+	if err != nil {
+		return nil, fmt.Errorf("error listing seats: %w", err)
+	}
+	seats := make([]OrganizationMember, 0, len(rows))
+	for _, r := range rows {
+		seats = append(seats, OrganizationMember{UserExternalID: r.UserExternalID, Role: r.Role, CreatedAt: r.SeatAssignedAt})
+	}
+	return seats, nil
+}
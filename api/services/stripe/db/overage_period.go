@@ -0,0 +1,102 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	sqldb "github.com/tbeaudouin05/stripe-trellai/internal/autogenerated/sqldb"
+)
+
+// OveragePeriod tracks units consumed beyond the allowance of an overage-enabled
+// subscription during one billing period. Period bounds are unix milliseconds.
+// OverageUnitAmount is the per-unit price in the currency's minor units (decimal string).
+type OveragePeriod struct {
+	ID                   int64  `json:"id"`
+	UserExternalID       string `json:"user_external_id"`
+	StripeSubscriptionID string `json:"stripe_subscription_id"`
+	StripeCustomerID     string `json:"stripe_customer_id"`
+	PeriodStart          int64  `json:"period_start"`
+	PeriodEnd            int64  `json:"period_end"`
+	Allowance            int64  `json:"allowance"`
+	OverageUnitAmount    string `json:"overage_unit_amount"`
+	Currency             string `json:"currency"`
+	OverageUnits         int64  `json:"overage_units"`
+}
+
+// RecordOverage upserts the overage observed for an account's current billing period.
+// p.UserExternalID is the stored (already hashed) identifier, as returned by
+// ListSubscribedAccounts. The stored overage never decreases and invoiced periods are left
+// untouched.
+func RecordOverage(p OveragePeriod) error {
+	ctx := context.Background()
+	if err := q.UpsertOveragePeriod(ctx, sqldb.UpsertOveragePeriodParams{
+		UserExternalID:       p.UserExternalID,
+		StripeSubscriptionID: p.StripeSubscriptionID,
+		StripeCustomerID:     p.StripeCustomerID,
+		PeriodStart:          p.PeriodStart,
+		PeriodEnd:            p.PeriodEnd,
+		Allowance:            p.Allowance,
+		OverageUnitAmount:    p.OverageUnitAmount,
+		Currency:             p.Currency,
+		OverageUnits:         p.OverageUnits,
+	}); err != nil {
+		return fmt.Errorf("error upserting overage_period: %w", err)
+	}
+	return nil
+}
+
+// ListUninvoicedOveragePeriods returns overage periods that ended before endedBefore (unix ms)
+// and have not been invoiced yet. UserExternalID is the stored (already hashed) identifier.
+func ListUninvoicedOveragePeriods(endedBefore int64) ([]OveragePeriod, error) {
+	ctx := context.Background()
+	rows, err := q.ListUninvoicedOveragePeriods(ctx, endedBefore)
+	if err != nil {
+		return nil, fmt.Errorf("error listing overage periods: %w", err)
+	}
+	out := make([]OveragePeriod, 0, len(rows))
+	for _, r := range rows {
+		out = append(out, OveragePeriod{
+			ID:                   r.ID,
+			UserExternalID:       r.UserExternalID,
+			StripeSubscriptionID: r.StripeSubscriptionID,
+			StripeCustomerID:     r.StripeCustomerID,
+			PeriodStart:          r.PeriodStart,
+			PeriodEnd:            r.PeriodEnd,
+			Allowance:            r.Allowance,
+			OverageUnitAmount:    r.OverageUnitAmount,
+			Currency:             r.Currency,
+		})
+	}
+	return out, nil
+}
+
+// MarkOveragePeriodInvoiced records the final overage of a period and the Stripe invoice item
+// billing it. invoiceItemID is empty when there was nothing to bill.
+func MarkOveragePeriodInvoiced(id, overageUnits int64, invoiceItemID string, invoicedAt int64) error {
+	ctx := context.Background()
+	if err := q.MarkOveragePeriodInvoiced(ctx, sqldb.MarkOveragePeriodInvoicedParams{
+		ID:                  id,
+		OverageUnits:        overageUnits,
+		StripeInvoiceItemID: sql.NullString{String: invoiceItemID, Valid: invoiceItemID != ""},
+		InvoicedAt:          sql.NullInt64{Int64: invoicedAt, Valid: true},
+	}); err != nil {
+		return fmt.Errorf("error marking overage_period invoiced: %w", err)
+	}
+	return nil
+}
+
+// CountStoredUnitsBetween is CountUnitsBetween for an already hashed user identifier (as
+// returned by background listings).
+func CountStoredUnitsBetween(hashedUserExternalID string, start, end int64) (int, error) {
+	ctx := context.Background()
+	c, err := q.CountUnitsBetween(ctx, sqldb.CountUnitsBetweenParams{
+		UserExternalID: hashedUserExternalID,
+		CreatedAt:      start,
+		CreatedAt_2:    end,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("error summing spending units: %w", err)
+	}
+	return int(c), nil
+}
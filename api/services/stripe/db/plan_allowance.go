@@ -0,0 +1,65 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	sqldb "github.com/tbeaudouin05/stripe-trellai/internal/autogenerated/sqldb"
+)
+
+// PlanAllowance is a cached per-plan unit allowance read from Stripe metadata.
+// HasUnits is false when the plan defines no units_per_period and callers should fall back.
+// OverageUnitAmount is empty when the plan does not bill overage.
+// HasTrialUnits is false when the plan defines no trial_units_per_period.
+// FeatureLimits is the plan's feature_limits metadata as read, empty when it defines none.
+type PlanAllowance struct {
+	StripePlanID        string `json:"stripe_plan_id"`
+	UnitsPerPeriod      int64  `json:"units_per_period"`
+	HasUnits            bool   `json:"has_units"`
+	OverageUnitAmount   string `json:"overage_unit_amount"`
+	TrialUnitsPerPeriod int64  `json:"trial_units_per_period"`
+	HasTrialUnits       bool   `json:"has_trial_units"`
+	FeatureLimits       string `json:"feature_limits"`
+	FetchedAt           int64  `json:"fetched_at"`
+}
+
+// GetPlanAllowance returns the cached allowance for a Stripe plan (price) ID.
+// The boolean is false when the plan has never been cached.
+func GetPlanAllowance(stripePlanID string) (PlanAllowance, bool, error) {
+	ctx := context.Background()
+	row, err := q.GetPlanAllowance(ctx, stripePlanID)
+	if err == sql.ErrNoRows {
+		return PlanAllowance{}, false, nil
+	}
+	if err != nil {
+		return PlanAllowance{}, false, fmt.Errorf("error reading plan_allowance: %w", err)
+	}
+	return PlanAllowance{
+		StripePlanID:        row.StripePlanID,
+		UnitsPerPeriod:      row.UnitsPerPeriod.Int64,
+		HasUnits:            row.UnitsPerPeriod.Valid,
+		OverageUnitAmount:   row.OverageUnitAmount.String,
+		TrialUnitsPerPeriod: row.TrialUnitsPerPeriod.Int64,
+		HasTrialUnits:       row.TrialUnitsPerPeriod.Valid,
+		FeatureLimits:       row.FeatureLimits.String,
+		FetchedAt:           row.FetchedAt,
+	}, true, nil
+}
+
+// UpsertPlanAllowance caches the allowance for a Stripe plan (price) ID.
+// fetchedAt is expected to be in unix milliseconds.
+func UpsertPlanAllowance(a PlanAllowance) error {
+	ctx := context.Background()
+	if err := q.UpsertPlanAllowance(ctx, sqldb.UpsertPlanAllowanceParams{
+		StripePlanID:        a.StripePlanID,
+		UnitsPerPeriod:      sql.NullInt64{Int64: a.UnitsPerPeriod, Valid: a.HasUnits},
+		OverageUnitAmount:   sql.NullString{String: a.OverageUnitAmount, Valid: a.OverageUnitAmount != ""},
+		TrialUnitsPerPeriod: sql.NullInt64{Int64: a.TrialUnitsPerPeriod, Valid: a.HasTrialUnits},
+		FeatureLimits:       toNullString(a.FeatureLimits),
+		FetchedAt:           a.FetchedAt,
+	}); err != nil {
+		return fmt.Errorf("error upserting plan_allowance: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/tbeaudouin05/stripe-trellai/api/database"
+	sqldb "github.com/tbeaudouin05/stripe-trellai/internal/autogenerated/sqldb"
+)
+
+// CreditPurchase is a paid credit pack checkout. Amount is in the currency's minor units.
+type CreditPurchase struct {
+	StripeCheckoutSessionID string `json:"stripe_checkout_session_id"`
+	PackID                  string `json:"pack_id"`
+	Units                   int64  `json:"units"`
+	Amount                  int64  `json:"amount"`
+	Currency                string `json:"currency"`
+}
+
+// GetPurchasedCredit returns the user's remaining purchased credit (0 when none was ever bought).
+func GetPurchasedCredit(userExternalID string) (int64, error) {
+	ctx := context.Background()
+	credit, err := q.GetPurchasedCredit(ctx, HashExternalID(userExternalID))
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("error reading purchased_credit: %w", err)
+	}
+	return credit, nil
+}
+
+// GrantCreditPurchase records a paid checkout and credits its units to the user's purchased
+// credit balance in one transaction. It returns false, without crediting, when the checkout
+// session was already recorded, so webhook retries are safe.
+// The user account must already exist.
+func GrantCreditPurchase(userExternalID string, p CreditPurchase) (bool, error) {
+	ctx := context.Background()
+	hashed := HashExternalID(userExternalID)
+	tx, err := database.GetDB().BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin credit purchase transaction: %w", err)
+	}
+	defer tx.Rollback()
+	qtx := q.WithTx(tx)
+
+	inserted, err := qtx.InsertCreditPurchase(ctx, sqldb.InsertCreditPurchaseParams{
+		StripeCheckoutSessionID: p.StripeCheckoutSessionID,
+		UserExternalID:          hashed,
+		PackID:                  p.PackID,
+		Units:                   p.Units,
+		Amount:                  p.Amount,
+		Currency:                p.Currency,
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to insert credit_purchase: %w", err)
+	}
+	n, err := toInt(inserted)
+	if err != nil {
+		return false, err
+	}
+	if n == 0 {
+		return false, nil
+	}
+	if err := qtx.AddPurchasedCredit(ctx, sqldb.AddPurchasedCreditParams{
+		UserExternalID: hashed,
+		Credit:         p.Units,
+	}); err != nil {
+		return false, fmt.Errorf("failed to add purchased credit: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit credit purchase: %w", err)
+	}
+	return true, nil
+}
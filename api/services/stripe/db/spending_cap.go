@@ -0,0 +1,219 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/tbeaudouin05/stripe-trellai/api/database"
+	sqldb "github.com/tbeaudouin05/stripe-trellai/internal/autogenerated/sqldb"
+)
+
+// Subjects a spending cap applies to.
+const (
+	CapSubjectMember = "member"
+	CapSubjectAPIKey = "api_key"
+)
+
+// SpendingCap limits the units one member, or one API key, spends from an organization's pool
+// per billing period. SubjectID is the member's hashed identifier, or the API key ID as given.
+// UpdatedBy is the hashed identifier of who last set it; UpdatedAt is unix ms.
+type SpendingCap struct {
+	SubjectType string
+	SubjectID   string
+	CapUnits    int64
+	UpdatedBy   string
+	UpdatedAt   int64
+}
+
+// capSubjectID returns how a cap subject is stored: members hashed, API keys as given.
+func capSubjectID(subjectType, subjectID string) string {
+	if subjectType == CapSubjectMember {
+		return HashExternalID(subjectID)
+	}
+	return subjectID
+}
+
+// SetSpendingCap sets (or replaces) the cap of a member, given by raw user identifier, or of an API key.
+func SetSpendingCap(organizationID int64, subjectType, subjectID string, capUnits int64, actorUserExternalID string) error {
+	err := q.UpsertSpendingCap(context.Background(), sqldb.UpsertSpendingCapParams{
+		OrganizationID: organizationID,
+		SubjectType:    subjectType,
+		SubjectID:      capSubjectID(subjectType, subjectID),
+		CapUnits:       capUnits,
+		UpdatedBy:      HashExternalID(actorUserExternalID),
+	})
+	if err != nil {
+		return fmt.Errorf("error upserting spending_cap: %w", err)
+	}
+	return nil
+}
+
+// RemoveSpendingCap removes a cap. It returns false when there was none.
+func RemoveSpendingCap(organizationID int64, subjectType, subjectID string) (bool, error) {
+	n, err := q.DeleteSpendingCap(context.Background(), sqldb.DeleteSpendingCapParams{
+		OrganizationID: organizationID,
+		SubjectType:    subjectType,
+		SubjectID:      capSubjectID(subjectType, subjectID),
+	})
+	if err != nil {
+		return false, fmt.Errorf("error deleting spending_cap: %w", err)
+	}
+	return n > 0, nil
+}
+
+// ListSpendingCaps returns the organization's caps, members first.
+func ListSpendingCaps(organizationID int64) ([]SpendingCap, error) {
+	rows, err := q.ListSpendingCaps(context.Background(), organizationID)
+	if err != nil {
+		return nil, fmt.Errorf("error listing spending caps: %w", err)
+	}
+	caps := make([]SpendingCap, 0, len(rows))
+	for _, r := range rows {
+		caps = append(caps, SpendingCap{SubjectType: r.SubjectType, SubjectID: r.SubjectID, CapUnits: r.CapUnits, UpdatedBy: r.UpdatedBy, UpdatedAt: r.UpdatedAt})
+	}
+	return caps, nil
+}
+
+// UnitsByMemberBetween sums the units each member spent from a pooled account between start and
+// end (inclusive, unix ms), keyed by hashed member identifier. Units spent directly on the account
+// are under "".
+func UnitsByMemberBetween(accountExternalID string, start, end int64) (map[string]int64, error) {
+	return unitsByMemberBetween(context.Background(), q, accountExternalID, start, end)
+}
+
+func unitsByMemberBetween(ctx context.Context, qq *sqldb.Queries, accountExternalID string, start, end int64) (map[string]int64, error) {
+	rows, err := qq.SumUnitsByMemberBetween(ctx, sqldb.SumUnitsByMemberBetweenParams{
+		UserExternalID: HashExternalID(accountExternalID),
+		CreatedAt:      start,
+		CreatedAt_2:    end,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error summing spending units by member: %w", err)
+	}
+	units := make(map[string]int64, len(rows))
+	for _, r := range rows {
+		units[r.MemberExternalID] = r.Units
+	}
+	return units, nil
+}
+
+// UnitsByAPIKeyBetween sums the units spent with each API key on an account between start and
+// end (inclusive, unix ms).
+func UnitsByAPIKeyBetween(accountExternalID string, start, end int64) (map[string]int64, error) {
+	return unitsByAPIKeyBetween(context.Background(), q, accountExternalID, start, end)
+}
+
+func unitsByAPIKeyBetween(ctx context.Context, qq *sqldb.Queries, accountExternalID string, start, end int64) (map[string]int64, error) {
+	rows, err := qq.SumUnitsByAPIKeyBetween(ctx, sqldb.SumUnitsByAPIKeyBetweenParams{
+		UserExternalID: HashExternalID(accountExternalID),
+		CreatedAt:      start,
+		CreatedAt_2:    end,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error summing spending units by API key: %w", err)
+	}
+	units := make(map[string]int64, len(rows))
+	for _, r := range rows {
+		units[r.ApiKeyID] = r.Units
+	}
+	return units, nil
+}
+
+// RecordedSpendingUnits returns which of the given (raw) spending unit external IDs are already recorded.
+func RecordedSpendingUnits(externalIDs []string) (map[string]bool, error) {
+	return recordedSpendingUnits(context.Background(), q, externalIDs)
+}
+
+func recordedSpendingUnits(ctx context.Context, qq *sqldb.Queries, externalIDs []string) (map[string]bool, error) {
+	byHash := make(map[string]string, len(externalIDs))
+	hashed := make([]string, 0, len(externalIDs))
+	for _, id := range externalIDs {
+		h := HashExternalID(id)
+		byHash[h] = id
+		hashed = append(hashed, h)
+	}
+	rows, err := qq.ListRecordedSpendingUnits(ctx, hashed)
+	if err != nil {
+		return nil, fmt.Errorf("error reading spending units: %w", err)
+	}
+	recorded := make(map[string]bool, len(rows))
+	for _, h := range rows {
+		recorded[byHash[h]] = true
+	}
+	return recorded, nil
+}
+
+// CapScope is a pooled account whose units in a batch are checked against its organization's
+// spending caps by AddSpendingUnitsWithinCaps. Account is the raw identifier of the pooled
+// account, whose usage is read between Start and End (inclusive, unix ms).
+type CapScope struct {
+	OrganizationID int64
+	Account        string
+	Start          int64
+	End            int64
+	// Check gets the account's usage by hashed member and by API key, and which units of the
+	// batch (raw external IDs) are already recorded. An error refuses the whole batch.
+	Check func(byMember, byKey map[string]int64, recorded map[string]bool) error
+}
+
+// AddSpendingUnitsWithinCaps is AddSpendingUnits for a batch with capped units. It locks the
+// scopes' organizations, checks each scope against the usage read under the lock and records the
+// batch in the same transaction, so concurrent batches can't both pass a cap. A failed check's
+// error is returned as is and nothing is recorded.
+func AddSpendingUnitsWithinCaps(items []SpendingUnit, scopes []CapScope) (int, error) {
+	ctx := context.Background()
+	params, err := spendingUnitParams(items)
+	if err != nil {
+		return 0, err
+	}
+	ids := make([]string, 0, len(items))
+	for _, it := range items {
+		ids = append(ids, it.ExternalID)
+	}
+	// lock in a fixed order, so batches spanning several organizations can't deadlock
+	sorted := append([]CapScope(nil), scopes...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].OrganizationID < sorted[j].OrganizationID })
+
+	tx, err := database.GetDB().BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin spending_unit transaction: %w", err)
+	}
+	defer tx.Rollback()
+	qtx := q.WithTx(tx)
+
+	for _, sc := range sorted {
+		if _, err := qtx.LockOrganization(ctx, sc.OrganizationID); err != nil {
+			return 0, fmt.Errorf("error locking organization: %w", err)
+		}
+	}
+	recorded, err := recordedSpendingUnits(ctx, qtx, ids)
+	if err != nil {
+		return 0, err
+	}
+	for _, sc := range sorted {
+		byMember, err := unitsByMemberBetween(ctx, qtx, sc.Account, sc.Start, sc.End)
+		if err != nil {
+			return 0, err
+		}
+		byKey, err := unitsByAPIKeyBetween(ctx, qtx, sc.Account, sc.Start, sc.End)
+		if err != nil {
+			return 0, err
+		}
+		if err := sc.Check(byMember, byKey, recorded); err != nil {
+			return 0, err
+		}
+	}
+	var total int
+	for i, p := range params {
+		n, err := insertSpendingUnitTx(ctx, qtx, p)
+		if err != nil {
+			return 0, fmt.Errorf("item %d: %w", i, err)
+		}
+		total += n
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit spending_unit: %w", err)
+	}
+	return total, nil
+}
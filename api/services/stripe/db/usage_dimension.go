@@ -0,0 +1,37 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	sqldb "github.com/tbeaudouin05/stripe-trellai/internal/autogenerated/sqldb"
+)
+
+// DimensionUnits is the usage of one feature (and label value) over a period. FeatureKey and
+// LabelValue are empty for units reported without them. AllowanceUnits leaves out units paid
+// for with free or purchased credit, which don't count against the plan.
+type DimensionUnits struct {
+	FeatureKey     string
+	LabelValue     string
+	Units          int64
+	AllowanceUnits int64
+}
+
+// UnitsByDimensionBetween sums a user's spending units between start and end (inclusive, unix ms)
+// by feature key and, when labelKey is not empty, by the value of that label.
+func UnitsByDimensionBetween(userExternalID, labelKey string, start, end int64) ([]DimensionUnits, error) {
+	rows, err := q.SumUnitsByDimensionBetween(context.Background(), sqldb.SumUnitsByDimensionBetweenParams{
+		LabelKey:       labelKey,
+		UserExternalID: HashExternalID(userExternalID),
+		PeriodStart:    start,
+		PeriodEnd:      end,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error summing spending units by dimension: %w", err)
+	}
+	units := make([]DimensionUnits, 0, len(rows))
+	for _, r := range rows {
+		units = append(units, DimensionUnits{FeatureKey: r.FeatureKey, LabelValue: r.LabelValue, Units: r.Units, AllowanceUnits: r.AllowanceUnits})
+	}
+	return units, nil
+}
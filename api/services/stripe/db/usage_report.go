@@ -0,0 +1,186 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/tbeaudouin05/stripe-trellai/api/database"
+	sqldb "github.com/tbeaudouin05/stripe-trellai/internal/autogenerated/sqldb"
+)
+
+// SubscribedAccount is a user account holding a Stripe subscription.
+// UserExternalID is the stored (already hashed) identifier.
+type SubscribedAccount struct {
+	UserExternalID       string `json:"user_external_id"`
+	StripeSubscriptionID string `json:"stripe_subscription_id"`
+	StripeCustomerID     string `json:"stripe_customer_id"`
+}
+
+// ListSubscribedAccounts returns every user account that references a Stripe subscription.
+func ListSubscribedAccounts() ([]SubscribedAccount, error) {
+	ctx := context.Background()
+	rows, err := q.ListSubscribedUserAccounts(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error listing subscribed user accounts: %w", err)
+	}
+	out := make([]SubscribedAccount, 0, len(rows))
+	for _, r := range rows {
+		out = append(out, SubscribedAccount{
+			UserExternalID:       r.UserExternalID,
+			StripeSubscriptionID: r.StripeSubscriptionID.String,
+			StripeCustomerID:     r.StripeCustomerID.String,
+		})
+	}
+	return out, nil
+}
+
+// UsageBatch is a set of spending units reported as a single usage record for a subscription
+// item. Units includes the negative balance carried from earlier batches; nothing is sent to
+// Stripe when it is zero or less.
+type UsageBatch struct {
+	SubscriptionItemID string `json:"subscription_item_id"`
+	// Batch is the name spending units of the batch are tagged with; empty when nothing is due.
+	Batch string `json:"batch"`
+	Units int64  `json:"units"`
+	// Timestamp is the usage record timestamp in unix seconds.
+	Timestamp int64 `json:"timestamp"`
+}
+
+// Empty reports whether the batch covers no spending units.
+func (b UsageBatch) Empty() bool { return b.Batch == "" }
+
+// IdempotencyKey is the key the batch's usage record is sent with, stable across retries.
+func (b UsageBatch) IdempotencyKey() string { return "usage-" + b.Batch }
+
+// NextUsageBatch returns the next batch of billable units to report for a subscription item.
+// If a previous attempt claimed a batch that was never committed, that same batch is returned
+// so it can be re-sent with the same idempotency key. Otherwise the account's untagged units
+// recorded since `since` (unix ms) are tagged with a new batch, persisted as pending, in one
+// transaction. Units tagged by a batch are never counted again, whatever order their
+// transactions commit in. hashedUserExternalID must already be hashed (as returned by
+// ListSubscribedAccounts).
+func NextUsageBatch(subscriptionItemID, hashedUserExternalID string, since, timestamp int64) (UsageBatch, error) {
+	ctx := context.Background()
+	if err := q.EnsureUsageReport(ctx, sqldb.EnsureUsageReportParams{
+		SubscriptionItemID: subscriptionItemID,
+		UserExternalID:     hashedUserExternalID,
+	}); err != nil {
+		return UsageBatch{}, fmt.Errorf("error creating usage_report: %w", err)
+	}
+	tx, err := database.GetDB().BeginTx(ctx, nil)
+	if err != nil {
+		return UsageBatch{}, fmt.Errorf("failed to begin usage batch transaction: %w", err)
+	}
+	defer tx.Rollback()
+	qtx := q.WithTx(tx)
+
+	row, err := qtx.LockUsageReport(ctx, subscriptionItemID)
+	if err != nil {
+		return UsageBatch{}, fmt.Errorf("error reading usage_report: %w", err)
+	}
+	if row.PendingBatch.Valid {
+		return UsageBatch{
+			SubscriptionItemID: subscriptionItemID,
+			Batch:              row.PendingBatch.String,
+			Units:              row.PendingUnits.Int64,
+			Timestamp:          row.PendingTimestamp.Int64,
+		}, nil
+	}
+	seq := row.BatchSeq + 1
+	b := UsageBatch{
+		SubscriptionItemID: subscriptionItemID,
+		Batch:              fmt.Sprintf("%s:%d", subscriptionItemID, seq),
+		Timestamp:          timestamp,
+	}
+	claimed, err := qtx.ClaimUsageBatch(ctx, sqldb.ClaimUsageBatchParams{
+		Batch:          toNullString(b.Batch),
+		UserExternalID: hashedUserExternalID,
+		Since:          since,
+	})
+	if err != nil {
+		return UsageBatch{}, fmt.Errorf("error claiming unreported units: %w", err)
+	}
+	if claimed.Claimed == 0 {
+		return UsageBatch{SubscriptionItemID: subscriptionItemID}, nil
+	}
+	b.Units = claimed.Units + row.CarriedUnits
+	if err := qtx.SetUsageReportPending(ctx, sqldb.SetUsageReportPendingParams{
+		SubscriptionItemID: subscriptionItemID,
+		BatchSeq:           seq,
+		PendingBatch:       toNullString(b.Batch),
+		PendingUnits:       sql.NullInt64{Int64: b.Units, Valid: true},
+		PendingTimestamp:   sql.NullInt64{Int64: b.Timestamp, Valid: true},
+	}); err != nil {
+		return UsageBatch{}, fmt.Errorf("error persisting pending usage batch: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return UsageBatch{}, fmt.Errorf("failed to commit usage batch: %w", err)
+	}
+	return b, nil
+}
+
+// RedateUsageBatch re-issues the pending batch b of a subscription item as a new batch, with the
+// same units, timestamped at timestamp (unix seconds). It is meant for a batch Stripe rejected for
+// good, e.g. because its period closed first: the new batch gets a new idempotency key, so it is
+// not answered with the rejection again. If b is no longer pending, the pending batch is returned
+// unchanged.
+func RedateUsageBatch(b UsageBatch, hashedUserExternalID string, timestamp int64) (UsageBatch, error) {
+	ctx := context.Background()
+	tx, err := database.GetDB().BeginTx(ctx, nil)
+	if err != nil {
+		return UsageBatch{}, fmt.Errorf("failed to begin usage batch transaction: %w", err)
+	}
+	defer tx.Rollback()
+	qtx := q.WithTx(tx)
+
+	row, err := qtx.LockUsageReport(ctx, b.SubscriptionItemID)
+	if err != nil {
+		return UsageBatch{}, fmt.Errorf("error reading usage_report: %w", err)
+	}
+	if row.PendingBatch.String != b.Batch {
+		return UsageBatch{
+			SubscriptionItemID: b.SubscriptionItemID,
+			Batch:              row.PendingBatch.String,
+			Units:              row.PendingUnits.Int64,
+			Timestamp:          row.PendingTimestamp.Int64,
+		}, nil
+	}
+	seq := row.BatchSeq + 1
+	redated := UsageBatch{
+		SubscriptionItemID: b.SubscriptionItemID,
+		Batch:              fmt.Sprintf("%s:%d", b.SubscriptionItemID, seq),
+		Units:              row.PendingUnits.Int64,
+		Timestamp:          timestamp,
+	}
+	if err := qtx.RetagUsageBatch(ctx, sqldb.RetagUsageBatchParams{
+		NewBatch:       toNullString(redated.Batch),
+		UserExternalID: hashedUserExternalID,
+		OldBatch:       toNullString(b.Batch),
+	}); err != nil {
+		return UsageBatch{}, fmt.Errorf("error retagging usage batch: %w", err)
+	}
+	if err := qtx.SetUsageReportPending(ctx, sqldb.SetUsageReportPendingParams{
+		SubscriptionItemID: b.SubscriptionItemID,
+		BatchSeq:           seq,
+		PendingBatch:       toNullString(redated.Batch),
+		PendingUnits:       sql.NullInt64{Int64: redated.Units, Valid: true},
+		PendingTimestamp:   sql.NullInt64{Int64: redated.Timestamp, Valid: true},
+	}); err != nil {
+		return UsageBatch{}, fmt.Errorf("error persisting pending usage batch: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return UsageBatch{}, fmt.Errorf("failed to commit usage batch: %w", err)
+	}
+	return redated, nil
+}
+
+// CommitUsageBatch marks the pending batch of a subscription item as reported. A batch netting
+// to zero or less leaves its deficit to the next batch.
+func CommitUsageBatch(subscriptionItemID string) error {
+	ctx := context.Background()
+	if err := q.CommitUsageReport(ctx, subscriptionItemID); err != nil {
+		return fmt.Errorf("error committing usage_report: %w", err)
+	}
+	return nil
+}
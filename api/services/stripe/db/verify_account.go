@@ -0,0 +1,60 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	sqldb "github.com/tbeaudouin05/stripe-trellai/internal/autogenerated/sqldb"
+)
+
+// VerifyAccount is what subscription verification reads from the database before calling
+// Stripe. FreeCreditCurrent is false when GetFreeCredit would first change FreeCredit (no
+// balance yet, expired credit, a pending refill or an expired admin grant); callers then need
+// GetFreeCredit for the actual balance.
+type VerifyAccount struct {
+	StripeSubscriptionID string
+	StripeCustomerID     string
+	FreeCredit           int
+	FreeCreditCurrent    bool
+	PurchasedCredit      int64
+	Billing              BillingStatus
+}
+
+// ListVerifyAccounts reads the verification state of many accounts in one query, keyed by the
+// given (raw) identifiers. Accounts without a user_account row are left out.
+func ListVerifyAccounts(userExternalIDs []string) (map[string]VerifyAccount, error) {
+	raw := make(map[string]string, len(userExternalIDs))
+	hashed := make([]string, 0, len(userExternalIDs))
+	for _, id := range userExternalIDs {
+		h := HashExternalID(id)
+		raw[h] = id
+		hashed = append(hashed, h)
+	}
+	p := freeCreditPolicy("", time.Now())
+	rows, err := q.ListVerifyAccounts(context.Background(), sqldb.ListVerifyAccountsParams{
+		Refill:          p.Refill,
+		PeriodStart:     p.PeriodStart,
+		Now:             p.Now,
+		UserExternalIds: hashed,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing user accounts to verify: %w", err)
+	}
+	out := make(map[string]VerifyAccount, len(rows))
+	for _, row := range rows {
+		out[raw[row.UserExternalID]] = VerifyAccount{
+			StripeSubscriptionID: row.StripeSubscriptionID.String,
+			StripeCustomerID:     row.StripeCustomerID.String,
+			FreeCredit:           int(row.FreeCredit),
+			FreeCreditCurrent:    row.FreeCreditCurrent,
+			PurchasedCredit:      row.PurchasedCredit,
+			Billing: BillingStatus{
+				InDunning:          row.InDunning,
+				AttemptCount:       int(row.AttemptCount),
+				NextPaymentAttempt: row.NextPaymentAttempt.Int64,
+			},
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,131 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	sqldb "github.com/tbeaudouin05/stripe-trellai/internal/autogenerated/sqldb"
+)
+
+// Webhook delivery statuses.
+const (
+	WebhookPending   = "pending"
+	WebhookDelivered = "delivered"
+	WebhookFailed    = "failed"
+)
+
+// WebhookDelivery is an outgoing webhook event queued for one endpoint, with the outcome of its
+// last attempt. Times are unix ms; Payload is only read back when claiming deliveries.
+type WebhookDelivery struct {
+	ID             int64  `json:"id"`
+	EventID        string `json:"event_id"`
+	EventType      string `json:"event_type"`
+	EndpointURL    string `json:"endpoint_url"`
+	Payload        string `json:"-"`
+	Status         string `json:"status"`
+	Attempts       int    `json:"attempts"`
+	NextAttemptAt  int64  `json:"next_attempt_at"`
+	LastStatusCode int    `json:"last_status_code"`
+	LastError      string `json:"last_error"`
+	DeliveredAt    int64  `json:"delivered_at"`
+	CreatedAt      int64  `json:"created_at"`
+}
+
+// QueueWebhookDelivery queues the event for the endpoint, due at dueAt. It returns false when the
+// event was already queued for it.
+func QueueWebhookDelivery(d WebhookDelivery, dueAt int64) (bool, error) {
+	n, err := q.InsertWebhookDelivery(context.Background(), sqldb.InsertWebhookDeliveryParams{
+		EventID:       d.EventID,
+		EventType:     d.EventType,
+		EndpointUrl:   d.EndpointURL,
+		Payload:       d.Payload,
+		NextAttemptAt: dueAt,
+	})
+	if err != nil {
+		return false, fmt.Errorf("error inserting webhook_delivery: %w", err)
+	}
+	return n > 0, nil
+}
+
+// ClaimDueWebhookDeliveries leases up to limit pending deliveries due at now until leaseUntil.
+// Deliveries whose attempt is never recorded (e.g. the process died) are retried once the lease ends.
+func ClaimDueWebhookDeliveries(now, leaseUntil int64, limit int) ([]WebhookDelivery, error) {
+	rows, err := q.ClaimDueWebhookDeliveries(context.Background(), sqldb.ClaimDueWebhookDeliveriesParams{
+		LeaseUntil: leaseUntil,
+		Now:        now,
+		RowLimit:   int32(limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error claiming webhook deliveries: %w", err)
+	}
+	deliveries := make([]WebhookDelivery, 0, len(rows))
+	for _, r := range rows {
+		deliveries = append(deliveries, WebhookDelivery{
+			ID:          r.ID,
+			EventID:     r.EventID,
+			EventType:   r.EventType,
+			EndpointURL: r.EndpointUrl,
+			Payload:     r.Payload,
+			Status:      WebhookPending,
+			Attempts:    int(r.Attempts),
+		})
+	}
+	return deliveries, nil
+}
+
+// RecordWebhookAttempt logs an attempt of the delivery claimed until leaseUntil: its new status,
+// when to try next (pending only), the endpoint's status code (0 without a response) and the
+// error, if any. It returns false, recording nothing, when the lease has already ended and the
+// delivery may have been claimed again.
+func RecordWebhookAttempt(id, leaseUntil int64, status string, nextAttemptAt int64, statusCode int, attemptErr string, deliveredAt int64) (bool, error) {
+	params := sqldb.RecordWebhookAttemptParams{
+		ID:            id,
+		Status:        status,
+		NextAttemptAt: nextAttemptAt,
+		LastError:     toNullString(attemptErr),
+		LeaseUntil:    leaseUntil,
+	}
+	if statusCode != 0 {
+		params.LastStatusCode = sql.NullInt32{Int32: int32(statusCode), Valid: true}
+	}
+	if deliveredAt != 0 {
+		params.DeliveredAt = sql.NullInt64{Int64: deliveredAt, Valid: true}
+	}
+	n, err := q.RecordWebhookAttempt(context.Background(), params)
+	if err != nil {
+		return false, fmt.Errorf("error updating webhook_delivery: %w", err)
+	}
+	return n > 0, nil
+}
+
+// ListWebhookDeliveries returns the delivery log newest first, before beforeID (0 from the
+// newest), optionally filtered by status and event type.
+func ListWebhookDeliveries(beforeID int64, status, eventType string, limit int) ([]WebhookDelivery, error) {
+	rows, err := q.ListWebhookDeliveries(context.Background(), sqldb.ListWebhookDeliveriesParams{
+		BeforeID:  beforeID,
+		Status:    status,
+		EventType: eventType,
+		RowLimit:  int32(limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing webhook deliveries: %w", err)
+	}
+	deliveries := make([]WebhookDelivery, 0, len(rows))
+	for _, r := range rows {
+		deliveries = append(deliveries, WebhookDelivery{
+			ID:             r.ID,
+			EventID:        r.EventID,
+			EventType:      r.EventType,
+			EndpointURL:    r.EndpointUrl,
+			Status:         r.Status,
+			Attempts:       int(r.Attempts),
+			NextAttemptAt:  r.NextAttemptAt,
+			LastStatusCode: int(r.LastStatusCode.Int32),
+			LastError:      r.LastError.String,
+			DeliveredAt:    r.DeliveredAt.Int64,
+			CreatedAt:      r.CreatedAt,
+		})
+	}
+	return deliveries, nil
+}
@@ -1,6 +1,18 @@
 package gateway
 
-import stripe "github.com/stripe/stripe-go"
+import (
+    "errors"
+
+    stripe "github.com/stripe/stripe-go"
+)
+
+// ErrNothingToRefund is returned by RefundLatestInvoice when the subscription's latest invoice
+// was not paid with a charge (e.g. a trial or a zero-amount invoice).
+var ErrNothingToRefund = errors.New("nothing to refund")
+
+// ErrUsageRejected is returned by CreateUsageRecord when Stripe refuses the record itself (e.g. a
+// timestamp before the current period); sending it again unchanged fails the same way.
+var ErrUsageRejected = errors.New("usage record rejected")
 
 // StripeGateway abstracts Stripe SDK operations needed by the app layer.
 // Methods return values (not pointers) to respect the project's preference
@@ -8,5 +20,80 @@ import stripe "github.com/stripe/stripe-go"
 type StripeGateway interface {
     GetSubscription(id string) (stripe.Subscription, error)
     CancelSubscription(id string) error
+    // RefundLatestInvoice refunds the charge paying the subscription's latest invoice and returns
+    // the refund ID, or ErrNothingToRefund when nothing was charged.
+    RefundLatestInvoice(subscriptionID, idempotencyKey string) (string, error)
     GetCustomer(id string) (stripe.Customer, error)
+    GetProduct(id string) (stripe.Product, error)
+    // CreateUsageRecord increments metered usage on a subscription item.
+    // timestamp is unix seconds; idempotencyKey lets retries of the same report be deduplicated.
+    // A record Stripe refuses as invalid fails with ErrUsageRejected.
+    CreateUsageRecord(subscriptionItemID string, quantity int64, timestamp int64, idempotencyKey string) error
+    // CreateInvoiceItem adds a pending one-off charge to the customer's subscription and returns its ID.
+    CreateInvoiceItem(item InvoiceItem) (string, error)
+    // CreatePaymentCheckout creates a one-time payment Checkout Session and returns its ID.
+    CreatePaymentCheckout(checkout PaymentCheckout) (string, error)
+    // ChangeSubscriptionPlan moves a subscription item to another plan and/or quantity in place.
+    ChangeSubscriptionPlan(change PlanChange) (stripe.Subscription, error)
+    // UpdateSubscriptionQuantity sets the quantity of a subscription item, prorating the change.
+    UpdateSubscriptionQuantity(subscriptionID, subscriptionItemID string, quantity int64) (stripe.Subscription, error)
+    // PauseSubscription pauses payment collection; behavior is void, keep_as_draft or mark_uncollectible
+    // and resumesAt (unix seconds) is optional.
+    PauseSubscription(id, behavior string, resumesAt int64) (stripe.Subscription, error)
+    // ResumeSubscription resumes payment collection on a paused subscription.
+    ResumeSubscription(id string) (stripe.Subscription, error)
+    // PreviewPlanChange returns what the subscription's upcoming invoice would be after the change.
+    PreviewPlanChange(change PlanChange) (PlanChangePreview, error)
+}
+
+// PlanChange moves one subscription item to PlanID with Quantity (0 for metered plans).
+type PlanChange struct {
+    CustomerID         string
+    SubscriptionID     string
+    SubscriptionItemID string
+    PlanID             string
+    Quantity           int64
+    // ProrationBehavior is create_prorations, always_invoice or none.
+    ProrationBehavior string
+    // ProrationDate (unix ms) is optional; reusing a preview's date bills exactly what it showed.
+    ProrationDate int64
+}
+
+// PlanChangePreview summarizes the upcoming invoice after a plan change.
+// Amounts are in the currency's minor units; ProrationDate is unix ms.
+type PlanChangePreview struct {
+    Currency        string
+    AmountDue       int64
+    ProrationAmount int64
+    ProrationDate   int64
+    // NextPaymentAttempt is when the upcoming invoice is due (unix ms).
+    NextPaymentAttempt int64
+}
+
+// PaymentCheckout describes a one-time payment Checkout Session for a single line item.
+// Amount is in the currency's minor units.
+type PaymentCheckout struct {
+    ClientReferenceID string
+    // CustomerID is optional; when empty Stripe creates a customer.
+    CustomerID string
+    Name       string
+    Amount     int64
+    Currency   string
+    SuccessURL string
+    CancelURL  string
+    Metadata   map[string]string
+}
+
+// InvoiceItem is a one-off charge picked up by the subscription's next invoice.
+// Amount is in the currency's minor units; period bounds are unix seconds.
+type InvoiceItem struct {
+    CustomerID     string
+    SubscriptionID string
+    Amount         int64
+    Currency       string
+    Description    string
+    PeriodStart    int64
+    PeriodEnd      int64
+    // IdempotencyKey lets retries of the same charge be deduplicated by Stripe.
+    IdempotencyKey string
 }
@@ -1,9 +1,18 @@
 package stripegw
 
 import (
+    "errors"
+    "fmt"
+
     stripe "github.com/stripe/stripe-go"
+    "github.com/stripe/stripe-go/checkout/session"
     "github.com/stripe/stripe-go/customer"
+    "github.com/stripe/stripe-go/invoice"
+    "github.com/stripe/stripe-go/invoiceitem"
+    "github.com/stripe/stripe-go/product"
+    "github.com/stripe/stripe-go/refund"
     "github.com/stripe/stripe-go/sub"
+    "github.com/stripe/stripe-go/usagerecord"
 
     gw "github.com/tbeaudouin05/stripe-trellai/api/services/stripe/gateway"
 )
@@ -18,7 +27,10 @@ type client struct{}
 func New() gw.StripeGateway { return client{} }
 
 func (client) GetSubscription(id string) (stripe.Subscription, error) {
-    subPtr, err := sub.Get(id, nil)
+    // Tiers are only returned when expanded; the allowance engine needs them for tiered prices.
+    params := &stripe.SubscriptionParams{}
+    params.AddExpand("items.data.plan.tiers")
+    subPtr, err := sub.Get(id, params)
     if err != nil {
         return stripe.Subscription{}, err
     }
@@ -33,6 +45,25 @@ func (client) CancelSubscription(id string) error {
     return err
 }
 
+func (client) RefundLatestInvoice(subscriptionID, idempotencyKey string) (string, error) {
+    params := &stripe.SubscriptionParams{}
+    params.AddExpand("latest_invoice.charge")
+    subPtr, err := sub.Get(subscriptionID, params)
+    if err != nil {
+        return "", err
+    }
+    if subPtr == nil || subPtr.LatestInvoice == nil || subPtr.LatestInvoice.Charge == nil || subPtr.LatestInvoice.Charge.ID == "" {
+        return "", gw.ErrNothingToRefund
+    }
+    refundParams := &stripe.RefundParams{Charge: stripe.String(subPtr.LatestInvoice.Charge.ID)}
+    refundParams.SetIdempotencyKey(idempotencyKey)
+    r, err := refund.New(refundParams)
+    if err != nil {
+        return "", err
+    }
+    return r.ID, nil
+}
+
 func (client) GetCustomer(id string) (stripe.Customer, error) {
     custPtr, err := customer.Get(id, nil)
     if err != nil {
@@ -43,3 +74,177 @@ func (client) GetCustomer(id string) (stripe.Customer, error) {
     }
     return *custPtr, nil
 }
+
+func (client) GetProduct(id string) (stripe.Product, error) {
+    prodPtr, err := product.Get(id, nil)
+    if err != nil {
+        return stripe.Product{}, err
+    }
+    if prodPtr == nil {
+        return stripe.Product{}, nil
+    }
+    return *prodPtr, nil
+}
+
+func (client) CreateUsageRecord(subscriptionItemID string, quantity int64, timestamp int64, idempotencyKey string) error {
+    params := &stripe.UsageRecordParams{
+        SubscriptionItem: stripe.String(subscriptionItemID),
+        Quantity:         stripe.Int64(quantity),
+        Timestamp:        stripe.Int64(timestamp),
+        Action:           stripe.String(stripe.UsageRecordActionIncrement),
+    }
+    params.SetIdempotencyKey(idempotencyKey)
+    _, err := usagerecord.New(params)
+    var stripeErr *stripe.Error
+    if errors.As(err, &stripeErr) && stripeErr.Type == stripe.ErrorTypeInvalidRequest {
+        return fmt.Errorf("%w: %v", gw.ErrUsageRejected, err)
+    }
+    return err
+}
+
+func (client) CreateInvoiceItem(item gw.InvoiceItem) (string, error) {
+    params := &stripe.InvoiceItemParams{
+        Customer:     stripe.String(item.CustomerID),
+        Subscription: stripe.String(item.SubscriptionID),
+        Amount:       stripe.Int64(item.Amount),
+        Currency:     stripe.String(item.Currency),
+        Description:  stripe.String(item.Description),
+        Period: &stripe.InvoiceItemPeriodParams{
+            Start: stripe.Int64(item.PeriodStart),
+            End:   stripe.Int64(item.PeriodEnd),
+        },
+    }
+    params.SetIdempotencyKey(item.IdempotencyKey)
+    ii, err := invoiceitem.New(params)
+    if err != nil {
+        return "", err
+    }
+    return ii.ID, nil
+}
+
+func (client) CreatePaymentCheckout(checkout gw.PaymentCheckout) (string, error) {
+    params := &stripe.CheckoutSessionParams{
+        Mode:               stripe.String(string(stripe.CheckoutSessionModePayment)),
+        ClientReferenceID:  stripe.String(checkout.ClientReferenceID),
+        PaymentMethodTypes: stripe.StringSlice([]string{"card"}),
+        LineItems: []*stripe.CheckoutSessionLineItemParams{{
+            Name:     stripe.String(checkout.Name),
+            Amount:   stripe.Int64(checkout.Amount),
+            Currency: stripe.String(checkout.Currency),
+            Quantity: stripe.Int64(1),
+        }},
+        SuccessURL: stripe.String(checkout.SuccessURL),
+        CancelURL:  stripe.String(checkout.CancelURL),
+    }
+    if checkout.CustomerID != "" {
+        params.Customer = stripe.String(checkout.CustomerID)
+    }
+    for k, v := range checkout.Metadata {
+        params.AddMetadata(k, v)
+    }
+    s, err := session.New(params)
+    if err != nil {
+        return "", err
+    }
+    return s.ID, nil
+}
+
+func planChangeItems(change gw.PlanChange) []*stripe.SubscriptionItemsParams {
+    item := &stripe.SubscriptionItemsParams{
+        ID:   stripe.String(change.SubscriptionItemID),
+        Plan: stripe.String(change.PlanID),
+    }
+    if change.Quantity > 0 {
+        item.Quantity = stripe.Int64(change.Quantity)
+    }
+    return []*stripe.SubscriptionItemsParams{item}
+}
+
+func (client) ChangeSubscriptionPlan(change gw.PlanChange) (stripe.Subscription, error) {
+    params := &stripe.SubscriptionParams{
+        Items:             planChangeItems(change),
+        ProrationBehavior: stripe.String(change.ProrationBehavior),
+    }
+    if change.ProrationDate > 0 {
+        // Stripe takes seconds
+        params.ProrationDate = stripe.Int64(change.ProrationDate / 1000)
+    }
+    params.AddExpand("items.data.plan.tiers")
+    subPtr, err := sub.Update(change.SubscriptionID, params)
+    if err != nil {
+        return stripe.Subscription{}, err
+    }
+    if subPtr == nil {
+        return stripe.Subscription{}, nil
+    }
+    return *subPtr, nil
+}
+
+func (client) UpdateSubscriptionQuantity(subscriptionID, subscriptionItemID string, quantity int64) (stripe.Subscription, error) {
+    params := &stripe.SubscriptionParams{
+        Items: []*stripe.SubscriptionItemsParams{{
+            ID:       stripe.String(subscriptionItemID),
+            Quantity: stripe.Int64(quantity),
+        }},
+        ProrationBehavior: stripe.String(string(stripe.SubscriptionProrationBehaviorCreateProrations)),
+    }
+    params.AddExpand("items.data.plan.tiers")
+    return updateSubscription(subscriptionID, params)
+}
+
+func (client) PauseSubscription(id, behavior string, resumesAt int64) (stripe.Subscription, error) {
+    pause := &stripe.SubscriptionPauseCollectionParams{Behavior: stripe.String(behavior)}
+    if resumesAt > 0 {
+        pause.ResumesAt = stripe.Int64(resumesAt)
+    }
+    return updateSubscription(id, &stripe.SubscriptionParams{PauseCollection: pause})
+}
+
+func (client) ResumeSubscription(id string) (stripe.Subscription, error) {
+    params := &stripe.SubscriptionParams{}
+    // an empty pause_collection unsets it
+    params.AddExtra("pause_collection", "")
+    return updateSubscription(id, params)
+}
+
+func updateSubscription(id string, params *stripe.SubscriptionParams) (stripe.Subscription, error) {
+    subPtr, err := sub.Update(id, params)
+    if err != nil {
+        return stripe.Subscription{}, err
+    }
+    if subPtr == nil {
+        return stripe.Subscription{}, nil
+    }
+    return *subPtr, nil
+}
+
+func (client) PreviewPlanChange(change gw.PlanChange) (gw.PlanChangePreview, error) {
+    params := &stripe.InvoiceParams{
+        Customer:                      stripe.String(change.CustomerID),
+        Subscription:                  stripe.String(change.SubscriptionID),
+        SubscriptionItems:             planChangeItems(change),
+        SubscriptionProrationBehavior: stripe.String(change.ProrationBehavior),
+    }
+    if change.ProrationDate > 0 {
+        // Stripe takes seconds
+        params.SubscriptionProrationDate = stripe.Int64(change.ProrationDate / 1000)
+    }
+    inv, err := invoice.GetNext(params)
+    if err != nil {
+        return gw.PlanChangePreview{}, err
+    }
+    preview := gw.PlanChangePreview{
+        Currency:           string(inv.Currency),
+        AmountDue:          inv.AmountDue,
+        ProrationDate:      inv.SubscriptionProrationDate * 1000,
+        NextPaymentAttempt: inv.NextPaymentAttempt * 1000,
+    }
+    if inv.Lines != nil {
+        for _, line := range inv.Lines.Data {
+            if line != nil && line.Proration {
+                preview.ProrationAmount += line.Amount
+            }
+        }
+    }
+    return preview, nil
+}
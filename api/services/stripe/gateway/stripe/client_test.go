@@ -0,0 +1,102 @@
+package stripegw
+
+import (
+    "bytes"
+    "testing"
+
+    "github.com/stretchr/testify/assert"
+    stripe "github.com/stripe/stripe-go"
+    "github.com/stripe/stripe-go/form"
+
+    gw "github.com/tbeaudouin05/stripe-trellai/api/services/stripe/gateway"
+)
+
+// fakeBackend stands in for the Stripe API: it records the params of each call and answers
+// upcoming invoice and subscription requests with fixed values (timestamps in unix seconds).
+type fakeBackend struct {
+    params []stripe.ParamsContainer
+}
+
+const (
+    fakeProrationDate      int64 = 1_700_000_000
+    fakeNextPaymentAttempt int64 = 1_700_086_400
+)
+
+func (b *fakeBackend) Call(method, path, key string, params stripe.ParamsContainer, v interface{}) error {
+    b.params = append(b.params, params)
+    switch out := v.(type) {
+    case *stripe.Invoice:
+        *out = stripe.Invoice{
+            Currency:                  stripe.CurrencyUSD,
+            AmountDue:                 1500,
+            SubscriptionProrationDate: fakeProrationDate,
+            NextPaymentAttempt:        fakeNextPaymentAttempt,
+        }
+    case *stripe.Subscription:
+        *out = stripe.Subscription{ID: "sub_change"}
+    }
+    return nil
+}
+
+func (b *fakeBackend) CallRaw(method, path, key string, body *form.Values, params *stripe.Params, v interface{}) error {
+    return nil
+}
+
+func (b *fakeBackend) CallMultipart(method, path, key, boundary string, body *bytes.Buffer, params *stripe.Params, v interface{}) error {
+    return nil
+}
+
+func (b *fakeBackend) SetMaxNetworkRetries(maxNetworkRetries int) {}
+
+func useFakeBackend(t *testing.T) *fakeBackend {
+    prev := stripe.GetBackend(stripe.APIBackend)
+    b := &fakeBackend{}
+    stripe.SetBackend(stripe.APIBackend, b)
+    t.Cleanup(func() { stripe.SetBackend(stripe.APIBackend, prev) })
+    return b
+}
+
+func Test_PlanChange_ConvertsTimestampsToUnixMs(t *testing.T) {
+    b := useFakeBackend(t)
+    change := gw.PlanChange{
+        SubscriptionID:     "sub_change",
+        SubscriptionItemID: "si_basic",
+        CustomerID:         "cust_change",
+        PlanID:             "plan_pro",
+        ProrationBehavior:  "create_prorations",
+        ProrationDate:      fakeProrationDate*1000 + 999,
+    }
+
+    preview, err := New().PreviewPlanChange(change)
+    assert.NoError(t, err)
+    // Stripe answers in seconds; the gateway hands out unix ms
+    assert.Equal(t, fakeProrationDate*1000, preview.ProrationDate)
+    assert.Equal(t, fakeNextPaymentAttempt*1000, preview.NextPaymentAttempt)
+
+    _, err = New().ChangeSubscriptionPlan(change)
+    assert.NoError(t, err)
+
+    // and sends Stripe seconds, whatever the milliseconds
+    if assert.Len(t, b.params, 2) {
+        inv, ok := b.params[0].(*stripe.InvoiceParams)
+        if assert.True(t, ok) && assert.NotNil(t, inv.SubscriptionProrationDate) {
+            assert.Equal(t, fakeProrationDate, *inv.SubscriptionProrationDate)
+        }
+        sub, ok := b.params[1].(*stripe.SubscriptionParams)
+        if assert.True(t, ok) && assert.NotNil(t, sub.ProrationDate) {
+            assert.Equal(t, fakeProrationDate, *sub.ProrationDate)
+        }
+    }
+}
+
+func Test_RefundLatestInvoice_NothingCharged(t *testing.T) {
+    b := useFakeBackend(t)
+
+    // the fake subscription's latest invoice has no charge
+    _, err := New().RefundLatestInvoice("sub_change", "duplicate-refund-sub_change")
+    assert.ErrorIs(t, err, gw.ErrNothingToRefund)
+    for _, p := range b.params {
+        _, isRefund := p.(*stripe.RefundParams)
+        assert.False(t, isRefund, "no refund is created")
+    }
+}
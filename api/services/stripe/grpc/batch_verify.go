@@ -0,0 +1,63 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"google.golang.org/grpc/codes"
+
+	bootstrap "github.com/tbeaudouin05/stripe-trellai/api/bootstrap"
+	appsvc "github.com/tbeaudouin05/stripe-trellai/api/services/stripe/app"
+	stripev1 "github.com/tbeaudouin05/stripe-trellai/internal/autogenerated/proto/stripe/v1"
+)
+
+// maxBatchVerifyUsers bounds the users verified by one BatchVerifySubscriptionValidity call.
+const maxBatchVerifyUsers = 1000
+
+// BatchVerifySubscriptionValidity implements RPC to verify many users at once.
+func (s Server) BatchVerifySubscriptionValidity(ctx context.Context, req *stripev1.BatchVerifySubscriptionValidityRequest) (*stripev1.BatchVerifySubscriptionValidityResponse, error) {
+	if err := bootstrap.Ensure(); err != nil {
+		return nil, fmt.Errorf("initialization error: %v", err)
+	}
+	users := req.GetUserExternalIds()
+	if len(users) == 0 {
+		return nil, fmt.Errorf("user_external_ids is required")
+	}
+	if len(users) > maxBatchVerifyUsers {
+		return nil, fmt.Errorf("at most %d user_external_ids are allowed", maxBatchVerifyUsers)
+	}
+	for i, u := range users {
+		if u == "" {
+			return nil, fmt.Errorf("user_external_ids %d: must not be empty", i)
+		}
+	}
+	results, err := s.app.BatchVerifySubscription(users)
+	if err != nil {
+		return nil, err
+	}
+	resp := &stripev1.BatchVerifySubscriptionValidityResponse{Results: make(map[string]*stripev1.BatchVerifySubscriptionValidityResult, len(results))}
+	for user, r := range results {
+		if r.Err != nil {
+			resp.Results[user] = &stripev1.BatchVerifySubscriptionValidityResult{Error: r.Err.Error(), ErrorCode: batchErrorCode(r.Err).String()}
+			continue
+		}
+		resp.Results[user] = &stripev1.BatchVerifySubscriptionValidityResult{Validity: verifyResponseToProto(r.Response)}
+	}
+	return resp, nil
+}
+
+// batchErrorCode classifies a user's failure in a batch; database and Stripe failures are
+// Unavailable, so callers know to retry those users.
+func batchErrorCode(err error) codes.Code {
+	switch {
+	case errors.Is(err, appsvc.ErrNotFound):
+		return codes.NotFound
+	case errors.Is(err, appsvc.ErrNotAllowed):
+		return codes.FailedPrecondition
+	case errors.Is(err, appsvc.ErrDatabase), errors.Is(err, appsvc.ErrGateway):
+		return codes.Unavailable
+	default:
+		return codes.Internal
+	}
+}
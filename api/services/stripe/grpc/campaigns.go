@@ -0,0 +1,94 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	bootstrap "github.com/tbeaudouin05/stripe-trellai/api/bootstrap"
+	appsvc "github.com/tbeaudouin05/stripe-trellai/api/services/stripe/app"
+	stripedb "github.com/tbeaudouin05/stripe-trellai/api/services/stripe/db"
+	stripev1 "github.com/tbeaudouin05/stripe-trellai/internal/autogenerated/proto/stripe/v1"
+)
+
+// refusalStatus maps refused requests (unknown or not allowed) to gRPC codes so clients can tell
+// them apart from failures.
+func refusalStatus(err error) error {
+	switch {
+	case errors.Is(err, appsvc.ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, appsvc.ErrNotAllowed):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	default:
+		return err
+	}
+}
+
+// RedeemCode implements RPC to redeem a promo or referral code.
+func (s Server) RedeemCode(ctx context.Context, req *stripev1.RedeemCodeRequest) (*stripev1.RedeemCodeResponse, error) {
+	if err := bootstrap.Ensure(); err != nil {
+		return nil, fmt.Errorf("initialization error: %v", err)
+	}
+	if req.GetUserExternalId() == "" || req.GetCode() == "" {
+		return nil, fmt.Errorf("user_external_id and code are required")
+	}
+	key := req.GetIdempotencyKey()
+	if key == "" {
+		// without a key, redeeming the same code again is a retry
+		key = stripedb.NormalizeCampaignCode(req.GetCode())
+	}
+	r, err := s.app.RedeemCode(req.GetUserExternalId(), req.GetCode(), key)
+	if err != nil {
+		return nil, refusalStatus(err)
+	}
+	return &stripev1.RedeemCodeResponse{Units: int32(r.Units), Pending: r.Pending, Credit: int32(r.Balance)}, nil
+}
+
+// GetReferralCode implements RPC returning the user's referral code.
+func (s Server) GetReferralCode(ctx context.Context, req *stripev1.GetReferralCodeRequest) (*stripev1.GetReferralCodeResponse, error) {
+	if err := bootstrap.Ensure(); err != nil {
+		return nil, fmt.Errorf("initialization error: %v", err)
+	}
+	if req.GetUserExternalId() == "" {
+		return nil, fmt.Errorf("user_external_id is required")
+	}
+	code, err := s.app.GetReferralCode(req.GetUserExternalId())
+	if err != nil {
+		return nil, refusalStatus(err)
+	}
+	return &stripev1.GetReferralCodeResponse{Code: code}, nil
+}
+
+// CreateCampaign implements the admin RPC creating a promo code campaign.
+func (s Server) CreateCampaign(ctx context.Context, req *stripev1.CreateCampaignRequest) (*stripev1.CreateCampaignResponse, error) {
+	if err := bootstrap.Ensure(); err != nil {
+		return nil, fmt.Errorf("initialization error: %v", err)
+	}
+	if _, err := adminActor(ctx); err != nil {
+		return nil, err
+	}
+	if req.GetCode() == "" {
+		return nil, fmt.Errorf("code is required")
+	}
+	if req.GetUnits() <= 0 {
+		return nil, fmt.Errorf("units must be > 0")
+	}
+	if req.GetMaxRedemptions() < 0 || req.GetPerUserLimit() < 0 {
+		return nil, fmt.Errorf("max_redemptions and per_user_limit must be >= 0")
+	}
+	id, err := s.app.CreateCampaign(stripedb.Campaign{
+		Code:           req.GetCode(),
+		Units:          int(req.GetUnits()),
+		MaxRedemptions: int(req.GetMaxRedemptions()),
+		PerUserLimit:   int(req.GetPerUserLimit()),
+		StartsAt:       req.GetStartsAt(),
+		EndsAt:         req.GetEndsAt(),
+	})
+	if err != nil {
+		return nil, refusalStatus(err)
+	}
+	return &stripev1.CreateCampaignResponse{CampaignId: id}, nil
+}
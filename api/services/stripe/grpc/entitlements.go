@@ -0,0 +1,149 @@
+package grpcserver
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	bootstrap "github.com/tbeaudouin05/stripe-trellai/api/bootstrap"
+	appsvc "github.com/tbeaudouin05/stripe-trellai/api/services/stripe/app"
+	stripev1 "github.com/tbeaudouin05/stripe-trellai/internal/autogenerated/proto/stripe/v1"
+)
+
+// sseHeartbeat keeps idle Server-Sent Events streams open through proxies.
+const sseHeartbeat = 25 * time.Second
+
+func entitlementStateToProto(userExternalID string, st appsvc.EntitlementState) *stripev1.EntitlementState {
+	return &stripev1.EntitlementState{
+		UserExternalId:  userExternalID,
+		Validity:        verifyResponseToProto(st.Validity),
+		FreeCredit:      st.FreeCredit,
+		PurchasedCredit: st.PurchasedCredit,
+		AllowanceUnits:  st.AllowanceUnits,
+		UsedUnits:       st.UsedUnits,
+		Unlimited:       st.Unlimited,
+		RemainingUnits:  st.RemainingUnits,
+		PeriodStart:     st.PeriodStart,
+		PeriodEnd:       st.PeriodEnd,
+		UpdatedAt:       time.Now().UnixMilli(),
+	}
+}
+
+// WatchEntitlements implements the server-streaming RPC pushing a user's entitlement changes.
+func (s Server) WatchEntitlements(req *stripev1.WatchEntitlementsRequest, stream grpc.ServerStreamingServer[stripev1.EntitlementState]) error {
+	if err := bootstrap.Ensure(); err != nil {
+		return fmt.Errorf("initialization error: %v", err)
+	}
+	user := req.GetUserExternalId()
+	if user == "" {
+		return fmt.Errorf("user_external_id is required")
+	}
+	err := s.app.WatchEntitlements(stream.Context(), user, func(st appsvc.EntitlementState) error {
+		return stream.Send(entitlementStateToProto(user, st))
+	})
+	if err != nil {
+		return refusalStatus(err)
+	}
+	return nil
+}
+
+// EntitlementsSSEHandler streams a user's entitlement changes as Server-Sent Events, for HTTP
+// clients: GET /api/entitlements/stream?user_external_id=... sends an "entitlements" event with
+// the EntitlementState as JSON first and after each change.
+func EntitlementsSSEHandler(app appsvc.Service) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		user := r.URL.Query().Get("user_external_id")
+		if user == "" {
+			http.Error(w, "user_external_id is required", http.StatusBadRequest)
+			return
+		}
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+			return
+		}
+		if err := bootstrap.Ensure(); err != nil {
+			http.Error(w, "initialization error", http.StatusInternalServerError)
+			return
+		}
+
+		var mu sync.Mutex
+		started := false
+		write := func(chunk string) error {
+			mu.Lock()
+			defer mu.Unlock()
+			if !started {
+				w.Header().Set("Content-Type", "text/event-stream")
+				w.Header().Set("Cache-Control", "no-cache")
+				w.Header().Set("X-Accel-Buffering", "no")
+				started = true
+			}
+			if _, err := fmt.Fprint(w, chunk); err != nil {
+				return err
+			}
+			flusher.Flush()
+			return nil
+		}
+		done := make(chan struct{})
+		defer close(done)
+		go func() {
+			t := time.NewTicker(sseHeartbeat)
+			defer t.Stop()
+			for {
+				select {
+				case <-done:
+					return
+				case <-t.C:
+					mu.Lock()
+					ready := started
+					mu.Unlock()
+					if ready {
+						_ = write(": ping\n\n")
+					}
+				}
+			}
+		}()
+
+		err := app.WatchEntitlements(r.Context(), user, func(st appsvc.EntitlementState) error {
+			b, err := protojson.Marshal(entitlementStateToProto(user, st))
+			if err != nil {
+				return err
+			}
+			return write("event: entitlements\ndata: " + string(b) + "\n\n")
+		})
+		if err == nil {
+			return
+		}
+		mu.Lock()
+		ready := started
+		mu.Unlock()
+		if !ready {
+			http.Error(w, err.Error(), sseErrorStatus(err))
+			return
+		}
+		slog.Warn("entitlements stream ended", "err", err)
+		_ = write("event: error\ndata: " + err.Error() + "\n\n")
+	})
+}
+
+// sseErrorStatus maps an error refusing a stream before it started to an HTTP status.
+func sseErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, appsvc.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, appsvc.ErrNotAllowed):
+		return http.StatusPreconditionFailed
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,73 @@
+package grpcserver
+
+import (
+	"context"
+	"fmt"
+
+	bootstrap "github.com/tbeaudouin05/stripe-trellai/api/bootstrap"
+	stripedb "github.com/tbeaudouin05/stripe-trellai/api/services/stripe/db"
+	stripev1 "github.com/tbeaudouin05/stripe-trellai/internal/autogenerated/proto/stripe/v1"
+)
+
+const (
+	defaultInvalidSubscriptionsLimit = 50
+	maxInvalidSubscriptionsLimit     = 500
+)
+
+func invalidSubscriptionToProto(e stripedb.InvalidSubscription) *stripev1.InvalidSubscription {
+	return &stripev1.InvalidSubscription{
+		Id:                   e.ID,
+		UserExternalId:       e.UserExternalID,
+		StripeSubscriptionId: e.StripeSubscriptionID,
+		StripeCustomerId:     e.StripeCustomerID,
+		Resolution:           e.Resolution,
+		ResolvedBy:           e.ResolvedBy,
+		ResolvedAt:           e.ResolvedAt,
+		CreatedAt:            e.CreatedAt,
+	}
+}
+
+// ListInvalidSubscriptions implements the admin RPC listing duplicate subscriptions.
+func (s Server) ListInvalidSubscriptions(ctx context.Context, req *stripev1.ListInvalidSubscriptionsRequest) (*stripev1.ListInvalidSubscriptionsResponse, error) {
+	if err := bootstrap.Ensure(); err != nil {
+		return nil, fmt.Errorf("initialization error: %v", err)
+	}
+	if _, err := adminActor(ctx); err != nil {
+		return nil, err
+	}
+	limit := int(req.GetLimit())
+	if limit < 0 || limit > maxInvalidSubscriptionsLimit {
+		return nil, fmt.Errorf("limit must be between 0 and %d", maxInvalidSubscriptionsLimit)
+	}
+	if limit == 0 {
+		limit = defaultInvalidSubscriptionsLimit
+	}
+	list, err := s.app.ListInvalidSubscriptions(req.GetAfterId(), limit, req.GetIncludeResolved())
+	if err != nil {
+		return nil, err
+	}
+	resp := &stripev1.ListInvalidSubscriptionsResponse{InvalidSubscriptions: make([]*stripev1.InvalidSubscription, 0, len(list))}
+	for _, e := range list {
+		resp.InvalidSubscriptions = append(resp.InvalidSubscriptions, invalidSubscriptionToProto(e))
+	}
+	return resp, nil
+}
+
+// ResolveInvalidSubscription implements the admin RPC resolving a queued duplicate subscription.
+func (s Server) ResolveInvalidSubscription(ctx context.Context, req *stripev1.ResolveInvalidSubscriptionRequest) (*stripev1.ResolveInvalidSubscriptionResponse, error) {
+	if err := bootstrap.Ensure(); err != nil {
+		return nil, fmt.Errorf("initialization error: %v", err)
+	}
+	actor, err := adminActor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if req.GetId() <= 0 || req.GetResolution() == "" {
+		return nil, fmt.Errorf("id and resolution are required")
+	}
+	e, err := s.app.ResolveInvalidSubscription(req.GetId(), req.GetResolution(), actor)
+	if err != nil {
+		return nil, refusalStatus(err)
+	}
+	return &stripev1.ResolveInvalidSubscriptionResponse{InvalidSubscription: invalidSubscriptionToProto(e)}, nil
+}
@@ -0,0 +1,178 @@
+package grpcserver
+
+import (
+	"context"
+	"fmt"
+
+	bootstrap "github.com/tbeaudouin05/stripe-trellai/api/bootstrap"
+	appsvc "github.com/tbeaudouin05/stripe-trellai/api/services/stripe/app"
+	stripev1 "github.com/tbeaudouin05/stripe-trellai/internal/autogenerated/proto/stripe/v1"
+)
+
+// CreateOrganization implements RPC creating a team account.
+func (s Server) CreateOrganization(ctx context.Context, req *stripev1.CreateOrganizationRequest) (*stripev1.CreateOrganizationResponse, error) {
+	if err := bootstrap.Ensure(); err != nil {
+		return nil, fmt.Errorf("initialization error: %v", err)
+	}
+	if req.GetOrganizationExternalId() == "" || req.GetOwnerUserExternalId() == "" {
+		return nil, fmt.Errorf("organization_external_id and owner_user_external_id are required")
+	}
+	id, err := s.app.CreateOrganization(req.GetOrganizationExternalId(), req.GetOwnerUserExternalId())
+	if err != nil {
+		return nil, refusalStatus(err)
+	}
+	return &stripev1.CreateOrganizationResponse{OrganizationId: id}, nil
+}
+
+// AddOrganizationMember implements RPC adding a member to an organization.
+func (s Server) AddOrganizationMember(ctx context.Context, req *stripev1.AddOrganizationMemberRequest) (*stripev1.AddOrganizationMemberResponse, error) {
+	if err := bootstrap.Ensure(); err != nil {
+		return nil, fmt.Errorf("initialization error: %v", err)
+	}
+	if req.GetOrganizationExternalId() == "" || req.GetActorUserExternalId() == "" || req.GetUserExternalId() == "" {
+		return nil, fmt.Errorf("organization_external_id, actor_user_external_id and user_external_id are required")
+	}
+	if err := s.app.AddOrganizationMember(req.GetOrganizationExternalId(), req.GetActorUserExternalId(), req.GetUserExternalId(), req.GetRole()); err != nil {
+		return nil, refusalStatus(err)
+	}
+	return &stripev1.AddOrganizationMemberResponse{}, nil
+}
+
+// RemoveOrganizationMember implements RPC removing a member from an organization.
+func (s Server) RemoveOrganizationMember(ctx context.Context, req *stripev1.RemoveOrganizationMemberRequest) (*stripev1.RemoveOrganizationMemberResponse, error) {
+	if err := bootstrap.Ensure(); err != nil {
+		return nil, fmt.Errorf("initialization error: %v", err)
+	}
+	if req.GetOrganizationExternalId() == "" || req.GetActorUserExternalId() == "" || req.GetUserExternalId() == "" {
+		return nil, fmt.Errorf("organization_external_id, actor_user_external_id and user_external_id are required")
+	}
+	if err := s.app.RemoveOrganizationMember(req.GetOrganizationExternalId(), req.GetActorUserExternalId(), req.GetUserExternalId()); err != nil {
+		return nil, refusalStatus(err)
+	}
+	return &stripev1.RemoveOrganizationMemberResponse{}, nil
+}
+
+// ListOrganizationMembers implements RPC listing an organization's members.
+func (s Server) ListOrganizationMembers(ctx context.Context, req *stripev1.ListOrganizationMembersRequest) (*stripev1.ListOrganizationMembersResponse, error) {
+	if err := bootstrap.Ensure(); err != nil {
+		return nil, fmt.Errorf("initialization error: %v", err)
+	}
+	if req.GetOrganizationExternalId() == "" || req.GetActorUserExternalId() == "" {
+		return nil, fmt.Errorf("organization_external_id and actor_user_external_id are required")
+	}
+	members, err := s.app.ListOrganizationMembers(req.GetOrganizationExternalId(), req.GetActorUserExternalId())
+	if err != nil {
+		return nil, refusalStatus(err)
+	}
+	resp := &stripev1.ListOrganizationMembersResponse{Members: make([]*stripev1.OrganizationMember, 0, len(members))}
+	for _, m := range members {
+		resp.Members = append(resp.Members, &stripev1.OrganizationMember{UserExternalId: m.UserExternalID, Role: m.Role, CreatedAt: m.CreatedAt})
+	}
+	return resp, nil
+}
+
+// AddSeat implements RPC assigning a seat in an organization.
+func (s Server) AddSeat(ctx context.Context, req *stripev1.AddSeatRequest) (*stripev1.SeatsResponse, error) {
+	if err := bootstrap.Ensure(); err != nil {
+		return nil, fmt.Errorf("initialization error: %v", err)
+	}
+	if req.GetOrganizationExternalId() == "" || req.GetActorUserExternalId() == "" || req.GetUserExternalId() == "" {
+		return nil, fmt.Errorf("organization_external_id, actor_user_external_id and user_external_id are required")
+	}
+	st, err := s.app.AddSeat(req.GetOrganizationExternalId(), req.GetActorUserExternalId(), req.GetUserExternalId())
+	if err != nil {
+		return nil, refusalStatus(err)
+	}
+	return seatsResponse(st), nil
+}
+
+// RemoveSeat implements RPC taking back a seat in an organization.
+func (s Server) RemoveSeat(ctx context.Context, req *stripev1.RemoveSeatRequest) (*stripev1.SeatsResponse, error) {
+	if err := bootstrap.Ensure(); err != nil {
+		return nil, fmt.Errorf("initialization error: %v", err)
+	}
+	if req.GetOrganizationExternalId() == "" || req.GetActorUserExternalId() == "" || req.GetUserExternalId() == "" {
+		return nil, fmt.Errorf("organization_external_id, actor_user_external_id and user_external_id are required")
+	}
+	st, err := s.app.RemoveSeat(req.GetOrganizationExternalId(), req.GetActorUserExternalId(), req.GetUserExternalId())
+	if err != nil {
+		return nil, refusalStatus(err)
+	}
+	return seatsResponse(st), nil
+}
+
+// ListSeats implements RPC listing an organization's seats.
+func (s Server) ListSeats(ctx context.Context, req *stripev1.ListSeatsRequest) (*stripev1.SeatsResponse, error) {
+	if err := bootstrap.Ensure(); err != nil {
+		return nil, fmt.Errorf("initialization error: %v", err)
+	}
+	if req.GetOrganizationExternalId() == "" || req.GetActorUserExternalId() == "" {
+		return nil, fmt.Errorf("organization_external_id and actor_user_external_id are required")
+	}
+	st, err := s.app.ListSeats(req.GetOrganizationExternalId(), req.GetActorUserExternalId())
+	if err != nil {
+		return nil, refusalStatus(err)
+	}
+	return seatsResponse(st), nil
+}
+
+func seatsResponse(st appsvc.SeatState) *stripev1.SeatsResponse {
+	resp := &stripev1.SeatsResponse{Seats: make([]*stripev1.Seat, 0, len(st.Seats)), Quantity: st.Quantity}
+	for _, m := range st.Seats {
+		resp.Seats = append(resp.Seats, &stripev1.Seat{UserExternalId: m.UserExternalID, Role: m.Role, AssignedAt: m.CreatedAt})
+	}
+	return resp
+}
+
+// SetSpendingCap implements RPC capping a member's or an API key's spending in an organization.
+func (s Server) SetSpendingCap(ctx context.Context, req *stripev1.SetSpendingCapRequest) (*stripev1.SetSpendingCapResponse, error) {
+	if err := bootstrap.Ensure(); err != nil {
+		return nil, fmt.Errorf("initialization error: %v", err)
+	}
+	if req.GetOrganizationExternalId() == "" || req.GetActorUserExternalId() == "" {
+		return nil, fmt.Errorf("organization_external_id and actor_user_external_id are required")
+	}
+	if (req.GetMemberUserExternalId() == "") == (req.GetApiKeyId() == "") {
+		return nil, fmt.Errorf("exactly one of member_user_external_id and api_key_id is required")
+	}
+	if req.GetCapUnits() < 0 {
+		return nil, fmt.Errorf("cap_units must be >= 0")
+	}
+	err := s.app.SetSpendingCap(req.GetOrganizationExternalId(), req.GetActorUserExternalId(), appsvc.SpendingCapChange{
+		MemberUserExternalID: req.GetMemberUserExternalId(),
+		APIKeyID:             req.GetApiKeyId(),
+		CapUnits:             req.GetCapUnits(),
+	})
+	if err != nil {
+		return nil, refusalStatus(err)
+	}
+	return &stripev1.SetSpendingCapResponse{}, nil
+}
+
+// GetUsageBreakdown implements RPC returning an organization's usage by member and API key.
+func (s Server) GetUsageBreakdown(ctx context.Context, req *stripev1.GetUsageBreakdownRequest) (*stripev1.GetUsageBreakdownResponse, error) {
+	if err := bootstrap.Ensure(); err != nil {
+		return nil, fmt.Errorf("initialization error: %v", err)
+	}
+	if req.GetOrganizationExternalId() == "" || req.GetActorUserExternalId() == "" {
+		return nil, fmt.Errorf("organization_external_id and actor_user_external_id are required")
+	}
+	b, err := s.app.GetUsageBreakdown(req.GetOrganizationExternalId(), req.GetActorUserExternalId())
+	if err != nil {
+		return nil, refusalStatus(err)
+	}
+	return &stripev1.GetUsageBreakdownResponse{
+		PeriodStart: b.PeriodStart,
+		PeriodEnd:   b.PeriodEnd,
+		Members:     spendingUsage(b.Members),
+		ApiKeys:     spendingUsage(b.APIKeys),
+	}, nil
+}
+
+func spendingUsage(usage []appsvc.SpendingUsage) []*stripev1.SpendingUsage {
+	out := make([]*stripev1.SpendingUsage, 0, len(usage))
+	for _, u := range usage {
+		out = append(out, &stripev1.SpendingUsage{Id: u.ID, Units: u.Units, CapUnits: u.CapUnits})
+	}
+	return out
+}
@@ -7,6 +7,7 @@ import (
     "strings"
 
     "github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+    stripe "github.com/stripe/stripe-go"
     "github.com/stripe/stripe-go/webhook"
     "google.golang.org/genproto/googleapis/api/httpbody"
     "google.golang.org/grpc/metadata"
@@ -22,6 +23,12 @@ import (
 // ConstructEvent is a replaceable function wrapper around webhook.ConstructEvent for testing.
 var ConstructEvent = webhook.ConstructEvent
 
+// Limits on the dimensions of a spending unit (feature_key is a VARCHAR(64) column).
+const (
+    maxFeatureKeyLength = 64
+    maxLabels           = 16
+)
+
 // Server implements stripev1.StripeServiceServer.
 // It adapts the existing app service to gRPC.
 type Server struct {
@@ -38,11 +45,14 @@ func RegisterGateway(ctx context.Context, mux *runtime.ServeMux, srv Server) err
     return stripev1.RegisterStripeServiceHandlerServer(ctx, mux, srv)
 }
 
-// HeaderMatcher forwards Stripe-Signature header into gRPC metadata.
+// HeaderMatcher forwards the Stripe-Signature and admin token headers into gRPC metadata.
 func HeaderMatcher(key string) (string, bool) {
     if strings.EqualFold(key, "Stripe-Signature") {
         return "stripe-signature", true
     }
+    if strings.EqualFold(key, AdminTokenHeader) {
+        return AdminTokenHeader, true
+    }
     return runtime.DefaultHeaderMatcher(key)
 }
 
@@ -56,11 +66,25 @@ func HandleWebhookPayload(app appsvc.Service, payload []byte, signature string)
     if err != nil {
         return fmt.Errorf("error verifying webhook signature: %v", err)
     }
+    return DispatchEvent(app, event)
+}
+
+// DispatchEvent routes a verified Stripe event to the matching app handler.
+// Shared by the gRPC webhook RPC and the raw HTTP webhook route.
+func DispatchEvent(app appsvc.Service, event stripe.Event) error {
     switch event.Type {
-    case "checkout.session.completed":
+    case "checkout.session.completed", "checkout.session.async_payment_succeeded":
         if err := app.HandleCheckoutSessionCompleted(event); err != nil {
             return err
         }
+    case "invoice.payment_failed", "invoice.paid", "invoice.voided", "invoice.marked_uncollectible":
+        if err := app.HandleInvoiceEvent(event); err != nil {
+            return err
+        }
+    case "customer.subscription.deleted":
+        if err := app.HandleSubscriptionDeleted(event); err != nil {
+            return err
+        }
     default:
         slog.Info("Unhandled event type", "type", event.Type)
     }
@@ -93,12 +117,23 @@ func (s Server) VerifySubscriptionValidity(ctx context.Context, req *stripev1.Ve
     if err != nil {
         return nil, err
     }
+    return verifyResponseToProto(resp), nil
+}
+
+func verifyResponseToProto(resp appsvc.VerifySubscriptionResponse) *stripev1.VerifySubscriptionValidityResponse {
     return &stripev1.VerifySubscriptionValidityResponse{
         IsValidSubscription: resp.IsValidSubscription,
         InvalidityType:      string(resp.InvalidityType),
         ValidityType:        string(resp.ValidityType),
         StripeCustomerEmail: resp.StripeCustomerEmail,
-    }, nil
+        TrialEnd:            resp.TrialEnd,
+        GraceDeadline:       resp.GraceDeadline,
+        ResumesAt:           resp.ResumesAt,
+        InDunning:           resp.InDunning,
+        PaymentAttemptCount: int32(resp.PaymentAttemptCount),
+        NextPaymentAttempt:  resp.NextPaymentAttempt,
+        ExhaustedFeatures:   resp.ExhaustedFeatures,
+    }
 }
 
 // HandleWebhook implements RPC handling of Stripe webhooks.
@@ -131,25 +166,221 @@ func (s Server) AddSpendingUnits(ctx context.Context, req *stripev1.AddSpendingU
     }
     items := make([]stripedb.SpendingUnit, 0, len(req.GetItems()))
     for i, it := range req.GetItems() {
-        if it.GetExternalId() == "" || it.GetUserExternalId() == "" {
-            return nil, fmt.Errorf("item %d: external_id and user_external_id are required", i)
-        }
-        if it.GetAmount() <= 0 {
-            return nil, fmt.Errorf("item %d: amount must be > 0", i)
+        item, err := spendingUnitFromProto(i, it)
+        if err != nil {
+            return nil, err
         }
-        if it.GetCreatedAt() == 0 {
-            return nil, fmt.Errorf("item %d: created_at is required", i)
-        }
-        items = append(items, stripedb.SpendingUnit{
-            ExternalID:     it.GetExternalId(),
-            UserExternalID: it.GetUserExternalId(),
-            Amount:         int(it.GetAmount()),
-            CreatedAt:      it.GetCreatedAt(),
-        })
+        items = append(items, item)
     }
     n, err := s.app.AddSpendingUnits(items)
     if err != nil {
-        return nil, err
+        return nil, refusalStatus(err)
     }
     return &stripev1.AddSpendingUnitsResponse{Inserted: int32(n)}, nil
 }
+
+// spendingUnitFromProto validates the i-th spending unit of a request or stream.
+func spendingUnitFromProto(i int, it *stripev1.SpendingUnit) (stripedb.SpendingUnit, error) {
+    if it.GetExternalId() == "" || it.GetUserExternalId() == "" {
+        return stripedb.SpendingUnit{}, fmt.Errorf("item %d: external_id and user_external_id are required", i)
+    }
+    if it.GetAmount() <= 0 {
+        return stripedb.SpendingUnit{}, fmt.Errorf("item %d: amount must be > 0", i)
+    }
+    if it.GetCreatedAt() == 0 {
+        return stripedb.SpendingUnit{}, fmt.Errorf("item %d: created_at is required", i)
+    }
+    if len(it.GetFeatureKey()) > maxFeatureKeyLength {
+        return stripedb.SpendingUnit{}, fmt.Errorf("item %d: feature_key must be at most %d characters", i, maxFeatureKeyLength)
+    }
+    if len(it.GetLabels()) > maxLabels {
+        return stripedb.SpendingUnit{}, fmt.Errorf("item %d: at most %d labels are allowed", i, maxLabels)
+    }
+    return stripedb.SpendingUnit{
+        ExternalID:     it.GetExternalId(),
+        UserExternalID: it.GetUserExternalId(),
+        Amount:         int(it.GetAmount()),
+        CreatedAt:      it.GetCreatedAt(),
+        APIKeyID:       it.GetApiKeyId(),
+        FeatureKey:     it.GetFeatureKey(),
+        Labels:         it.GetLabels(),
+    }, nil
+}
+
+// RefundSpendingUnits implements RPC to reverse previously added spending units.
+func (s Server) RefundSpendingUnits(ctx context.Context, req *stripev1.RefundSpendingUnitsRequest) (*stripev1.RefundSpendingUnitsResponse, error) {
+    if err := bootstrap.Ensure(); err != nil {
+        return nil, fmt.Errorf("initialization error: %v", err)
+    }
+    if req == nil || len(req.GetExternalIds()) == 0 {
+        return nil, fmt.Errorf("external_ids is required")
+    }
+    for i, id := range req.GetExternalIds() {
+        if id == "" {
+            return nil, fmt.Errorf("item %d: external_id is required", i)
+        }
+    }
+    n, err := s.app.RefundSpendingUnits(req.GetExternalIds())
+    if err != nil && n > 0 {
+        // the units before the failing one stay refunded; retrying the request skips them
+        return nil, fmt.Errorf("%w (%d units refunded before the failure)", err, n)
+    }
+    if err != nil {
+        return nil, err
+    }
+    return &stripev1.RefundSpendingUnitsResponse{Refunded: int32(n)}, nil
+}
+
+// GetBillingStatus implements RPC returning the user's dunning state.
+func (s Server) GetBillingStatus(ctx context.Context, req *stripev1.GetBillingStatusRequest) (*stripev1.GetBillingStatusResponse, error) {
+    if err := bootstrap.Ensure(); err != nil {
+        return nil, fmt.Errorf("initialization error: %v", err)
+    }
+    if req.GetUserExternalId() == "" {
+        return nil, fmt.Errorf("user_external_id is required")
+    }
+    st, err := s.app.GetBillingStatus(req.GetUserExternalId())
+    if err != nil {
+        return nil, err
+    }
+    return &stripev1.GetBillingStatusResponse{
+        InDunning:          st.InDunning,
+        StripeInvoiceId:    st.StripeInvoiceID,
+        AttemptCount:       int32(st.AttemptCount),
+        NextPaymentAttempt: st.NextPaymentAttempt,
+        UpdatedAt:          st.LastEventAt,
+    }, nil
+}
+
+// GetUsageByDimension implements RPC returning the user's usage by feature and label.
+func (s Server) GetUsageByDimension(ctx context.Context, req *stripev1.GetUsageByDimensionRequest) (*stripev1.GetUsageByDimensionResponse, error) {
+    if err := bootstrap.Ensure(); err != nil {
+        return nil, fmt.Errorf("initialization error: %v", err)
+    }
+    if req.GetUserExternalId() == "" {
+        return nil, fmt.Errorf("user_external_id is required")
+    }
+    u, err := s.app.GetUsageByDimension(req.GetUserExternalId(), req.GetLabelKey())
+    if err != nil {
+        return nil, err
+    }
+    resp := &stripev1.GetUsageByDimensionResponse{PeriodStart: u.PeriodStart, PeriodEnd: u.PeriodEnd, Usage: make([]*stripev1.DimensionUsage, 0, len(u.Rows))}
+    for _, r := range u.Rows {
+        resp.Usage = append(resp.Usage, &stripev1.DimensionUsage{FeatureKey: r.FeatureKey, LabelValue: r.LabelValue, Units: r.Units})
+    }
+    return resp, nil
+}
+
+func planChangeFromRequest(req *stripev1.PlanChangeRequest) (appsvc.PlanChange, error) {
+    if req.GetUserExternalId() == "" || req.GetPlanId() == "" {
+        return appsvc.PlanChange{}, fmt.Errorf("user_external_id and plan_id are required")
+    }
+    return appsvc.PlanChange{
+        UserExternalID:     req.GetUserExternalId(),
+        SubscriptionItemID: req.GetSubscriptionItemId(),
+        PlanID:             req.GetPlanId(),
+        Quantity:           req.GetQuantity(),
+        ProrationBehavior:  req.GetProrationBehavior(),
+        ProrationDate:      req.GetProrationDate(),
+    }, nil
+}
+
+// PreviewPlanChange implements RPC previewing the invoice of a plan change.
+func (s Server) PreviewPlanChange(ctx context.Context, req *stripev1.PlanChangeRequest) (*stripev1.PreviewPlanChangeResponse, error) {
+    if err := bootstrap.Ensure(); err != nil {
+        return nil, fmt.Errorf("initialization error: %v", err)
+    }
+    change, err := planChangeFromRequest(req)
+    if err != nil {
+        return nil, err
+    }
+    preview, err := s.app.PreviewPlanChange(change)
+    if err != nil {
+        return nil, err
+    }
+    return &stripev1.PreviewPlanChangeResponse{
+        Currency:           preview.Currency,
+        AmountDue:          preview.AmountDue,
+        ProrationAmount:    preview.ProrationAmount,
+        ProrationDate:      preview.ProrationDate,
+        NextPaymentAttempt: preview.NextPaymentAttempt,
+    }, nil
+}
+
+// ChangePlan implements RPC changing the plan of the user's subscription in place.
+func (s Server) ChangePlan(ctx context.Context, req *stripev1.PlanChangeRequest) (*stripev1.ChangePlanResponse, error) {
+    if err := bootstrap.Ensure(); err != nil {
+        return nil, fmt.Errorf("initialization error: %v", err)
+    }
+    change, err := planChangeFromRequest(req)
+    if err != nil {
+        return nil, err
+    }
+    res, err := s.app.ChangePlan(change)
+    if err != nil {
+        return nil, err
+    }
+    return &stripev1.ChangePlanResponse{
+        SubscriptionId: res.SubscriptionID,
+        PlanId:         res.PlanID,
+        Quantity:       res.Quantity,
+        Status:         res.Status,
+    }, nil
+}
+
+func pauseResponse(st appsvc.PauseState) *stripev1.SubscriptionPauseResponse {
+    return &stripev1.SubscriptionPauseResponse{
+        SubscriptionId: st.SubscriptionID,
+        Paused:         st.Paused,
+        Behavior:       st.Behavior,
+        ResumesAt:      st.ResumesAt,
+    }
+}
+
+// PauseSubscription implements RPC pausing payment collection on the user's subscription.
+func (s Server) PauseSubscription(ctx context.Context, req *stripev1.PauseSubscriptionRequest) (*stripev1.SubscriptionPauseResponse, error) {
+    if err := bootstrap.Ensure(); err != nil {
+        return nil, fmt.Errorf("initialization error: %v", err)
+    }
+    if req.GetUserExternalId() == "" {
+        return nil, fmt.Errorf("user_external_id is required")
+    }
+    st, err := s.app.PauseSubscription(req.GetUserExternalId(), req.GetBehavior(), req.GetResumesAt())
+    if err != nil {
+        return nil, refusalStatus(err)
+    }
+    return pauseResponse(st), nil
+}
+
+// ResumeSubscription implements RPC resuming payment collection on the user's subscription.
+func (s Server) ResumeSubscription(ctx context.Context, req *stripev1.ResumeSubscriptionRequest) (*stripev1.SubscriptionPauseResponse, error) {
+    if err := bootstrap.Ensure(); err != nil {
+        return nil, fmt.Errorf("initialization error: %v", err)
+    }
+    if req.GetUserExternalId() == "" {
+        return nil, fmt.Errorf("user_external_id is required")
+    }
+    st, err := s.app.ResumeSubscription(req.GetUserExternalId())
+    if err != nil {
+        return nil, refusalStatus(err)
+    }
+    return pauseResponse(st), nil
+}
+
+// CreateCreditPackCheckout implements RPC to start a credit pack purchase.
+func (s Server) CreateCreditPackCheckout(ctx context.Context, req *stripev1.CreateCreditPackCheckoutRequest) (*stripev1.CreateCreditPackCheckoutResponse, error) {
+    if err := bootstrap.Ensure(); err != nil {
+        return nil, fmt.Errorf("initialization error: %v", err)
+    }
+    if req.GetUserExternalId() == "" || req.GetPackId() == "" {
+        return nil, fmt.Errorf("user_external_id and pack_id are required")
+    }
+    if req.GetSuccessUrl() == "" || req.GetCancelUrl() == "" {
+        return nil, fmt.Errorf("success_url and cancel_url are required")
+    }
+    id, err := s.app.CreateCreditPackCheckout(req.GetUserExternalId(), req.GetPackId(), req.GetSuccessUrl(), req.GetCancelUrl())
+    if err != nil {
+        return nil, err
+    }
+    return &stripev1.CreateCreditPackCheckoutResponse{CheckoutSessionId: id}, nil
+}
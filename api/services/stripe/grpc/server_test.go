@@ -2,15 +2,24 @@ package grpcserver
 
 import (
 	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
 	stripe "github.com/stripe/stripe-go"
 	config "github.com/tbeaudouin05/stripe-trellai/api/config"
 	app "github.com/tbeaudouin05/stripe-trellai/api/services/stripe/app"
 	stripedb "github.com/tbeaudouin05/stripe-trellai/api/services/stripe/db"
+	"github.com/tbeaudouin05/stripe-trellai/api/services/stripe/gateway"
 	stripev1 "github.com/tbeaudouin05/stripe-trellai/internal/autogenerated/proto/stripe/v1"
 	"google.golang.org/genproto/googleapis/api/httpbody"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 // stubService implements app.Service with function fields.
@@ -19,6 +28,20 @@ type stubService struct {
 	VerifyFn func(string) (app.VerifySubscriptionResponse, error)
 	HandleFn func(stripe.Event) error
 	AddUnitsFn func([]stripedb.SpendingUnit) (int, error)
+	RefundFn   func([]string) (int, error)
+	CheckoutFn func(userExternalID, packID, successURL, cancelURL string) (string, error)
+	GrantFn    func(userExternalID string, amount int, reason, actor string, expiresAt int64) (stripedb.CreditAdjustment, error)
+	RedeemFn   func(userExternalID, code, idempotencyKey string) (stripedb.Redemption, error)
+	InvoiceFn  func(stripe.Event) error
+	DeletedFn  func(stripe.Event) error
+	BatchVerifyFn func([]string) (map[string]app.VerifyResult, error)
+	WatchFn    func(ctx context.Context, userExternalID string, send func(app.EntitlementState) error) error
+	ChangePlanFn func(app.PlanChange) (app.PlanChangeResult, error)
+	PauseFn      func(userExternalID, behavior string, resumesAt int64) (app.PauseState, error)
+	AddMemberFn  func(orgExternalID, actorUserExternalID, userExternalID, role string) error
+	RemoveSeatFn func(orgExternalID, actorUserExternalID, userExternalID string) (app.SeatState, error)
+	SetCapFn     func(orgExternalID, actorUserExternalID string, c app.SpendingCapChange) error
+	ListInvalidFn func(afterID int64, limit int, includeResolved bool) ([]stripedb.InvalidSubscription, error)
 }
 
 func (s stubService) CancelSubscription(id string) error {
@@ -35,6 +58,24 @@ func (s stubService) VerifySubscription(userExternalID string) (app.VerifySubscr
 	return app.VerifySubscriptionResponse{}, nil
 }
 
+func (s stubService) BatchVerifySubscription(userExternalIDs []string) (map[string]app.VerifyResult, error) {
+	if s.BatchVerifyFn != nil {
+		return s.BatchVerifyFn(userExternalIDs)
+	}
+	return map[string]app.VerifyResult{}, nil
+}
+
+func (s stubService) GetEntitlements(userExternalID string) (app.EntitlementState, error) {
+	return app.EntitlementState{}, nil
+}
+
+func (s stubService) WatchEntitlements(ctx context.Context, userExternalID string, send func(app.EntitlementState) error) error {
+	if s.WatchFn != nil {
+		return s.WatchFn(ctx, userExternalID, send)
+	}
+	return nil
+}
+
 func (s stubService) HandleCheckoutSessionCompleted(e stripe.Event) error {
 	if s.HandleFn != nil {
 		return s.HandleFn(e)
@@ -49,6 +90,154 @@ func (s stubService) AddSpendingUnits(items []stripedb.SpendingUnit) (int, error
 	return 0, nil
 }
 
+func (s stubService) RefundSpendingUnits(externalIDs []string) (int, error) {
+	if s.RefundFn != nil {
+		return s.RefundFn(externalIDs)
+	}
+	return 0, nil
+}
+
+func (s stubService) ReportMeteredUsage() (int, error) { return 0, nil }
+
+func (s stubService) InvoiceOverages() (int, error) { return 0, nil }
+
+func (s stubService) DeliverWebhooks() (int, error) { return 0, nil }
+
+func (s stubService) ListWebhookDeliveries(beforeID int64, status, eventType string, limit int) ([]stripedb.WebhookDelivery, error) {
+	return nil, nil
+}
+
+func (s stubService) RefreshFreeCredits() (int, error) { return 0, nil }
+
+func (s stubService) GrantCredits(userExternalID string, amount int, reason, actor string, expiresAt int64) (stripedb.CreditAdjustment, error) {
+	if s.GrantFn != nil {
+		return s.GrantFn(userExternalID, amount, reason, actor, expiresAt)
+	}
+	return stripedb.CreditAdjustment{}, nil
+}
+
+func (s stubService) RevokeCredits(userExternalID string, amount int, reason, actor string) (stripedb.CreditAdjustment, error) {
+	return stripedb.CreditAdjustment{}, nil
+}
+
+func (s stubService) CreateCampaign(c stripedb.Campaign) (int64, error) { return 0, nil }
+
+func (s stubService) RedeemCode(userExternalID, code, idempotencyKey string) (stripedb.Redemption, error) {
+	if s.RedeemFn != nil {
+		return s.RedeemFn(userExternalID, code, idempotencyKey)
+	}
+	return stripedb.Redemption{}, nil
+}
+
+func (s stubService) GetReferralCode(userExternalID string) (string, error) { return "", nil }
+
+func (s stubService) HandleInvoiceEvent(e stripe.Event) error {
+	if s.InvoiceFn != nil {
+		return s.InvoiceFn(e)
+	}
+	return nil
+}
+
+func (s stubService) HandleSubscriptionDeleted(e stripe.Event) error {
+	if s.DeletedFn != nil {
+		return s.DeletedFn(e)
+	}
+	return nil
+}
+
+func (s stubService) GetBillingStatus(userExternalID string) (stripedb.BillingStatus, error) {
+	return stripedb.BillingStatus{}, nil
+}
+
+func (s stubService) PreviewPlanChange(c app.PlanChange) (gateway.PlanChangePreview, error) {
+	return gateway.PlanChangePreview{}, nil
+}
+
+func (s stubService) ChangePlan(c app.PlanChange) (app.PlanChangeResult, error) {
+	if s.ChangePlanFn != nil {
+		return s.ChangePlanFn(c)
+	}
+	return app.PlanChangeResult{}, nil
+}
+
+func (s stubService) ListInvalidSubscriptions(afterID int64, limit int, includeResolved bool) ([]stripedb.InvalidSubscription, error) {
+	if s.ListInvalidFn != nil {
+		return s.ListInvalidFn(afterID, limit, includeResolved)
+	}
+	return nil, nil
+}
+
+func (s stubService) ResolveInvalidSubscription(id int64, resolution, actor string) (stripedb.InvalidSubscription, error) {
+	return stripedb.InvalidSubscription{ID: id, Resolution: resolution, ResolvedBy: actor}, nil
+}
+
+func (s stubService) PauseSubscription(userExternalID, behavior string, resumesAt int64) (app.PauseState, error) {
+	if s.PauseFn != nil {
+		return s.PauseFn(userExternalID, behavior, resumesAt)
+	}
+	return app.PauseState{}, nil
+}
+
+func (s stubService) ResumeSubscription(userExternalID string) (app.PauseState, error) {
+	return app.PauseState{}, nil
+}
+
+func (s stubService) CreateOrganization(orgExternalID, ownerUserExternalID string) (int64, error) {
+	return 0, nil
+}
+
+func (s stubService) AddOrganizationMember(orgExternalID, actorUserExternalID, userExternalID, role string) error {
+	if s.AddMemberFn != nil {
+		return s.AddMemberFn(orgExternalID, actorUserExternalID, userExternalID, role)
+	}
+	return nil
+}
+
+func (s stubService) RemoveOrganizationMember(orgExternalID, actorUserExternalID, userExternalID string) error {
+	return nil
+}
+
+func (s stubService) ListOrganizationMembers(orgExternalID, actorUserExternalID string) ([]stripedb.OrganizationMember, error) {
+	return nil, nil
+}
+
+func (s stubService) AddSeat(orgExternalID, actorUserExternalID, userExternalID string) (app.SeatState, error) {
+	return app.SeatState{}, nil
+}
+
+func (s stubService) RemoveSeat(orgExternalID, actorUserExternalID, userExternalID string) (app.SeatState, error) {
+	if s.RemoveSeatFn != nil {
+		return s.RemoveSeatFn(orgExternalID, actorUserExternalID, userExternalID)
+	}
+	return app.SeatState{}, nil
+}
+
+func (s stubService) ListSeats(orgExternalID, actorUserExternalID string) (app.SeatState, error) {
+	return app.SeatState{}, nil
+}
+
+func (s stubService) SetSpendingCap(orgExternalID, actorUserExternalID string, c app.SpendingCapChange) error {
+	if s.SetCapFn != nil {
+		return s.SetCapFn(orgExternalID, actorUserExternalID, c)
+	}
+	return nil
+}
+
+func (s stubService) GetUsageBreakdown(orgExternalID, actorUserExternalID string) (app.UsageBreakdown, error) {
+	return app.UsageBreakdown{}, nil
+}
+
+func (s stubService) GetUsageByDimension(userExternalID, labelKey string) (app.DimensionUsage, error) {
+	return app.DimensionUsage{}, nil
+}
+
+func (s stubService) CreateCreditPackCheckout(userExternalID, packID, successURL, cancelURL string) (string, error) {
+	if s.CheckoutFn != nil {
+		return s.CheckoutFn(userExternalID, packID, successURL, cancelURL)
+	}
+	return "", nil
+}
+
 func ensureConfig(t *testing.T) {
 	t.Helper()
 	if config.AppConfig == nil {
@@ -127,3 +316,497 @@ func TestHandleWebhook_Success_WithStubbedConstructEvent(t *testing.T) {
 		t.Fatalf("expected HandleCheckoutSessionCompleted to be called")
 	}
 }
+
+func TestDispatchEvent_RoutesInvoiceEvents(t *testing.T) {
+	var got []string
+	app := stubService{
+		HandleFn:  func(e stripe.Event) error { t.Fatalf("unexpected checkout handler call for %s", e.Type); return nil },
+		InvoiceFn: func(e stripe.Event) error { got = append(got, e.Type); return nil },
+	}
+	for _, typ := range []string{"invoice.payment_failed", "invoice.paid", "invoice.voided", "invoice.marked_uncollectible", "invoice.created"} {
+		if err := DispatchEvent(app, stripe.Event{Type: typ}); err != nil {
+			t.Fatalf("DispatchEvent(%s) returned error: %v", typ, err)
+		}
+	}
+	if len(got) != 4 || got[0] != "invoice.payment_failed" || got[1] != "invoice.paid" || got[2] != "invoice.voided" || got[3] != "invoice.marked_uncollectible" {
+		t.Fatalf("unexpected invoice events handled: %v", got)
+	}
+}
+
+func TestDispatchEvent_RoutesSubscriptionDeleted(t *testing.T) {
+	var got []string
+	app := stubService{
+		InvoiceFn: func(e stripe.Event) error { t.Fatalf("unexpected invoice handler call for %s", e.Type); return nil },
+		DeletedFn: func(e stripe.Event) error { got = append(got, e.Type); return nil },
+	}
+	for _, typ := range []string{"customer.subscription.deleted", "customer.subscription.updated"} {
+		if err := DispatchEvent(app, stripe.Event{Type: typ}); err != nil {
+			t.Fatalf("DispatchEvent(%s) returned error: %v", typ, err)
+		}
+	}
+	if len(got) != 1 || got[0] != "customer.subscription.deleted" {
+		t.Fatalf("unexpected subscription events handled: %v", got)
+	}
+}
+
+func TestRefundSpendingUnits_OK(t *testing.T) {
+	ensureConfig(t)
+	var got []string
+	srv := New(stubService{RefundFn: func(ids []string) (int, error) {
+		got = ids
+		return len(ids), nil
+	}})
+	resp, err := srv.RefundSpendingUnits(context.Background(), &stripev1.RefundSpendingUnitsRequest{ExternalIds: []string{"evt-1", "evt-2"}})
+	if err != nil {
+		t.Fatalf("RefundSpendingUnits returned error: %v", err)
+	}
+	if resp.GetRefunded() != 2 || len(got) != 2 || got[0] != "evt-1" {
+		t.Fatalf("unexpected response: %+v (forwarded %v)", resp, got)
+	}
+}
+
+func TestRefundSpendingUnits_MissingIDs(t *testing.T) {
+	ensureConfig(t)
+	srv := New(stubService{})
+	if _, err := srv.RefundSpendingUnits(context.Background(), &stripev1.RefundSpendingUnitsRequest{}); err == nil {
+		t.Fatalf("expected error for empty external_ids, got nil")
+	}
+}
+
+func TestCreateCreditPackCheckout_OK(t *testing.T) {
+	ensureConfig(t)
+	var gotPack string
+	srv := New(stubService{CheckoutFn: func(userExternalID, packID, successURL, cancelURL string) (string, error) {
+		gotPack = packID
+		return "cs_test_123", nil
+	}})
+	resp, err := srv.CreateCreditPackCheckout(context.Background(), &stripev1.CreateCreditPackCheckoutRequest{
+		UserExternalId: "user-1",
+		PackId:         "starter",
+		SuccessUrl:     "https://example.com/ok",
+		CancelUrl:      "https://example.com/cancel",
+	})
+	if err != nil {
+		t.Fatalf("CreateCreditPackCheckout returned error: %v", err)
+	}
+	if resp.GetCheckoutSessionId() != "cs_test_123" || gotPack != "starter" {
+		t.Fatalf("unexpected response: %+v (pack %q)", resp, gotPack)
+	}
+}
+
+func TestGrantCredits_RequiresAdminToken(t *testing.T) {
+	ensureConfig(t)
+	prev := config.AppConfig.AdminAPITokens
+	defer func() { config.AppConfig.AdminAPITokens = prev }()
+	config.AppConfig.AdminAPITokens = "support:s3cret"
+
+	var gotActor string
+	srv := New(stubService{GrantFn: func(userExternalID string, amount int, reason, actor string, expiresAt int64) (stripedb.CreditAdjustment, error) {
+		gotActor = actor
+		return stripedb.CreditAdjustment{GrantID: 7, Applied: amount, Balance: amount}, nil
+	}})
+	req := &stripev1.GrantCreditsRequest{UserExternalId: "user-1", Amount: 100, Reason: "goodwill"}
+
+	if _, err := srv.GrantCredits(context.Background(), req); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("expected Unauthenticated without token, got %v", err)
+	}
+	bad := metadata.NewIncomingContext(context.Background(), metadata.Pairs(AdminTokenHeader, "wrong"))
+	if _, err := srv.GrantCredits(bad, req); status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("expected PermissionDenied with wrong token, got %v", err)
+	}
+	ok := metadata.NewIncomingContext(context.Background(), metadata.Pairs(AdminTokenHeader, "s3cret"))
+	resp, err := srv.GrantCredits(ok, req)
+	if err != nil {
+		t.Fatalf("GrantCredits returned error: %v", err)
+	}
+	if resp.GetGrantId() != 7 || resp.GetCredit() != 100 || gotActor != "support" {
+		t.Fatalf("unexpected response: %+v (actor %q)", resp, gotActor)
+	}
+}
+
+func TestRedeemCode_MapsRefusals(t *testing.T) {
+	ensureConfig(t)
+	var gotKey string
+	srv := New(stubService{RedeemFn: func(userExternalID, code, idempotencyKey string) (stripedb.Redemption, error) {
+		gotKey = idempotencyKey
+		switch code {
+		case "GONE":
+			return stripedb.Redemption{}, fmt.Errorf("%w: %v", app.ErrNotAllowed, stripedb.ErrCampaignExhausted)
+		case "NOPE":
+			return stripedb.Redemption{}, fmt.Errorf("%w: %v", app.ErrNotFound, stripedb.ErrCampaignNotFound)
+		}
+		return stripedb.Redemption{Code: code, Units: 50, Balance: 150}, nil
+	}})
+
+	resp, err := srv.RedeemCode(context.Background(), &stripev1.RedeemCodeRequest{UserExternalId: "user-1", Code: " welcome "})
+	if err != nil {
+		t.Fatalf("RedeemCode returned error: %v", err)
+	}
+	if resp.GetUnits() != 50 || resp.GetCredit() != 150 || gotKey != "WELCOME" {
+		t.Fatalf("unexpected response: %+v (key %q)", resp, gotKey)
+	}
+	if _, err := srv.RedeemCode(context.Background(), &stripev1.RedeemCodeRequest{UserExternalId: "user-1", Code: "GONE"}); status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("expected FailedPrecondition, got %v", err)
+	}
+	if _, err := srv.RedeemCode(context.Background(), &stripev1.RedeemCodeRequest{UserExternalId: "user-1", Code: "NOPE"}); status.Code(err) != codes.NotFound {
+		t.Fatalf("expected NotFound, got %v", err)
+	}
+}
+
+func TestChangePlan_OK(t *testing.T) {
+	ensureConfig(t)
+	var got app.PlanChange
+	srv := New(stubService{ChangePlanFn: func(c app.PlanChange) (app.PlanChangeResult, error) {
+		got = c
+		return app.PlanChangeResult{SubscriptionID: "sub_1", PlanID: c.PlanID, Quantity: 3, Status: "active"}, nil
+	}})
+	resp, err := srv.ChangePlan(context.Background(), &stripev1.PlanChangeRequest{UserExternalId: "user-1", PlanId: "plan_pro", Quantity: 3, ProrationBehavior: "always_invoice"})
+	if err != nil {
+		t.Fatalf("ChangePlan returned error: %v", err)
+	}
+	if resp.GetPlanId() != "plan_pro" || resp.GetQuantity() != 3 || got.ProrationBehavior != "always_invoice" || got.UserExternalID != "user-1" {
+		t.Fatalf("unexpected response: %+v (forwarded %+v)", resp, got)
+	}
+	if _, err := srv.ChangePlan(context.Background(), &stripev1.PlanChangeRequest{UserExternalId: "user-1"}); err == nil {
+		t.Fatalf("expected error for missing plan_id")
+	}
+}
+
+func TestListInvalidSubscriptions_DefaultsToPending(t *testing.T) {
+	ensureConfig(t)
+	prev := config.AppConfig.AdminAPITokens
+	defer func() { config.AppConfig.AdminAPITokens = prev }()
+	config.AppConfig.AdminAPITokens = "support:s3cret"
+
+	var gotAfter int64
+	var gotLimit int
+	var gotResolved bool
+	srv := New(stubService{ListInvalidFn: func(afterID int64, limit int, includeResolved bool) ([]stripedb.InvalidSubscription, error) {
+		gotAfter, gotLimit, gotResolved = afterID, limit, includeResolved
+		return []stripedb.InvalidSubscription{{ID: 4, StripeSubscriptionID: "sub-dup"}}, nil
+	}})
+	ok := metadata.NewIncomingContext(context.Background(), metadata.Pairs(AdminTokenHeader, "s3cret"))
+
+	if _, err := srv.ListInvalidSubscriptions(context.Background(), &stripev1.ListInvalidSubscriptionsRequest{}); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("expected Unauthenticated without token, got %v", err)
+	}
+	resp, err := srv.ListInvalidSubscriptions(ok, &stripev1.ListInvalidSubscriptionsRequest{AfterId: 3})
+	if err != nil {
+		t.Fatalf("ListInvalidSubscriptions returned error: %v", err)
+	}
+	if gotAfter != 3 || gotLimit != 50 || gotResolved {
+		t.Fatalf("unexpected arguments: after %d, limit %d, include_resolved %v", gotAfter, gotLimit, gotResolved)
+	}
+	if len(resp.GetInvalidSubscriptions()) != 1 || resp.GetInvalidSubscriptions()[0].GetStripeSubscriptionId() != "sub-dup" {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+	if _, err := srv.ListInvalidSubscriptions(ok, &stripev1.ListInvalidSubscriptionsRequest{Limit: 1000}); err == nil {
+		t.Fatalf("expected error for limit above maximum")
+	}
+
+	res, err := srv.ResolveInvalidSubscription(ok, &stripev1.ResolveInvalidSubscriptionRequest{Id: 4, Resolution: "dismiss"})
+	if err != nil {
+		t.Fatalf("ResolveInvalidSubscription returned error: %v", err)
+	}
+	if res.GetInvalidSubscription().GetResolvedBy() != "support" {
+		t.Fatalf("unexpected response: %+v", res)
+	}
+}
+
+func TestPauseSubscription_MapsRefusals(t *testing.T) {
+	ensureConfig(t)
+	srv := New(stubService{PauseFn: func(userExternalID, behavior string, resumesAt int64) (app.PauseState, error) {
+		if userExternalID == "no-sub" {
+			return app.PauseState{}, fmt.Errorf("%w: user has no subscription", app.ErrNotFound)
+		}
+		return app.PauseState{SubscriptionID: "sub_1", Paused: true, Behavior: behavior, ResumesAt: resumesAt}, nil
+	}})
+
+	resp, err := srv.PauseSubscription(context.Background(), &stripev1.PauseSubscriptionRequest{UserExternalId: "user-1", Behavior: "keep_as_draft", ResumesAt: 1767225600000})
+	if err != nil {
+		t.Fatalf("PauseSubscription returned error: %v", err)
+	}
+	if !resp.GetPaused() || resp.GetBehavior() != "keep_as_draft" || resp.GetResumesAt() != 1767225600000 {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+	if _, err := srv.PauseSubscription(context.Background(), &stripev1.PauseSubscriptionRequest{UserExternalId: "no-sub"}); status.Code(err) != codes.NotFound {
+		t.Fatalf("expected NotFound, got %v", err)
+	}
+}
+
+func TestAddOrganizationMember_MapsRefusals(t *testing.T) {
+	ensureConfig(t)
+	srv := New(stubService{AddMemberFn: func(orgExternalID, actorUserExternalID, userExternalID, role string) error {
+		if actorUserExternalID != "owner-1" {
+			return fmt.Errorf("%w: only the owner and admins manage members", app.ErrNotAllowed)
+		}
+		return nil
+	}})
+
+	req := &stripev1.AddOrganizationMemberRequest{OrganizationExternalId: "team-1", ActorUserExternalId: "owner-1", UserExternalId: "user-2"}
+	if _, err := srv.AddOrganizationMember(context.Background(), req); err != nil {
+		t.Fatalf("AddOrganizationMember returned error: %v", err)
+	}
+	req.ActorUserExternalId = "user-3"
+	if _, err := srv.AddOrganizationMember(context.Background(), req); status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("expected FailedPrecondition, got %v", err)
+	}
+	if _, err := srv.AddOrganizationMember(context.Background(), &stripev1.AddOrganizationMemberRequest{OrganizationExternalId: "team-1"}); err == nil {
+		t.Fatalf("expected error for missing fields")
+	}
+}
+
+func TestRemoveSeat_MapsRefusals(t *testing.T) {
+	ensureConfig(t)
+	srv := New(stubService{RemoveSeatFn: func(orgExternalID, actorUserExternalID, userExternalID string) (app.SeatState, error) {
+		switch userExternalID {
+		case "owner-1":
+			return app.SeatState{}, fmt.Errorf("%w: an organization keeps at least one seat", app.ErrNotAllowed)
+		case "user-3":
+			return app.SeatState{}, fmt.Errorf("%w: user has no seat", app.ErrNotFound)
+		}
+		return app.SeatState{Seats: []stripedb.OrganizationMember{{UserExternalID: "h-owner", Role: stripedb.RoleOwner, CreatedAt: 1}}, Quantity: 1}, nil
+	}})
+
+	req := &stripev1.RemoveSeatRequest{OrganizationExternalId: "team-1", ActorUserExternalId: "owner-1", UserExternalId: "user-2"}
+	resp, err := srv.RemoveSeat(context.Background(), req)
+	if err != nil {
+		t.Fatalf("RemoveSeat returned error: %v", err)
+	}
+	if resp.GetQuantity() != 1 || len(resp.GetSeats()) != 1 || resp.GetSeats()[0].GetRole() != stripedb.RoleOwner {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+	req.UserExternalId = "owner-1"
+	if _, err := srv.RemoveSeat(context.Background(), req); status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("expected FailedPrecondition, got %v", err)
+	}
+	req.UserExternalId = "user-3"
+	if _, err := srv.RemoveSeat(context.Background(), req); status.Code(err) != codes.NotFound {
+		t.Fatalf("expected NotFound, got %v", err)
+	}
+}
+
+func TestAddSpendingUnits_CapReached(t *testing.T) {
+	ensureConfig(t)
+	srv := New(stubService{AddUnitsFn: func(items []stripedb.SpendingUnit) (int, error) {
+		if items[0].APIKeyID == "key-capped" {
+			return 0, fmt.Errorf("%w: item 0: API key spending cap of 10 units reached", app.ErrNotAllowed)
+		}
+		return len(items), nil
+	}})
+
+	req := &stripev1.AddSpendingUnitsRequest{Items: []*stripev1.SpendingUnit{
+		{ExternalId: "unit-1", UserExternalId: "user-1", Amount: 3, CreatedAt: 1, ApiKeyId: "key-1"},
+	}}
+	resp, err := srv.AddSpendingUnits(context.Background(), req)
+	if err != nil || resp.GetInserted() != 1 {
+		t.Fatalf("AddSpendingUnits = %v, %v", resp, err)
+	}
+	req.Items[0].ApiKeyId = "key-capped"
+	if _, err := srv.AddSpendingUnits(context.Background(), req); status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("expected FailedPrecondition, got %v", err)
+	}
+}
+
+func TestSetSpendingCap_RequiresOneSubject(t *testing.T) {
+	ensureConfig(t)
+	var got app.SpendingCapChange
+	srv := New(stubService{SetCapFn: func(orgExternalID, actorUserExternalID string, c app.SpendingCapChange) error {
+		got = c
+		return nil
+	}})
+
+	req := &stripev1.SetSpendingCapRequest{OrganizationExternalId: "team-1", ActorUserExternalId: "owner-1", CapUnits: 50}
+	if _, err := srv.SetSpendingCap(context.Background(), req); err == nil {
+		t.Fatalf("expected error without member or API key")
+	}
+	req.MemberUserExternalId, req.ApiKeyId = "user-2", "key-1"
+	if _, err := srv.SetSpendingCap(context.Background(), req); err == nil {
+		t.Fatalf("expected error with both member and API key")
+	}
+	req.ApiKeyId = ""
+	if _, err := srv.SetSpendingCap(context.Background(), req); err != nil {
+		t.Fatalf("SetSpendingCap returned error: %v", err)
+	}
+	if got.MemberUserExternalID != "user-2" || got.CapUnits != 50 {
+		t.Fatalf("unexpected cap change: %+v", got)
+	}
+}
+
+func TestAddSpendingUnits_Dimensions(t *testing.T) {
+	ensureConfig(t)
+	var got []stripedb.SpendingUnit
+	srv := New(stubService{AddUnitsFn: func(items []stripedb.SpendingUnit) (int, error) {
+		got = items
+		return len(items), nil
+	}})
+
+	item := &stripev1.SpendingUnit{ExternalId: "unit-1", UserExternalId: "user-1", Amount: 1, CreatedAt: 1,
+		FeatureKey: "image_generation", Labels: map[string]string{"project": "p-1"}}
+	if _, err := srv.AddSpendingUnits(context.Background(), &stripev1.AddSpendingUnitsRequest{Items: []*stripev1.SpendingUnit{item}}); err != nil {
+		t.Fatalf("AddSpendingUnits returned error: %v", err)
+	}
+	if got[0].FeatureKey != "image_generation" || got[0].Labels["project"] != "p-1" {
+		t.Fatalf("dimensions not passed through: %+v", got[0])
+	}
+	item.FeatureKey = strings.Repeat("x", 65)
+	if _, err := srv.AddSpendingUnits(context.Background(), &stripev1.AddSpendingUnitsRequest{Items: []*stripev1.SpendingUnit{item}}); err == nil {
+		t.Fatalf("expected error for a feature_key over 64 characters")
+	}
+}
+
+func TestEntitlementsSSEHandler_StreamsStates(t *testing.T) {
+	ensureConfig(t)
+	svc := stubService{WatchFn: func(ctx context.Context, user string, send func(app.EntitlementState) error) error {
+		if user != "user_123" {
+			t.Fatalf("unexpected user %q", user)
+		}
+		for _, remaining := range []int64{10, 7} {
+			if err := send(app.EntitlementState{RemainingUnits: remaining, Validity: app.VerifySubscriptionResponse{IsValidSubscription: true}}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}}
+	rec := httptest.NewRecorder()
+	EntitlementsSSEHandler(svc).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/entitlements/stream?user_external_id=user_123", nil))
+
+	if ct := rec.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("unexpected content type %q", ct)
+	}
+	events := strings.Split(strings.TrimSpace(rec.Body.String()), "\n\n")
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %q", rec.Body.String())
+	}
+	for i, want := range []string{`"remainingUnits":"10"`, `"remainingUnits":"7"`} {
+		if !strings.HasPrefix(events[i], "event: entitlements\ndata: ") || !strings.Contains(events[i], want) || !strings.Contains(events[i], `"isValidSubscription":true`) {
+			t.Fatalf("unexpected event %d: %q", i, events[i])
+		}
+	}
+}
+
+func TestEntitlementsSSEHandler_RefusalBeforeFirstState(t *testing.T) {
+	ensureConfig(t)
+	svc := stubService{WatchFn: func(ctx context.Context, user string, send func(app.EntitlementState) error) error {
+		return fmt.Errorf("%w: organization not found", app.ErrNotFound)
+	}}
+	rec := httptest.NewRecorder()
+	EntitlementsSSEHandler(svc).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/entitlements/stream?user_external_id=user_123", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	EntitlementsSSEHandler(svc).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/entitlements/stream", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 without user_external_id, got %d", rec.Code)
+	}
+}
+
+// fakeUnitStream feeds units to StreamSpendingUnits and records its response.
+type fakeUnitStream struct {
+	grpc.ServerStream
+	units []*stripev1.SpendingUnit
+	resp  *stripev1.StreamSpendingUnitsResponse
+}
+
+func (f *fakeUnitStream) Context() context.Context { return context.Background() }
+
+func (f *fakeUnitStream) Recv() (*stripev1.SpendingUnit, error) {
+	if len(f.units) == 0 {
+		return nil, io.EOF
+	}
+	it := f.units[0]
+	f.units = f.units[1:]
+	return it, nil
+}
+
+func (f *fakeUnitStream) SendAndClose(resp *stripev1.StreamSpendingUnitsResponse) error {
+	f.resp = resp
+	return nil
+}
+
+func TestStreamSpendingUnits_MicroBatches(t *testing.T) {
+	ensureConfig(t)
+	defer func(size int) { config.AppConfig.SpendingUnitsStreamBatchSize = size }(config.AppConfig.SpendingUnitsStreamBatchSize)
+	config.AppConfig.SpendingUnitsStreamBatchSize = 2
+
+	var sizes []int
+	seen := make(map[string]bool)
+	srv := New(stubService{AddUnitsFn: func(items []stripedb.SpendingUnit) (int, error) {
+		sizes = append(sizes, len(items))
+		n := 0
+		for _, it := range items {
+			if !seen[it.ExternalID] {
+				seen[it.ExternalID] = true
+				n++
+			}
+		}
+		return n, nil
+	}})
+	stream := &fakeUnitStream{}
+	for _, id := range []string{"evt-1", "evt-2", "evt-1", "evt-3", "evt-4"} {
+		stream.units = append(stream.units, &stripev1.SpendingUnit{ExternalId: id, UserExternalId: "user_123", Amount: 1, CreatedAt: 1723500000000})
+	}
+	if err := srv.StreamSpendingUnits(stream); err != nil {
+		t.Fatalf("StreamSpendingUnits returned error: %v", err)
+	}
+	if stream.resp.GetReceived() != 5 || stream.resp.GetInserted() != 4 || stream.resp.GetDuplicates() != 1 {
+		t.Fatalf("unexpected counts: %+v", stream.resp)
+	}
+	total := 0
+	for _, n := range sizes {
+		if n > 2 {
+			t.Fatalf("batch of %d exceeds the batch size: %v", n, sizes)
+		}
+		total += n
+	}
+	if total != 5 || int(stream.resp.GetBatches()) != len(sizes) {
+		t.Fatalf("unexpected batches %v for %+v", sizes, stream.resp)
+	}
+}
+
+func TestStreamSpendingUnits_InvalidUnit(t *testing.T) {
+	ensureConfig(t)
+	srv := New(stubService{AddUnitsFn: func(items []stripedb.SpendingUnit) (int, error) {
+		return len(items), nil
+	}})
+	stream := &fakeUnitStream{units: []*stripev1.SpendingUnit{
+		{ExternalId: "evt-1", UserExternalId: "user_123", Amount: 1, CreatedAt: 1723500000000},
+		{ExternalId: "evt-2", UserExternalId: "user_123", Amount: 0, CreatedAt: 1723500000000},
+	}}
+	err := srv.StreamSpendingUnits(stream)
+	if err == nil || !strings.Contains(err.Error(), "item 1") {
+		t.Fatalf("expected an error for item 1, got %v", err)
+	}
+	if stream.resp != nil {
+		t.Fatalf("expected no response after an invalid unit")
+	}
+}
+
+func TestBatchVerifySubscriptionValidity_PerUserResults(t *testing.T) {
+	ensureConfig(t)
+	srv := New(stubService{BatchVerifyFn: func(users []string) (map[string]app.VerifyResult, error) {
+		return map[string]app.VerifyResult{
+			"u1": {Response: app.VerifySubscriptionResponse{IsValidSubscription: true, ValidityType: app.ValidityTypeFreeTier}},
+			"u2": {Err: fmt.Errorf("%w: error getting subscription: timeout", app.ErrGateway)},
+		}, nil
+	}})
+	resp, err := srv.BatchVerifySubscriptionValidity(context.Background(), &stripev1.BatchVerifySubscriptionValidityRequest{UserExternalIds: []string{"u1", "u2"}})
+	if err != nil {
+		t.Fatalf("BatchVerifySubscriptionValidity returned error: %v", err)
+	}
+	if r := resp.GetResults()["u1"]; !r.GetValidity().GetIsValidSubscription() || r.GetError() != "" {
+		t.Fatalf("unexpected result for u1: %+v", r)
+	}
+	if r := resp.GetResults()["u2"]; r.GetValidity() != nil || r.GetErrorCode() != codes.Unavailable.String() || !strings.Contains(r.GetError(), "timeout") {
+		t.Fatalf("unexpected result for u2: %+v", r)
+	}
+
+	if _, err := srv.BatchVerifySubscriptionValidity(context.Background(), &stripev1.BatchVerifySubscriptionValidityRequest{}); err == nil {
+		t.Fatalf("expected error without user_external_ids")
+	}
+	if _, err := srv.BatchVerifySubscriptionValidity(context.Background(), &stripev1.BatchVerifySubscriptionValidityRequest{UserExternalIds: make([]string, maxBatchVerifyUsers+1)}); err == nil {
+		t.Fatalf("expected error over %d users", maxBatchVerifyUsers)
+	}
+}
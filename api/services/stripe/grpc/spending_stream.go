@@ -0,0 +1,102 @@
+package grpcserver
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"google.golang.org/grpc"
+
+	bootstrap "github.com/tbeaudouin05/stripe-trellai/api/bootstrap"
+	config "github.com/tbeaudouin05/stripe-trellai/api/config"
+	stripedb "github.com/tbeaudouin05/stripe-trellai/api/services/stripe/db"
+	stripev1 "github.com/tbeaudouin05/stripe-trellai/internal/autogenerated/proto/stripe/v1"
+)
+
+// spendingStreamFlushInterval bounds how long a received unit waits for its micro-batch to fill.
+const spendingStreamFlushInterval = 200 * time.Millisecond
+
+// StreamSpendingUnits implements the client-streaming RPC recording spending units in
+// micro-batches of SPENDING_UNITS_STREAM_BATCH_SIZE, flushed when full or after
+// spendingStreamFlushInterval. Units are read at most one batch ahead of the database, so a
+// producer faster than the writes is held back by gRPC flow control. On error, batches already
+// written stay recorded; resending the stream is safe since duplicate external_ids are skipped.
+func (s Server) StreamSpendingUnits(stream grpc.ClientStreamingServer[stripev1.SpendingUnit, stripev1.StreamSpendingUnitsResponse]) error {
+	if err := bootstrap.Ensure(); err != nil {
+		return fmt.Errorf("initialization error: %v", err)
+	}
+	size := config.AppConfig.SpendingUnitsStreamBatchSize
+
+	units := make(chan *stripev1.SpendingUnit, size)
+	recvErr := make(chan error, 1)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		defer close(units)
+		for {
+			it, err := stream.Recv()
+			if err != nil {
+				if err != io.EOF {
+					recvErr <- err
+				}
+				return
+			}
+			select {
+			case units <- it:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	resp := &stripev1.StreamSpendingUnitsResponse{}
+	batch := make([]stripedb.SpendingUnit, 0, size)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		n, err := s.app.AddSpendingUnits(batch)
+		if err != nil {
+			return refusalStatus(err)
+		}
+		resp.Inserted += int64(n)
+		resp.Duplicates += int64(len(batch) - n)
+		resp.Batches++
+		batch = make([]stripedb.SpendingUnit, 0, size)
+		return nil
+	}
+	ticker := time.NewTicker(spendingStreamFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case it, ok := <-units:
+			if !ok {
+				select {
+				case err := <-recvErr:
+					// the client went away: the partial batch is dropped, like a failed unary call
+					return err
+				default:
+				}
+				if err := flush(); err != nil {
+					return err
+				}
+				return stream.SendAndClose(resp)
+			}
+			item, err := spendingUnitFromProto(int(resp.Received), it)
+			if err != nil {
+				return err
+			}
+			resp.Received++
+			batch = append(batch, item)
+			if len(batch) >= size {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		case <-ticker.C:
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+}
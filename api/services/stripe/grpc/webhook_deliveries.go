@@ -0,0 +1,57 @@
+package grpcserver
+
+import (
+	"context"
+	"fmt"
+
+	bootstrap "github.com/tbeaudouin05/stripe-trellai/api/bootstrap"
+	stripedb "github.com/tbeaudouin05/stripe-trellai/api/services/stripe/db"
+	stripev1 "github.com/tbeaudouin05/stripe-trellai/internal/autogenerated/proto/stripe/v1"
+)
+
+const (
+	defaultWebhookDeliveriesLimit = 50
+	maxWebhookDeliveriesLimit     = 500
+)
+
+func webhookDeliveryToProto(d stripedb.WebhookDelivery) *stripev1.WebhookDelivery {
+	return &stripev1.WebhookDelivery{
+		Id:             d.ID,
+		EventId:        d.EventID,
+		EventType:      d.EventType,
+		EndpointUrl:    d.EndpointURL,
+		Status:         d.Status,
+		Attempts:       int32(d.Attempts),
+		NextAttemptAt:  d.NextAttemptAt,
+		LastStatusCode: int32(d.LastStatusCode),
+		LastError:      d.LastError,
+		DeliveredAt:    d.DeliveredAt,
+		CreatedAt:      d.CreatedAt,
+	}
+}
+
+// ListWebhookDeliveries implements the admin RPC listing the outgoing webhook delivery log.
+func (s Server) ListWebhookDeliveries(ctx context.Context, req *stripev1.ListWebhookDeliveriesRequest) (*stripev1.ListWebhookDeliveriesResponse, error) {
+	if err := bootstrap.Ensure(); err != nil {
+		return nil, fmt.Errorf("initialization error: %v", err)
+	}
+	if _, err := adminActor(ctx); err != nil {
+		return nil, err
+	}
+	limit := int(req.GetLimit())
+	if limit < 0 || limit > maxWebhookDeliveriesLimit {
+		return nil, fmt.Errorf("limit must be between 0 and %d", maxWebhookDeliveriesLimit)
+	}
+	if limit == 0 {
+		limit = defaultWebhookDeliveriesLimit
+	}
+	list, err := s.app.ListWebhookDeliveries(req.GetBeforeId(), req.GetStatus(), req.GetEventType(), limit)
+	if err != nil {
+		return nil, err
+	}
+	resp := &stripev1.ListWebhookDeliveriesResponse{Deliveries: make([]*stripev1.WebhookDelivery, 0, len(list))}
+	for _, d := range list {
+		resp.Deliveries = append(resp.Deliveries, webhookDeliveryToProto(d))
+	}
+	return resp, nil
+}
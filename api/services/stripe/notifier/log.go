@@ -0,0 +1,12 @@
+package notifier
+
+import "log/slog"
+
+// Log writes alerts to the structured log.
+type Log struct{}
+
+func (Log) Notify(a Alert) error {
+	slog.Info("allowance alert", "type", a.Type, "user_external_id", a.UserExternalID,
+		"threshold_percent", a.ThresholdPercent, "units", a.Units, "allowance", a.Allowance, "period_start", a.PeriodStart)
+	return nil
+}
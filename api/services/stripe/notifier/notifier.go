@@ -0,0 +1,53 @@
+package notifier
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/tbeaudouin05/stripe-trellai/api/config"
+)
+
+// AlertTypeAllowanceThreshold is the type of alerts sent when an account's usage crosses one of
+// ALLOWANCE_ALERT_THRESHOLDS.
+const AlertTypeAllowanceThreshold = "allowance.threshold_reached"
+
+// Alert tells that an account used ThresholdPercent of its allowance in the billing period
+// [PeriodStart, PeriodEnd] (unix ms). UserExternalID is the account as the caller knows it (the
+// organization for pooled accounts); Email is its Stripe customer email, when known.
+type Alert struct {
+	Type             string `json:"type"`
+	UserExternalID   string `json:"user_external_id"`
+	Email            string `json:"email,omitempty"`
+	ThresholdPercent int    `json:"threshold_percent"`
+	Units            int64  `json:"units"`
+	Allowance        int64  `json:"allowance"`
+	PeriodStart      int64  `json:"period_start"`
+	PeriodEnd        int64  `json:"period_end"`
+}
+
+// Notifier delivers alerts. An error means the alert was not delivered and may be sent again.
+type Notifier interface {
+	Notify(a Alert) error
+}
+
+// New returns the notifier selected by ALERT_NOTIFIER.
+func New(c *config.Config) (Notifier, error) {
+	switch c.AlertNotifier {
+	case "", "log":
+		return Log{}, nil
+	case "none":
+		return Discard{}, nil
+	case "webhook":
+		return Webhook{URL: c.AlertWebhookURL, Client: &http.Client{Timeout: 10 * time.Second}}, nil
+	case "smtp":
+		return SMTP{Addr: c.SMTPAddr, Username: c.SMTPUsername, Password: c.SMTPPassword, From: c.AlertEmailFrom}, nil
+	default:
+		return nil, fmt.Errorf("unknown ALERT_NOTIFIER %q", c.AlertNotifier)
+	}
+}
+
+// Discard drops alerts.
+type Discard struct{}
+
+func (Discard) Notify(Alert) error { return nil }
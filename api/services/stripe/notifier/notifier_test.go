@@ -0,0 +1,57 @@
+package notifier
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tbeaudouin05/stripe-trellai/api/config"
+)
+
+func TestWebhook_PostsAlertAsJSON(t *testing.T) {
+	var got Alert
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	a := Alert{Type: AlertTypeAllowanceThreshold, UserExternalID: "board-1", ThresholdPercent: 80, Units: 8, Allowance: 10}
+	assert.NoError(t, Webhook{URL: srv.URL}.Notify(a))
+	assert.Equal(t, a, got)
+}
+
+func TestWebhook_ErrorStatusFails(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	assert.Error(t, Webhook{URL: srv.URL}.Notify(Alert{}))
+}
+
+func TestNew(t *testing.T) {
+	for kind, want := range map[string]Notifier{"": Log{}, "log": Log{}, "none": Discard{}} {
+		n, err := New(&config.Config{AlertNotifier: kind})
+		assert.NoError(t, err)
+		assert.Equal(t, want, n)
+	}
+	n, err := New(&config.Config{AlertNotifier: "webhook", AlertWebhookURL: "https://example.com/alerts"})
+	assert.NoError(t, err)
+	assert.Equal(t, "https://example.com/alerts", n.(Webhook).URL)
+
+	_, err = New(&config.Config{AlertNotifier: "pager"})
+	assert.Error(t, err)
+}
+
+func TestAlertEmail(t *testing.T) {
+	msg := string(alertEmail("billing@example.com", Alert{Email: "user@example.com", ThresholdPercent: 80, Units: 8, Allowance: 10}))
+	assert.True(t, strings.HasPrefix(msg, "From: billing@example.com\r\nTo: user@example.com\r\n"))
+	assert.Contains(t, msg, "Subject: You have used 80% of your allowance")
+	assert.Contains(t, msg, "8 of the 10 units")
+}
@@ -0,0 +1,49 @@
+package notifier
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+	"net/smtp"
+	"strings"
+)
+
+// SMTP emails alerts to the account's Stripe customer email through the server at Addr
+// (host:port), authenticating when Username is set. Alerts without an email are only logged.
+type SMTP struct {
+	Addr     string
+	Username string
+	Password string
+	From     string
+}
+
+func (s SMTP) Notify(a Alert) error {
+	if a.Email == "" {
+		slog.Warn("allowance alert without email, not sent", "user_external_id", a.UserExternalID, "threshold_percent", a.ThresholdPercent)
+		return nil
+	}
+	var auth smtp.Auth
+	if s.Username != "" {
+		host, _, err := net.SplitHostPort(s.Addr)
+		if err != nil {
+			return fmt.Errorf("invalid SMTP_ADDR %q: %w", s.Addr, err)
+		}
+		auth = smtp.PlainAuth("", s.Username, s.Password, host)
+	}
+	if err := smtp.SendMail(s.Addr, auth, s.From, []string{a.Email}, alertEmail(s.From, a)); err != nil {
+		return fmt.Errorf("error sending alert email: %w", err)
+	}
+	return nil
+}
+
+// alertEmail renders the alert as a plain text email message.
+func alertEmail(from string, a Alert) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", a.Email)
+	fmt.Fprintf(&b, "Subject: You have used %d%% of your allowance\r\n", a.ThresholdPercent)
+	b.WriteString("MIME-Version: 1.0\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n")
+	fmt.Fprintf(&b, "You have used %d of the %d units included in your plan for the current billing period (%d%%).\r\n",
+		a.Units, a.Allowance, a.ThresholdPercent)
+	return []byte(b.String())
+}
@@ -0,0 +1,136 @@
+// Package outbound signs and sends the webhooks this service emits to our own application.
+package outbound
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Headers sent with every delivery. SignatureHeader is "t=<unix seconds>,v1=<hex HMAC-SHA256 of
+// "<t>.<body>">", as Stripe does, so receivers can reject replayed deliveries.
+const (
+	SignatureHeader = "Webhook-Signature"
+	IDHeader        = "Webhook-Id"
+)
+
+// SendTimeout bounds a delivery made with the default client, response included.
+const SendTimeout = 10 * time.Second
+
+// Event types.
+const (
+	EventSubscriptionActivated = "subscription.activated"
+	EventSubscriptionCancelled = "subscription.cancelled"
+	EventCreditsExhausted      = "credits.exhausted"
+	EventCreditsGranted        = "credits.granted"
+)
+
+// Event is the JSON body of a delivery. Created is unix ms.
+type Event struct {
+	ID      string    `json:"id"`
+	Type    string    `json:"type"`
+	Created int64     `json:"created"`
+	Data    EventData `json:"data"`
+}
+
+// EventData describes the account an event is about. UserHash is the SHA-256 the service stores
+// identifiers as; UserExternalID is only set when the event comes from a call that carried it.
+// Units and Source are set for credits.granted.
+type EventData struct {
+	UserExternalID       string `json:"user_external_id,omitempty"`
+	UserHash             string `json:"user_hash"`
+	StripeSubscriptionID string `json:"stripe_subscription_id,omitempty"`
+	StripeCustomerID     string `json:"stripe_customer_id,omitempty"`
+	Units                int64  `json:"units,omitempty"`
+	Source               string `json:"source,omitempty"`
+}
+
+// Sign returns the SignatureHeader value of body sent at t.
+func Sign(secret string, t time.Time, body []byte) string {
+	ts := strconv.FormatInt(t.Unix(), 10)
+	return "t=" + ts + ",v1=" + mac(secret, ts, body)
+}
+
+// Verify checks a SignatureHeader value against body, refusing signatures older than tolerance.
+func Verify(secret, header string, body []byte, tolerance time.Duration, now time.Time) error {
+	var ts, sig string
+	for _, part := range strings.Split(header, ",") {
+		k, v, _ := strings.Cut(part, "=")
+		switch k {
+		case "t":
+			ts = v
+		case "v1":
+			sig = v
+		}
+	}
+	sec, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil || sig == "" {
+		return fmt.Errorf("malformed signature header")
+	}
+	if now.Sub(time.Unix(sec, 0)) > tolerance {
+		return fmt.Errorf("signature too old")
+	}
+	if !hmac.Equal([]byte(sig), []byte(mac(secret, ts, body))) {
+		return fmt.Errorf("signature mismatch")
+	}
+	return nil
+}
+
+func mac(secret, ts string, body []byte) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(ts))
+	h.Write([]byte("."))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Backoff returns how long to wait after the given number of failed attempts: 30s doubling up to 6h.
+func Backoff(attempts int) time.Duration {
+	d := 30 * time.Second
+	for i := 1; i < attempts && d < 6*time.Hour; i++ {
+		d *= 2
+	}
+	if d > 6*time.Hour {
+		d = 6 * time.Hour
+	}
+	return d
+}
+
+// Sender posts signed event bodies.
+type Sender struct {
+	Secret string
+	Client *http.Client
+}
+
+// Send posts body to url and returns the response status code (0 without a response). Any
+// non-2xx response is an error.
+func (s Sender) Send(url, eventID string, body []byte) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("error building webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(IDHeader, eventID)
+	req.Header.Set(SignatureHeader, Sign(s.Secret, time.Now(), body))
+	client := s.Client
+	if client == nil {
+		client = &http.Client{Timeout: SendTimeout}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("error posting webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("endpoint responded %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
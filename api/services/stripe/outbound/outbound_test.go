@@ -0,0 +1,64 @@
+package outbound
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignVerify(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	body := []byte(`{"id":"evt_1"}`)
+	header := Sign("whsec", now, body)
+	assert.Regexp(t, `^t=1700000000,v1=[0-9a-f]{64}$`, header)
+
+	assert.NoError(t, Verify("whsec", header, body, 5*time.Minute, now.Add(time.Minute)))
+	assert.Error(t, Verify("other", header, body, 5*time.Minute, now), "wrong secret")
+	assert.Error(t, Verify("whsec", header, []byte(`{"id":"evt_2"}`), 5*time.Minute, now), "tampered body")
+	assert.Error(t, Verify("whsec", header, body, 5*time.Minute, now.Add(10*time.Minute)), "replayed")
+	assert.Error(t, Verify("whsec", "v1=abc", body, 5*time.Minute, now), "no timestamp")
+}
+
+func TestBackoff(t *testing.T) {
+	assert.Equal(t, 30*time.Second, Backoff(1))
+	assert.Equal(t, time.Minute, Backoff(2))
+	assert.Equal(t, 4*time.Minute, Backoff(4))
+	assert.Equal(t, 6*time.Hour, Backoff(20))
+}
+
+func TestSender_Send(t *testing.T) {
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "evt_1", r.Header.Get(IDHeader))
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+	s := Sender{Secret: "whsec"}
+
+	code, err := s.Send(srv.URL, "evt_1", []byte(`{}`))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, code)
+
+	status = http.StatusServiceUnavailable
+	code, err = s.Send(srv.URL, "evt_1", []byte(`{}`))
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+}
+
+func TestSender_SignsBody(t *testing.T) {
+	body := []byte(`{"id":"evt_1","type":"credits.granted"}`)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		assert.Equal(t, body, got)
+		assert.NoError(t, Verify("whsec", r.Header.Get(SignatureHeader), got, time.Minute, time.Now()))
+	}))
+	defer srv.Close()
+
+	_, err := Sender{Secret: "whsec"}.Send(srv.URL, "evt_1", body)
+	assert.NoError(t, err)
+}
@@ -154,6 +154,13 @@ type VerifySubscriptionValidityResponse struct {
 	InvalidityType      string                 `protobuf:"bytes,2,opt,name=invalidity_type,json=invalidityType,proto3" json:"invalidity_type,omitempty"`
 	ValidityType        string                 `protobuf:"bytes,3,opt,name=validity_type,json=validityType,proto3" json:"validity_type,omitempty"`
 	StripeCustomerEmail string                 `protobuf:"bytes,4,opt,name=stripe_customer_email,json=stripeCustomerEmail,proto3" json:"stripe_customer_email,omitempty"`
+	TrialEnd            int64                  `protobuf:"varint,5,opt,name=trial_end,json=trialEnd,proto3" json:"trial_end,omitempty"`                // unix ms; set while the subscription is trialing
+	GraceDeadline       int64                  `protobuf:"varint,6,opt,name=grace_deadline,json=graceDeadline,proto3" json:"grace_deadline,omitempty"` // unix ms; set while a past_due or incomplete subscription is in its grace period
+	InDunning           bool                   `protobuf:"varint,7,opt,name=in_dunning,json=inDunning,proto3" json:"in_dunning,omitempty"`             // an invoice payment failed and the invoice is not paid yet
+	PaymentAttemptCount int32                  `protobuf:"varint,8,opt,name=payment_attempt_count,json=paymentAttemptCount,proto3" json:"payment_attempt_count,omitempty"`
+	NextPaymentAttempt  int64                  `protobuf:"varint,9,opt,name=next_payment_attempt,json=nextPaymentAttempt,proto3" json:"next_payment_attempt,omitempty"` // unix ms; 0 when Stripe has no retry scheduled
+	ResumesAt           int64                  `protobuf:"varint,10,opt,name=resumes_at,json=resumesAt,proto3" json:"resumes_at,omitempty"`                             // unix ms; set when a paused subscription has a resume date
+	ExhaustedFeatures   []string               `protobuf:"bytes,11,rep,name=exhausted_features,json=exhaustedFeatures,proto3" json:"exhausted_features,omitempty"`      // features past their plan's feature_limits this period
 	unknownFields       protoimpl.UnknownFields
 	sizeCache           protoimpl.SizeCache
 }
@@ -216,32 +223,3450 @@ func (x *VerifySubscriptionValidityResponse) GetStripeCustomerEmail() string {
 	return ""
 }
 
+func (x *VerifySubscriptionValidityResponse) GetTrialEnd() int64 {
+	if x != nil {
+		return x.TrialEnd
+	}
+	return 0
+}
+
+func (x *VerifySubscriptionValidityResponse) GetGraceDeadline() int64 {
+	if x != nil {
+		return x.GraceDeadline
+	}
+	return 0
+}
+
+func (x *VerifySubscriptionValidityResponse) GetInDunning() bool {
+	if x != nil {
+		return x.InDunning
+	}
+	return false
+}
+
+func (x *VerifySubscriptionValidityResponse) GetPaymentAttemptCount() int32 {
+	if x != nil {
+		return x.PaymentAttemptCount
+	}
+	return 0
+}
+
+func (x *VerifySubscriptionValidityResponse) GetNextPaymentAttempt() int64 {
+	if x != nil {
+		return x.NextPaymentAttempt
+	}
+	return 0
+}
+
+func (x *VerifySubscriptionValidityResponse) GetResumesAt() int64 {
+	if x != nil {
+		return x.ResumesAt
+	}
+	return 0
+}
+
+func (x *VerifySubscriptionValidityResponse) GetExhaustedFeatures() []string {
+	if x != nil {
+		return x.ExhaustedFeatures
+	}
+	return nil
+}
+
+type BatchVerifySubscriptionValidityRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	UserExternalIds []string               `protobuf:"bytes,1,rep,name=user_external_ids,json=userExternalIds,proto3" json:"user_external_ids,omitempty"` // duplicates are verified once
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *BatchVerifySubscriptionValidityRequest) Reset() {
+	*x = BatchVerifySubscriptionValidityRequest{}
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchVerifySubscriptionValidityRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchVerifySubscriptionValidityRequest) ProtoMessage() {}
+
+func (x *BatchVerifySubscriptionValidityRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchVerifySubscriptionValidityRequest.ProtoReflect.Descriptor instead.
+func (*BatchVerifySubscriptionValidityRequest) Descriptor() ([]byte, []int) {
+	return file_stripe_v1_stripe_service_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *BatchVerifySubscriptionValidityRequest) GetUserExternalIds() []string {
+	if x != nil {
+		return x.UserExternalIds
+	}
+	return nil
+}
+
+type BatchVerifySubscriptionValidityResult struct {
+	state         protoimpl.MessageState              `protogen:"open.v1"`
+	Validity      *VerifySubscriptionValidityResponse `protobuf:"bytes,1,opt,name=validity,proto3" json:"validity,omitempty"` // unset when error is set
+	Error         string                              `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	ErrorCode     string                              `protobuf:"bytes,3,opt,name=error_code,json=errorCode,proto3" json:"error_code,omitempty"` // gRPC code name, e.g. NotFound, or Unavailable for retryable database and Stripe failures
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchVerifySubscriptionValidityResult) Reset() {
+	*x = BatchVerifySubscriptionValidityResult{}
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchVerifySubscriptionValidityResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchVerifySubscriptionValidityResult) ProtoMessage() {}
+
+func (x *BatchVerifySubscriptionValidityResult) ProtoReflect() protoreflect.Message {
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchVerifySubscriptionValidityResult.ProtoReflect.Descriptor instead.
+func (*BatchVerifySubscriptionValidityResult) Descriptor() ([]byte, []int) {
+	return file_stripe_v1_stripe_service_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *BatchVerifySubscriptionValidityResult) GetValidity() *VerifySubscriptionValidityResponse {
+	if x != nil {
+		return x.Validity
+	}
+	return nil
+}
+
+func (x *BatchVerifySubscriptionValidityResult) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *BatchVerifySubscriptionValidityResult) GetErrorCode() string {
+	if x != nil {
+		return x.ErrorCode
+	}
+	return ""
+}
+
+type BatchVerifySubscriptionValidityResponse struct {
+	state         protoimpl.MessageState                            `protogen:"open.v1"`
+	Results       map[string]*BatchVerifySubscriptionValidityResult `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // by user_external_id
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchVerifySubscriptionValidityResponse) Reset() {
+	*x = BatchVerifySubscriptionValidityResponse{}
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchVerifySubscriptionValidityResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchVerifySubscriptionValidityResponse) ProtoMessage() {}
+
+func (x *BatchVerifySubscriptionValidityResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchVerifySubscriptionValidityResponse.ProtoReflect.Descriptor instead.
+func (*BatchVerifySubscriptionValidityResponse) Descriptor() ([]byte, []int) {
+	return file_stripe_v1_stripe_service_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *BatchVerifySubscriptionValidityResponse) GetResults() map[string]*BatchVerifySubscriptionValidityResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+type WatchEntitlementsRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	UserExternalId string                 `protobuf:"bytes,1,opt,name=user_external_id,json=userExternalId,proto3" json:"user_external_id,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *WatchEntitlementsRequest) Reset() {
+	*x = WatchEntitlementsRequest{}
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchEntitlementsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchEntitlementsRequest) ProtoMessage() {}
+
+func (x *WatchEntitlementsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchEntitlementsRequest.ProtoReflect.Descriptor instead.
+func (*WatchEntitlementsRequest) Descriptor() ([]byte, []int) {
+	return file_stripe_v1_stripe_service_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *WatchEntitlementsRequest) GetUserExternalId() string {
+	if x != nil {
+		return x.UserExternalId
+	}
+	return ""
+}
+
+// EntitlementState is what a user can currently use. Members get their organization's pool.
+type EntitlementState struct {
+	state           protoimpl.MessageState              `protogen:"open.v1"`
+	UserExternalId  string                              `protobuf:"bytes,1,opt,name=user_external_id,json=userExternalId,proto3" json:"user_external_id,omitempty"`
+	Validity        *VerifySubscriptionValidityResponse `protobuf:"bytes,2,opt,name=validity,proto3" json:"validity,omitempty"`
+	FreeCredit      int64                               `protobuf:"varint,3,opt,name=free_credit,json=freeCredit,proto3" json:"free_credit,omitempty"`
+	PurchasedCredit int64                               `protobuf:"varint,4,opt,name=purchased_credit,json=purchasedCredit,proto3" json:"purchased_credit,omitempty"`
+	AllowanceUnits  int64                               `protobuf:"varint,5,opt,name=allowance_units,json=allowanceUnits,proto3" json:"allowance_units,omitempty"` // the subscription's allowance this period; 0 without a live subscription
+	UsedUnits       int64                               `protobuf:"varint,6,opt,name=used_units,json=usedUnits,proto3" json:"used_units,omitempty"`                // units counted against the allowance this period
+	Unlimited       bool                                `protobuf:"varint,7,opt,name=unlimited,proto3" json:"unlimited,omitempty"`                                 // metered plan without a cap; remaining_units then leaves the allowance out
+	RemainingUnits  int64                               `protobuf:"varint,8,opt,name=remaining_units,json=remainingUnits,proto3" json:"remaining_units,omitempty"` // free + purchased credit + allowance left
+	PeriodStart     int64                               `protobuf:"varint,9,opt,name=period_start,json=periodStart,proto3" json:"period_start,omitempty"`          // unix ms
+	PeriodEnd       int64                               `protobuf:"varint,10,opt,name=period_end,json=periodEnd,proto3" json:"period_end,omitempty"`               // unix ms
+	UpdatedAt       int64                               `protobuf:"varint,11,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`               // unix ms, when this state was computed
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *EntitlementState) Reset() {
+	*x = EntitlementState{}
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EntitlementState) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EntitlementState) ProtoMessage() {}
+
+func (x *EntitlementState) ProtoReflect() protoreflect.Message {
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EntitlementState.ProtoReflect.Descriptor instead.
+func (*EntitlementState) Descriptor() ([]byte, []int) {
+	return file_stripe_v1_stripe_service_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *EntitlementState) GetUserExternalId() string {
+	if x != nil {
+		return x.UserExternalId
+	}
+	return ""
+}
+
+func (x *EntitlementState) GetValidity() *VerifySubscriptionValidityResponse {
+	if x != nil {
+		return x.Validity
+	}
+	return nil
+}
+
+func (x *EntitlementState) GetFreeCredit() int64 {
+	if x != nil {
+		return x.FreeCredit
+	}
+	return 0
+}
+
+func (x *EntitlementState) GetPurchasedCredit() int64 {
+	if x != nil {
+		return x.PurchasedCredit
+	}
+	return 0
+}
+
+func (x *EntitlementState) GetAllowanceUnits() int64 {
+	if x != nil {
+		return x.AllowanceUnits
+	}
+	return 0
+}
+
+func (x *EntitlementState) GetUsedUnits() int64 {
+	if x != nil {
+		return x.UsedUnits
+	}
+	return 0
+}
+
+func (x *EntitlementState) GetUnlimited() bool {
+	if x != nil {
+		return x.Unlimited
+	}
+	return false
+}
+
+func (x *EntitlementState) GetRemainingUnits() int64 {
+	if x != nil {
+		return x.RemainingUnits
+	}
+	return 0
+}
+
+func (x *EntitlementState) GetPeriodStart() int64 {
+	if x != nil {
+		return x.PeriodStart
+	}
+	return 0
+}
+
+func (x *EntitlementState) GetPeriodEnd() int64 {
+	if x != nil {
+		return x.PeriodEnd
+	}
+	return 0
+}
+
+func (x *EntitlementState) GetUpdatedAt() int64 {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return 0
+}
+
 // SpendingUnit represents a unit to insert.
 type SpendingUnit struct {
 	state          protoimpl.MessageState `protogen:"open.v1"`
-	ExternalId     string                 `protobuf:"bytes,1,opt,name=external_id,json=externalId,proto3" json:"external_id,omitempty"`
-	UserExternalId string                 `protobuf:"bytes,2,opt,name=user_external_id,json=userExternalId,proto3" json:"user_external_id,omitempty"`
-	Amount         int32                  `protobuf:"varint,3,opt,name=amount,proto3" json:"amount,omitempty"`
-	CreatedAt      int64                  `protobuf:"varint,4,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"` // unix ms
+	ExternalId     string                 `protobuf:"bytes,1,opt,name=external_id,json=externalId,proto3" json:"external_id,omitempty"`
+	UserExternalId string                 `protobuf:"bytes,2,opt,name=user_external_id,json=userExternalId,proto3" json:"user_external_id,omitempty"`
+	Amount         int32                  `protobuf:"varint,3,opt,name=amount,proto3" json:"amount,omitempty"`
+	CreatedAt      int64                  `protobuf:"varint,4,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`                                                   // unix ms
+	ApiKeyId       string                 `protobuf:"bytes,5,opt,name=api_key_id,json=apiKeyId,proto3" json:"api_key_id,omitempty"`                                                     // optional: API key the units were spent with (an identifier, not the secret)
+	FeatureKey     string                 `protobuf:"bytes,6,opt,name=feature_key,json=featureKey,proto3" json:"feature_key,omitempty"`                                                 // optional: feature the units were spent on, e.g. image_generation
+	Labels         map[string]string      `protobuf:"bytes,7,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // optional free-form labels, reported in usage breakdowns
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *SpendingUnit) Reset() {
+	*x = SpendingUnit{}
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SpendingUnit) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SpendingUnit) ProtoMessage() {}
+
+func (x *SpendingUnit) ProtoReflect() protoreflect.Message {
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SpendingUnit.ProtoReflect.Descriptor instead.
+func (*SpendingUnit) Descriptor() ([]byte, []int) {
+	return file_stripe_v1_stripe_service_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *SpendingUnit) GetExternalId() string {
+	if x != nil {
+		return x.ExternalId
+	}
+	return ""
+}
+
+func (x *SpendingUnit) GetUserExternalId() string {
+	if x != nil {
+		return x.UserExternalId
+	}
+	return ""
+}
+
+func (x *SpendingUnit) GetAmount() int32 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+func (x *SpendingUnit) GetCreatedAt() int64 {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return 0
+}
+
+func (x *SpendingUnit) GetApiKeyId() string {
+	if x != nil {
+		return x.ApiKeyId
+	}
+	return ""
+}
+
+func (x *SpendingUnit) GetFeatureKey() string {
+	if x != nil {
+		return x.FeatureKey
+	}
+	return ""
+}
+
+func (x *SpendingUnit) GetLabels() map[string]string {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
+type AddSpendingUnitsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Items         []*SpendingUnit        `protobuf:"bytes,1,rep,name=items,proto3" json:"items,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddSpendingUnitsRequest) Reset() {
+	*x = AddSpendingUnitsRequest{}
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddSpendingUnitsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddSpendingUnitsRequest) ProtoMessage() {}
+
+func (x *AddSpendingUnitsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddSpendingUnitsRequest.ProtoReflect.Descriptor instead.
+func (*AddSpendingUnitsRequest) Descriptor() ([]byte, []int) {
+	return file_stripe_v1_stripe_service_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *AddSpendingUnitsRequest) GetItems() []*SpendingUnit {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+type AddSpendingUnitsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Inserted      int32                  `protobuf:"varint,1,opt,name=inserted,proto3" json:"inserted,omitempty"` // number of rows inserted (duplicates skipped)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddSpendingUnitsResponse) Reset() {
+	*x = AddSpendingUnitsResponse{}
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddSpendingUnitsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddSpendingUnitsResponse) ProtoMessage() {}
+
+func (x *AddSpendingUnitsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddSpendingUnitsResponse.ProtoReflect.Descriptor instead.
+func (*AddSpendingUnitsResponse) Descriptor() ([]byte, []int) {
+	return file_stripe_v1_stripe_service_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *AddSpendingUnitsResponse) GetInserted() int32 {
+	if x != nil {
+		return x.Inserted
+	}
+	return 0
+}
+
+type StreamSpendingUnitsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Received      int64                  `protobuf:"varint,1,opt,name=received,proto3" json:"received,omitempty"`     // spending units read from the stream
+	Inserted      int64                  `protobuf:"varint,2,opt,name=inserted,proto3" json:"inserted,omitempty"`     // rows inserted
+	Duplicates    int64                  `protobuf:"varint,3,opt,name=duplicates,proto3" json:"duplicates,omitempty"` // units skipped because their external_id was already recorded
+	Batches       int32                  `protobuf:"varint,4,opt,name=batches,proto3" json:"batches,omitempty"`       // micro-batches written
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamSpendingUnitsResponse) Reset() {
+	*x = StreamSpendingUnitsResponse{}
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamSpendingUnitsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamSpendingUnitsResponse) ProtoMessage() {}
+
+func (x *StreamSpendingUnitsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamSpendingUnitsResponse.ProtoReflect.Descriptor instead.
+func (*StreamSpendingUnitsResponse) Descriptor() ([]byte, []int) {
+	return file_stripe_v1_stripe_service_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *StreamSpendingUnitsResponse) GetReceived() int64 {
+	if x != nil {
+		return x.Received
+	}
+	return 0
+}
+
+func (x *StreamSpendingUnitsResponse) GetInserted() int64 {
+	if x != nil {
+		return x.Inserted
+	}
+	return 0
+}
+
+func (x *StreamSpendingUnitsResponse) GetDuplicates() int64 {
+	if x != nil {
+		return x.Duplicates
+	}
+	return 0
+}
+
+func (x *StreamSpendingUnitsResponse) GetBatches() int32 {
+	if x != nil {
+		return x.Batches
+	}
+	return 0
+}
+
+type RefundSpendingUnitsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ExternalIds   []string               `protobuf:"bytes,1,rep,name=external_ids,json=externalIds,proto3" json:"external_ids,omitempty"` // external_id of each spending unit to refund
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RefundSpendingUnitsRequest) Reset() {
+	*x = RefundSpendingUnitsRequest{}
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RefundSpendingUnitsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RefundSpendingUnitsRequest) ProtoMessage() {}
+
+func (x *RefundSpendingUnitsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RefundSpendingUnitsRequest.ProtoReflect.Descriptor instead.
+func (*RefundSpendingUnitsRequest) Descriptor() ([]byte, []int) {
+	return file_stripe_v1_stripe_service_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *RefundSpendingUnitsRequest) GetExternalIds() []string {
+	if x != nil {
+		return x.ExternalIds
+	}
+	return nil
+}
+
+type RefundSpendingUnitsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Refunded      int32                  `protobuf:"varint,1,opt,name=refunded,proto3" json:"refunded,omitempty"` // number of units refunded (already-refunded ids skipped)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RefundSpendingUnitsResponse) Reset() {
+	*x = RefundSpendingUnitsResponse{}
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RefundSpendingUnitsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RefundSpendingUnitsResponse) ProtoMessage() {}
+
+func (x *RefundSpendingUnitsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RefundSpendingUnitsResponse.ProtoReflect.Descriptor instead.
+func (*RefundSpendingUnitsResponse) Descriptor() ([]byte, []int) {
+	return file_stripe_v1_stripe_service_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *RefundSpendingUnitsResponse) GetRefunded() int32 {
+	if x != nil {
+		return x.Refunded
+	}
+	return 0
+}
+
+type CreateCreditPackCheckoutRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	UserExternalId string                 `protobuf:"bytes,1,opt,name=user_external_id,json=userExternalId,proto3" json:"user_external_id,omitempty"`
+	PackId         string                 `protobuf:"bytes,2,opt,name=pack_id,json=packId,proto3" json:"pack_id,omitempty"` // id of an entry in CREDIT_PACKS
+	SuccessUrl     string                 `protobuf:"bytes,3,opt,name=success_url,json=successUrl,proto3" json:"success_url,omitempty"`
+	CancelUrl      string                 `protobuf:"bytes,4,opt,name=cancel_url,json=cancelUrl,proto3" json:"cancel_url,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *CreateCreditPackCheckoutRequest) Reset() {
+	*x = CreateCreditPackCheckoutRequest{}
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateCreditPackCheckoutRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateCreditPackCheckoutRequest) ProtoMessage() {}
+
+func (x *CreateCreditPackCheckoutRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateCreditPackCheckoutRequest.ProtoReflect.Descriptor instead.
+func (*CreateCreditPackCheckoutRequest) Descriptor() ([]byte, []int) {
+	return file_stripe_v1_stripe_service_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *CreateCreditPackCheckoutRequest) GetUserExternalId() string {
+	if x != nil {
+		return x.UserExternalId
+	}
+	return ""
+}
+
+func (x *CreateCreditPackCheckoutRequest) GetPackId() string {
+	if x != nil {
+		return x.PackId
+	}
+	return ""
+}
+
+func (x *CreateCreditPackCheckoutRequest) GetSuccessUrl() string {
+	if x != nil {
+		return x.SuccessUrl
+	}
+	return ""
+}
+
+func (x *CreateCreditPackCheckoutRequest) GetCancelUrl() string {
+	if x != nil {
+		return x.CancelUrl
+	}
+	return ""
+}
+
+type CreateCreditPackCheckoutResponse struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	CheckoutSessionId string                 `protobuf:"bytes,1,opt,name=checkout_session_id,json=checkoutSessionId,proto3" json:"checkout_session_id,omitempty"` // pass to stripe.js redirectToCheckout
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *CreateCreditPackCheckoutResponse) Reset() {
+	*x = CreateCreditPackCheckoutResponse{}
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateCreditPackCheckoutResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateCreditPackCheckoutResponse) ProtoMessage() {}
+
+func (x *CreateCreditPackCheckoutResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateCreditPackCheckoutResponse.ProtoReflect.Descriptor instead.
+func (*CreateCreditPackCheckoutResponse) Descriptor() ([]byte, []int) {
+	return file_stripe_v1_stripe_service_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *CreateCreditPackCheckoutResponse) GetCheckoutSessionId() string {
+	if x != nil {
+		return x.CheckoutSessionId
+	}
+	return ""
+}
+
+type GrantCreditsRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	UserExternalId string                 `protobuf:"bytes,1,opt,name=user_external_id,json=userExternalId,proto3" json:"user_external_id,omitempty"`
+	Amount         int32                  `protobuf:"varint,2,opt,name=amount,proto3" json:"amount,omitempty"`
+	Reason         string                 `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`                         // recorded in the audit trail
+	ExpiresAt      int64                  `protobuf:"varint,4,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"` // unix ms; 0 never expires
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *GrantCreditsRequest) Reset() {
+	*x = GrantCreditsRequest{}
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GrantCreditsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GrantCreditsRequest) ProtoMessage() {}
+
+func (x *GrantCreditsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GrantCreditsRequest.ProtoReflect.Descriptor instead.
+func (*GrantCreditsRequest) Descriptor() ([]byte, []int) {
+	return file_stripe_v1_stripe_service_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *GrantCreditsRequest) GetUserExternalId() string {
+	if x != nil {
+		return x.UserExternalId
+	}
+	return ""
+}
+
+func (x *GrantCreditsRequest) GetAmount() int32 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+func (x *GrantCreditsRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *GrantCreditsRequest) GetExpiresAt() int64 {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return 0
+}
+
+type GrantCreditsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	GrantId       int64                  `protobuf:"varint,1,opt,name=grant_id,json=grantId,proto3" json:"grant_id,omitempty"`
+	Credit        int32                  `protobuf:"varint,2,opt,name=credit,proto3" json:"credit,omitempty"` // free credit balance after the grant
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GrantCreditsResponse) Reset() {
+	*x = GrantCreditsResponse{}
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GrantCreditsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GrantCreditsResponse) ProtoMessage() {}
+
+func (x *GrantCreditsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GrantCreditsResponse.ProtoReflect.Descriptor instead.
+func (*GrantCreditsResponse) Descriptor() ([]byte, []int) {
+	return file_stripe_v1_stripe_service_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *GrantCreditsResponse) GetGrantId() int64 {
+	if x != nil {
+		return x.GrantId
+	}
+	return 0
+}
+
+func (x *GrantCreditsResponse) GetCredit() int32 {
+	if x != nil {
+		return x.Credit
+	}
+	return 0
+}
+
+type RevokeCreditsRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	UserExternalId string                 `protobuf:"bytes,1,opt,name=user_external_id,json=userExternalId,proto3" json:"user_external_id,omitempty"`
+	Amount         int32                  `protobuf:"varint,2,opt,name=amount,proto3" json:"amount,omitempty"`
+	Reason         string                 `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"` // recorded in the audit trail
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *RevokeCreditsRequest) Reset() {
+	*x = RevokeCreditsRequest{}
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RevokeCreditsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RevokeCreditsRequest) ProtoMessage() {}
+
+func (x *RevokeCreditsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RevokeCreditsRequest.ProtoReflect.Descriptor instead.
+func (*RevokeCreditsRequest) Descriptor() ([]byte, []int) {
+	return file_stripe_v1_stripe_service_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *RevokeCreditsRequest) GetUserExternalId() string {
+	if x != nil {
+		return x.UserExternalId
+	}
+	return ""
+}
+
+func (x *RevokeCreditsRequest) GetAmount() int32 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+func (x *RevokeCreditsRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type RevokeCreditsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	GrantId       int64                  `protobuf:"varint,1,opt,name=grant_id,json=grantId,proto3" json:"grant_id,omitempty"`
+	Revoked       int32                  `protobuf:"varint,2,opt,name=revoked,proto3" json:"revoked,omitempty"` // amount actually removed
+	Credit        int32                  `protobuf:"varint,3,opt,name=credit,proto3" json:"credit,omitempty"`   // free credit balance after the revocation
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RevokeCreditsResponse) Reset() {
+	*x = RevokeCreditsResponse{}
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RevokeCreditsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RevokeCreditsResponse) ProtoMessage() {}
+
+func (x *RevokeCreditsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RevokeCreditsResponse.ProtoReflect.Descriptor instead.
+func (*RevokeCreditsResponse) Descriptor() ([]byte, []int) {
+	return file_stripe_v1_stripe_service_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *RevokeCreditsResponse) GetGrantId() int64 {
+	if x != nil {
+		return x.GrantId
+	}
+	return 0
+}
+
+func (x *RevokeCreditsResponse) GetRevoked() int32 {
+	if x != nil {
+		return x.Revoked
+	}
+	return 0
+}
+
+func (x *RevokeCreditsResponse) GetCredit() int32 {
+	if x != nil {
+		return x.Credit
+	}
+	return 0
+}
+
+type RedeemCodeRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	UserExternalId string                 `protobuf:"bytes,1,opt,name=user_external_id,json=userExternalId,proto3" json:"user_external_id,omitempty"`
+	Code           string                 `protobuf:"bytes,2,opt,name=code,proto3" json:"code,omitempty"`
+	IdempotencyKey string                 `protobuf:"bytes,3,opt,name=idempotency_key,json=idempotencyKey,proto3" json:"idempotency_key,omitempty"` // retries with the same key return the original outcome
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *RedeemCodeRequest) Reset() {
+	*x = RedeemCodeRequest{}
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RedeemCodeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RedeemCodeRequest) ProtoMessage() {}
+
+func (x *RedeemCodeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RedeemCodeRequest.ProtoReflect.Descriptor instead.
+func (*RedeemCodeRequest) Descriptor() ([]byte, []int) {
+	return file_stripe_v1_stripe_service_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *RedeemCodeRequest) GetUserExternalId() string {
+	if x != nil {
+		return x.UserExternalId
+	}
+	return ""
+}
+
+func (x *RedeemCodeRequest) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *RedeemCodeRequest) GetIdempotencyKey() string {
+	if x != nil {
+		return x.IdempotencyKey
+	}
+	return ""
+}
+
+type RedeemCodeResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Units         int32                  `protobuf:"varint,1,opt,name=units,proto3" json:"units,omitempty"`
+	Pending       bool                   `protobuf:"varint,2,opt,name=pending,proto3" json:"pending,omitempty"` // referral: units are credited once the user completes a checkout
+	Credit        int32                  `protobuf:"varint,3,opt,name=credit,proto3" json:"credit,omitempty"`   // free credit balance after the redemption
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RedeemCodeResponse) Reset() {
+	*x = RedeemCodeResponse{}
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RedeemCodeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RedeemCodeResponse) ProtoMessage() {}
+
+func (x *RedeemCodeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RedeemCodeResponse.ProtoReflect.Descriptor instead.
+func (*RedeemCodeResponse) Descriptor() ([]byte, []int) {
+	return file_stripe_v1_stripe_service_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *RedeemCodeResponse) GetUnits() int32 {
+	if x != nil {
+		return x.Units
+	}
+	return 0
+}
+
+func (x *RedeemCodeResponse) GetPending() bool {
+	if x != nil {
+		return x.Pending
+	}
+	return false
+}
+
+func (x *RedeemCodeResponse) GetCredit() int32 {
+	if x != nil {
+		return x.Credit
+	}
+	return 0
+}
+
+type GetReferralCodeRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	UserExternalId string                 `protobuf:"bytes,1,opt,name=user_external_id,json=userExternalId,proto3" json:"user_external_id,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *GetReferralCodeRequest) Reset() {
+	*x = GetReferralCodeRequest{}
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetReferralCodeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetReferralCodeRequest) ProtoMessage() {}
+
+func (x *GetReferralCodeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetReferralCodeRequest.ProtoReflect.Descriptor instead.
+func (*GetReferralCodeRequest) Descriptor() ([]byte, []int) {
+	return file_stripe_v1_stripe_service_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *GetReferralCodeRequest) GetUserExternalId() string {
+	if x != nil {
+		return x.UserExternalId
+	}
+	return ""
+}
+
+type GetReferralCodeResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Code          string                 `protobuf:"bytes,1,opt,name=code,proto3" json:"code,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetReferralCodeResponse) Reset() {
+	*x = GetReferralCodeResponse{}
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetReferralCodeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetReferralCodeResponse) ProtoMessage() {}
+
+func (x *GetReferralCodeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetReferralCodeResponse.ProtoReflect.Descriptor instead.
+func (*GetReferralCodeResponse) Descriptor() ([]byte, []int) {
+	return file_stripe_v1_stripe_service_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *GetReferralCodeResponse) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+type CreateCampaignRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Code           string                 `protobuf:"bytes,1,opt,name=code,proto3" json:"code,omitempty"` // case-insensitive
+	Units          int32                  `protobuf:"varint,2,opt,name=units,proto3" json:"units,omitempty"`
+	MaxRedemptions int32                  `protobuf:"varint,3,opt,name=max_redemptions,json=maxRedemptions,proto3" json:"max_redemptions,omitempty"` // across all users; 0 is unlimited
+	PerUserLimit   int32                  `protobuf:"varint,4,opt,name=per_user_limit,json=perUserLimit,proto3" json:"per_user_limit,omitempty"`     // default 1
+	StartsAt       int64                  `protobuf:"varint,5,opt,name=starts_at,json=startsAt,proto3" json:"starts_at,omitempty"`                   // unix ms; 0 is immediately
+	EndsAt         int64                  `protobuf:"varint,6,opt,name=ends_at,json=endsAt,proto3" json:"ends_at,omitempty"`                         // unix ms; 0 never ends
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *CreateCampaignRequest) Reset() {
+	*x = CreateCampaignRequest{}
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateCampaignRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateCampaignRequest) ProtoMessage() {}
+
+func (x *CreateCampaignRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateCampaignRequest.ProtoReflect.Descriptor instead.
+func (*CreateCampaignRequest) Descriptor() ([]byte, []int) {
+	return file_stripe_v1_stripe_service_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *CreateCampaignRequest) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *CreateCampaignRequest) GetUnits() int32 {
+	if x != nil {
+		return x.Units
+	}
+	return 0
+}
+
+func (x *CreateCampaignRequest) GetMaxRedemptions() int32 {
+	if x != nil {
+		return x.MaxRedemptions
+	}
+	return 0
+}
+
+func (x *CreateCampaignRequest) GetPerUserLimit() int32 {
+	if x != nil {
+		return x.PerUserLimit
+	}
+	return 0
+}
+
+func (x *CreateCampaignRequest) GetStartsAt() int64 {
+	if x != nil {
+		return x.StartsAt
+	}
+	return 0
+}
+
+func (x *CreateCampaignRequest) GetEndsAt() int64 {
+	if x != nil {
+		return x.EndsAt
+	}
+	return 0
+}
+
+type CreateCampaignResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	CampaignId    int64                  `protobuf:"varint,1,opt,name=campaign_id,json=campaignId,proto3" json:"campaign_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateCampaignResponse) Reset() {
+	*x = CreateCampaignResponse{}
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateCampaignResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateCampaignResponse) ProtoMessage() {}
+
+func (x *CreateCampaignResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateCampaignResponse.ProtoReflect.Descriptor instead.
+func (*CreateCampaignResponse) Descriptor() ([]byte, []int) {
+	return file_stripe_v1_stripe_service_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *CreateCampaignResponse) GetCampaignId() int64 {
+	if x != nil {
+		return x.CampaignId
+	}
+	return 0
+}
+
+type GetBillingStatusRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	UserExternalId string                 `protobuf:"bytes,1,opt,name=user_external_id,json=userExternalId,proto3" json:"user_external_id,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *GetBillingStatusRequest) Reset() {
+	*x = GetBillingStatusRequest{}
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetBillingStatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetBillingStatusRequest) ProtoMessage() {}
+
+func (x *GetBillingStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetBillingStatusRequest.ProtoReflect.Descriptor instead.
+func (*GetBillingStatusRequest) Descriptor() ([]byte, []int) {
+	return file_stripe_v1_stripe_service_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *GetBillingStatusRequest) GetUserExternalId() string {
+	if x != nil {
+		return x.UserExternalId
+	}
+	return ""
+}
+
+type GetBillingStatusResponse struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	InDunning          bool                   `protobuf:"varint,1,opt,name=in_dunning,json=inDunning,proto3" json:"in_dunning,omitempty"`
+	StripeInvoiceId    string                 `protobuf:"bytes,2,opt,name=stripe_invoice_id,json=stripeInvoiceId,proto3" json:"stripe_invoice_id,omitempty"` // the failed invoice (or, once cleared, the invoice paid)
+	AttemptCount       int32                  `protobuf:"varint,3,opt,name=attempt_count,json=attemptCount,proto3" json:"attempt_count,omitempty"`
+	NextPaymentAttempt int64                  `protobuf:"varint,4,opt,name=next_payment_attempt,json=nextPaymentAttempt,proto3" json:"next_payment_attempt,omitempty"` // unix ms; 0 when Stripe has no retry scheduled
+	UpdatedAt          int64                  `protobuf:"varint,5,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`                              // unix ms of the last invoice event applied; 0 when none was seen
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *GetBillingStatusResponse) Reset() {
+	*x = GetBillingStatusResponse{}
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetBillingStatusResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetBillingStatusResponse) ProtoMessage() {}
+
+func (x *GetBillingStatusResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetBillingStatusResponse.ProtoReflect.Descriptor instead.
+func (*GetBillingStatusResponse) Descriptor() ([]byte, []int) {
+	return file_stripe_v1_stripe_service_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *GetBillingStatusResponse) GetInDunning() bool {
+	if x != nil {
+		return x.InDunning
+	}
+	return false
+}
+
+func (x *GetBillingStatusResponse) GetStripeInvoiceId() string {
+	if x != nil {
+		return x.StripeInvoiceId
+	}
+	return ""
+}
+
+func (x *GetBillingStatusResponse) GetAttemptCount() int32 {
+	if x != nil {
+		return x.AttemptCount
+	}
+	return 0
+}
+
+func (x *GetBillingStatusResponse) GetNextPaymentAttempt() int64 {
+	if x != nil {
+		return x.NextPaymentAttempt
+	}
+	return 0
+}
+
+func (x *GetBillingStatusResponse) GetUpdatedAt() int64 {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return 0
+}
+
+type GetUsageByDimensionRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	UserExternalId string                 `protobuf:"bytes,1,opt,name=user_external_id,json=userExternalId,proto3" json:"user_external_id,omitempty"`
+	LabelKey       string                 `protobuf:"bytes,2,opt,name=label_key,json=labelKey,proto3" json:"label_key,omitempty"` // optional: also break usage down by the value of this label
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *GetUsageByDimensionRequest) Reset() {
+	*x = GetUsageByDimensionRequest{}
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetUsageByDimensionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetUsageByDimensionRequest) ProtoMessage() {}
+
+func (x *GetUsageByDimensionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetUsageByDimensionRequest.ProtoReflect.Descriptor instead.
+func (*GetUsageByDimensionRequest) Descriptor() ([]byte, []int) {
+	return file_stripe_v1_stripe_service_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *GetUsageByDimensionRequest) GetUserExternalId() string {
+	if x != nil {
+		return x.UserExternalId
+	}
+	return ""
+}
+
+func (x *GetUsageByDimensionRequest) GetLabelKey() string {
+	if x != nil {
+		return x.LabelKey
+	}
+	return ""
+}
+
+type DimensionUsage struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	FeatureKey    string                 `protobuf:"bytes,1,opt,name=feature_key,json=featureKey,proto3" json:"feature_key,omitempty"` // "" for units reported without a feature
+	LabelValue    string                 `protobuf:"bytes,2,opt,name=label_value,json=labelValue,proto3" json:"label_value,omitempty"` // "" without label_key, or for units without that label
+	Units         int64                  `protobuf:"varint,3,opt,name=units,proto3" json:"units,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DimensionUsage) Reset() {
+	*x = DimensionUsage{}
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DimensionUsage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DimensionUsage) ProtoMessage() {}
+
+func (x *DimensionUsage) ProtoReflect() protoreflect.Message {
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DimensionUsage.ProtoReflect.Descriptor instead.
+func (*DimensionUsage) Descriptor() ([]byte, []int) {
+	return file_stripe_v1_stripe_service_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *DimensionUsage) GetFeatureKey() string {
+	if x != nil {
+		return x.FeatureKey
+	}
+	return ""
+}
+
+func (x *DimensionUsage) GetLabelValue() string {
+	if x != nil {
+		return x.LabelValue
+	}
+	return ""
+}
+
+func (x *DimensionUsage) GetUnits() int64 {
+	if x != nil {
+		return x.Units
+	}
+	return 0
+}
+
+type GetUsageByDimensionResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PeriodStart   int64                  `protobuf:"varint,1,opt,name=period_start,json=periodStart,proto3" json:"period_start,omitempty"` // unix ms
+	PeriodEnd     int64                  `protobuf:"varint,2,opt,name=period_end,json=periodEnd,proto3" json:"period_end,omitempty"`       // unix ms
+	Usage         []*DimensionUsage      `protobuf:"bytes,3,rep,name=usage,proto3" json:"usage,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetUsageByDimensionResponse) Reset() {
+	*x = GetUsageByDimensionResponse{}
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetUsageByDimensionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetUsageByDimensionResponse) ProtoMessage() {}
+
+func (x *GetUsageByDimensionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetUsageByDimensionResponse.ProtoReflect.Descriptor instead.
+func (*GetUsageByDimensionResponse) Descriptor() ([]byte, []int) {
+	return file_stripe_v1_stripe_service_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *GetUsageByDimensionResponse) GetPeriodStart() int64 {
+	if x != nil {
+		return x.PeriodStart
+	}
+	return 0
+}
+
+func (x *GetUsageByDimensionResponse) GetPeriodEnd() int64 {
+	if x != nil {
+		return x.PeriodEnd
+	}
+	return 0
+}
+
+func (x *GetUsageByDimensionResponse) GetUsage() []*DimensionUsage {
+	if x != nil {
+		return x.Usage
+	}
+	return nil
+}
+
+type PlanChangeRequest struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	UserExternalId     string                 `protobuf:"bytes,1,opt,name=user_external_id,json=userExternalId,proto3" json:"user_external_id,omitempty"`
+	PlanId             string                 `protobuf:"bytes,2,opt,name=plan_id,json=planId,proto3" json:"plan_id,omitempty"`                                       // Stripe price (plan) ID to switch to
+	Quantity           int64                  `protobuf:"varint,3,opt,name=quantity,proto3" json:"quantity,omitempty"`                                                // 0 keeps the current quantity
+	ProrationBehavior  string                 `protobuf:"bytes,4,opt,name=proration_behavior,json=prorationBehavior,proto3" json:"proration_behavior,omitempty"`      // create_prorations (default), always_invoice or none
+	ProrationDate      int64                  `protobuf:"varint,5,opt,name=proration_date,json=prorationDate,proto3" json:"proration_date,omitempty"`                 // unix ms; pass a preview's proration_date to bill exactly what it showed
+	SubscriptionItemId string                 `protobuf:"bytes,6,opt,name=subscription_item_id,json=subscriptionItemId,proto3" json:"subscription_item_id,omitempty"` // required only for subscriptions with several items
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *PlanChangeRequest) Reset() {
+	*x = PlanChangeRequest{}
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PlanChangeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PlanChangeRequest) ProtoMessage() {}
+
+func (x *PlanChangeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PlanChangeRequest.ProtoReflect.Descriptor instead.
+func (*PlanChangeRequest) Descriptor() ([]byte, []int) {
+	return file_stripe_v1_stripe_service_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *PlanChangeRequest) GetUserExternalId() string {
+	if x != nil {
+		return x.UserExternalId
+	}
+	return ""
+}
+
+func (x *PlanChangeRequest) GetPlanId() string {
+	if x != nil {
+		return x.PlanId
+	}
+	return ""
+}
+
+func (x *PlanChangeRequest) GetQuantity() int64 {
+	if x != nil {
+		return x.Quantity
+	}
+	return 0
+}
+
+func (x *PlanChangeRequest) GetProrationBehavior() string {
+	if x != nil {
+		return x.ProrationBehavior
+	}
+	return ""
+}
+
+func (x *PlanChangeRequest) GetProrationDate() int64 {
+	if x != nil {
+		return x.ProrationDate
+	}
+	return 0
+}
+
+func (x *PlanChangeRequest) GetSubscriptionItemId() string {
+	if x != nil {
+		return x.SubscriptionItemId
+	}
+	return ""
+}
+
+type PauseSubscriptionRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	UserExternalId string                 `protobuf:"bytes,1,opt,name=user_external_id,json=userExternalId,proto3" json:"user_external_id,omitempty"`
+	Behavior       string                 `protobuf:"bytes,2,opt,name=behavior,proto3" json:"behavior,omitempty"`                     // void (default), keep_as_draft or mark_uncollectible
+	ResumesAt      int64                  `protobuf:"varint,3,opt,name=resumes_at,json=resumesAt,proto3" json:"resumes_at,omitempty"` // unix ms; optional, paused until resumed otherwise
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *PauseSubscriptionRequest) Reset() {
+	*x = PauseSubscriptionRequest{}
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PauseSubscriptionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PauseSubscriptionRequest) ProtoMessage() {}
+
+func (x *PauseSubscriptionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PauseSubscriptionRequest.ProtoReflect.Descriptor instead.
+func (*PauseSubscriptionRequest) Descriptor() ([]byte, []int) {
+	return file_stripe_v1_stripe_service_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *PauseSubscriptionRequest) GetUserExternalId() string {
+	if x != nil {
+		return x.UserExternalId
+	}
+	return ""
+}
+
+func (x *PauseSubscriptionRequest) GetBehavior() string {
+	if x != nil {
+		return x.Behavior
+	}
+	return ""
+}
+
+func (x *PauseSubscriptionRequest) GetResumesAt() int64 {
+	if x != nil {
+		return x.ResumesAt
+	}
+	return 0
+}
+
+type ResumeSubscriptionRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	UserExternalId string                 `protobuf:"bytes,1,opt,name=user_external_id,json=userExternalId,proto3" json:"user_external_id,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ResumeSubscriptionRequest) Reset() {
+	*x = ResumeSubscriptionRequest{}
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResumeSubscriptionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResumeSubscriptionRequest) ProtoMessage() {}
+
+func (x *ResumeSubscriptionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResumeSubscriptionRequest.ProtoReflect.Descriptor instead.
+func (*ResumeSubscriptionRequest) Descriptor() ([]byte, []int) {
+	return file_stripe_v1_stripe_service_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *ResumeSubscriptionRequest) GetUserExternalId() string {
+	if x != nil {
+		return x.UserExternalId
+	}
+	return ""
+}
+
+type SubscriptionPauseResponse struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	SubscriptionId string                 `protobuf:"bytes,1,opt,name=subscription_id,json=subscriptionId,proto3" json:"subscription_id,omitempty"`
+	Paused         bool                   `protobuf:"varint,2,opt,name=paused,proto3" json:"paused,omitempty"`
+	Behavior       string                 `protobuf:"bytes,3,opt,name=behavior,proto3" json:"behavior,omitempty"`
+	ResumesAt      int64                  `protobuf:"varint,4,opt,name=resumes_at,json=resumesAt,proto3" json:"resumes_at,omitempty"` // unix ms; 0 without a resume date
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *SubscriptionPauseResponse) Reset() {
+	*x = SubscriptionPauseResponse{}
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubscriptionPauseResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubscriptionPauseResponse) ProtoMessage() {}
+
+func (x *SubscriptionPauseResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubscriptionPauseResponse.ProtoReflect.Descriptor instead.
+func (*SubscriptionPauseResponse) Descriptor() ([]byte, []int) {
+	return file_stripe_v1_stripe_service_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *SubscriptionPauseResponse) GetSubscriptionId() string {
+	if x != nil {
+		return x.SubscriptionId
+	}
+	return ""
+}
+
+func (x *SubscriptionPauseResponse) GetPaused() bool {
+	if x != nil {
+		return x.Paused
+	}
+	return false
+}
+
+func (x *SubscriptionPauseResponse) GetBehavior() string {
+	if x != nil {
+		return x.Behavior
+	}
+	return ""
+}
+
+func (x *SubscriptionPauseResponse) GetResumesAt() int64 {
+	if x != nil {
+		return x.ResumesAt
+	}
+	return 0
+}
+
+type PreviewPlanChangeResponse struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	Currency           string                 `protobuf:"bytes,1,opt,name=currency,proto3" json:"currency,omitempty"`
+	AmountDue          int64                  `protobuf:"varint,2,opt,name=amount_due,json=amountDue,proto3" json:"amount_due,omitempty"`                              // upcoming invoice total, in minor units
+	ProrationAmount    int64                  `protobuf:"varint,3,opt,name=proration_amount,json=prorationAmount,proto3" json:"proration_amount,omitempty"`            // part of amount_due from prorations (negative for credits)
+	ProrationDate      int64                  `protobuf:"varint,4,opt,name=proration_date,json=prorationDate,proto3" json:"proration_date,omitempty"`                  // unix ms
+	NextPaymentAttempt int64                  `protobuf:"varint,5,opt,name=next_payment_attempt,json=nextPaymentAttempt,proto3" json:"next_payment_attempt,omitempty"` // unix ms
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *PreviewPlanChangeResponse) Reset() {
+	*x = PreviewPlanChangeResponse{}
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[36]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PreviewPlanChangeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PreviewPlanChangeResponse) ProtoMessage() {}
+
+func (x *PreviewPlanChangeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[36]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PreviewPlanChangeResponse.ProtoReflect.Descriptor instead.
+func (*PreviewPlanChangeResponse) Descriptor() ([]byte, []int) {
+	return file_stripe_v1_stripe_service_proto_rawDescGZIP(), []int{36}
+}
+
+func (x *PreviewPlanChangeResponse) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+func (x *PreviewPlanChangeResponse) GetAmountDue() int64 {
+	if x != nil {
+		return x.AmountDue
+	}
+	return 0
+}
+
+func (x *PreviewPlanChangeResponse) GetProrationAmount() int64 {
+	if x != nil {
+		return x.ProrationAmount
+	}
+	return 0
+}
+
+func (x *PreviewPlanChangeResponse) GetProrationDate() int64 {
+	if x != nil {
+		return x.ProrationDate
+	}
+	return 0
+}
+
+func (x *PreviewPlanChangeResponse) GetNextPaymentAttempt() int64 {
+	if x != nil {
+		return x.NextPaymentAttempt
+	}
+	return 0
+}
+
+type ChangePlanResponse struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	SubscriptionId string                 `protobuf:"bytes,1,opt,name=subscription_id,json=subscriptionId,proto3" json:"subscription_id,omitempty"`
+	PlanId         string                 `protobuf:"bytes,2,opt,name=plan_id,json=planId,proto3" json:"plan_id,omitempty"`
+	Quantity       int64                  `protobuf:"varint,3,opt,name=quantity,proto3" json:"quantity,omitempty"`
+	Status         string                 `protobuf:"bytes,4,opt,name=status,proto3" json:"status,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ChangePlanResponse) Reset() {
+	*x = ChangePlanResponse{}
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[37]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChangePlanResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChangePlanResponse) ProtoMessage() {}
+
+func (x *ChangePlanResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[37]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChangePlanResponse.ProtoReflect.Descriptor instead.
+func (*ChangePlanResponse) Descriptor() ([]byte, []int) {
+	return file_stripe_v1_stripe_service_proto_rawDescGZIP(), []int{37}
+}
+
+func (x *ChangePlanResponse) GetSubscriptionId() string {
+	if x != nil {
+		return x.SubscriptionId
+	}
+	return ""
+}
+
+func (x *ChangePlanResponse) GetPlanId() string {
+	if x != nil {
+		return x.PlanId
+	}
+	return ""
+}
+
+func (x *ChangePlanResponse) GetQuantity() int64 {
+	if x != nil {
+		return x.Quantity
+	}
+	return 0
+}
+
+func (x *ChangePlanResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+type InvalidSubscription struct {
+	state                protoimpl.MessageState `protogen:"open.v1"`
+	Id                   int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	UserExternalId       string                 `protobuf:"bytes,2,opt,name=user_external_id,json=userExternalId,proto3" json:"user_external_id,omitempty"` // hashed, as stored
+	StripeSubscriptionId string                 `protobuf:"bytes,3,opt,name=stripe_subscription_id,json=stripeSubscriptionId,proto3" json:"stripe_subscription_id,omitempty"`
+	StripeCustomerId     string                 `protobuf:"bytes,4,opt,name=stripe_customer_id,json=stripeCustomerId,proto3" json:"stripe_customer_id,omitempty"`
+	Resolution           string                 `protobuf:"bytes,5,opt,name=resolution,proto3" json:"resolution,omitempty"`                    // empty while pending review; cancel_new_refund, cancel_old or dismiss
+	ResolvedBy           string                 `protobuf:"bytes,6,opt,name=resolved_by,json=resolvedBy,proto3" json:"resolved_by,omitempty"`  // admin token name, or "policy" when resolved automatically
+	ResolvedAt           int64                  `protobuf:"varint,7,opt,name=resolved_at,json=resolvedAt,proto3" json:"resolved_at,omitempty"` // unix ms
+	CreatedAt            int64                  `protobuf:"varint,8,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`    // unix ms
+	unknownFields        protoimpl.UnknownFields
+	sizeCache            protoimpl.SizeCache
+}
+
+func (x *InvalidSubscription) Reset() {
+	*x = InvalidSubscription{}
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[38]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InvalidSubscription) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InvalidSubscription) ProtoMessage() {}
+
+func (x *InvalidSubscription) ProtoReflect() protoreflect.Message {
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[38]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InvalidSubscription.ProtoReflect.Descriptor instead.
+func (*InvalidSubscription) Descriptor() ([]byte, []int) {
+	return file_stripe_v1_stripe_service_proto_rawDescGZIP(), []int{38}
+}
+
+func (x *InvalidSubscription) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *InvalidSubscription) GetUserExternalId() string {
+	if x != nil {
+		return x.UserExternalId
+	}
+	return ""
+}
+
+func (x *InvalidSubscription) GetStripeSubscriptionId() string {
+	if x != nil {
+		return x.StripeSubscriptionId
+	}
+	return ""
+}
+
+func (x *InvalidSubscription) GetStripeCustomerId() string {
+	if x != nil {
+		return x.StripeCustomerId
+	}
+	return ""
+}
+
+func (x *InvalidSubscription) GetResolution() string {
+	if x != nil {
+		return x.Resolution
+	}
+	return ""
+}
+
+func (x *InvalidSubscription) GetResolvedBy() string {
+	if x != nil {
+		return x.ResolvedBy
+	}
+	return ""
+}
+
+func (x *InvalidSubscription) GetResolvedAt() int64 {
+	if x != nil {
+		return x.ResolvedAt
+	}
+	return 0
+}
+
+func (x *InvalidSubscription) GetCreatedAt() int64 {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return 0
+}
+
+type ListInvalidSubscriptionsRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	AfterId         int64                  `protobuf:"varint,1,opt,name=after_id,json=afterId,proto3" json:"after_id,omitempty"` // returns entries with a greater id; pass the last id of the previous page
+	Limit           int32                  `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`                    // defaults to 50, at most 500
+	IncludeResolved bool                   `protobuf:"varint,3,opt,name=include_resolved,json=includeResolved,proto3" json:"include_resolved,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *ListInvalidSubscriptionsRequest) Reset() {
+	*x = ListInvalidSubscriptionsRequest{}
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[39]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListInvalidSubscriptionsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListInvalidSubscriptionsRequest) ProtoMessage() {}
+
+func (x *ListInvalidSubscriptionsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[39]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListInvalidSubscriptionsRequest.ProtoReflect.Descriptor instead.
+func (*ListInvalidSubscriptionsRequest) Descriptor() ([]byte, []int) {
+	return file_stripe_v1_stripe_service_proto_rawDescGZIP(), []int{39}
+}
+
+func (x *ListInvalidSubscriptionsRequest) GetAfterId() int64 {
+	if x != nil {
+		return x.AfterId
+	}
+	return 0
+}
+
+func (x *ListInvalidSubscriptionsRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ListInvalidSubscriptionsRequest) GetIncludeResolved() bool {
+	if x != nil {
+		return x.IncludeResolved
+	}
+	return false
+}
+
+type ListInvalidSubscriptionsResponse struct {
+	state                protoimpl.MessageState `protogen:"open.v1"`
+	InvalidSubscriptions []*InvalidSubscription `protobuf:"bytes,1,rep,name=invalid_subscriptions,json=invalidSubscriptions,proto3" json:"invalid_subscriptions,omitempty"`
+	unknownFields        protoimpl.UnknownFields
+	sizeCache            protoimpl.SizeCache
+}
+
+func (x *ListInvalidSubscriptionsResponse) Reset() {
+	*x = ListInvalidSubscriptionsResponse{}
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[40]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListInvalidSubscriptionsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListInvalidSubscriptionsResponse) ProtoMessage() {}
+
+func (x *ListInvalidSubscriptionsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[40]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListInvalidSubscriptionsResponse.ProtoReflect.Descriptor instead.
+func (*ListInvalidSubscriptionsResponse) Descriptor() ([]byte, []int) {
+	return file_stripe_v1_stripe_service_proto_rawDescGZIP(), []int{40}
+}
+
+func (x *ListInvalidSubscriptionsResponse) GetInvalidSubscriptions() []*InvalidSubscription {
+	if x != nil {
+		return x.InvalidSubscriptions
+	}
+	return nil
+}
+
+type ResolveInvalidSubscriptionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Resolution    string                 `protobuf:"bytes,2,opt,name=resolution,proto3" json:"resolution,omitempty"` // cancel_new_refund, cancel_old or dismiss
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResolveInvalidSubscriptionRequest) Reset() {
+	*x = ResolveInvalidSubscriptionRequest{}
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[41]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResolveInvalidSubscriptionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResolveInvalidSubscriptionRequest) ProtoMessage() {}
+
+func (x *ResolveInvalidSubscriptionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[41]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResolveInvalidSubscriptionRequest.ProtoReflect.Descriptor instead.
+func (*ResolveInvalidSubscriptionRequest) Descriptor() ([]byte, []int) {
+	return file_stripe_v1_stripe_service_proto_rawDescGZIP(), []int{41}
+}
+
+func (x *ResolveInvalidSubscriptionRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *ResolveInvalidSubscriptionRequest) GetResolution() string {
+	if x != nil {
+		return x.Resolution
+	}
+	return ""
+}
+
+type ResolveInvalidSubscriptionResponse struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	InvalidSubscription *InvalidSubscription   `protobuf:"bytes,1,opt,name=invalid_subscription,json=invalidSubscription,proto3" json:"invalid_subscription,omitempty"`
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *ResolveInvalidSubscriptionResponse) Reset() {
+	*x = ResolveInvalidSubscriptionResponse{}
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[42]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResolveInvalidSubscriptionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResolveInvalidSubscriptionResponse) ProtoMessage() {}
+
+func (x *ResolveInvalidSubscriptionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[42]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResolveInvalidSubscriptionResponse.ProtoReflect.Descriptor instead.
+func (*ResolveInvalidSubscriptionResponse) Descriptor() ([]byte, []int) {
+	return file_stripe_v1_stripe_service_proto_rawDescGZIP(), []int{42}
+}
+
+func (x *ResolveInvalidSubscriptionResponse) GetInvalidSubscription() *InvalidSubscription {
+	if x != nil {
+		return x.InvalidSubscription
+	}
+	return nil
+}
+
+type CreateOrganizationRequest struct {
+	state                  protoimpl.MessageState `protogen:"open.v1"`
+	OrganizationExternalId string                 `protobuf:"bytes,1,opt,name=organization_external_id,json=organizationExternalId,proto3" json:"organization_external_id,omitempty"` // also the checkout client_reference_id of the organization's subscription
+	OwnerUserExternalId    string                 `protobuf:"bytes,2,opt,name=owner_user_external_id,json=ownerUserExternalId,proto3" json:"owner_user_external_id,omitempty"`
+	unknownFields          protoimpl.UnknownFields
+	sizeCache              protoimpl.SizeCache
+}
+
+func (x *CreateOrganizationRequest) Reset() {
+	*x = CreateOrganizationRequest{}
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[43]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateOrganizationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateOrganizationRequest) ProtoMessage() {}
+
+func (x *CreateOrganizationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[43]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateOrganizationRequest.ProtoReflect.Descriptor instead.
+func (*CreateOrganizationRequest) Descriptor() ([]byte, []int) {
+	return file_stripe_v1_stripe_service_proto_rawDescGZIP(), []int{43}
+}
+
+func (x *CreateOrganizationRequest) GetOrganizationExternalId() string {
+	if x != nil {
+		return x.OrganizationExternalId
+	}
+	return ""
+}
+
+func (x *CreateOrganizationRequest) GetOwnerUserExternalId() string {
+	if x != nil {
+		return x.OwnerUserExternalId
+	}
+	return ""
+}
+
+type CreateOrganizationResponse struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	OrganizationId int64                  `protobuf:"varint,1,opt,name=organization_id,json=organizationId,proto3" json:"organization_id,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *CreateOrganizationResponse) Reset() {
+	*x = CreateOrganizationResponse{}
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[44]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateOrganizationResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateOrganizationResponse) ProtoMessage() {}
+
+func (x *CreateOrganizationResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[44]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateOrganizationResponse.ProtoReflect.Descriptor instead.
+func (*CreateOrganizationResponse) Descriptor() ([]byte, []int) {
+	return file_stripe_v1_stripe_service_proto_rawDescGZIP(), []int{44}
+}
+
+func (x *CreateOrganizationResponse) GetOrganizationId() int64 {
+	if x != nil {
+		return x.OrganizationId
+	}
+	return 0
+}
+
+type AddOrganizationMemberRequest struct {
+	state                  protoimpl.MessageState `protogen:"open.v1"`
+	OrganizationExternalId string                 `protobuf:"bytes,1,opt,name=organization_external_id,json=organizationExternalId,proto3" json:"organization_external_id,omitempty"`
+	ActorUserExternalId    string                 `protobuf:"bytes,2,opt,name=actor_user_external_id,json=actorUserExternalId,proto3" json:"actor_user_external_id,omitempty"`
+	UserExternalId         string                 `protobuf:"bytes,3,opt,name=user_external_id,json=userExternalId,proto3" json:"user_external_id,omitempty"`
+	Role                   string                 `protobuf:"bytes,4,opt,name=role,proto3" json:"role,omitempty"` // admin or member (default)
+	unknownFields          protoimpl.UnknownFields
+	sizeCache              protoimpl.SizeCache
+}
+
+func (x *AddOrganizationMemberRequest) Reset() {
+	*x = AddOrganizationMemberRequest{}
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[45]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddOrganizationMemberRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddOrganizationMemberRequest) ProtoMessage() {}
+
+func (x *AddOrganizationMemberRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[45]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddOrganizationMemberRequest.ProtoReflect.Descriptor instead.
+func (*AddOrganizationMemberRequest) Descriptor() ([]byte, []int) {
+	return file_stripe_v1_stripe_service_proto_rawDescGZIP(), []int{45}
+}
+
+func (x *AddOrganizationMemberRequest) GetOrganizationExternalId() string {
+	if x != nil {
+		return x.OrganizationExternalId
+	}
+	return ""
+}
+
+func (x *AddOrganizationMemberRequest) GetActorUserExternalId() string {
+	if x != nil {
+		return x.ActorUserExternalId
+	}
+	return ""
+}
+
+func (x *AddOrganizationMemberRequest) GetUserExternalId() string {
+	if x != nil {
+		return x.UserExternalId
+	}
+	return ""
+}
+
+func (x *AddOrganizationMemberRequest) GetRole() string {
+	if x != nil {
+		return x.Role
+	}
+	return ""
+}
+
+type AddOrganizationMemberResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddOrganizationMemberResponse) Reset() {
+	*x = AddOrganizationMemberResponse{}
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[46]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddOrganizationMemberResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddOrganizationMemberResponse) ProtoMessage() {}
+
+func (x *AddOrganizationMemberResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[46]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddOrganizationMemberResponse.ProtoReflect.Descriptor instead.
+func (*AddOrganizationMemberResponse) Descriptor() ([]byte, []int) {
+	return file_stripe_v1_stripe_service_proto_rawDescGZIP(), []int{46}
+}
+
+type RemoveOrganizationMemberRequest struct {
+	state                  protoimpl.MessageState `protogen:"open.v1"`
+	OrganizationExternalId string                 `protobuf:"bytes,1,opt,name=organization_external_id,json=organizationExternalId,proto3" json:"organization_external_id,omitempty"`
+	ActorUserExternalId    string                 `protobuf:"bytes,2,opt,name=actor_user_external_id,json=actorUserExternalId,proto3" json:"actor_user_external_id,omitempty"`
+	UserExternalId         string                 `protobuf:"bytes,3,opt,name=user_external_id,json=userExternalId,proto3" json:"user_external_id,omitempty"`
+	unknownFields          protoimpl.UnknownFields
+	sizeCache              protoimpl.SizeCache
+}
+
+func (x *RemoveOrganizationMemberRequest) Reset() {
+	*x = RemoveOrganizationMemberRequest{}
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[47]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RemoveOrganizationMemberRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RemoveOrganizationMemberRequest) ProtoMessage() {}
+
+func (x *RemoveOrganizationMemberRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[47]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RemoveOrganizationMemberRequest.ProtoReflect.Descriptor instead.
+func (*RemoveOrganizationMemberRequest) Descriptor() ([]byte, []int) {
+	return file_stripe_v1_stripe_service_proto_rawDescGZIP(), []int{47}
+}
+
+func (x *RemoveOrganizationMemberRequest) GetOrganizationExternalId() string {
+	if x != nil {
+		return x.OrganizationExternalId
+	}
+	return ""
+}
+
+func (x *RemoveOrganizationMemberRequest) GetActorUserExternalId() string {
+	if x != nil {
+		return x.ActorUserExternalId
+	}
+	return ""
+}
+
+func (x *RemoveOrganizationMemberRequest) GetUserExternalId() string {
+	if x != nil {
+		return x.UserExternalId
+	}
+	return ""
+}
+
+type RemoveOrganizationMemberResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RemoveOrganizationMemberResponse) Reset() {
+	*x = RemoveOrganizationMemberResponse{}
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[48]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RemoveOrganizationMemberResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RemoveOrganizationMemberResponse) ProtoMessage() {}
+
+func (x *RemoveOrganizationMemberResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[48]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RemoveOrganizationMemberResponse.ProtoReflect.Descriptor instead.
+func (*RemoveOrganizationMemberResponse) Descriptor() ([]byte, []int) {
+	return file_stripe_v1_stripe_service_proto_rawDescGZIP(), []int{48}
+}
+
+type ListOrganizationMembersRequest struct {
+	state                  protoimpl.MessageState `protogen:"open.v1"`
+	OrganizationExternalId string                 `protobuf:"bytes,1,opt,name=organization_external_id,json=organizationExternalId,proto3" json:"organization_external_id,omitempty"`
+	ActorUserExternalId    string                 `protobuf:"bytes,2,opt,name=actor_user_external_id,json=actorUserExternalId,proto3" json:"actor_user_external_id,omitempty"`
+	unknownFields          protoimpl.UnknownFields
+	sizeCache              protoimpl.SizeCache
+}
+
+func (x *ListOrganizationMembersRequest) Reset() {
+	*x = ListOrganizationMembersRequest{}
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[49]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListOrganizationMembersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListOrganizationMembersRequest) ProtoMessage() {}
+
+func (x *ListOrganizationMembersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[49]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListOrganizationMembersRequest.ProtoReflect.Descriptor instead.
+func (*ListOrganizationMembersRequest) Descriptor() ([]byte, []int) {
+	return file_stripe_v1_stripe_service_proto_rawDescGZIP(), []int{49}
+}
+
+func (x *ListOrganizationMembersRequest) GetOrganizationExternalId() string {
+	if x != nil {
+		return x.OrganizationExternalId
+	}
+	return ""
+}
+
+func (x *ListOrganizationMembersRequest) GetActorUserExternalId() string {
+	if x != nil {
+		return x.ActorUserExternalId
+	}
+	return ""
+}
+
+type OrganizationMember struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	UserExternalId string                 `protobuf:"bytes,1,opt,name=user_external_id,json=userExternalId,proto3" json:"user_external_id,omitempty"` // hashed, as stored
+	Role           string                 `protobuf:"bytes,2,opt,name=role,proto3" json:"role,omitempty"`                                             // owner, admin or member
+	CreatedAt      int64                  `protobuf:"varint,3,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`                 // unix ms
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *OrganizationMember) Reset() {
+	*x = OrganizationMember{}
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[50]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OrganizationMember) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OrganizationMember) ProtoMessage() {}
+
+func (x *OrganizationMember) ProtoReflect() protoreflect.Message {
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[50]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OrganizationMember.ProtoReflect.Descriptor instead.
+func (*OrganizationMember) Descriptor() ([]byte, []int) {
+	return file_stripe_v1_stripe_service_proto_rawDescGZIP(), []int{50}
+}
+
+func (x *OrganizationMember) GetUserExternalId() string {
+	if x != nil {
+		return x.UserExternalId
+	}
+	return ""
+}
+
+func (x *OrganizationMember) GetRole() string {
+	if x != nil {
+		return x.Role
+	}
+	return ""
+}
+
+func (x *OrganizationMember) GetCreatedAt() int64 {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return 0
+}
+
+type ListOrganizationMembersResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Members       []*OrganizationMember  `protobuf:"bytes,1,rep,name=members,proto3" json:"members,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListOrganizationMembersResponse) Reset() {
+	*x = ListOrganizationMembersResponse{}
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[51]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListOrganizationMembersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListOrganizationMembersResponse) ProtoMessage() {}
+
+func (x *ListOrganizationMembersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[51]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListOrganizationMembersResponse.ProtoReflect.Descriptor instead.
+func (*ListOrganizationMembersResponse) Descriptor() ([]byte, []int) {
+	return file_stripe_v1_stripe_service_proto_rawDescGZIP(), []int{51}
+}
+
+func (x *ListOrganizationMembersResponse) GetMembers() []*OrganizationMember {
+	if x != nil {
+		return x.Members
+	}
+	return nil
+}
+
+type AddSeatRequest struct {
+	state                  protoimpl.MessageState `protogen:"open.v1"`
+	OrganizationExternalId string                 `protobuf:"bytes,1,opt,name=organization_external_id,json=organizationExternalId,proto3" json:"organization_external_id,omitempty"`
+	ActorUserExternalId    string                 `protobuf:"bytes,2,opt,name=actor_user_external_id,json=actorUserExternalId,proto3" json:"actor_user_external_id,omitempty"`
+	UserExternalId         string                 `protobuf:"bytes,3,opt,name=user_external_id,json=userExternalId,proto3" json:"user_external_id,omitempty"`
+	unknownFields          protoimpl.UnknownFields
+	sizeCache              protoimpl.SizeCache
+}
+
+func (x *AddSeatRequest) Reset() {
+	*x = AddSeatRequest{}
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[52]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddSeatRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddSeatRequest) ProtoMessage() {}
+
+func (x *AddSeatRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[52]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddSeatRequest.ProtoReflect.Descriptor instead.
+func (*AddSeatRequest) Descriptor() ([]byte, []int) {
+	return file_stripe_v1_stripe_service_proto_rawDescGZIP(), []int{52}
+}
+
+func (x *AddSeatRequest) GetOrganizationExternalId() string {
+	if x != nil {
+		return x.OrganizationExternalId
+	}
+	return ""
+}
+
+func (x *AddSeatRequest) GetActorUserExternalId() string {
+	if x != nil {
+		return x.ActorUserExternalId
+	}
+	return ""
+}
+
+func (x *AddSeatRequest) GetUserExternalId() string {
+	if x != nil {
+		return x.UserExternalId
+	}
+	return ""
+}
+
+type RemoveSeatRequest struct {
+	state                  protoimpl.MessageState `protogen:"open.v1"`
+	OrganizationExternalId string                 `protobuf:"bytes,1,opt,name=organization_external_id,json=organizationExternalId,proto3" json:"organization_external_id,omitempty"`
+	ActorUserExternalId    string                 `protobuf:"bytes,2,opt,name=actor_user_external_id,json=actorUserExternalId,proto3" json:"actor_user_external_id,omitempty"`
+	UserExternalId         string                 `protobuf:"bytes,3,opt,name=user_external_id,json=userExternalId,proto3" json:"user_external_id,omitempty"`
+	unknownFields          protoimpl.UnknownFields
+	sizeCache              protoimpl.SizeCache
+}
+
+func (x *RemoveSeatRequest) Reset() {
+	*x = RemoveSeatRequest{}
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[53]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RemoveSeatRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RemoveSeatRequest) ProtoMessage() {}
+
+func (x *RemoveSeatRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[53]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RemoveSeatRequest.ProtoReflect.Descriptor instead.
+func (*RemoveSeatRequest) Descriptor() ([]byte, []int) {
+	return file_stripe_v1_stripe_service_proto_rawDescGZIP(), []int{53}
+}
+
+func (x *RemoveSeatRequest) GetOrganizationExternalId() string {
+	if x != nil {
+		return x.OrganizationExternalId
+	}
+	return ""
+}
+
+func (x *RemoveSeatRequest) GetActorUserExternalId() string {
+	if x != nil {
+		return x.ActorUserExternalId
+	}
+	return ""
+}
+
+func (x *RemoveSeatRequest) GetUserExternalId() string {
+	if x != nil {
+		return x.UserExternalId
+	}
+	return ""
+}
+
+type ListSeatsRequest struct {
+	state                  protoimpl.MessageState `protogen:"open.v1"`
+	OrganizationExternalId string                 `protobuf:"bytes,1,opt,name=organization_external_id,json=organizationExternalId,proto3" json:"organization_external_id,omitempty"`
+	ActorUserExternalId    string                 `protobuf:"bytes,2,opt,name=actor_user_external_id,json=actorUserExternalId,proto3" json:"actor_user_external_id,omitempty"`
+	unknownFields          protoimpl.UnknownFields
+	sizeCache              protoimpl.SizeCache
+}
+
+func (x *ListSeatsRequest) Reset() {
+	*x = ListSeatsRequest{}
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[54]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListSeatsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSeatsRequest) ProtoMessage() {}
+
+func (x *ListSeatsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[54]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSeatsRequest.ProtoReflect.Descriptor instead.
+func (*ListSeatsRequest) Descriptor() ([]byte, []int) {
+	return file_stripe_v1_stripe_service_proto_rawDescGZIP(), []int{54}
+}
+
+func (x *ListSeatsRequest) GetOrganizationExternalId() string {
+	if x != nil {
+		return x.OrganizationExternalId
+	}
+	return ""
+}
+
+func (x *ListSeatsRequest) GetActorUserExternalId() string {
+	if x != nil {
+		return x.ActorUserExternalId
+	}
+	return ""
+}
+
+type Seat struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	UserExternalId string                 `protobuf:"bytes,1,opt,name=user_external_id,json=userExternalId,proto3" json:"user_external_id,omitempty"` // hashed, as stored
+	Role           string                 `protobuf:"bytes,2,opt,name=role,proto3" json:"role,omitempty"`                                             // owner, admin or member
+	AssignedAt     int64                  `protobuf:"varint,3,opt,name=assigned_at,json=assignedAt,proto3" json:"assigned_at,omitempty"`              // unix ms
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *Seat) Reset() {
+	*x = Seat{}
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[55]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Seat) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Seat) ProtoMessage() {}
+
+func (x *Seat) ProtoReflect() protoreflect.Message {
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[55]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Seat.ProtoReflect.Descriptor instead.
+func (*Seat) Descriptor() ([]byte, []int) {
+	return file_stripe_v1_stripe_service_proto_rawDescGZIP(), []int{55}
+}
+
+func (x *Seat) GetUserExternalId() string {
+	if x != nil {
+		return x.UserExternalId
+	}
+	return ""
+}
+
+func (x *Seat) GetRole() string {
+	if x != nil {
+		return x.Role
+	}
+	return ""
+}
+
+func (x *Seat) GetAssignedAt() int64 {
+	if x != nil {
+		return x.AssignedAt
+	}
+	return 0
+}
+
+type SeatsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Seats         []*Seat                `protobuf:"bytes,1,rep,name=seats,proto3" json:"seats,omitempty"`
+	Quantity      int64                  `protobuf:"varint,2,opt,name=quantity,proto3" json:"quantity,omitempty"` // subscription quantity; 0 without a subscription billed per seat
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SeatsResponse) Reset() {
+	*x = SeatsResponse{}
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[56]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SeatsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SeatsResponse) ProtoMessage() {}
+
+func (x *SeatsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[56]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SeatsResponse.ProtoReflect.Descriptor instead.
+func (*SeatsResponse) Descriptor() ([]byte, []int) {
+	return file_stripe_v1_stripe_service_proto_rawDescGZIP(), []int{56}
+}
+
+func (x *SeatsResponse) GetSeats() []*Seat {
+	if x != nil {
+		return x.Seats
+	}
+	return nil
+}
+
+func (x *SeatsResponse) GetQuantity() int64 {
+	if x != nil {
+		return x.Quantity
+	}
+	return 0
+}
+
+type SetSpendingCapRequest struct {
+	state                  protoimpl.MessageState `protogen:"open.v1"`
+	OrganizationExternalId string                 `protobuf:"bytes,1,opt,name=organization_external_id,json=organizationExternalId,proto3" json:"organization_external_id,omitempty"`
+	ActorUserExternalId    string                 `protobuf:"bytes,2,opt,name=actor_user_external_id,json=actorUserExternalId,proto3" json:"actor_user_external_id,omitempty"`
+	// exactly one of member_user_external_id and api_key_id
+	MemberUserExternalId string `protobuf:"bytes,3,opt,name=member_user_external_id,json=memberUserExternalId,proto3" json:"member_user_external_id,omitempty"`
+	ApiKeyId             string `protobuf:"bytes,4,opt,name=api_key_id,json=apiKeyId,proto3" json:"api_key_id,omitempty"`
+	CapUnits             int64  `protobuf:"varint,5,opt,name=cap_units,json=capUnits,proto3" json:"cap_units,omitempty"` // per billing period; 0 removes the cap
+	unknownFields        protoimpl.UnknownFields
+	sizeCache            protoimpl.SizeCache
+}
+
+func (x *SetSpendingCapRequest) Reset() {
+	*x = SetSpendingCapRequest{}
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[57]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetSpendingCapRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetSpendingCapRequest) ProtoMessage() {}
+
+func (x *SetSpendingCapRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[57]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetSpendingCapRequest.ProtoReflect.Descriptor instead.
+func (*SetSpendingCapRequest) Descriptor() ([]byte, []int) {
+	return file_stripe_v1_stripe_service_proto_rawDescGZIP(), []int{57}
+}
+
+func (x *SetSpendingCapRequest) GetOrganizationExternalId() string {
+	if x != nil {
+		return x.OrganizationExternalId
+	}
+	return ""
+}
+
+func (x *SetSpendingCapRequest) GetActorUserExternalId() string {
+	if x != nil {
+		return x.ActorUserExternalId
+	}
+	return ""
+}
+
+func (x *SetSpendingCapRequest) GetMemberUserExternalId() string {
+	if x != nil {
+		return x.MemberUserExternalId
+	}
+	return ""
+}
+
+func (x *SetSpendingCapRequest) GetApiKeyId() string {
+	if x != nil {
+		return x.ApiKeyId
+	}
+	return ""
+}
+
+func (x *SetSpendingCapRequest) GetCapUnits() int64 {
+	if x != nil {
+		return x.CapUnits
+	}
+	return 0
+}
+
+type SetSpendingCapResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetSpendingCapResponse) Reset() {
+	*x = SetSpendingCapResponse{}
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[58]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetSpendingCapResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetSpendingCapResponse) ProtoMessage() {}
+
+func (x *SetSpendingCapResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[58]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetSpendingCapResponse.ProtoReflect.Descriptor instead.
+func (*SetSpendingCapResponse) Descriptor() ([]byte, []int) {
+	return file_stripe_v1_stripe_service_proto_rawDescGZIP(), []int{58}
+}
+
+type GetUsageBreakdownRequest struct {
+	state                  protoimpl.MessageState `protogen:"open.v1"`
+	OrganizationExternalId string                 `protobuf:"bytes,1,opt,name=organization_external_id,json=organizationExternalId,proto3" json:"organization_external_id,omitempty"`
+	ActorUserExternalId    string                 `protobuf:"bytes,2,opt,name=actor_user_external_id,json=actorUserExternalId,proto3" json:"actor_user_external_id,omitempty"`
+	unknownFields          protoimpl.UnknownFields
+	sizeCache              protoimpl.SizeCache
+}
+
+func (x *GetUsageBreakdownRequest) Reset() {
+	*x = GetUsageBreakdownRequest{}
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[59]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetUsageBreakdownRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetUsageBreakdownRequest) ProtoMessage() {}
+
+func (x *GetUsageBreakdownRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[59]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetUsageBreakdownRequest.ProtoReflect.Descriptor instead.
+func (*GetUsageBreakdownRequest) Descriptor() ([]byte, []int) {
+	return file_stripe_v1_stripe_service_proto_rawDescGZIP(), []int{59}
+}
+
+func (x *GetUsageBreakdownRequest) GetOrganizationExternalId() string {
+	if x != nil {
+		return x.OrganizationExternalId
+	}
+	return ""
+}
+
+func (x *GetUsageBreakdownRequest) GetActorUserExternalId() string {
+	if x != nil {
+		return x.ActorUserExternalId
+	}
+	return ""
+}
+
+type SpendingUsage struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"` // hashed member identifier ("" for units spent directly on the organization), or API key ID
+	Units         int64                  `protobuf:"varint,2,opt,name=units,proto3" json:"units,omitempty"`
+	CapUnits      int64                  `protobuf:"varint,3,opt,name=cap_units,json=capUnits,proto3" json:"cap_units,omitempty"` // 0 when uncapped
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SpendingUsage) Reset() {
+	*x = SpendingUsage{}
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[60]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SpendingUsage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SpendingUsage) ProtoMessage() {}
+
+func (x *SpendingUsage) ProtoReflect() protoreflect.Message {
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[60]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SpendingUsage.ProtoReflect.Descriptor instead.
+func (*SpendingUsage) Descriptor() ([]byte, []int) {
+	return file_stripe_v1_stripe_service_proto_rawDescGZIP(), []int{60}
+}
+
+func (x *SpendingUsage) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *SpendingUsage) GetUnits() int64 {
+	if x != nil {
+		return x.Units
+	}
+	return 0
+}
+
+func (x *SpendingUsage) GetCapUnits() int64 {
+	if x != nil {
+		return x.CapUnits
+	}
+	return 0
+}
+
+type GetUsageBreakdownResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PeriodStart   int64                  `protobuf:"varint,1,opt,name=period_start,json=periodStart,proto3" json:"period_start,omitempty"` // unix ms
+	PeriodEnd     int64                  `protobuf:"varint,2,opt,name=period_end,json=periodEnd,proto3" json:"period_end,omitempty"`       // unix ms
+	Members       []*SpendingUsage       `protobuf:"bytes,3,rep,name=members,proto3" json:"members,omitempty"`
+	ApiKeys       []*SpendingUsage       `protobuf:"bytes,4,rep,name=api_keys,json=apiKeys,proto3" json:"api_keys,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetUsageBreakdownResponse) Reset() {
+	*x = GetUsageBreakdownResponse{}
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[61]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetUsageBreakdownResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetUsageBreakdownResponse) ProtoMessage() {}
+
+func (x *GetUsageBreakdownResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[61]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetUsageBreakdownResponse.ProtoReflect.Descriptor instead.
+func (*GetUsageBreakdownResponse) Descriptor() ([]byte, []int) {
+	return file_stripe_v1_stripe_service_proto_rawDescGZIP(), []int{61}
+}
+
+func (x *GetUsageBreakdownResponse) GetPeriodStart() int64 {
+	if x != nil {
+		return x.PeriodStart
+	}
+	return 0
+}
+
+func (x *GetUsageBreakdownResponse) GetPeriodEnd() int64 {
+	if x != nil {
+		return x.PeriodEnd
+	}
+	return 0
+}
+
+func (x *GetUsageBreakdownResponse) GetMembers() []*SpendingUsage {
+	if x != nil {
+		return x.Members
+	}
+	return nil
+}
+
+func (x *GetUsageBreakdownResponse) GetApiKeys() []*SpendingUsage {
+	if x != nil {
+		return x.ApiKeys
+	}
+	return nil
+}
+
+type WebhookDelivery struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Id             int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	EventId        string                 `protobuf:"bytes,2,opt,name=event_id,json=eventId,proto3" json:"event_id,omitempty"`       // sent in the Webhook-Id header; the same for every endpoint of an event
+	EventType      string                 `protobuf:"bytes,3,opt,name=event_type,json=eventType,proto3" json:"event_type,omitempty"` // subscription.activated, subscription.cancelled, credits.exhausted or credits.granted
+	EndpointUrl    string                 `protobuf:"bytes,4,opt,name=endpoint_url,json=endpointUrl,proto3" json:"endpoint_url,omitempty"`
+	Status         string                 `protobuf:"bytes,5,opt,name=status,proto3" json:"status,omitempty"` // pending, delivered or failed
+	Attempts       int32                  `protobuf:"varint,6,opt,name=attempts,proto3" json:"attempts,omitempty"`
+	NextAttemptAt  int64                  `protobuf:"varint,7,opt,name=next_attempt_at,json=nextAttemptAt,proto3" json:"next_attempt_at,omitempty"`    // unix ms; meaningful while pending
+	LastStatusCode int32                  `protobuf:"varint,8,opt,name=last_status_code,json=lastStatusCode,proto3" json:"last_status_code,omitempty"` // 0 when the endpoint did not respond
+	LastError      string                 `protobuf:"bytes,9,opt,name=last_error,json=lastError,proto3" json:"last_error,omitempty"`
+	DeliveredAt    int64                  `protobuf:"varint,10,opt,name=delivered_at,json=deliveredAt,proto3" json:"delivered_at,omitempty"` // unix ms
+	CreatedAt      int64                  `protobuf:"varint,11,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`       // unix ms
 	unknownFields  protoimpl.UnknownFields
 	sizeCache      protoimpl.SizeCache
 }
 
-func (x *SpendingUnit) Reset() {
-	*x = SpendingUnit{}
-	mi := &file_stripe_v1_stripe_service_proto_msgTypes[4]
+func (x *WebhookDelivery) Reset() {
+	*x = WebhookDelivery{}
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[62]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SpendingUnit) String() string {
+func (x *WebhookDelivery) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SpendingUnit) ProtoMessage() {}
+func (*WebhookDelivery) ProtoMessage() {}
 
-func (x *SpendingUnit) ProtoReflect() protoreflect.Message {
-	mi := &file_stripe_v1_stripe_service_proto_msgTypes[4]
+func (x *WebhookDelivery) ProtoReflect() protoreflect.Message {
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[62]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -252,61 +3677,113 @@ func (x *SpendingUnit) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SpendingUnit.ProtoReflect.Descriptor instead.
-func (*SpendingUnit) Descriptor() ([]byte, []int) {
-	return file_stripe_v1_stripe_service_proto_rawDescGZIP(), []int{4}
+// Deprecated: Use WebhookDelivery.ProtoReflect.Descriptor instead.
+func (*WebhookDelivery) Descriptor() ([]byte, []int) {
+	return file_stripe_v1_stripe_service_proto_rawDescGZIP(), []int{62}
 }
 
-func (x *SpendingUnit) GetExternalId() string {
+func (x *WebhookDelivery) GetId() int64 {
 	if x != nil {
-		return x.ExternalId
+		return x.Id
+	}
+	return 0
+}
+
+func (x *WebhookDelivery) GetEventId() string {
+	if x != nil {
+		return x.EventId
 	}
 	return ""
 }
 
-func (x *SpendingUnit) GetUserExternalId() string {
+func (x *WebhookDelivery) GetEventType() string {
 	if x != nil {
-		return x.UserExternalId
+		return x.EventType
 	}
 	return ""
 }
 
-func (x *SpendingUnit) GetAmount() int32 {
+func (x *WebhookDelivery) GetEndpointUrl() string {
 	if x != nil {
-		return x.Amount
+		return x.EndpointUrl
+	}
+	return ""
+}
+
+func (x *WebhookDelivery) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *WebhookDelivery) GetAttempts() int32 {
+	if x != nil {
+		return x.Attempts
 	}
 	return 0
 }
 
-func (x *SpendingUnit) GetCreatedAt() int64 {
+func (x *WebhookDelivery) GetNextAttemptAt() int64 {
+	if x != nil {
+		return x.NextAttemptAt
+	}
+	return 0
+}
+
+func (x *WebhookDelivery) GetLastStatusCode() int32 {
+	if x != nil {
+		return x.LastStatusCode
+	}
+	return 0
+}
+
+func (x *WebhookDelivery) GetLastError() string {
+	if x != nil {
+		return x.LastError
+	}
+	return ""
+}
+
+func (x *WebhookDelivery) GetDeliveredAt() int64 {
+	if x != nil {
+		return x.DeliveredAt
+	}
+	return 0
+}
+
+func (x *WebhookDelivery) GetCreatedAt() int64 {
 	if x != nil {
 		return x.CreatedAt
 	}
 	return 0
 }
 
-type AddSpendingUnitsRequest struct {
+type ListWebhookDeliveriesRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Items         []*SpendingUnit        `protobuf:"bytes,1,rep,name=items,proto3" json:"items,omitempty"`
+	BeforeId      int64                  `protobuf:"varint,1,opt,name=before_id,json=beforeId,proto3" json:"before_id,omitempty"`   // returns entries with a smaller id; pass the last id of the previous page
+	Limit         int32                  `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`                         // defaults to 50, at most 500
+	Status        string                 `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"`                        // optional: pending, delivered or failed
+	EventType     string                 `protobuf:"bytes,4,opt,name=event_type,json=eventType,proto3" json:"event_type,omitempty"` // optional
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *AddSpendingUnitsRequest) Reset() {
-	*x = AddSpendingUnitsRequest{}
-	mi := &file_stripe_v1_stripe_service_proto_msgTypes[5]
+func (x *ListWebhookDeliveriesRequest) Reset() {
+	*x = ListWebhookDeliveriesRequest{}
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[63]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *AddSpendingUnitsRequest) String() string {
+func (x *ListWebhookDeliveriesRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*AddSpendingUnitsRequest) ProtoMessage() {}
+func (*ListWebhookDeliveriesRequest) ProtoMessage() {}
 
-func (x *AddSpendingUnitsRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_stripe_v1_stripe_service_proto_msgTypes[5]
+func (x *ListWebhookDeliveriesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[63]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -317,40 +3794,61 @@ func (x *AddSpendingUnitsRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use AddSpendingUnitsRequest.ProtoReflect.Descriptor instead.
-func (*AddSpendingUnitsRequest) Descriptor() ([]byte, []int) {
-	return file_stripe_v1_stripe_service_proto_rawDescGZIP(), []int{5}
+// Deprecated: Use ListWebhookDeliveriesRequest.ProtoReflect.Descriptor instead.
+func (*ListWebhookDeliveriesRequest) Descriptor() ([]byte, []int) {
+	return file_stripe_v1_stripe_service_proto_rawDescGZIP(), []int{63}
 }
 
-func (x *AddSpendingUnitsRequest) GetItems() []*SpendingUnit {
+func (x *ListWebhookDeliveriesRequest) GetBeforeId() int64 {
 	if x != nil {
-		return x.Items
+		return x.BeforeId
 	}
-	return nil
+	return 0
 }
 
-type AddSpendingUnitsResponse struct {
+func (x *ListWebhookDeliveriesRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ListWebhookDeliveriesRequest) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *ListWebhookDeliveriesRequest) GetEventType() string {
+	if x != nil {
+		return x.EventType
+	}
+	return ""
+}
+
+type ListWebhookDeliveriesResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Inserted      int32                  `protobuf:"varint,1,opt,name=inserted,proto3" json:"inserted,omitempty"` // number of rows inserted (duplicates skipped)
+	Deliveries    []*WebhookDelivery     `protobuf:"bytes,1,rep,name=deliveries,proto3" json:"deliveries,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *AddSpendingUnitsResponse) Reset() {
-	*x = AddSpendingUnitsResponse{}
-	mi := &file_stripe_v1_stripe_service_proto_msgTypes[6]
+func (x *ListWebhookDeliveriesResponse) Reset() {
+	*x = ListWebhookDeliveriesResponse{}
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[64]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *AddSpendingUnitsResponse) String() string {
+func (x *ListWebhookDeliveriesResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*AddSpendingUnitsResponse) ProtoMessage() {}
+func (*ListWebhookDeliveriesResponse) ProtoMessage() {}
 
-func (x *AddSpendingUnitsResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_stripe_v1_stripe_service_proto_msgTypes[6]
+func (x *ListWebhookDeliveriesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_stripe_v1_stripe_service_proto_msgTypes[64]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -361,16 +3859,16 @@ func (x *AddSpendingUnitsResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use AddSpendingUnitsResponse.ProtoReflect.Descriptor instead.
-func (*AddSpendingUnitsResponse) Descriptor() ([]byte, []int) {
-	return file_stripe_v1_stripe_service_proto_rawDescGZIP(), []int{6}
+// Deprecated: Use ListWebhookDeliveriesResponse.ProtoReflect.Descriptor instead.
+func (*ListWebhookDeliveriesResponse) Descriptor() ([]byte, []int) {
+	return file_stripe_v1_stripe_service_proto_rawDescGZIP(), []int{64}
 }
 
-func (x *AddSpendingUnitsResponse) GetInserted() int32 {
+func (x *ListWebhookDeliveriesResponse) GetDeliveries() []*WebhookDelivery {
 	if x != nil {
-		return x.Inserted
+		return x.Deliveries
 	}
-	return 0
+	return nil
 }
 
 var File_stripe_v1_stripe_service_proto protoreflect.FileDescriptor
@@ -382,28 +3880,342 @@ const file_stripe_v1_stripe_service_proto_rawDesc = "" +
 	"\x0fsubscription_id\x18\x01 \x01(\tR\x0esubscriptionId\"\x1c\n" +
 	"\x1aCancelSubscriptionResponse\"M\n" +
 	"!VerifySubscriptionValidityRequest\x12(\n" +
-	"\x10user_external_id\x18\x01 \x01(\tR\x0euserExternalId\"\xda\x01\n" +
+	"\x10user_external_id\x18\x01 \x01(\tR\x0euserExternalId\"\xf1\x03\n" +
 	"\"VerifySubscriptionValidityResponse\x122\n" +
 	"\x15is_valid_subscription\x18\x01 \x01(\bR\x13isValidSubscription\x12'\n" +
 	"\x0finvalidity_type\x18\x02 \x01(\tR\x0einvalidityType\x12#\n" +
 	"\rvalidity_type\x18\x03 \x01(\tR\fvalidityType\x122\n" +
-	"\x15stripe_customer_email\x18\x04 \x01(\tR\x13stripeCustomerEmail\"\x90\x01\n" +
+	"\x15stripe_customer_email\x18\x04 \x01(\tR\x13stripeCustomerEmail\x12\x1b\n" +
+	"\ttrial_end\x18\x05 \x01(\x03R\btrialEnd\x12%\n" +
+	"\x0egrace_deadline\x18\x06 \x01(\x03R\rgraceDeadline\x12\x1d\n" +
+	"\n" +
+	"in_dunning\x18\a \x01(\bR\tinDunning\x122\n" +
+	"\x15payment_attempt_count\x18\b \x01(\x05R\x13paymentAttemptCount\x120\n" +
+	"\x14next_payment_attempt\x18\t \x01(\x03R\x12nextPaymentAttempt\x12\x1d\n" +
+	"\n" +
+	"resumes_at\x18\n" +
+	" \x01(\x03R\tresumesAt\x12-\n" +
+	"\x12exhausted_features\x18\v \x03(\tR\x11exhaustedFeatures\"T\n" +
+	"&BatchVerifySubscriptionValidityRequest\x12*\n" +
+	"\x11user_external_ids\x18\x01 \x03(\tR\x0fuserExternalIds\"\xa7\x01\n" +
+	"%BatchVerifySubscriptionValidityResult\x12I\n" +
+	"\bvalidity\x18\x01 \x01(\v2-.stripe.v1.VerifySubscriptionValidityResponseR\bvalidity\x12\x14\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error\x12\x1d\n" +
+	"\n" +
+	"error_code\x18\x03 \x01(\tR\terrorCode\"\xf2\x01\n" +
+	"'BatchVerifySubscriptionValidityResponse\x12Y\n" +
+	"\aresults\x18\x01 \x03(\v2?.stripe.v1.BatchVerifySubscriptionValidityResponse.ResultsEntryR\aresults\x1al\n" +
+	"\fResultsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12F\n" +
+	"\x05value\x18\x02 \x01(\v20.stripe.v1.BatchVerifySubscriptionValidityResultR\x05value:\x028\x01\"D\n" +
+	"\x18WatchEntitlementsRequest\x12(\n" +
+	"\x10user_external_id\x18\x01 \x01(\tR\x0euserExternalId\"\xc3\x03\n" +
+	"\x10EntitlementState\x12(\n" +
+	"\x10user_external_id\x18\x01 \x01(\tR\x0euserExternalId\x12I\n" +
+	"\bvalidity\x18\x02 \x01(\v2-.stripe.v1.VerifySubscriptionValidityResponseR\bvalidity\x12\x1f\n" +
+	"\vfree_credit\x18\x03 \x01(\x03R\n" +
+	"freeCredit\x12)\n" +
+	"\x10purchased_credit\x18\x04 \x01(\x03R\x0fpurchasedCredit\x12'\n" +
+	"\x0fallowance_units\x18\x05 \x01(\x03R\x0eallowanceUnits\x12\x1d\n" +
+	"\n" +
+	"used_units\x18\x06 \x01(\x03R\tusedUnits\x12\x1c\n" +
+	"\tunlimited\x18\a \x01(\bR\tunlimited\x12'\n" +
+	"\x0fremaining_units\x18\b \x01(\x03R\x0eremainingUnits\x12!\n" +
+	"\fperiod_start\x18\t \x01(\x03R\vperiodStart\x12\x1d\n" +
+	"\n" +
+	"period_end\x18\n" +
+	" \x01(\x03R\tperiodEnd\x12\x1d\n" +
+	"\n" +
+	"updated_at\x18\v \x01(\x03R\tupdatedAt\"\xc7\x02\n" +
 	"\fSpendingUnit\x12\x1f\n" +
 	"\vexternal_id\x18\x01 \x01(\tR\n" +
 	"externalId\x12(\n" +
 	"\x10user_external_id\x18\x02 \x01(\tR\x0euserExternalId\x12\x16\n" +
 	"\x06amount\x18\x03 \x01(\x05R\x06amount\x12\x1d\n" +
 	"\n" +
-	"created_at\x18\x04 \x01(\x03R\tcreatedAt\"H\n" +
+	"created_at\x18\x04 \x01(\x03R\tcreatedAt\x12\x1c\n" +
+	"\n" +
+	"api_key_id\x18\x05 \x01(\tR\bapiKeyId\x12\x1f\n" +
+	"\vfeature_key\x18\x06 \x01(\tR\n" +
+	"featureKey\x12;\n" +
+	"\x06labels\x18\a \x03(\v2#.stripe.v1.SpendingUnit.LabelsEntryR\x06labels\x1a9\n" +
+	"\vLabelsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"H\n" +
 	"\x17AddSpendingUnitsRequest\x12-\n" +
 	"\x05items\x18\x01 \x03(\v2\x17.stripe.v1.SpendingUnitR\x05items\"6\n" +
 	"\x18AddSpendingUnitsResponse\x12\x1a\n" +
-	"\binserted\x18\x01 \x01(\x05R\binserted2\xa6\x04\n" +
+	"\binserted\x18\x01 \x01(\x05R\binserted\"\x8f\x01\n" +
+	"\x1bStreamSpendingUnitsResponse\x12\x1a\n" +
+	"\breceived\x18\x01 \x01(\x03R\breceived\x12\x1a\n" +
+	"\binserted\x18\x02 \x01(\x03R\binserted\x12\x1e\n" +
+	"\n" +
+	"duplicates\x18\x03 \x01(\x03R\n" +
+	"duplicates\x12\x18\n" +
+	"\abatches\x18\x04 \x01(\x05R\abatches\"?\n" +
+	"\x1aRefundSpendingUnitsRequest\x12!\n" +
+	"\fexternal_ids\x18\x01 \x03(\tR\vexternalIds\"9\n" +
+	"\x1bRefundSpendingUnitsResponse\x12\x1a\n" +
+	"\brefunded\x18\x01 \x01(\x05R\brefunded\"\xa4\x01\n" +
+	"\x1fCreateCreditPackCheckoutRequest\x12(\n" +
+	"\x10user_external_id\x18\x01 \x01(\tR\x0euserExternalId\x12\x17\n" +
+	"\apack_id\x18\x02 \x01(\tR\x06packId\x12\x1f\n" +
+	"\vsuccess_url\x18\x03 \x01(\tR\n" +
+	"successUrl\x12\x1d\n" +
+	"\n" +
+	"cancel_url\x18\x04 \x01(\tR\tcancelUrl\"R\n" +
+	" CreateCreditPackCheckoutResponse\x12.\n" +
+	"\x13checkout_session_id\x18\x01 \x01(\tR\x11checkoutSessionId\"\x8e\x01\n" +
+	"\x13GrantCreditsRequest\x12(\n" +
+	"\x10user_external_id\x18\x01 \x01(\tR\x0euserExternalId\x12\x16\n" +
+	"\x06amount\x18\x02 \x01(\x05R\x06amount\x12\x16\n" +
+	"\x06reason\x18\x03 \x01(\tR\x06reason\x12\x1d\n" +
+	"\n" +
+	"expires_at\x18\x04 \x01(\x03R\texpiresAt\"I\n" +
+	"\x14GrantCreditsResponse\x12\x19\n" +
+	"\bgrant_id\x18\x01 \x01(\x03R\agrantId\x12\x16\n" +
+	"\x06credit\x18\x02 \x01(\x05R\x06credit\"p\n" +
+	"\x14RevokeCreditsRequest\x12(\n" +
+	"\x10user_external_id\x18\x01 \x01(\tR\x0euserExternalId\x12\x16\n" +
+	"\x06amount\x18\x02 \x01(\x05R\x06amount\x12\x16\n" +
+	"\x06reason\x18\x03 \x01(\tR\x06reason\"d\n" +
+	"\x15RevokeCreditsResponse\x12\x19\n" +
+	"\bgrant_id\x18\x01 \x01(\x03R\agrantId\x12\x18\n" +
+	"\arevoked\x18\x02 \x01(\x05R\arevoked\x12\x16\n" +
+	"\x06credit\x18\x03 \x01(\x05R\x06credit\"z\n" +
+	"\x11RedeemCodeRequest\x12(\n" +
+	"\x10user_external_id\x18\x01 \x01(\tR\x0euserExternalId\x12\x12\n" +
+	"\x04code\x18\x02 \x01(\tR\x04code\x12'\n" +
+	"\x0fidempotency_key\x18\x03 \x01(\tR\x0eidempotencyKey\"\\\n" +
+	"\x12RedeemCodeResponse\x12\x14\n" +
+	"\x05units\x18\x01 \x01(\x05R\x05units\x12\x18\n" +
+	"\apending\x18\x02 \x01(\bR\apending\x12\x16\n" +
+	"\x06credit\x18\x03 \x01(\x05R\x06credit\"B\n" +
+	"\x16GetReferralCodeRequest\x12(\n" +
+	"\x10user_external_id\x18\x01 \x01(\tR\x0euserExternalId\"-\n" +
+	"\x17GetReferralCodeResponse\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\tR\x04code\"\xc6\x01\n" +
+	"\x15CreateCampaignRequest\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\tR\x04code\x12\x14\n" +
+	"\x05units\x18\x02 \x01(\x05R\x05units\x12'\n" +
+	"\x0fmax_redemptions\x18\x03 \x01(\x05R\x0emaxRedemptions\x12$\n" +
+	"\x0eper_user_limit\x18\x04 \x01(\x05R\fperUserLimit\x12\x1b\n" +
+	"\tstarts_at\x18\x05 \x01(\x03R\bstartsAt\x12\x17\n" +
+	"\aends_at\x18\x06 \x01(\x03R\x06endsAt\"9\n" +
+	"\x16CreateCampaignResponse\x12\x1f\n" +
+	"\vcampaign_id\x18\x01 \x01(\x03R\n" +
+	"campaignId\"C\n" +
+	"\x17GetBillingStatusRequest\x12(\n" +
+	"\x10user_external_id\x18\x01 \x01(\tR\x0euserExternalId\"\xdb\x01\n" +
+	"\x18GetBillingStatusResponse\x12\x1d\n" +
+	"\n" +
+	"in_dunning\x18\x01 \x01(\bR\tinDunning\x12*\n" +
+	"\x11stripe_invoice_id\x18\x02 \x01(\tR\x0fstripeInvoiceId\x12#\n" +
+	"\rattempt_count\x18\x03 \x01(\x05R\fattemptCount\x120\n" +
+	"\x14next_payment_attempt\x18\x04 \x01(\x03R\x12nextPaymentAttempt\x12\x1d\n" +
+	"\n" +
+	"updated_at\x18\x05 \x01(\x03R\tupdatedAt\"c\n" +
+	"\x1aGetUsageByDimensionRequest\x12(\n" +
+	"\x10user_external_id\x18\x01 \x01(\tR\x0euserExternalId\x12\x1b\n" +
+	"\tlabel_key\x18\x02 \x01(\tR\blabelKey\"h\n" +
+	"\x0eDimensionUsage\x12\x1f\n" +
+	"\vfeature_key\x18\x01 \x01(\tR\n" +
+	"featureKey\x12\x1f\n" +
+	"\vlabel_value\x18\x02 \x01(\tR\n" +
+	"labelValue\x12\x14\n" +
+	"\x05units\x18\x03 \x01(\x03R\x05units\"\x90\x01\n" +
+	"\x1bGetUsageByDimensionResponse\x12!\n" +
+	"\fperiod_start\x18\x01 \x01(\x03R\vperiodStart\x12\x1d\n" +
+	"\n" +
+	"period_end\x18\x02 \x01(\x03R\tperiodEnd\x12/\n" +
+	"\x05usage\x18\x03 \x03(\v2\x19.stripe.v1.DimensionUsageR\x05usage\"\xfa\x01\n" +
+	"\x11PlanChangeRequest\x12(\n" +
+	"\x10user_external_id\x18\x01 \x01(\tR\x0euserExternalId\x12\x17\n" +
+	"\aplan_id\x18\x02 \x01(\tR\x06planId\x12\x1a\n" +
+	"\bquantity\x18\x03 \x01(\x03R\bquantity\x12-\n" +
+	"\x12proration_behavior\x18\x04 \x01(\tR\x11prorationBehavior\x12%\n" +
+	"\x0eproration_date\x18\x05 \x01(\x03R\rprorationDate\x120\n" +
+	"\x14subscription_item_id\x18\x06 \x01(\tR\x12subscriptionItemId\"\x7f\n" +
+	"\x18PauseSubscriptionRequest\x12(\n" +
+	"\x10user_external_id\x18\x01 \x01(\tR\x0euserExternalId\x12\x1a\n" +
+	"\bbehavior\x18\x02 \x01(\tR\bbehavior\x12\x1d\n" +
+	"\n" +
+	"resumes_at\x18\x03 \x01(\x03R\tresumesAt\"E\n" +
+	"\x19ResumeSubscriptionRequest\x12(\n" +
+	"\x10user_external_id\x18\x01 \x01(\tR\x0euserExternalId\"\x97\x01\n" +
+	"\x19SubscriptionPauseResponse\x12'\n" +
+	"\x0fsubscription_id\x18\x01 \x01(\tR\x0esubscriptionId\x12\x16\n" +
+	"\x06paused\x18\x02 \x01(\bR\x06paused\x12\x1a\n" +
+	"\bbehavior\x18\x03 \x01(\tR\bbehavior\x12\x1d\n" +
+	"\n" +
+	"resumes_at\x18\x04 \x01(\x03R\tresumesAt\"\xda\x01\n" +
+	"\x19PreviewPlanChangeResponse\x12\x1a\n" +
+	"\bcurrency\x18\x01 \x01(\tR\bcurrency\x12\x1d\n" +
+	"\n" +
+	"amount_due\x18\x02 \x01(\x03R\tamountDue\x12)\n" +
+	"\x10proration_amount\x18\x03 \x01(\x03R\x0fprorationAmount\x12%\n" +
+	"\x0eproration_date\x18\x04 \x01(\x03R\rprorationDate\x120\n" +
+	"\x14next_payment_attempt\x18\x05 \x01(\x03R\x12nextPaymentAttempt\"\x8a\x01\n" +
+	"\x12ChangePlanResponse\x12'\n" +
+	"\x0fsubscription_id\x18\x01 \x01(\tR\x0esubscriptionId\x12\x17\n" +
+	"\aplan_id\x18\x02 \x01(\tR\x06planId\x12\x1a\n" +
+	"\bquantity\x18\x03 \x01(\x03R\bquantity\x12\x16\n" +
+	"\x06status\x18\x04 \x01(\tR\x06status\"\xb4\x02\n" +
+	"\x13InvalidSubscription\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12(\n" +
+	"\x10user_external_id\x18\x02 \x01(\tR\x0euserExternalId\x124\n" +
+	"\x16stripe_subscription_id\x18\x03 \x01(\tR\x14stripeSubscriptionId\x12,\n" +
+	"\x12stripe_customer_id\x18\x04 \x01(\tR\x10stripeCustomerId\x12\x1e\n" +
+	"\n" +
+	"resolution\x18\x05 \x01(\tR\n" +
+	"resolution\x12\x1f\n" +
+	"\vresolved_by\x18\x06 \x01(\tR\n" +
+	"resolvedBy\x12\x1f\n" +
+	"\vresolved_at\x18\a \x01(\x03R\n" +
+	"resolvedAt\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\b \x01(\x03R\tcreatedAt\"}\n" +
+	"\x1fListInvalidSubscriptionsRequest\x12\x19\n" +
+	"\bafter_id\x18\x01 \x01(\x03R\aafterId\x12\x14\n" +
+	"\x05limit\x18\x02 \x01(\x05R\x05limit\x12)\n" +
+	"\x10include_resolved\x18\x03 \x01(\bR\x0fincludeResolved\"w\n" +
+	" ListInvalidSubscriptionsResponse\x12S\n" +
+	"\x15invalid_subscriptions\x18\x01 \x03(\v2\x1e.stripe.v1.InvalidSubscriptionR\x14invalidSubscriptions\"S\n" +
+	"!ResolveInvalidSubscriptionRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x1e\n" +
+	"\n" +
+	"resolution\x18\x02 \x01(\tR\n" +
+	"resolution\"w\n" +
+	"\"ResolveInvalidSubscriptionResponse\x12Q\n" +
+	"\x14invalid_subscription\x18\x01 \x01(\v2\x1e.stripe.v1.InvalidSubscriptionR\x13invalidSubscription\"\x8a\x01\n" +
+	"\x19CreateOrganizationRequest\x128\n" +
+	"\x18organization_external_id\x18\x01 \x01(\tR\x16organizationExternalId\x123\n" +
+	"\x16owner_user_external_id\x18\x02 \x01(\tR\x13ownerUserExternalId\"E\n" +
+	"\x1aCreateOrganizationResponse\x12'\n" +
+	"\x0forganization_id\x18\x01 \x01(\x03R\x0eorganizationId\"\xcb\x01\n" +
+	"\x1cAddOrganizationMemberRequest\x128\n" +
+	"\x18organization_external_id\x18\x01 \x01(\tR\x16organizationExternalId\x123\n" +
+	"\x16actor_user_external_id\x18\x02 \x01(\tR\x13actorUserExternalId\x12(\n" +
+	"\x10user_external_id\x18\x03 \x01(\tR\x0euserExternalId\x12\x12\n" +
+	"\x04role\x18\x04 \x01(\tR\x04role\"\x1f\n" +
+	"\x1dAddOrganizationMemberResponse\"\xba\x01\n" +
+	"\x1fRemoveOrganizationMemberRequest\x128\n" +
+	"\x18organization_external_id\x18\x01 \x01(\tR\x16organizationExternalId\x123\n" +
+	"\x16actor_user_external_id\x18\x02 \x01(\tR\x13actorUserExternalId\x12(\n" +
+	"\x10user_external_id\x18\x03 \x01(\tR\x0euserExternalId\"\"\n" +
+	" RemoveOrganizationMemberResponse\"\x8f\x01\n" +
+	"\x1eListOrganizationMembersRequest\x128\n" +
+	"\x18organization_external_id\x18\x01 \x01(\tR\x16organizationExternalId\x123\n" +
+	"\x16actor_user_external_id\x18\x02 \x01(\tR\x13actorUserExternalId\"q\n" +
+	"\x12OrganizationMember\x12(\n" +
+	"\x10user_external_id\x18\x01 \x01(\tR\x0euserExternalId\x12\x12\n" +
+	"\x04role\x18\x02 \x01(\tR\x04role\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\x03 \x01(\x03R\tcreatedAt\"Z\n" +
+	"\x1fListOrganizationMembersResponse\x127\n" +
+	"\amembers\x18\x01 \x03(\v2\x1d.stripe.v1.OrganizationMemberR\amembers\"\xa9\x01\n" +
+	"\x0eAddSeatRequest\x128\n" +
+	"\x18organization_external_id\x18\x01 \x01(\tR\x16organizationExternalId\x123\n" +
+	"\x16actor_user_external_id\x18\x02 \x01(\tR\x13actorUserExternalId\x12(\n" +
+	"\x10user_external_id\x18\x03 \x01(\tR\x0euserExternalId\"\xac\x01\n" +
+	"\x11RemoveSeatRequest\x128\n" +
+	"\x18organization_external_id\x18\x01 \x01(\tR\x16organizationExternalId\x123\n" +
+	"\x16actor_user_external_id\x18\x02 \x01(\tR\x13actorUserExternalId\x12(\n" +
+	"\x10user_external_id\x18\x03 \x01(\tR\x0euserExternalId\"\x81\x01\n" +
+	"\x10ListSeatsRequest\x128\n" +
+	"\x18organization_external_id\x18\x01 \x01(\tR\x16organizationExternalId\x123\n" +
+	"\x16actor_user_external_id\x18\x02 \x01(\tR\x13actorUserExternalId\"e\n" +
+	"\x04Seat\x12(\n" +
+	"\x10user_external_id\x18\x01 \x01(\tR\x0euserExternalId\x12\x12\n" +
+	"\x04role\x18\x02 \x01(\tR\x04role\x12\x1f\n" +
+	"\vassigned_at\x18\x03 \x01(\x03R\n" +
+	"assignedAt\"R\n" +
+	"\rSeatsResponse\x12%\n" +
+	"\x05seats\x18\x01 \x03(\v2\x0f.stripe.v1.SeatR\x05seats\x12\x1a\n" +
+	"\bquantity\x18\x02 \x01(\x03R\bquantity\"\xf8\x01\n" +
+	"\x15SetSpendingCapRequest\x128\n" +
+	"\x18organization_external_id\x18\x01 \x01(\tR\x16organizationExternalId\x123\n" +
+	"\x16actor_user_external_id\x18\x02 \x01(\tR\x13actorUserExternalId\x125\n" +
+	"\x17member_user_external_id\x18\x03 \x01(\tR\x14memberUserExternalId\x12\x1c\n" +
+	"\n" +
+	"api_key_id\x18\x04 \x01(\tR\bapiKeyId\x12\x1b\n" +
+	"\tcap_units\x18\x05 \x01(\x03R\bcapUnits\"\x18\n" +
+	"\x16SetSpendingCapResponse\"\x89\x01\n" +
+	"\x18GetUsageBreakdownRequest\x128\n" +
+	"\x18organization_external_id\x18\x01 \x01(\tR\x16organizationExternalId\x123\n" +
+	"\x16actor_user_external_id\x18\x02 \x01(\tR\x13actorUserExternalId\"R\n" +
+	"\rSpendingUsage\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x14\n" +
+	"\x05units\x18\x02 \x01(\x03R\x05units\x12\x1b\n" +
+	"\tcap_units\x18\x03 \x01(\x03R\bcapUnits\"\xc6\x01\n" +
+	"\x19GetUsageBreakdownResponse\x12!\n" +
+	"\fperiod_start\x18\x01 \x01(\x03R\vperiodStart\x12\x1d\n" +
+	"\n" +
+	"period_end\x18\x02 \x01(\x03R\tperiodEnd\x122\n" +
+	"\amembers\x18\x03 \x03(\v2\x18.stripe.v1.SpendingUsageR\amembers\x123\n" +
+	"\bapi_keys\x18\x04 \x03(\v2\x18.stripe.v1.SpendingUsageR\aapiKeys\"\xe5\x02\n" +
+	"\x0fWebhookDelivery\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x19\n" +
+	"\bevent_id\x18\x02 \x01(\tR\aeventId\x12\x1d\n" +
+	"\n" +
+	"event_type\x18\x03 \x01(\tR\teventType\x12!\n" +
+	"\fendpoint_url\x18\x04 \x01(\tR\vendpointUrl\x12\x16\n" +
+	"\x06status\x18\x05 \x01(\tR\x06status\x12\x1a\n" +
+	"\battempts\x18\x06 \x01(\x05R\battempts\x12&\n" +
+	"\x0fnext_attempt_at\x18\a \x01(\x03R\rnextAttemptAt\x12(\n" +
+	"\x10last_status_code\x18\b \x01(\x05R\x0elastStatusCode\x12\x1d\n" +
+	"\n" +
+	"last_error\x18\t \x01(\tR\tlastError\x12!\n" +
+	"\fdelivered_at\x18\n" +
+	" \x01(\x03R\vdeliveredAt\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\v \x01(\x03R\tcreatedAt\"\x88\x01\n" +
+	"\x1cListWebhookDeliveriesRequest\x12\x1b\n" +
+	"\tbefore_id\x18\x01 \x01(\x03R\bbeforeId\x12\x14\n" +
+	"\x05limit\x18\x02 \x01(\x05R\x05limit\x12\x16\n" +
+	"\x06status\x18\x03 \x01(\tR\x06status\x12\x1d\n" +
+	"\n" +
+	"event_type\x18\x04 \x01(\tR\teventType\"[\n" +
+	"\x1dListWebhookDeliveriesResponse\x12:\n" +
+	"\n" +
+	"deliveries\x18\x01 \x03(\v2\x1a.stripe.v1.WebhookDeliveryR\n" +
+	"deliveries2\x94!\n" +
 	"\rStripeService\x12\x86\x01\n" +
 	"\x12CancelSubscription\x12$.stripe.v1.CancelSubscriptionRequest\x1a%.stripe.v1.CancelSubscriptionResponse\"#\x82\xd3\xe4\x93\x02\x1d:\x01*\"\x18/api/cancel-subscription\x12\xa7\x01\n" +
-	"\x1aVerifySubscriptionValidity\x12,.stripe.v1.VerifySubscriptionValidityRequest\x1a-.stripe.v1.VerifySubscriptionValidityResponse\",\x82\xd3\xe4\x93\x02&:\x01*\"!/api/verify-subscription-validity\x12e\n" +
+	"\x1aVerifySubscriptionValidity\x12,.stripe.v1.VerifySubscriptionValidityRequest\x1a-.stripe.v1.VerifySubscriptionValidityResponse\",\x82\xd3\xe4\x93\x02&:\x01*\"!/api/verify-subscription-validity\x12\xbc\x01\n" +
+	"\x1fBatchVerifySubscriptionValidity\x121.stripe.v1.BatchVerifySubscriptionValidityRequest\x1a2.stripe.v1.BatchVerifySubscriptionValidityResponse\"2\x82\xd3\xe4\x93\x02,:\x01*\"'/api/verify-subscription-validity/batch\x12W\n" +
+	"\x11WatchEntitlements\x12#.stripe.v1.WatchEntitlementsRequest\x1a\x1b.stripe.v1.EntitlementState0\x01\x12e\n" +
 	"\rHandleWebhook\x12\x14.google.api.HttpBody\x1a\x16.google.protobuf.Empty\"&\x82\xd3\xe4\x93\x02 :\x01*\"\x1b/api/receive-stripe-webhook\x12{\n" +
-	"\x10AddSpendingUnits\x12\".stripe.v1.AddSpendingUnitsRequest\x1a#.stripe.v1.AddSpendingUnitsResponse\"\x1e\x82\xd3\xe4\x93\x02\x18:\x01*\"\x13/api/spending-unitsBXZVgithub.com/tbeaudouin05/stripe-trellai/internal/autogenerated/proto/stripe/v1;stripev1b\x06proto3"
+	"\x10AddSpendingUnits\x12\".stripe.v1.AddSpendingUnitsRequest\x1a#.stripe.v1.AddSpendingUnitsResponse\"\x1e\x82\xd3\xe4\x93\x02\x18:\x01*\"\x13/api/spending-units\x12X\n" +
+	"\x13StreamSpendingUnits\x12\x17.stripe.v1.SpendingUnit\x1a&.stripe.v1.StreamSpendingUnitsResponse(\x01\x12\x8b\x01\n" +
+	"\x13RefundSpendingUnits\x12%.stripe.v1.RefundSpendingUnitsRequest\x1a&.stripe.v1.RefundSpendingUnitsResponse\"%\x82\xd3\xe4\x93\x02\x1f:\x01*\"\x1a/api/spending-units/refund\x12x\n" +
+	"\x10GetBillingStatus\x12\".stripe.v1.GetBillingStatusRequest\x1a#.stripe.v1.GetBillingStatusResponse\"\x1b\x82\xd3\xe4\x93\x02\x15\x12\x13/api/billing-status\x12\x83\x01\n" +
+	"\x13GetUsageByDimension\x12%.stripe.v1.GetUsageByDimensionRequest\x1a&.stripe.v1.GetUsageByDimensionResponse\"\x1d\x82\xd3\xe4\x93\x02\x17\x12\x15/api/usage/dimensions\x12\x89\x01\n" +
+	"\x11PreviewPlanChange\x12\x1c.stripe.v1.PlanChangeRequest\x1a$.stripe.v1.PreviewPlanChangeResponse\"0\x82\xd3\xe4\x93\x02*:\x01*\"%/api/subscription/preview-plan-change\x12s\n" +
+	"\n" +
+	"ChangePlan\x12\x1c.stripe.v1.PlanChangeRequest\x1a\x1d.stripe.v1.ChangePlanResponse\"(\x82\xd3\xe4\x93\x02\":\x01*\"\x1d/api/subscription/change-plan\x12\x82\x01\n" +
+	"\x11PauseSubscription\x12#.stripe.v1.PauseSubscriptionRequest\x1a$.stripe.v1.SubscriptionPauseResponse\"\"\x82\xd3\xe4\x93\x02\x1c:\x01*\"\x17/api/subscription/pause\x12\x85\x01\n" +
+	"\x12ResumeSubscription\x12$.stripe.v1.ResumeSubscriptionRequest\x1a$.stripe.v1.SubscriptionPauseResponse\"#\x82\xd3\xe4\x93\x02\x1d:\x01*\"\x18/api/subscription/resume\x12\x80\x01\n" +
+	"\x12CreateOrganization\x12$.stripe.v1.CreateOrganizationRequest\x1a%.stripe.v1.CreateOrganizationResponse\"\x1d\x82\xd3\xe4\x93\x02\x17:\x01*\"\x12/api/organizations\x12\x91\x01\n" +
+	"\x15AddOrganizationMember\x12'.stripe.v1.AddOrganizationMemberRequest\x1a(.stripe.v1.AddOrganizationMemberResponse\"%\x82\xd3\xe4\x93\x02\x1f:\x01*\"\x1a/api/organizations/members\x12\xa1\x01\n" +
+	"\x18RemoveOrganizationMember\x12*.stripe.v1.RemoveOrganizationMemberRequest\x1a+.stripe.v1.RemoveOrganizationMemberResponse\",\x82\xd3\xe4\x93\x02&:\x01*\"!/api/organizations/members/remove\x12\x94\x01\n" +
+	"\x17ListOrganizationMembers\x12).stripe.v1.ListOrganizationMembersRequest\x1a*.stripe.v1.ListOrganizationMembersResponse\"\"\x82\xd3\xe4\x93\x02\x1c\x12\x1a/api/organizations/members\x12c\n" +
+	"\aAddSeat\x12\x19.stripe.v1.AddSeatRequest\x1a\x18.stripe.v1.SeatsResponse\"#\x82\xd3\xe4\x93\x02\x1d:\x01*\"\x18/api/organizations/seats\x12p\n" +
+	"\n" +
+	"RemoveSeat\x12\x1c.stripe.v1.RemoveSeatRequest\x1a\x18.stripe.v1.SeatsResponse\"*\x82\xd3\xe4\x93\x02$:\x01*\"\x1f/api/organizations/seats/remove\x12d\n" +
+	"\tListSeats\x12\x1b.stripe.v1.ListSeatsRequest\x1a\x18.stripe.v1.SeatsResponse\" \x82\xd3\xe4\x93\x02\x1a\x12\x18/api/organizations/seats\x12\x82\x01\n" +
+	"\x0eSetSpendingCap\x12 .stripe.v1.SetSpendingCapRequest\x1a!.stripe.v1.SetSpendingCapResponse\"+\x82\xd3\xe4\x93\x02%:\x01*\" /api/organizations/spending-caps\x12\x80\x01\n" +
+	"\x11GetUsageBreakdown\x12#.stripe.v1.GetUsageBreakdownRequest\x1a$.stripe.v1.GetUsageBreakdownResponse\" \x82\xd3\xe4\x93\x02\x1a\x12\x18/api/organizations/usage\x12\x9a\x01\n" +
+	"\x18CreateCreditPackCheckout\x12*.stripe.v1.CreateCreditPackCheckoutRequest\x1a+.stripe.v1.CreateCreditPackCheckoutResponse\"%\x82\xd3\xe4\x93\x02\x1f:\x01*\"\x1a/api/credit-packs/checkout\x12t\n" +
+	"\fGrantCredits\x12\x1e.stripe.v1.GrantCreditsRequest\x1a\x1f.stripe.v1.GrantCreditsResponse\"#\x82\xd3\xe4\x93\x02\x1d:\x01*\"\x18/api/admin/credits/grant\x12x\n" +
+	"\rRevokeCredits\x12\x1f.stripe.v1.RevokeCreditsRequest\x1a .stripe.v1.RevokeCreditsResponse\"$\x82\xd3\xe4\x93\x02\x1e:\x01*\"\x19/api/admin/credits/revoke\x12g\n" +
+	"\n" +
+	"RedeemCode\x12\x1c.stripe.v1.RedeemCodeRequest\x1a\x1d.stripe.v1.RedeemCodeResponse\"\x1c\x82\xd3\xe4\x93\x02\x16:\x01*\"\x11/api/codes/redeem\x12t\n" +
+	"\x0fGetReferralCode\x12!.stripe.v1.GetReferralCodeRequest\x1a\".stripe.v1.GetReferralCodeResponse\"\x1a\x82\xd3\xe4\x93\x02\x14\x12\x12/api/referral-code\x12v\n" +
+	"\x0eCreateCampaign\x12 .stripe.v1.CreateCampaignRequest\x1a!.stripe.v1.CreateCampaignResponse\"\x1f\x82\xd3\xe4\x93\x02\x19:\x01*\"\x14/api/admin/campaigns\x12\x9d\x01\n" +
+	"\x18ListInvalidSubscriptions\x12*.stripe.v1.ListInvalidSubscriptionsRequest\x1a+.stripe.v1.ListInvalidSubscriptionsResponse\"(\x82\xd3\xe4\x93\x02\"\x12 /api/admin/invalid-subscriptions\x12\xae\x01\n" +
+	"\x1aResolveInvalidSubscription\x12,.stripe.v1.ResolveInvalidSubscriptionRequest\x1a-.stripe.v1.ResolveInvalidSubscriptionResponse\"3\x82\xd3\xe4\x93\x02-:\x01*\"(/api/admin/invalid-subscriptions/resolve\x12\x91\x01\n" +
+	"\x15ListWebhookDeliveries\x12'.stripe.v1.ListWebhookDeliveriesRequest\x1a(.stripe.v1.ListWebhookDeliveriesResponse\"%\x82\xd3\xe4\x93\x02\x1f\x12\x1d/api/admin/webhook-deliveriesBXZVgithub.com/tbeaudouin05/stripe-trellai/internal/autogenerated/proto/stripe/v1;stripev1b\x06proto3"
 
 var (
 	file_stripe_v1_stripe_service_proto_rawDescOnce sync.Once
@@ -417,33 +4229,162 @@ func file_stripe_v1_stripe_service_proto_rawDescGZIP() []byte {
 	return file_stripe_v1_stripe_service_proto_rawDescData
 }
 
-var file_stripe_v1_stripe_service_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_stripe_v1_stripe_service_proto_msgTypes = make([]protoimpl.MessageInfo, 67)
 var file_stripe_v1_stripe_service_proto_goTypes = []any{
-	(*CancelSubscriptionRequest)(nil),          // 0: stripe.v1.CancelSubscriptionRequest
-	(*CancelSubscriptionResponse)(nil),         // 1: stripe.v1.CancelSubscriptionResponse
-	(*VerifySubscriptionValidityRequest)(nil),  // 2: stripe.v1.VerifySubscriptionValidityRequest
-	(*VerifySubscriptionValidityResponse)(nil), // 3: stripe.v1.VerifySubscriptionValidityResponse
-	(*SpendingUnit)(nil),                       // 4: stripe.v1.SpendingUnit
-	(*AddSpendingUnitsRequest)(nil),            // 5: stripe.v1.AddSpendingUnitsRequest
-	(*AddSpendingUnitsResponse)(nil),           // 6: stripe.v1.AddSpendingUnitsResponse
-	(*httpbody.HttpBody)(nil),                  // 7: google.api.HttpBody
-	(*emptypb.Empty)(nil),                      // 8: google.protobuf.Empty
+	(*CancelSubscriptionRequest)(nil),               // 0: stripe.v1.CancelSubscriptionRequest
+	(*CancelSubscriptionResponse)(nil),              // 1: stripe.v1.CancelSubscriptionResponse
+	(*VerifySubscriptionValidityRequest)(nil),       // 2: stripe.v1.VerifySubscriptionValidityRequest
+	(*VerifySubscriptionValidityResponse)(nil),      // 3: stripe.v1.VerifySubscriptionValidityResponse
+	(*BatchVerifySubscriptionValidityRequest)(nil),  // 4: stripe.v1.BatchVerifySubscriptionValidityRequest
+	(*BatchVerifySubscriptionValidityResult)(nil),   // 5: stripe.v1.BatchVerifySubscriptionValidityResult
+	(*BatchVerifySubscriptionValidityResponse)(nil), // 6: stripe.v1.BatchVerifySubscriptionValidityResponse
+	(*WatchEntitlementsRequest)(nil),                // 7: stripe.v1.WatchEntitlementsRequest
+	(*EntitlementState)(nil),                        // 8: stripe.v1.EntitlementState
+	(*SpendingUnit)(nil),                            // 9: stripe.v1.SpendingUnit
+	(*AddSpendingUnitsRequest)(nil),                 // 10: stripe.v1.AddSpendingUnitsRequest
+	(*AddSpendingUnitsResponse)(nil),                // 11: stripe.v1.AddSpendingUnitsResponse
+	(*StreamSpendingUnitsResponse)(nil),             // 12: stripe.v1.StreamSpendingUnitsResponse
+	(*RefundSpendingUnitsRequest)(nil),              // 13: stripe.v1.RefundSpendingUnitsRequest
+	(*RefundSpendingUnitsResponse)(nil),             // 14: stripe.v1.RefundSpendingUnitsResponse
+	(*CreateCreditPackCheckoutRequest)(nil),         // 15: stripe.v1.CreateCreditPackCheckoutRequest
+	(*CreateCreditPackCheckoutResponse)(nil),        // 16: stripe.v1.CreateCreditPackCheckoutResponse
+	(*GrantCreditsRequest)(nil),                     // 17: stripe.v1.GrantCreditsRequest
+	(*GrantCreditsResponse)(nil),                    // 18: stripe.v1.GrantCreditsResponse
+	(*RevokeCreditsRequest)(nil),                    // 19: stripe.v1.RevokeCreditsRequest
+	(*RevokeCreditsResponse)(nil),                   // 20: stripe.v1.RevokeCreditsResponse
+	(*RedeemCodeRequest)(nil),                       // 21: stripe.v1.RedeemCodeRequest
+	(*RedeemCodeResponse)(nil),                      // 22: stripe.v1.RedeemCodeResponse
+	(*GetReferralCodeRequest)(nil),                  // 23: stripe.v1.GetReferralCodeRequest
+	(*GetReferralCodeResponse)(nil),                 // 24: stripe.v1.GetReferralCodeResponse
+	(*CreateCampaignRequest)(nil),                   // 25: stripe.v1.CreateCampaignRequest
+	(*CreateCampaignResponse)(nil),                  // 26: stripe.v1.CreateCampaignResponse
+	(*GetBillingStatusRequest)(nil),                 // 27: stripe.v1.GetBillingStatusRequest
+	(*GetBillingStatusResponse)(nil),                // 28: stripe.v1.GetBillingStatusResponse
+	(*GetUsageByDimensionRequest)(nil),              // 29: stripe.v1.GetUsageByDimensionRequest
+	(*DimensionUsage)(nil),                          // 30: stripe.v1.DimensionUsage
+	(*GetUsageByDimensionResponse)(nil),             // 31: stripe.v1.GetUsageByDimensionResponse
+	(*PlanChangeRequest)(nil),                       // 32: stripe.v1.PlanChangeRequest
+	(*PauseSubscriptionRequest)(nil),                // 33: stripe.v1.PauseSubscriptionRequest
+	(*ResumeSubscriptionRequest)(nil),               // 34: stripe.v1.ResumeSubscriptionRequest
+	(*SubscriptionPauseResponse)(nil),               // 35: stripe.v1.SubscriptionPauseResponse
+	(*PreviewPlanChangeResponse)(nil),               // 36: stripe.v1.PreviewPlanChangeResponse
+	(*ChangePlanResponse)(nil),                      // 37: stripe.v1.ChangePlanResponse
+	(*InvalidSubscription)(nil),                     // 38: stripe.v1.InvalidSubscription
+	(*ListInvalidSubscriptionsRequest)(nil),         // 39: stripe.v1.ListInvalidSubscriptionsRequest
+	(*ListInvalidSubscriptionsResponse)(nil),        // 40: stripe.v1.ListInvalidSubscriptionsResponse
+	(*ResolveInvalidSubscriptionRequest)(nil),       // 41: stripe.v1.ResolveInvalidSubscriptionRequest
+	(*ResolveInvalidSubscriptionResponse)(nil),      // 42: stripe.v1.ResolveInvalidSubscriptionResponse
+	(*CreateOrganizationRequest)(nil),               // 43: stripe.v1.CreateOrganizationRequest
+	(*CreateOrganizationResponse)(nil),              // 44: stripe.v1.CreateOrganizationResponse
+	(*AddOrganizationMemberRequest)(nil),            // 45: stripe.v1.AddOrganizationMemberRequest
+	(*AddOrganizationMemberResponse)(nil),           // 46: stripe.v1.AddOrganizationMemberResponse
+	(*RemoveOrganizationMemberRequest)(nil),         // 47: stripe.v1.RemoveOrganizationMemberRequest
+	(*RemoveOrganizationMemberResponse)(nil),        // 48: stripe.v1.RemoveOrganizationMemberResponse
+	(*ListOrganizationMembersRequest)(nil),          // 49: stripe.v1.ListOrganizationMembersRequest
+	(*OrganizationMember)(nil),                      // 50: stripe.v1.OrganizationMember
+	(*ListOrganizationMembersResponse)(nil),         // 51: stripe.v1.ListOrganizationMembersResponse
+	(*AddSeatRequest)(nil),                          // 52: stripe.v1.AddSeatRequest
+	(*RemoveSeatRequest)(nil),                       // 53: stripe.v1.RemoveSeatRequest
+	(*ListSeatsRequest)(nil),                        // 54: stripe.v1.ListSeatsRequest
+	(*Seat)(nil),                                    // 55: stripe.v1.Seat
+	(*SeatsResponse)(nil),                           // 56: stripe.v1.SeatsResponse
+	(*SetSpendingCapRequest)(nil),                   // 57: stripe.v1.SetSpendingCapRequest
+	(*SetSpendingCapResponse)(nil),                  // 58: stripe.v1.SetSpendingCapResponse
+	(*GetUsageBreakdownRequest)(nil),                // 59: stripe.v1.GetUsageBreakdownRequest
+	(*SpendingUsage)(nil),                           // 60: stripe.v1.SpendingUsage
+	(*GetUsageBreakdownResponse)(nil),               // 61: stripe.v1.GetUsageBreakdownResponse
+	(*WebhookDelivery)(nil),                         // 62: stripe.v1.WebhookDelivery
+	(*ListWebhookDeliveriesRequest)(nil),            // 63: stripe.v1.ListWebhookDeliveriesRequest
+	(*ListWebhookDeliveriesResponse)(nil),           // 64: stripe.v1.ListWebhookDeliveriesResponse
+	nil,                                             // 65: stripe.v1.BatchVerifySubscriptionValidityResponse.ResultsEntry
+	nil,                                             // 66: stripe.v1.SpendingUnit.LabelsEntry
+	(*httpbody.HttpBody)(nil),                       // 67: google.api.HttpBody
+	(*emptypb.Empty)(nil),                           // 68: google.protobuf.Empty
 }
 var file_stripe_v1_stripe_service_proto_depIdxs = []int32{
-	4, // 0: stripe.v1.AddSpendingUnitsRequest.items:type_name -> stripe.v1.SpendingUnit
-	0, // 1: stripe.v1.StripeService.CancelSubscription:input_type -> stripe.v1.CancelSubscriptionRequest
-	2, // 2: stripe.v1.StripeService.VerifySubscriptionValidity:input_type -> stripe.v1.VerifySubscriptionValidityRequest
-	7, // 3: stripe.v1.StripeService.HandleWebhook:input_type -> google.api.HttpBody
-	5, // 4: stripe.v1.StripeService.AddSpendingUnits:input_type -> stripe.v1.AddSpendingUnitsRequest
-	1, // 5: stripe.v1.StripeService.CancelSubscription:output_type -> stripe.v1.CancelSubscriptionResponse
-	3, // 6: stripe.v1.StripeService.VerifySubscriptionValidity:output_type -> stripe.v1.VerifySubscriptionValidityResponse
-	8, // 7: stripe.v1.StripeService.HandleWebhook:output_type -> google.protobuf.Empty
-	6, // 8: stripe.v1.StripeService.AddSpendingUnits:output_type -> stripe.v1.AddSpendingUnitsResponse
-	5, // [5:9] is the sub-list for method output_type
-	1, // [1:5] is the sub-list for method input_type
-	1, // [1:1] is the sub-list for extension type_name
-	1, // [1:1] is the sub-list for extension extendee
-	0, // [0:1] is the sub-list for field type_name
+	3,  // 0: stripe.v1.BatchVerifySubscriptionValidityResult.validity:type_name -> stripe.v1.VerifySubscriptionValidityResponse
+	65, // 1: stripe.v1.BatchVerifySubscriptionValidityResponse.results:type_name -> stripe.v1.BatchVerifySubscriptionValidityResponse.ResultsEntry
+	3,  // 2: stripe.v1.EntitlementState.validity:type_name -> stripe.v1.VerifySubscriptionValidityResponse
+	66, // 3: stripe.v1.SpendingUnit.labels:type_name -> stripe.v1.SpendingUnit.LabelsEntry
+	9,  // 4: stripe.v1.AddSpendingUnitsRequest.items:type_name -> stripe.v1.SpendingUnit
+	30, // 5: stripe.v1.GetUsageByDimensionResponse.usage:type_name -> stripe.v1.DimensionUsage
+	38, // 6: stripe.v1.ListInvalidSubscriptionsResponse.invalid_subscriptions:type_name -> stripe.v1.InvalidSubscription
+	38, // 7: stripe.v1.ResolveInvalidSubscriptionResponse.invalid_subscription:type_name -> stripe.v1.InvalidSubscription
+	50, // 8: stripe.v1.ListOrganizationMembersResponse.members:type_name -> stripe.v1.OrganizationMember
+	55, // 9: stripe.v1.SeatsResponse.seats:type_name -> stripe.v1.Seat
+	60, // 10: stripe.v1.GetUsageBreakdownResponse.members:type_name -> stripe.v1.SpendingUsage
+	60, // 11: stripe.v1.GetUsageBreakdownResponse.api_keys:type_name -> stripe.v1.SpendingUsage
+	62, // 12: stripe.v1.ListWebhookDeliveriesResponse.deliveries:type_name -> stripe.v1.WebhookDelivery
+	5,  // 13: stripe.v1.BatchVerifySubscriptionValidityResponse.ResultsEntry.value:type_name -> stripe.v1.BatchVerifySubscriptionValidityResult
+	0,  // 14: stripe.v1.StripeService.CancelSubscription:input_type -> stripe.v1.CancelSubscriptionRequest
+	2,  // 15: stripe.v1.StripeService.VerifySubscriptionValidity:input_type -> stripe.v1.VerifySubscriptionValidityRequest
+	4,  // 16: stripe.v1.StripeService.BatchVerifySubscriptionValidity:input_type -> stripe.v1.BatchVerifySubscriptionValidityRequest
+	7,  // 17: stripe.v1.StripeService.WatchEntitlements:input_type -> stripe.v1.WatchEntitlementsRequest
+	67, // 18: stripe.v1.StripeService.HandleWebhook:input_type -> google.api.HttpBody
+	10, // 19: stripe.v1.StripeService.AddSpendingUnits:input_type -> stripe.v1.AddSpendingUnitsRequest
+	9,  // 20: stripe.v1.StripeService.StreamSpendingUnits:input_type -> stripe.v1.SpendingUnit
+	13, // 21: stripe.v1.StripeService.RefundSpendingUnits:input_type -> stripe.v1.RefundSpendingUnitsRequest
+	27, // 22: stripe.v1.StripeService.GetBillingStatus:input_type -> stripe.v1.GetBillingStatusRequest
+	29, // 23: stripe.v1.StripeService.GetUsageByDimension:input_type -> stripe.v1.GetUsageByDimensionRequest
+	32, // 24: stripe.v1.StripeService.PreviewPlanChange:input_type -> stripe.v1.PlanChangeRequest
+	32, // 25: stripe.v1.StripeService.ChangePlan:input_type -> stripe.v1.PlanChangeRequest
+	33, // 26: stripe.v1.StripeService.PauseSubscription:input_type -> stripe.v1.PauseSubscriptionRequest
+	34, // 27: stripe.v1.StripeService.ResumeSubscription:input_type -> stripe.v1.ResumeSubscriptionRequest
+	43, // 28: stripe.v1.StripeService.CreateOrganization:input_type -> stripe.v1.CreateOrganizationRequest
+	45, // 29: stripe.v1.StripeService.AddOrganizationMember:input_type -> stripe.v1.AddOrganizationMemberRequest
+	47, // 30: stripe.v1.StripeService.RemoveOrganizationMember:input_type -> stripe.v1.RemoveOrganizationMemberRequest
+	49, // 31: stripe.v1.StripeService.ListOrganizationMembers:input_type -> stripe.v1.ListOrganizationMembersRequest
+	52, // 32: stripe.v1.StripeService.AddSeat:input_type -> stripe.v1.AddSeatRequest
+	53, // 33: stripe.v1.StripeService.RemoveSeat:input_type -> stripe.v1.RemoveSeatRequest
+	54, // 34: stripe.v1.StripeService.ListSeats:input_type -> stripe.v1.ListSeatsRequest
+	57, // 35: stripe.v1.StripeService.SetSpendingCap:input_type -> stripe.v1.SetSpendingCapRequest
+	59, // 36: stripe.v1.StripeService.GetUsageBreakdown:input_type -> stripe.v1.GetUsageBreakdownRequest
+	15, // 37: stripe.v1.StripeService.CreateCreditPackCheckout:input_type -> stripe.v1.CreateCreditPackCheckoutRequest
+	17, // 38: stripe.v1.StripeService.GrantCredits:input_type -> stripe.v1.GrantCreditsRequest
+	19, // 39: stripe.v1.StripeService.RevokeCredits:input_type -> stripe.v1.RevokeCreditsRequest
+	21, // 40: stripe.v1.StripeService.RedeemCode:input_type -> stripe.v1.RedeemCodeRequest
+	23, // 41: stripe.v1.StripeService.GetReferralCode:input_type -> stripe.v1.GetReferralCodeRequest
+	25, // 42: stripe.v1.StripeService.CreateCampaign:input_type -> stripe.v1.CreateCampaignRequest
+	39, // 43: stripe.v1.StripeService.ListInvalidSubscriptions:input_type -> stripe.v1.ListInvalidSubscriptionsRequest
+	41, // 44: stripe.v1.StripeService.ResolveInvalidSubscription:input_type -> stripe.v1.ResolveInvalidSubscriptionRequest
+	63, // 45: stripe.v1.StripeService.ListWebhookDeliveries:input_type -> stripe.v1.ListWebhookDeliveriesRequest
+	1,  // 46: stripe.v1.StripeService.CancelSubscription:output_type -> stripe.v1.CancelSubscriptionResponse
+	3,  // 47: stripe.v1.StripeService.VerifySubscriptionValidity:output_type -> stripe.v1.VerifySubscriptionValidityResponse
+	6,  // 48: stripe.v1.StripeService.BatchVerifySubscriptionValidity:output_type -> stripe.v1.BatchVerifySubscriptionValidityResponse
+	8,  // 49: stripe.v1.StripeService.WatchEntitlements:output_type -> stripe.v1.EntitlementState
+	68, // 50: stripe.v1.StripeService.HandleWebhook:output_type -> google.protobuf.Empty
+	11, // 51: stripe.v1.StripeService.AddSpendingUnits:output_type -> stripe.v1.AddSpendingUnitsResponse
+	12, // 52: stripe.v1.StripeService.StreamSpendingUnits:output_type -> stripe.v1.StreamSpendingUnitsResponse
+	14, // 53: stripe.v1.StripeService.RefundSpendingUnits:output_type -> stripe.v1.RefundSpendingUnitsResponse
+	28, // 54: stripe.v1.StripeService.GetBillingStatus:output_type -> stripe.v1.GetBillingStatusResponse
+	31, // 55: stripe.v1.StripeService.GetUsageByDimension:output_type -> stripe.v1.GetUsageByDimensionResponse
+	36, // 56: stripe.v1.StripeService.PreviewPlanChange:output_type -> stripe.v1.PreviewPlanChangeResponse
+	37, // 57: stripe.v1.StripeService.ChangePlan:output_type -> stripe.v1.ChangePlanResponse
+	35, // 58: stripe.v1.StripeService.PauseSubscription:output_type -> stripe.v1.SubscriptionPauseResponse
+	35, // 59: stripe.v1.StripeService.ResumeSubscription:output_type -> stripe.v1.SubscriptionPauseResponse
+	44, // 60: stripe.v1.StripeService.CreateOrganization:output_type -> stripe.v1.CreateOrganizationResponse
+	46, // 61: stripe.v1.StripeService.AddOrganizationMember:output_type -> stripe.v1.AddOrganizationMemberResponse
+	48, // 62: stripe.v1.StripeService.RemoveOrganizationMember:output_type -> stripe.v1.RemoveOrganizationMemberResponse
+	51, // 63: stripe.v1.StripeService.ListOrganizationMembers:output_type -> stripe.v1.ListOrganizationMembersResponse
+	56, // 64: stripe.v1.StripeService.AddSeat:output_type -> stripe.v1.SeatsResponse
+	56, // 65: stripe.v1.StripeService.RemoveSeat:output_type -> stripe.v1.SeatsResponse
+	56, // 66: stripe.v1.StripeService.ListSeats:output_type -> stripe.v1.SeatsResponse
+	58, // 67: stripe.v1.StripeService.SetSpendingCap:output_type -> stripe.v1.SetSpendingCapResponse
+	61, // 68: stripe.v1.StripeService.GetUsageBreakdown:output_type -> stripe.v1.GetUsageBreakdownResponse
+	16, // 69: stripe.v1.StripeService.CreateCreditPackCheckout:output_type -> stripe.v1.CreateCreditPackCheckoutResponse
+	18, // 70: stripe.v1.StripeService.GrantCredits:output_type -> stripe.v1.GrantCreditsResponse
+	20, // 71: stripe.v1.StripeService.RevokeCredits:output_type -> stripe.v1.RevokeCreditsResponse
+	22, // 72: stripe.v1.StripeService.RedeemCode:output_type -> stripe.v1.RedeemCodeResponse
+	24, // 73: stripe.v1.StripeService.GetReferralCode:output_type -> stripe.v1.GetReferralCodeResponse
+	26, // 74: stripe.v1.StripeService.CreateCampaign:output_type -> stripe.v1.CreateCampaignResponse
+	40, // 75: stripe.v1.StripeService.ListInvalidSubscriptions:output_type -> stripe.v1.ListInvalidSubscriptionsResponse
+	42, // 76: stripe.v1.StripeService.ResolveInvalidSubscription:output_type -> stripe.v1.ResolveInvalidSubscriptionResponse
+	64, // 77: stripe.v1.StripeService.ListWebhookDeliveries:output_type -> stripe.v1.ListWebhookDeliveriesResponse
+	46, // [46:78] is the sub-list for method output_type
+	14, // [14:46] is the sub-list for method input_type
+	14, // [14:14] is the sub-list for extension type_name
+	14, // [14:14] is the sub-list for extension extendee
+	0,  // [0:14] is the sub-list for field type_name
 }
 
 func init() { file_stripe_v1_stripe_service_proto_init() }
@@ -457,7 +4398,7 @@ func file_stripe_v1_stripe_service_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_stripe_v1_stripe_service_proto_rawDesc), len(file_stripe_v1_stripe_service_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   7,
+			NumMessages:   67,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
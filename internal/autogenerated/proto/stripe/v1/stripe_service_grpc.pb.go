@@ -21,10 +21,38 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	StripeService_CancelSubscription_FullMethodName         = "/stripe.v1.StripeService/CancelSubscription"
-	StripeService_VerifySubscriptionValidity_FullMethodName = "/stripe.v1.StripeService/VerifySubscriptionValidity"
-	StripeService_HandleWebhook_FullMethodName              = "/stripe.v1.StripeService/HandleWebhook"
-	StripeService_AddSpendingUnits_FullMethodName           = "/stripe.v1.StripeService/AddSpendingUnits"
+	StripeService_CancelSubscription_FullMethodName              = "/stripe.v1.StripeService/CancelSubscription"
+	StripeService_VerifySubscriptionValidity_FullMethodName      = "/stripe.v1.StripeService/VerifySubscriptionValidity"
+	StripeService_BatchVerifySubscriptionValidity_FullMethodName = "/stripe.v1.StripeService/BatchVerifySubscriptionValidity"
+	StripeService_WatchEntitlements_FullMethodName               = "/stripe.v1.StripeService/WatchEntitlements"
+	StripeService_HandleWebhook_FullMethodName                   = "/stripe.v1.StripeService/HandleWebhook"
+	StripeService_AddSpendingUnits_FullMethodName                = "/stripe.v1.StripeService/AddSpendingUnits"
+	StripeService_StreamSpendingUnits_FullMethodName             = "/stripe.v1.StripeService/StreamSpendingUnits"
+	StripeService_RefundSpendingUnits_FullMethodName             = "/stripe.v1.StripeService/RefundSpendingUnits"
+	StripeService_GetBillingStatus_FullMethodName                = "/stripe.v1.StripeService/GetBillingStatus"
+	StripeService_GetUsageByDimension_FullMethodName             = "/stripe.v1.StripeService/GetUsageByDimension"
+	StripeService_PreviewPlanChange_FullMethodName               = "/stripe.v1.StripeService/PreviewPlanChange"
+	StripeService_ChangePlan_FullMethodName                      = "/stripe.v1.StripeService/ChangePlan"
+	StripeService_PauseSubscription_FullMethodName               = "/stripe.v1.StripeService/PauseSubscription"
+	StripeService_ResumeSubscription_FullMethodName              = "/stripe.v1.StripeService/ResumeSubscription"
+	StripeService_CreateOrganization_FullMethodName              = "/stripe.v1.StripeService/CreateOrganization"
+	StripeService_AddOrganizationMember_FullMethodName           = "/stripe.v1.StripeService/AddOrganizationMember"
+	StripeService_RemoveOrganizationMember_FullMethodName        = "/stripe.v1.StripeService/RemoveOrganizationMember"
+	StripeService_ListOrganizationMembers_FullMethodName         = "/stripe.v1.StripeService/ListOrganizationMembers"
+	StripeService_AddSeat_FullMethodName                         = "/stripe.v1.StripeService/AddSeat"
+	StripeService_RemoveSeat_FullMethodName                      = "/stripe.v1.StripeService/RemoveSeat"
+	StripeService_ListSeats_FullMethodName                       = "/stripe.v1.StripeService/ListSeats"
+	StripeService_SetSpendingCap_FullMethodName                  = "/stripe.v1.StripeService/SetSpendingCap"
+	StripeService_GetUsageBreakdown_FullMethodName               = "/stripe.v1.StripeService/GetUsageBreakdown"
+	StripeService_CreateCreditPackCheckout_FullMethodName        = "/stripe.v1.StripeService/CreateCreditPackCheckout"
+	StripeService_GrantCredits_FullMethodName                    = "/stripe.v1.StripeService/GrantCredits"
+	StripeService_RevokeCredits_FullMethodName                   = "/stripe.v1.StripeService/RevokeCredits"
+	StripeService_RedeemCode_FullMethodName                      = "/stripe.v1.StripeService/RedeemCode"
+	StripeService_GetReferralCode_FullMethodName                 = "/stripe.v1.StripeService/GetReferralCode"
+	StripeService_CreateCampaign_FullMethodName                  = "/stripe.v1.StripeService/CreateCampaign"
+	StripeService_ListInvalidSubscriptions_FullMethodName        = "/stripe.v1.StripeService/ListInvalidSubscriptions"
+	StripeService_ResolveInvalidSubscription_FullMethodName      = "/stripe.v1.StripeService/ResolveInvalidSubscription"
+	StripeService_ListWebhookDeliveries_FullMethodName           = "/stripe.v1.StripeService/ListWebhookDeliveries"
 )
 
 // StripeServiceClient is the client API for StripeService service.
@@ -37,11 +65,78 @@ type StripeServiceClient interface {
 	CancelSubscription(ctx context.Context, in *CancelSubscriptionRequest, opts ...grpc.CallOption) (*CancelSubscriptionResponse, error)
 	// Verifies a user's subscription validity by external user id.
 	VerifySubscriptionValidity(ctx context.Context, in *VerifySubscriptionValidityRequest, opts ...grpc.CallOption) (*VerifySubscriptionValidityResponse, error)
+	// Verifies many users at once; failures are reported per user.
+	BatchVerifySubscriptionValidity(ctx context.Context, in *BatchVerifySubscriptionValidityRequest, opts ...grpc.CallOption) (*BatchVerifySubscriptionValidityResponse, error)
+	// Streams a user's entitlement state: the current state first, then each change caused by
+	// webhooks, spending units or account changes, on any instance. Not mapped by grpc-gateway;
+	// HTTP clients use the Server-Sent Events route GET /api/entitlements/stream instead.
+	WatchEntitlements(ctx context.Context, in *WatchEntitlementsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[EntitlementState], error)
 	// Processes a Stripe webhook event.
 	// Uses google.api.HttpBody to receive the raw payload via grpc-gateway.
 	HandleWebhook(ctx context.Context, in *httpbody.HttpBody, opts ...grpc.CallOption) (*emptypb.Empty, error)
 	// Adds spending units in batch.
 	AddSpendingUnits(ctx context.Context, in *AddSpendingUnitsRequest, opts ...grpc.CallOption) (*AddSpendingUnitsResponse, error)
+	// Adds an unbounded stream of spending units, written in micro-batches (gRPC only).
+	StreamSpendingUnits(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[SpendingUnit, StreamSpendingUnitsResponse], error)
+	// Refunds previously added spending units by their original external ids.
+	// Idempotent: already-refunded units are skipped.
+	RefundSpendingUnits(ctx context.Context, in *RefundSpendingUnitsRequest, opts ...grpc.CallOption) (*RefundSpendingUnitsResponse, error)
+	// Returns the user's dunning state, maintained from invoice.payment_failed and invoice.paid webhooks.
+	GetBillingStatus(ctx context.Context, in *GetBillingStatusRequest, opts ...grpc.CallOption) (*GetBillingStatusResponse, error)
+	// Returns the user's usage in the current billing period by feature and, optionally, by one label.
+	GetUsageByDimension(ctx context.Context, in *GetUsageByDimensionRequest, opts ...grpc.CallOption) (*GetUsageByDimensionResponse, error)
+	// Previews the upcoming invoice of a plan change on the user's existing subscription.
+	PreviewPlanChange(ctx context.Context, in *PlanChangeRequest, opts ...grpc.CallOption) (*PreviewPlanChangeResponse, error)
+	// Upgrades or downgrades the user's existing subscription in place.
+	ChangePlan(ctx context.Context, in *PlanChangeRequest, opts ...grpc.CallOption) (*ChangePlanResponse, error)
+	// Pauses payment collection on the user's subscription, optionally until resumes_at.
+	PauseSubscription(ctx context.Context, in *PauseSubscriptionRequest, opts ...grpc.CallOption) (*SubscriptionPauseResponse, error)
+	// Resumes payment collection on the user's paused subscription.
+	ResumeSubscription(ctx context.Context, in *ResumeSubscriptionRequest, opts ...grpc.CallOption) (*SubscriptionPauseResponse, error)
+	// Creates an organization (team account) sharing one subscription and credit pool among its members.
+	CreateOrganization(ctx context.Context, in *CreateOrganizationRequest, opts ...grpc.CallOption) (*CreateOrganizationResponse, error)
+	// Adds a member to an organization, or changes their role. The actor must be the owner or an admin.
+	AddOrganizationMember(ctx context.Context, in *AddOrganizationMemberRequest, opts ...grpc.CallOption) (*AddOrganizationMemberResponse, error)
+	// Removes a member from an organization. The actor must be the owner or an admin, or the member leaving.
+	RemoveOrganizationMember(ctx context.Context, in *RemoveOrganizationMemberRequest, opts ...grpc.CallOption) (*RemoveOrganizationMemberResponse, error)
+	// Lists an organization's members. The actor must be a member.
+	ListOrganizationMembers(ctx context.Context, in *ListOrganizationMembersRequest, opts ...grpc.CallOption) (*ListOrganizationMembersResponse, error)
+	// Assigns a seat to a user, adding them as a member if needed, and raises the subscription quantity
+	// to match. The actor must be the owner or an admin.
+	AddSeat(ctx context.Context, in *AddSeatRequest, opts ...grpc.CallOption) (*SeatsResponse, error)
+	// Takes a member's seat back and lowers the subscription quantity to match. The actor must be the
+	// owner or an admin.
+	RemoveSeat(ctx context.Context, in *RemoveSeatRequest, opts ...grpc.CallOption) (*SeatsResponse, error)
+	// Lists an organization's seats. The actor must be a member.
+	ListSeats(ctx context.Context, in *ListSeatsRequest, opts ...grpc.CallOption) (*SeatsResponse, error)
+	// Caps what a member or an API key can spend from the organization's pool per billing period.
+	// The actor must be the owner or an admin.
+	SetSpendingCap(ctx context.Context, in *SetSpendingCapRequest, opts ...grpc.CallOption) (*SetSpendingCapResponse, error)
+	// Returns the organization's usage in the current billing period by member and by API key.
+	// The actor must be the owner or an admin.
+	GetUsageBreakdown(ctx context.Context, in *GetUsageBreakdownRequest, opts ...grpc.CallOption) (*GetUsageBreakdownResponse, error)
+	// Starts a one-time payment checkout for a configured credit pack.
+	// The purchased units are credited when Stripe reports the session as paid.
+	CreateCreditPackCheckout(ctx context.Context, in *CreateCreditPackCheckoutRequest, opts ...grpc.CallOption) (*CreateCreditPackCheckoutResponse, error)
+	// Admin: grants free credit to a user, optionally expiring. Requires the x-admin-token header.
+	GrantCredits(ctx context.Context, in *GrantCreditsRequest, opts ...grpc.CallOption) (*GrantCreditsResponse, error)
+	// Admin: revokes free credit from a user (clamped at 0). Requires the x-admin-token header.
+	RevokeCredits(ctx context.Context, in *RevokeCreditsRequest, opts ...grpc.CallOption) (*RevokeCreditsResponse, error)
+	// Redeems a promo or referral code. Promo codes credit free credit immediately; referral
+	// codes credit both users once the redeeming user completes a checkout.
+	RedeemCode(ctx context.Context, in *RedeemCodeRequest, opts ...grpc.CallOption) (*RedeemCodeResponse, error)
+	// Returns the user's referral code, creating it on first use.
+	GetReferralCode(ctx context.Context, in *GetReferralCodeRequest, opts ...grpc.CallOption) (*GetReferralCodeResponse, error)
+	// Admin: creates a promo code campaign. Requires the x-admin-token header.
+	CreateCampaign(ctx context.Context, in *CreateCampaignRequest, opts ...grpc.CallOption) (*CreateCampaignResponse, error)
+	// Admin: lists subscriptions checked out while the user already had an active one, pending
+	// review unless include_resolved is set. Requires the x-admin-token header.
+	ListInvalidSubscriptions(ctx context.Context, in *ListInvalidSubscriptionsRequest, opts ...grpc.CallOption) (*ListInvalidSubscriptionsResponse, error)
+	// Admin: resolves a queued duplicate subscription. Requires the x-admin-token header.
+	ResolveInvalidSubscription(ctx context.Context, in *ResolveInvalidSubscriptionRequest, opts ...grpc.CallOption) (*ResolveInvalidSubscriptionResponse, error)
+	// Admin: lists outgoing webhook deliveries to our own application, newest first. Requires the
+	// x-admin-token header.
+	ListWebhookDeliveries(ctx context.Context, in *ListWebhookDeliveriesRequest, opts ...grpc.CallOption) (*ListWebhookDeliveriesResponse, error)
 }
 
 type stripeServiceClient struct {
@@ -72,6 +167,35 @@ func (c *stripeServiceClient) VerifySubscriptionValidity(ctx context.Context, in
 	return out, nil
 }
 
+func (c *stripeServiceClient) BatchVerifySubscriptionValidity(ctx context.Context, in *BatchVerifySubscriptionValidityRequest, opts ...grpc.CallOption) (*BatchVerifySubscriptionValidityResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BatchVerifySubscriptionValidityResponse)
+	err := c.cc.Invoke(ctx, StripeService_BatchVerifySubscriptionValidity_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *stripeServiceClient) WatchEntitlements(ctx context.Context, in *WatchEntitlementsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[EntitlementState], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &StripeService_ServiceDesc.Streams[0], StripeService_WatchEntitlements_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchEntitlementsRequest, EntitlementState]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type StripeService_WatchEntitlementsClient = grpc.ServerStreamingClient[EntitlementState]
+
 func (c *stripeServiceClient) HandleWebhook(ctx context.Context, in *httpbody.HttpBody, opts ...grpc.CallOption) (*emptypb.Empty, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(emptypb.Empty)
@@ -92,6 +216,269 @@ func (c *stripeServiceClient) AddSpendingUnits(ctx context.Context, in *AddSpend
 	return out, nil
 }
 
+func (c *stripeServiceClient) StreamSpendingUnits(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[SpendingUnit, StreamSpendingUnitsResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &StripeService_ServiceDesc.Streams[1], StripeService_StreamSpendingUnits_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[SpendingUnit, StreamSpendingUnitsResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type StripeService_StreamSpendingUnitsClient = grpc.ClientStreamingClient[SpendingUnit, StreamSpendingUnitsResponse]
+
+func (c *stripeServiceClient) RefundSpendingUnits(ctx context.Context, in *RefundSpendingUnitsRequest, opts ...grpc.CallOption) (*RefundSpendingUnitsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RefundSpendingUnitsResponse)
+	err := c.cc.Invoke(ctx, StripeService_RefundSpendingUnits_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *stripeServiceClient) GetBillingStatus(ctx context.Context, in *GetBillingStatusRequest, opts ...grpc.CallOption) (*GetBillingStatusResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetBillingStatusResponse)
+	err := c.cc.Invoke(ctx, StripeService_GetBillingStatus_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *stripeServiceClient) GetUsageByDimension(ctx context.Context, in *GetUsageByDimensionRequest, opts ...grpc.CallOption) (*GetUsageByDimensionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetUsageByDimensionResponse)
+	err := c.cc.Invoke(ctx, StripeService_GetUsageByDimension_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *stripeServiceClient) PreviewPlanChange(ctx context.Context, in *PlanChangeRequest, opts ...grpc.CallOption) (*PreviewPlanChangeResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PreviewPlanChangeResponse)
+	err := c.cc.Invoke(ctx, StripeService_PreviewPlanChange_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *stripeServiceClient) ChangePlan(ctx context.Context, in *PlanChangeRequest, opts ...grpc.CallOption) (*ChangePlanResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ChangePlanResponse)
+	err := c.cc.Invoke(ctx, StripeService_ChangePlan_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *stripeServiceClient) PauseSubscription(ctx context.Context, in *PauseSubscriptionRequest, opts ...grpc.CallOption) (*SubscriptionPauseResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SubscriptionPauseResponse)
+	err := c.cc.Invoke(ctx, StripeService_PauseSubscription_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *stripeServiceClient) ResumeSubscription(ctx context.Context, in *ResumeSubscriptionRequest, opts ...grpc.CallOption) (*SubscriptionPauseResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SubscriptionPauseResponse)
+	err := c.cc.Invoke(ctx, StripeService_ResumeSubscription_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *stripeServiceClient) CreateOrganization(ctx context.Context, in *CreateOrganizationRequest, opts ...grpc.CallOption) (*CreateOrganizationResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CreateOrganizationResponse)
+	err := c.cc.Invoke(ctx, StripeService_CreateOrganization_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *stripeServiceClient) AddOrganizationMember(ctx context.Context, in *AddOrganizationMemberRequest, opts ...grpc.CallOption) (*AddOrganizationMemberResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AddOrganizationMemberResponse)
+	err := c.cc.Invoke(ctx, StripeService_AddOrganizationMember_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *stripeServiceClient) RemoveOrganizationMember(ctx context.Context, in *RemoveOrganizationMemberRequest, opts ...grpc.CallOption) (*RemoveOrganizationMemberResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RemoveOrganizationMemberResponse)
+	err := c.cc.Invoke(ctx, StripeService_RemoveOrganizationMember_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *stripeServiceClient) ListOrganizationMembers(ctx context.Context, in *ListOrganizationMembersRequest, opts ...grpc.CallOption) (*ListOrganizationMembersResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListOrganizationMembersResponse)
+	err := c.cc.Invoke(ctx, StripeService_ListOrganizationMembers_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *stripeServiceClient) AddSeat(ctx context.Context, in *AddSeatRequest, opts ...grpc.CallOption) (*SeatsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SeatsResponse)
+	err := c.cc.Invoke(ctx, StripeService_AddSeat_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *stripeServiceClient) RemoveSeat(ctx context.Context, in *RemoveSeatRequest, opts ...grpc.CallOption) (*SeatsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SeatsResponse)
+	err := c.cc.Invoke(ctx, StripeService_RemoveSeat_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *stripeServiceClient) ListSeats(ctx context.Context, in *ListSeatsRequest, opts ...grpc.CallOption) (*SeatsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SeatsResponse)
+	err := c.cc.Invoke(ctx, StripeService_ListSeats_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *stripeServiceClient) SetSpendingCap(ctx context.Context, in *SetSpendingCapRequest, opts ...grpc.CallOption) (*SetSpendingCapResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SetSpendingCapResponse)
+	err := c.cc.Invoke(ctx, StripeService_SetSpendingCap_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *stripeServiceClient) GetUsageBreakdown(ctx context.Context, in *GetUsageBreakdownRequest, opts ...grpc.CallOption) (*GetUsageBreakdownResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetUsageBreakdownResponse)
+	err := c.cc.Invoke(ctx, StripeService_GetUsageBreakdown_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *stripeServiceClient) CreateCreditPackCheckout(ctx context.Context, in *CreateCreditPackCheckoutRequest, opts ...grpc.CallOption) (*CreateCreditPackCheckoutResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CreateCreditPackCheckoutResponse)
+	err := c.cc.Invoke(ctx, StripeService_CreateCreditPackCheckout_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *stripeServiceClient) GrantCredits(ctx context.Context, in *GrantCreditsRequest, opts ...grpc.CallOption) (*GrantCreditsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GrantCreditsResponse)
+	err := c.cc.Invoke(ctx, StripeService_GrantCredits_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *stripeServiceClient) RevokeCredits(ctx context.Context, in *RevokeCreditsRequest, opts ...grpc.CallOption) (*RevokeCreditsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RevokeCreditsResponse)
+	err := c.cc.Invoke(ctx, StripeService_RevokeCredits_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *stripeServiceClient) RedeemCode(ctx context.Context, in *RedeemCodeRequest, opts ...grpc.CallOption) (*RedeemCodeResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RedeemCodeResponse)
+	err := c.cc.Invoke(ctx, StripeService_RedeemCode_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *stripeServiceClient) GetReferralCode(ctx context.Context, in *GetReferralCodeRequest, opts ...grpc.CallOption) (*GetReferralCodeResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetReferralCodeResponse)
+	err := c.cc.Invoke(ctx, StripeService_GetReferralCode_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *stripeServiceClient) CreateCampaign(ctx context.Context, in *CreateCampaignRequest, opts ...grpc.CallOption) (*CreateCampaignResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CreateCampaignResponse)
+	err := c.cc.Invoke(ctx, StripeService_CreateCampaign_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *stripeServiceClient) ListInvalidSubscriptions(ctx context.Context, in *ListInvalidSubscriptionsRequest, opts ...grpc.CallOption) (*ListInvalidSubscriptionsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListInvalidSubscriptionsResponse)
+	err := c.cc.Invoke(ctx, StripeService_ListInvalidSubscriptions_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *stripeServiceClient) ResolveInvalidSubscription(ctx context.Context, in *ResolveInvalidSubscriptionRequest, opts ...grpc.CallOption) (*ResolveInvalidSubscriptionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ResolveInvalidSubscriptionResponse)
+	err := c.cc.Invoke(ctx, StripeService_ResolveInvalidSubscription_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *stripeServiceClient) ListWebhookDeliveries(ctx context.Context, in *ListWebhookDeliveriesRequest, opts ...grpc.CallOption) (*ListWebhookDeliveriesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListWebhookDeliveriesResponse)
+	err := c.cc.Invoke(ctx, StripeService_ListWebhookDeliveries_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // StripeServiceServer is the server API for StripeService service.
 // All implementations must embed UnimplementedStripeServiceServer
 // for forward compatibility.
@@ -102,11 +489,78 @@ type StripeServiceServer interface {
 	CancelSubscription(context.Context, *CancelSubscriptionRequest) (*CancelSubscriptionResponse, error)
 	// Verifies a user's subscription validity by external user id.
 	VerifySubscriptionValidity(context.Context, *VerifySubscriptionValidityRequest) (*VerifySubscriptionValidityResponse, error)
+	// Verifies many users at once; failures are reported per user.
+	BatchVerifySubscriptionValidity(context.Context, *BatchVerifySubscriptionValidityRequest) (*BatchVerifySubscriptionValidityResponse, error)
+	// Streams a user's entitlement state: the current state first, then each change caused by
+	// webhooks, spending units or account changes, on any instance. Not mapped by grpc-gateway;
+	// HTTP clients use the Server-Sent Events route GET /api/entitlements/stream instead.
+	WatchEntitlements(*WatchEntitlementsRequest, grpc.ServerStreamingServer[EntitlementState]) error
 	// Processes a Stripe webhook event.
 	// Uses google.api.HttpBody to receive the raw payload via grpc-gateway.
 	HandleWebhook(context.Context, *httpbody.HttpBody) (*emptypb.Empty, error)
 	// Adds spending units in batch.
 	AddSpendingUnits(context.Context, *AddSpendingUnitsRequest) (*AddSpendingUnitsResponse, error)
+	// Adds an unbounded stream of spending units, written in micro-batches (gRPC only).
+	StreamSpendingUnits(grpc.ClientStreamingServer[SpendingUnit, StreamSpendingUnitsResponse]) error
+	// Refunds previously added spending units by their original external ids.
+	// Idempotent: already-refunded units are skipped.
+	RefundSpendingUnits(context.Context, *RefundSpendingUnitsRequest) (*RefundSpendingUnitsResponse, error)
+	// Returns the user's dunning state, maintained from invoice.payment_failed and invoice.paid webhooks.
+	GetBillingStatus(context.Context, *GetBillingStatusRequest) (*GetBillingStatusResponse, error)
+	// Returns the user's usage in the current billing period by feature and, optionally, by one label.
+	GetUsageByDimension(context.Context, *GetUsageByDimensionRequest) (*GetUsageByDimensionResponse, error)
+	// Previews the upcoming invoice of a plan change on the user's existing subscription.
+	PreviewPlanChange(context.Context, *PlanChangeRequest) (*PreviewPlanChangeResponse, error)
+	// Upgrades or downgrades the user's existing subscription in place.
+	ChangePlan(context.Context, *PlanChangeRequest) (*ChangePlanResponse, error)
+	// Pauses payment collection on the user's subscription, optionally until resumes_at.
+	PauseSubscription(context.Context, *PauseSubscriptionRequest) (*SubscriptionPauseResponse, error)
+	// Resumes payment collection on the user's paused subscription.
+	ResumeSubscription(context.Context, *ResumeSubscriptionRequest) (*SubscriptionPauseResponse, error)
+	// Creates an organization (team account) sharing one subscription and credit pool among its members.
+	CreateOrganization(context.Context, *CreateOrganizationRequest) (*CreateOrganizationResponse, error)
+	// Adds a member to an organization, or changes their role. The actor must be the owner or an admin.
+	AddOrganizationMember(context.Context, *AddOrganizationMemberRequest) (*AddOrganizationMemberResponse, error)
+	// Removes a member from an organization. The actor must be the owner or an admin, or the member leaving.
+	RemoveOrganizationMember(context.Context, *RemoveOrganizationMemberRequest) (*RemoveOrganizationMemberResponse, error)
+	// Lists an organization's members. The actor must be a member.
+	ListOrganizationMembers(context.Context, *ListOrganizationMembersRequest) (*ListOrganizationMembersResponse, error)
+	// Assigns a seat to a user, adding them as a member if needed, and raises the subscription quantity
+	// to match. The actor must be the owner or an admin.
+	AddSeat(context.Context, *AddSeatRequest) (*SeatsResponse, error)
+	// Takes a member's seat back and lowers the subscription quantity to match. The actor must be the
+	// owner or an admin.
+	RemoveSeat(context.Context, *RemoveSeatRequest) (*SeatsResponse, error)
+	// Lists an organization's seats. The actor must be a member.
+	ListSeats(context.Context, *ListSeatsRequest) (*SeatsResponse, error)
+	// Caps what a member or an API key can spend from the organization's pool per billing period.
+	// The actor must be the owner or an admin.
+	SetSpendingCap(context.Context, *SetSpendingCapRequest) (*SetSpendingCapResponse, error)
+	// Returns the organization's usage in the current billing period by member and by API key.
+	// The actor must be the owner or an admin.
+	GetUsageBreakdown(context.Context, *GetUsageBreakdownRequest) (*GetUsageBreakdownResponse, error)
+	// Starts a one-time payment checkout for a configured credit pack.
+	// The purchased units are credited when Stripe reports the session as paid.
+	CreateCreditPackCheckout(context.Context, *CreateCreditPackCheckoutRequest) (*CreateCreditPackCheckoutResponse, error)
+	// Admin: grants free credit to a user, optionally expiring. Requires the x-admin-token header.
+	GrantCredits(context.Context, *GrantCreditsRequest) (*GrantCreditsResponse, error)
+	// Admin: revokes free credit from a user (clamped at 0). Requires the x-admin-token header.
+	RevokeCredits(context.Context, *RevokeCreditsRequest) (*RevokeCreditsResponse, error)
+	// Redeems a promo or referral code. Promo codes credit free credit immediately; referral
+	// codes credit both users once the redeeming user completes a checkout.
+	RedeemCode(context.Context, *RedeemCodeRequest) (*RedeemCodeResponse, error)
+	// Returns the user's referral code, creating it on first use.
+	GetReferralCode(context.Context, *GetReferralCodeRequest) (*GetReferralCodeResponse, error)
+	// Admin: creates a promo code campaign. Requires the x-admin-token header.
+	CreateCampaign(context.Context, *CreateCampaignRequest) (*CreateCampaignResponse, error)
+	// Admin: lists subscriptions checked out while the user already had an active one, pending
+	// review unless include_resolved is set. Requires the x-admin-token header.
+	ListInvalidSubscriptions(context.Context, *ListInvalidSubscriptionsRequest) (*ListInvalidSubscriptionsResponse, error)
+	// Admin: resolves a queued duplicate subscription. Requires the x-admin-token header.
+	ResolveInvalidSubscription(context.Context, *ResolveInvalidSubscriptionRequest) (*ResolveInvalidSubscriptionResponse, error)
+	// Admin: lists outgoing webhook deliveries to our own application, newest first. Requires the
+	// x-admin-token header.
+	ListWebhookDeliveries(context.Context, *ListWebhookDeliveriesRequest) (*ListWebhookDeliveriesResponse, error)
 	mustEmbedUnimplementedStripeServiceServer()
 }
 
@@ -123,12 +577,96 @@ func (UnimplementedStripeServiceServer) CancelSubscription(context.Context, *Can
 func (UnimplementedStripeServiceServer) VerifySubscriptionValidity(context.Context, *VerifySubscriptionValidityRequest) (*VerifySubscriptionValidityResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method VerifySubscriptionValidity not implemented")
 }
+func (UnimplementedStripeServiceServer) BatchVerifySubscriptionValidity(context.Context, *BatchVerifySubscriptionValidityRequest) (*BatchVerifySubscriptionValidityResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchVerifySubscriptionValidity not implemented")
+}
+func (UnimplementedStripeServiceServer) WatchEntitlements(*WatchEntitlementsRequest, grpc.ServerStreamingServer[EntitlementState]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchEntitlements not implemented")
+}
 func (UnimplementedStripeServiceServer) HandleWebhook(context.Context, *httpbody.HttpBody) (*emptypb.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method HandleWebhook not implemented")
 }
 func (UnimplementedStripeServiceServer) AddSpendingUnits(context.Context, *AddSpendingUnitsRequest) (*AddSpendingUnitsResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method AddSpendingUnits not implemented")
 }
+func (UnimplementedStripeServiceServer) StreamSpendingUnits(grpc.ClientStreamingServer[SpendingUnit, StreamSpendingUnitsResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamSpendingUnits not implemented")
+}
+func (UnimplementedStripeServiceServer) RefundSpendingUnits(context.Context, *RefundSpendingUnitsRequest) (*RefundSpendingUnitsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RefundSpendingUnits not implemented")
+}
+func (UnimplementedStripeServiceServer) GetBillingStatus(context.Context, *GetBillingStatusRequest) (*GetBillingStatusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBillingStatus not implemented")
+}
+func (UnimplementedStripeServiceServer) GetUsageByDimension(context.Context, *GetUsageByDimensionRequest) (*GetUsageByDimensionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetUsageByDimension not implemented")
+}
+func (UnimplementedStripeServiceServer) PreviewPlanChange(context.Context, *PlanChangeRequest) (*PreviewPlanChangeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PreviewPlanChange not implemented")
+}
+func (UnimplementedStripeServiceServer) ChangePlan(context.Context, *PlanChangeRequest) (*ChangePlanResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ChangePlan not implemented")
+}
+func (UnimplementedStripeServiceServer) PauseSubscription(context.Context, *PauseSubscriptionRequest) (*SubscriptionPauseResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PauseSubscription not implemented")
+}
+func (UnimplementedStripeServiceServer) ResumeSubscription(context.Context, *ResumeSubscriptionRequest) (*SubscriptionPauseResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResumeSubscription not implemented")
+}
+func (UnimplementedStripeServiceServer) CreateOrganization(context.Context, *CreateOrganizationRequest) (*CreateOrganizationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateOrganization not implemented")
+}
+func (UnimplementedStripeServiceServer) AddOrganizationMember(context.Context, *AddOrganizationMemberRequest) (*AddOrganizationMemberResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddOrganizationMember not implemented")
+}
+func (UnimplementedStripeServiceServer) RemoveOrganizationMember(context.Context, *RemoveOrganizationMemberRequest) (*RemoveOrganizationMemberResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemoveOrganizationMember not implemented")
+}
+func (UnimplementedStripeServiceServer) ListOrganizationMembers(context.Context, *ListOrganizationMembersRequest) (*ListOrganizationMembersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListOrganizationMembers not implemented")
+}
+func (UnimplementedStripeServiceServer) AddSeat(context.Context, *AddSeatRequest) (*SeatsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddSeat not implemented")
+}
+func (UnimplementedStripeServiceServer) RemoveSeat(context.Context, *RemoveSeatRequest) (*SeatsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemoveSeat not implemented")
+}
+func (UnimplementedStripeServiceServer) ListSeats(context.Context, *ListSeatsRequest) (*SeatsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListSeats not implemented")
+}
+func (UnimplementedStripeServiceServer) SetSpendingCap(context.Context, *SetSpendingCapRequest) (*SetSpendingCapResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetSpendingCap not implemented")
+}
+func (UnimplementedStripeServiceServer) GetUsageBreakdown(context.Context, *GetUsageBreakdownRequest) (*GetUsageBreakdownResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetUsageBreakdown not implemented")
+}
+func (UnimplementedStripeServiceServer) CreateCreditPackCheckout(context.Context, *CreateCreditPackCheckoutRequest) (*CreateCreditPackCheckoutResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateCreditPackCheckout not implemented")
+}
+func (UnimplementedStripeServiceServer) GrantCredits(context.Context, *GrantCreditsRequest) (*GrantCreditsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GrantCredits not implemented")
+}
+func (UnimplementedStripeServiceServer) RevokeCredits(context.Context, *RevokeCreditsRequest) (*RevokeCreditsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RevokeCredits not implemented")
+}
+func (UnimplementedStripeServiceServer) RedeemCode(context.Context, *RedeemCodeRequest) (*RedeemCodeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RedeemCode not implemented")
+}
+func (UnimplementedStripeServiceServer) GetReferralCode(context.Context, *GetReferralCodeRequest) (*GetReferralCodeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetReferralCode not implemented")
+}
+func (UnimplementedStripeServiceServer) CreateCampaign(context.Context, *CreateCampaignRequest) (*CreateCampaignResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateCampaign not implemented")
+}
+func (UnimplementedStripeServiceServer) ListInvalidSubscriptions(context.Context, *ListInvalidSubscriptionsRequest) (*ListInvalidSubscriptionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListInvalidSubscriptions not implemented")
+}
+func (UnimplementedStripeServiceServer) ResolveInvalidSubscription(context.Context, *ResolveInvalidSubscriptionRequest) (*ResolveInvalidSubscriptionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResolveInvalidSubscription not implemented")
+}
+func (UnimplementedStripeServiceServer) ListWebhookDeliveries(context.Context, *ListWebhookDeliveriesRequest) (*ListWebhookDeliveriesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListWebhookDeliveries not implemented")
+}
 func (UnimplementedStripeServiceServer) mustEmbedUnimplementedStripeServiceServer() {}
 func (UnimplementedStripeServiceServer) testEmbeddedByValue()                       {}
 
@@ -186,6 +724,35 @@ func _StripeService_VerifySubscriptionValidity_Handler(srv interface{}, ctx cont
 	return interceptor(ctx, in, info, handler)
 }
 
+func _StripeService_BatchVerifySubscriptionValidity_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchVerifySubscriptionValidityRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StripeServiceServer).BatchVerifySubscriptionValidity(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StripeService_BatchVerifySubscriptionValidity_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StripeServiceServer).BatchVerifySubscriptionValidity(ctx, req.(*BatchVerifySubscriptionValidityRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StripeService_WatchEntitlements_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchEntitlementsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(StripeServiceServer).WatchEntitlements(m, &grpc.GenericServerStream[WatchEntitlementsRequest, EntitlementState]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type StripeService_WatchEntitlementsServer = grpc.ServerStreamingServer[EntitlementState]
+
 func _StripeService_HandleWebhook_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(httpbody.HttpBody)
 	if err := dec(in); err != nil {
@@ -222,21 +789,482 @@ func _StripeService_AddSpendingUnits_Handler(srv interface{}, ctx context.Contex
 	return interceptor(ctx, in, info, handler)
 }
 
-// StripeService_ServiceDesc is the grpc.ServiceDesc for StripeService service.
-// It's only intended for direct use with grpc.RegisterService,
-// and not to be introspected or modified (even as a copy)
-var StripeService_ServiceDesc = grpc.ServiceDesc{
-	ServiceName: "stripe.v1.StripeService",
-	HandlerType: (*StripeServiceServer)(nil),
-	Methods: []grpc.MethodDesc{
-		{
-			MethodName: "CancelSubscription",
-			Handler:    _StripeService_CancelSubscription_Handler,
-		},
+func _StripeService_StreamSpendingUnits_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(StripeServiceServer).StreamSpendingUnits(&grpc.GenericServerStream[SpendingUnit, StreamSpendingUnitsResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type StripeService_StreamSpendingUnitsServer = grpc.ClientStreamingServer[SpendingUnit, StreamSpendingUnitsResponse]
+
+func _StripeService_RefundSpendingUnits_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RefundSpendingUnitsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StripeServiceServer).RefundSpendingUnits(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StripeService_RefundSpendingUnits_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StripeServiceServer).RefundSpendingUnits(ctx, req.(*RefundSpendingUnitsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StripeService_GetBillingStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetBillingStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StripeServiceServer).GetBillingStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StripeService_GetBillingStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StripeServiceServer).GetBillingStatus(ctx, req.(*GetBillingStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StripeService_GetUsageByDimension_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetUsageByDimensionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StripeServiceServer).GetUsageByDimension(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StripeService_GetUsageByDimension_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StripeServiceServer).GetUsageByDimension(ctx, req.(*GetUsageByDimensionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StripeService_PreviewPlanChange_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PlanChangeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StripeServiceServer).PreviewPlanChange(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StripeService_PreviewPlanChange_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StripeServiceServer).PreviewPlanChange(ctx, req.(*PlanChangeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StripeService_ChangePlan_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PlanChangeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StripeServiceServer).ChangePlan(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StripeService_ChangePlan_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StripeServiceServer).ChangePlan(ctx, req.(*PlanChangeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StripeService_PauseSubscription_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PauseSubscriptionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StripeServiceServer).PauseSubscription(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StripeService_PauseSubscription_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StripeServiceServer).PauseSubscription(ctx, req.(*PauseSubscriptionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StripeService_ResumeSubscription_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResumeSubscriptionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StripeServiceServer).ResumeSubscription(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StripeService_ResumeSubscription_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StripeServiceServer).ResumeSubscription(ctx, req.(*ResumeSubscriptionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StripeService_CreateOrganization_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateOrganizationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StripeServiceServer).CreateOrganization(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StripeService_CreateOrganization_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StripeServiceServer).CreateOrganization(ctx, req.(*CreateOrganizationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StripeService_AddOrganizationMember_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddOrganizationMemberRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StripeServiceServer).AddOrganizationMember(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StripeService_AddOrganizationMember_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StripeServiceServer).AddOrganizationMember(ctx, req.(*AddOrganizationMemberRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StripeService_RemoveOrganizationMember_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RemoveOrganizationMemberRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StripeServiceServer).RemoveOrganizationMember(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StripeService_RemoveOrganizationMember_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StripeServiceServer).RemoveOrganizationMember(ctx, req.(*RemoveOrganizationMemberRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StripeService_ListOrganizationMembers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListOrganizationMembersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StripeServiceServer).ListOrganizationMembers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StripeService_ListOrganizationMembers_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StripeServiceServer).ListOrganizationMembers(ctx, req.(*ListOrganizationMembersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StripeService_AddSeat_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddSeatRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StripeServiceServer).AddSeat(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StripeService_AddSeat_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StripeServiceServer).AddSeat(ctx, req.(*AddSeatRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StripeService_RemoveSeat_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RemoveSeatRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StripeServiceServer).RemoveSeat(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StripeService_RemoveSeat_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StripeServiceServer).RemoveSeat(ctx, req.(*RemoveSeatRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StripeService_ListSeats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListSeatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StripeServiceServer).ListSeats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StripeService_ListSeats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StripeServiceServer).ListSeats(ctx, req.(*ListSeatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StripeService_SetSpendingCap_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetSpendingCapRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StripeServiceServer).SetSpendingCap(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StripeService_SetSpendingCap_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StripeServiceServer).SetSpendingCap(ctx, req.(*SetSpendingCapRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StripeService_GetUsageBreakdown_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetUsageBreakdownRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StripeServiceServer).GetUsageBreakdown(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StripeService_GetUsageBreakdown_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StripeServiceServer).GetUsageBreakdown(ctx, req.(*GetUsageBreakdownRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StripeService_CreateCreditPackCheckout_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateCreditPackCheckoutRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StripeServiceServer).CreateCreditPackCheckout(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StripeService_CreateCreditPackCheckout_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StripeServiceServer).CreateCreditPackCheckout(ctx, req.(*CreateCreditPackCheckoutRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StripeService_GrantCredits_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GrantCreditsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StripeServiceServer).GrantCredits(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StripeService_GrantCredits_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StripeServiceServer).GrantCredits(ctx, req.(*GrantCreditsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StripeService_RevokeCredits_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RevokeCreditsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StripeServiceServer).RevokeCredits(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StripeService_RevokeCredits_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StripeServiceServer).RevokeCredits(ctx, req.(*RevokeCreditsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StripeService_RedeemCode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RedeemCodeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StripeServiceServer).RedeemCode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StripeService_RedeemCode_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StripeServiceServer).RedeemCode(ctx, req.(*RedeemCodeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StripeService_GetReferralCode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetReferralCodeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StripeServiceServer).GetReferralCode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StripeService_GetReferralCode_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StripeServiceServer).GetReferralCode(ctx, req.(*GetReferralCodeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StripeService_CreateCampaign_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateCampaignRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StripeServiceServer).CreateCampaign(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StripeService_CreateCampaign_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StripeServiceServer).CreateCampaign(ctx, req.(*CreateCampaignRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StripeService_ListInvalidSubscriptions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListInvalidSubscriptionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StripeServiceServer).ListInvalidSubscriptions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StripeService_ListInvalidSubscriptions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StripeServiceServer).ListInvalidSubscriptions(ctx, req.(*ListInvalidSubscriptionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StripeService_ResolveInvalidSubscription_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResolveInvalidSubscriptionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StripeServiceServer).ResolveInvalidSubscription(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StripeService_ResolveInvalidSubscription_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StripeServiceServer).ResolveInvalidSubscription(ctx, req.(*ResolveInvalidSubscriptionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StripeService_ListWebhookDeliveries_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListWebhookDeliveriesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StripeServiceServer).ListWebhookDeliveries(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StripeService_ListWebhookDeliveries_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StripeServiceServer).ListWebhookDeliveries(ctx, req.(*ListWebhookDeliveriesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// StripeService_ServiceDesc is the grpc.ServiceDesc for StripeService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var StripeService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "stripe.v1.StripeService",
+	HandlerType: (*StripeServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CancelSubscription",
+			Handler:    _StripeService_CancelSubscription_Handler,
+		},
 		{
 			MethodName: "VerifySubscriptionValidity",
 			Handler:    _StripeService_VerifySubscriptionValidity_Handler,
 		},
+		{
+			MethodName: "BatchVerifySubscriptionValidity",
+			Handler:    _StripeService_BatchVerifySubscriptionValidity_Handler,
+		},
 		{
 			MethodName: "HandleWebhook",
 			Handler:    _StripeService_HandleWebhook_Handler,
@@ -245,7 +1273,118 @@ var StripeService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "AddSpendingUnits",
 			Handler:    _StripeService_AddSpendingUnits_Handler,
 		},
+		{
+			MethodName: "RefundSpendingUnits",
+			Handler:    _StripeService_RefundSpendingUnits_Handler,
+		},
+		{
+			MethodName: "GetBillingStatus",
+			Handler:    _StripeService_GetBillingStatus_Handler,
+		},
+		{
+			MethodName: "GetUsageByDimension",
+			Handler:    _StripeService_GetUsageByDimension_Handler,
+		},
+		{
+			MethodName: "PreviewPlanChange",
+			Handler:    _StripeService_PreviewPlanChange_Handler,
+		},
+		{
+			MethodName: "ChangePlan",
+			Handler:    _StripeService_ChangePlan_Handler,
+		},
+		{
+			MethodName: "PauseSubscription",
+			Handler:    _StripeService_PauseSubscription_Handler,
+		},
+		{
+			MethodName: "ResumeSubscription",
+			Handler:    _StripeService_ResumeSubscription_Handler,
+		},
+		{
+			MethodName: "CreateOrganization",
+			Handler:    _StripeService_CreateOrganization_Handler,
+		},
+		{
+			MethodName: "AddOrganizationMember",
+			Handler:    _StripeService_AddOrganizationMember_Handler,
+		},
+		{
+			MethodName: "RemoveOrganizationMember",
+			Handler:    _StripeService_RemoveOrganizationMember_Handler,
+		},
+		{
+			MethodName: "ListOrganizationMembers",
+			Handler:    _StripeService_ListOrganizationMembers_Handler,
+		},
+		{
+			MethodName: "AddSeat",
+			Handler:    _StripeService_AddSeat_Handler,
+		},
+		{
+			MethodName: "RemoveSeat",
+			Handler:    _StripeService_RemoveSeat_Handler,
+		},
+		{
+			MethodName: "ListSeats",
+			Handler:    _StripeService_ListSeats_Handler,
+		},
+		{
+			MethodName: "SetSpendingCap",
+			Handler:    _StripeService_SetSpendingCap_Handler,
+		},
+		{
+			MethodName: "GetUsageBreakdown",
+			Handler:    _StripeService_GetUsageBreakdown_Handler,
+		},
+		{
+			MethodName: "CreateCreditPackCheckout",
+			Handler:    _StripeService_CreateCreditPackCheckout_Handler,
+		},
+		{
+			MethodName: "GrantCredits",
+			Handler:    _StripeService_GrantCredits_Handler,
+		},
+		{
+			MethodName: "RevokeCredits",
+			Handler:    _StripeService_RevokeCredits_Handler,
+		},
+		{
+			MethodName: "RedeemCode",
+			Handler:    _StripeService_RedeemCode_Handler,
+		},
+		{
+			MethodName: "GetReferralCode",
+			Handler:    _StripeService_GetReferralCode_Handler,
+		},
+		{
+			MethodName: "CreateCampaign",
+			Handler:    _StripeService_CreateCampaign_Handler,
+		},
+		{
+			MethodName: "ListInvalidSubscriptions",
+			Handler:    _StripeService_ListInvalidSubscriptions_Handler,
+		},
+		{
+			MethodName: "ResolveInvalidSubscription",
+			Handler:    _StripeService_ResolveInvalidSubscription_Handler,
+		},
+		{
+			MethodName: "ListWebhookDeliveries",
+			Handler:    _StripeService_ListWebhookDeliveries_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchEntitlements",
+			Handler:       _StripeService_WatchEntitlements_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "StreamSpendingUnits",
+			Handler:       _StripeService_StreamSpendingUnits_Handler,
+			ClientStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "stripe/v1/stripe_service.proto",
 }
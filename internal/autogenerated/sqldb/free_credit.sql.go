@@ -7,40 +7,204 @@ package sqldb
 
 import (
 	"context"
+	"database/sql"
 )
 
-const consumeFreeCredit = `-- name: ConsumeFreeCredit :exec
+const adjustFreeCredit = `-- name: AdjustFreeCredit :one
+WITH prev AS (
+  SELECT credit
+  FROM free_credit
+  WHERE user_external_id = $2
+  FOR UPDATE
+)
 UPDATE free_credit
-SET credit = credit - LEAST(credit, $2)
-WHERE user_external_id = $1
+SET credit = GREATEST(prev.credit + $1::int, 0)
+FROM prev
+WHERE free_credit.user_external_id = $2
+RETURNING free_credit.credit AS credit, (free_credit.credit - prev.credit)::int AS applied
+`
+
+type AdjustFreeCreditParams struct {
+	Delta          int32  `json:"delta"`
+	UserExternalID string `json:"user_external_id"`
+}
+
+type AdjustFreeCreditRow struct {
+	Credit  int32 `json:"credit"`
+	Applied int32 `json:"applied"`
+}
+
+// Adds delta (possibly negative) to the balance, clamped at 0, and returns the new balance
+// along with the change actually applied.
+func (q *Queries) AdjustFreeCredit(ctx context.Context, arg AdjustFreeCreditParams) (AdjustFreeCreditRow, error) {
+	row := q.db.QueryRowContext(ctx, adjustFreeCredit, arg.Delta, arg.UserExternalID)
+	var i AdjustFreeCreditRow
+	err := row.Scan(&i.Credit, &i.Applied)
+	return i, err
+}
+
+const consumeFreeCredit = `-- name: ConsumeFreeCredit :one
+WITH prev AS (
+  SELECT credit
+  FROM free_credit
+  WHERE user_external_id = $2
+  FOR UPDATE
+)
+UPDATE free_credit
+SET credit = free_credit.credit - LEAST(prev.credit, $1::int)
+FROM prev
+WHERE free_credit.user_external_id = $2
+RETURNING LEAST(prev.credit, $1::int)::int AS consumed
 `
 
 type ConsumeFreeCreditParams struct {
+	Amount         int32  `json:"amount"`
+	UserExternalID string `json:"user_external_id"`
+}
+
+// Returns how much credit was actually consumed (clamped at the remaining balance).
+func (q *Queries) ConsumeFreeCredit(ctx context.Context, arg ConsumeFreeCreditParams) (int32, error) {
+	row := q.db.QueryRowContext(ctx, consumeFreeCredit, arg.Amount, arg.UserExternalID)
+	var consumed int32
+	err := row.Scan(&consumed)
+	return consumed, err
+}
+
+const lockFreeCredit = `-- name: LockFreeCredit :one
+SELECT credit
+FROM free_credit
+WHERE user_external_id = $1
+FOR UPDATE
+`
+
+// Serializes credit changes for a user within a transaction.
+func (q *Queries) LockFreeCredit(ctx context.Context, userExternalID string) (int32, error) {
+	row := q.db.QueryRowContext(ctx, lockFreeCredit, userExternalID)
+	var credit int32
+	err := row.Scan(&credit)
+	return credit, err
+}
+
+const refreshFreeCredits = `-- name: RefreshFreeCredits :execrows
+UPDATE free_credit
+SET credit = CASE
+      WHEN $1::int > 0 AND COALESCE(refilled_at, 0) < $2::bigint
+        AND (expires_at IS NULL OR expires_at > $3::bigint)
+        THEN GREATEST(credit, $1::int + LEAST(credit, (
+          SELECT COALESCE(SUM(g.remaining), 0)::int FROM credit_grant g
+          WHERE g.user_external_id = free_credit.user_external_id AND g.remaining > 0 AND g.expired_at IS NULL
+        )))
+      WHEN $1::int > 0 AND COALESCE(refilled_at, 0) < $2::bigint
+        THEN $1::int + LEAST(credit, (
+          SELECT COALESCE(SUM(g.remaining), 0)::int FROM credit_grant g
+          WHERE g.user_external_id = free_credit.user_external_id AND g.remaining > 0 AND g.expired_at IS NULL
+        ))
+      ELSE LEAST(credit, (
+          SELECT COALESCE(SUM(g.remaining), 0)::int FROM credit_grant g
+          WHERE g.user_external_id = free_credit.user_external_id AND g.remaining > 0 AND g.expired_at IS NULL
+        ))
+    END,
+    expires_at = NULL,
+    refilled_at = CASE
+      WHEN $1::int > 0 AND COALESCE(refilled_at, 0) < $2::bigint THEN $2::bigint
+      ELSE refilled_at
+    END
+WHERE ($1::int > 0 AND COALESCE(refilled_at, 0) < $2::bigint)
+   OR expires_at <= $3::bigint
+`
+
+type RefreshFreeCreditsParams struct {
+	Refill      int32 `json:"refill"`
+	PeriodStart int64 `json:"period_start"`
+	Now         int64 `json:"now"`
+}
+
+// Same policy as UpsertAndGetFreeCredit, applied to every row that is due.
+func (q *Queries) RefreshFreeCredits(ctx context.Context, arg RefreshFreeCreditsParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, refreshFreeCredits, arg.Refill, arg.PeriodStart, arg.Now)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const restoreFreeCredit = `-- name: RestoreFreeCredit :exec
+UPDATE free_credit
+SET credit = credit + $2
+WHERE user_external_id = $1
+`
+
+type RestoreFreeCreditParams struct {
 	UserExternalID string `json:"user_external_id"`
 	Credit         int32  `json:"credit"`
 }
 
-func (q *Queries) ConsumeFreeCredit(ctx context.Context, arg ConsumeFreeCreditParams) error {
-	_, err := q.db.ExecContext(ctx, consumeFreeCredit, arg.UserExternalID, arg.Credit)
+func (q *Queries) RestoreFreeCredit(ctx context.Context, arg RestoreFreeCreditParams) error {
+	_, err := q.db.ExecContext(ctx, restoreFreeCredit, arg.UserExternalID, arg.Credit)
 	return err
 }
 
 const upsertAndGetFreeCredit = `-- name: UpsertAndGetFreeCredit :one
 INSERT INTO free_credit (
   user_external_id,
-  credit
-) VALUES ($1, $2)
-ON CONFLICT (user_external_id) DO UPDATE SET user_external_id = EXCLUDED.user_external_id
+  credit,
+  expires_at,
+  refilled_at
+) VALUES ($1, $2, $3, $4::bigint)
+ON CONFLICT (user_external_id) DO UPDATE SET
+  credit = CASE
+    WHEN $5::int > 0 AND COALESCE(free_credit.refilled_at, 0) < $4::bigint
+      AND (free_credit.expires_at IS NULL OR free_credit.expires_at > $6::bigint)
+      THEN GREATEST(free_credit.credit, $5::int + LEAST(free_credit.credit, (
+        SELECT COALESCE(SUM(g.remaining), 0)::int FROM credit_grant g
+        WHERE g.user_external_id = free_credit.user_external_id AND g.remaining > 0 AND g.expired_at IS NULL
+      )))
+    WHEN $5::int > 0 AND COALESCE(free_credit.refilled_at, 0) < $4::bigint
+      THEN $5::int + LEAST(free_credit.credit, (
+        SELECT COALESCE(SUM(g.remaining), 0)::int FROM credit_grant g
+        WHERE g.user_external_id = free_credit.user_external_id AND g.remaining > 0 AND g.expired_at IS NULL
+      ))
+    WHEN free_credit.expires_at <= $6::bigint
+      THEN LEAST(free_credit.credit, (
+        SELECT COALESCE(SUM(g.remaining), 0)::int FROM credit_grant g
+        WHERE g.user_external_id = free_credit.user_external_id AND g.remaining > 0 AND g.expired_at IS NULL
+      ))
+    ELSE free_credit.credit
+  END,
+  expires_at = CASE
+    WHEN $5::int > 0 AND COALESCE(free_credit.refilled_at, 0) < $4::bigint THEN NULL
+    WHEN free_credit.expires_at <= $6::bigint THEN NULL
+    ELSE free_credit.expires_at
+  END,
+  refilled_at = CASE
+    WHEN $5::int > 0 AND COALESCE(free_credit.refilled_at, 0) < $4::bigint THEN $4::bigint
+    ELSE free_credit.refilled_at
+  END
 RETURNING credit
 `
 
 type UpsertAndGetFreeCreditParams struct {
-	UserExternalID string `json:"user_external_id"`
-	Credit         int32  `json:"credit"`
+	UserExternalID string        `json:"user_external_id"`
+	Credit         int32         `json:"credit"`
+	ExpiresAt      sql.NullInt64 `json:"expires_at"`
+	PeriodStart    int64         `json:"period_start"`
+	Refill         int32         `json:"refill"`
+	Now            int64         `json:"now"`
 }
 
+// Creates the row with the initial grant, or brings an existing row up to date first:
+// a pending monthly refill tops the balance up to the refill amount (unexpired credit above it
+// is kept), otherwise expired credit drops to 0.
+// Credit still covered by what remains of active admin grants (credit_grant) survives both.
 func (q *Queries) UpsertAndGetFreeCredit(ctx context.Context, arg UpsertAndGetFreeCreditParams) (int32, error) {
-	row := q.db.QueryRowContext(ctx, upsertAndGetFreeCredit, arg.UserExternalID, arg.Credit)
+	row := q.db.QueryRowContext(ctx, upsertAndGetFreeCredit,
+		arg.UserExternalID,
+		arg.Credit,
+		arg.ExpiresAt,
+		arg.PeriodStart,
+		arg.Refill,
+		arg.Now,
+	)
 	var credit int32
 	err := row.Scan(&credit)
 	return credit, err
@@ -10,13 +10,89 @@ import (
 	"database/sql"
 )
 
-const insertInvalidSubscription = `-- name: InsertInvalidSubscription :exec
+const getInvalidSubscription = `-- name: GetInvalidSubscription :one
+SELECT id, user_external_id, stripe_subscription_id, stripe_plan_id, stripe_customer_id, resolution, resolved_by, resolved_at, created_at
+FROM invalid_subscription
+WHERE id = $1
+`
+
+type GetInvalidSubscriptionRow struct {
+	ID                   int64          `json:"id"`
+	UserExternalID       string         `json:"user_external_id"`
+	StripeSubscriptionID sql.NullString `json:"stripe_subscription_id"`
+	StripePlanID         sql.NullString `json:"stripe_plan_id"`
+	StripeCustomerID     sql.NullString `json:"stripe_customer_id"`
+	Resolution           sql.NullString `json:"resolution"`
+	ResolvedBy           sql.NullString `json:"resolved_by"`
+	ResolvedAt           sql.NullInt64  `json:"resolved_at"`
+	CreatedAt            int64          `json:"created_at"`
+}
+
+func (q *Queries) GetInvalidSubscription(ctx context.Context, id int64) (GetInvalidSubscriptionRow, error) {
+	row := q.db.QueryRowContext(ctx, getInvalidSubscription, id)
+	var i GetInvalidSubscriptionRow
+	err := row.Scan(
+		&i.ID,
+		&i.UserExternalID,
+		&i.StripeSubscriptionID,
+		&i.StripePlanID,
+		&i.StripeCustomerID,
+		&i.Resolution,
+		&i.ResolvedBy,
+		&i.ResolvedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getInvalidSubscriptionBySubscriptionID = `-- name: GetInvalidSubscriptionBySubscriptionID :one
+SELECT id, user_external_id, stripe_subscription_id, stripe_plan_id, stripe_customer_id, resolution, resolved_by, resolved_at, created_at
+FROM invalid_subscription
+WHERE stripe_subscription_id = $1
+ORDER BY id
+LIMIT 1
+`
+
+type GetInvalidSubscriptionBySubscriptionIDRow struct {
+	ID                   int64          `json:"id"`
+	UserExternalID       string         `json:"user_external_id"`
+	StripeSubscriptionID sql.NullString `json:"stripe_subscription_id"`
+	StripePlanID         sql.NullString `json:"stripe_plan_id"`
+	StripeCustomerID     sql.NullString `json:"stripe_customer_id"`
+	Resolution           sql.NullString `json:"resolution"`
+	ResolvedBy           sql.NullString `json:"resolved_by"`
+	ResolvedAt           sql.NullInt64  `json:"resolved_at"`
+	CreatedAt            int64          `json:"created_at"`
+}
+
+func (q *Queries) GetInvalidSubscriptionBySubscriptionID(ctx context.Context, stripeSubscriptionID sql.NullString) (GetInvalidSubscriptionBySubscriptionIDRow, error) {
+	row := q.db.QueryRowContext(ctx, getInvalidSubscriptionBySubscriptionID, stripeSubscriptionID)
+	var i GetInvalidSubscriptionBySubscriptionIDRow
+	err := row.Scan(
+		&i.ID,
+		&i.UserExternalID,
+		&i.StripeSubscriptionID,
+		&i.StripePlanID,
+		&i.StripeCustomerID,
+		&i.Resolution,
+		&i.ResolvedBy,
+		&i.ResolvedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const insertInvalidSubscription = `-- name: InsertInvalidSubscription :one
 INSERT INTO invalid_subscription (
   user_external_id,
   stripe_subscription_id,
   stripe_plan_id,
-  stripe_customer_id
-) VALUES ($1, $2, $3, $4)
+  stripe_customer_id,
+  resolution,
+  resolved_by,
+  resolved_at
+) VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING id
 `
 
 type InsertInvalidSubscriptionParams struct {
@@ -24,14 +100,115 @@ type InsertInvalidSubscriptionParams struct {
 	StripeSubscriptionID sql.NullString `json:"stripe_subscription_id"`
 	StripePlanID         sql.NullString `json:"stripe_plan_id"`
 	StripeCustomerID     sql.NullString `json:"stripe_customer_id"`
+	Resolution           sql.NullString `json:"resolution"`
+	ResolvedBy           sql.NullString `json:"resolved_by"`
+	ResolvedAt           sql.NullInt64  `json:"resolved_at"`
 }
 
-func (q *Queries) InsertInvalidSubscription(ctx context.Context, arg InsertInvalidSubscriptionParams) error {
-	_, err := q.db.ExecContext(ctx, insertInvalidSubscription,
+// resolution is NULL for duplicates queued for manual review.
+func (q *Queries) InsertInvalidSubscription(ctx context.Context, arg InsertInvalidSubscriptionParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, insertInvalidSubscription,
 		arg.UserExternalID,
 		arg.StripeSubscriptionID,
 		arg.StripePlanID,
 		arg.StripeCustomerID,
+		arg.Resolution,
+		arg.ResolvedBy,
+		arg.ResolvedAt,
+	)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
+const listInvalidSubscriptions = `-- name: ListInvalidSubscriptions :many
+SELECT id, user_external_id, stripe_subscription_id, stripe_plan_id, stripe_customer_id, resolution, resolved_by, resolved_at, created_at
+FROM invalid_subscription
+WHERE id > $1::bigint
+  AND ($2::boolean OR resolution IS NULL)
+ORDER BY id
+LIMIT $3::int
+`
+
+type ListInvalidSubscriptionsParams struct {
+	AfterID         int64 `json:"after_id"`
+	IncludeResolved bool  `json:"include_resolved"`
+	RowLimit        int32 `json:"row_limit"`
+}
+
+type ListInvalidSubscriptionsRow struct {
+	ID                   int64          `json:"id"`
+	UserExternalID       string         `json:"user_external_id"`
+	StripeSubscriptionID sql.NullString `json:"stripe_subscription_id"`
+	StripePlanID         sql.NullString `json:"stripe_plan_id"`
+	StripeCustomerID     sql.NullString `json:"stripe_customer_id"`
+	Resolution           sql.NullString `json:"resolution"`
+	ResolvedBy           sql.NullString `json:"resolved_by"`
+	ResolvedAt           sql.NullInt64  `json:"resolved_at"`
+	CreatedAt            int64          `json:"created_at"`
+}
+
+// Keyset-paginated by id; resolved entries are only returned when include_resolved is set.
+func (q *Queries) ListInvalidSubscriptions(ctx context.Context, arg ListInvalidSubscriptionsParams) ([]ListInvalidSubscriptionsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listInvalidSubscriptions, arg.AfterID, arg.IncludeResolved, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListInvalidSubscriptionsRow
+	for rows.Next() {
+		var i ListInvalidSubscriptionsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserExternalID,
+			&i.StripeSubscriptionID,
+			&i.StripePlanID,
+			&i.StripeCustomerID,
+			&i.Resolution,
+			&i.ResolvedBy,
+			&i.ResolvedAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const resolveInvalidSubscription = `-- name: ResolveInvalidSubscription :execrows
+UPDATE invalid_subscription
+SET
+  resolution = $2,
+  resolved_by = $3,
+  resolved_at = $4
+WHERE id = $1
+  AND resolution IS NULL
+`
+
+type ResolveInvalidSubscriptionParams struct {
+	ID         int64          `json:"id"`
+	Resolution sql.NullString `json:"resolution"`
+	ResolvedBy sql.NullString `json:"resolved_by"`
+	ResolvedAt sql.NullInt64  `json:"resolved_at"`
+}
+
+// Only entries still queued for review can be resolved.
+func (q *Queries) ResolveInvalidSubscription(ctx context.Context, arg ResolveInvalidSubscriptionParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, resolveInvalidSubscription,
+		arg.ID,
+		arg.Resolution,
+		arg.ResolvedBy,
+		arg.ResolvedAt,
 	)
-	return err
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
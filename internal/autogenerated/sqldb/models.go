@@ -6,14 +6,92 @@ package sqldb
 
 import (
 	"database/sql"
+	"encoding/json"
 )
 
+type AllowanceAlert struct {
+	ID               int64         `json:"id"`
+	UserExternalID   string        `json:"user_external_id"`
+	PeriodStart      int64         `json:"period_start"`
+	ThresholdPercent int32         `json:"threshold_percent"`
+	Units            int64         `json:"units"`
+	Allowance        int64         `json:"allowance"`
+	NotifiedAt       sql.NullInt64 `json:"notified_at"`
+	CreatedAt        int64         `json:"created_at"`
+	UpdatedAt        int64         `json:"updated_at"`
+}
+
+type BillingStatus struct {
+	ID                 int64          `json:"id"`
+	UserExternalID     string         `json:"user_external_id"`
+	InDunning          bool           `json:"in_dunning"`
+	StripeInvoiceID    sql.NullString `json:"stripe_invoice_id"`
+	AttemptCount       int32          `json:"attempt_count"`
+	NextPaymentAttempt sql.NullInt64  `json:"next_payment_attempt"`
+	LastEventAt        int64          `json:"last_event_at"`
+	CreatedAt          int64          `json:"created_at"`
+	UpdatedAt          int64          `json:"updated_at"`
+}
+
+type Campaign struct {
+	ID                     int64          `json:"id"`
+	Code                   string         `json:"code"`
+	Units                  int32          `json:"units"`
+	ReferrerUserExternalID sql.NullString `json:"referrer_user_external_id"`
+	ReferrerUnits          int32          `json:"referrer_units"`
+	MaxRedemptions         sql.NullInt32  `json:"max_redemptions"`
+	PerUserLimit           int32          `json:"per_user_limit"`
+	Redemptions            int32          `json:"redemptions"`
+	StartsAt               sql.NullInt64  `json:"starts_at"`
+	EndsAt                 sql.NullInt64  `json:"ends_at"`
+	CreatedAt              int64          `json:"created_at"`
+	UpdatedAt              int64          `json:"updated_at"`
+}
+
+type CampaignRedemption struct {
+	ID             int64         `json:"id"`
+	CampaignID     int64         `json:"campaign_id"`
+	UserExternalID string        `json:"user_external_id"`
+	IdempotencyKey string        `json:"idempotency_key"`
+	Units          int32         `json:"units"`
+	RewardedAt     sql.NullInt64 `json:"rewarded_at"`
+	CreatedAt      int64         `json:"created_at"`
+	UpdatedAt      int64         `json:"updated_at"`
+}
+
+type CreditGrant struct {
+	ID             int64         `json:"id"`
+	UserExternalID string        `json:"user_external_id"`
+	Amount         int32         `json:"amount"`
+	Remaining      int32         `json:"remaining"`
+	Reason         string        `json:"reason"`
+	Actor          string        `json:"actor"`
+	ExpiresAt      sql.NullInt64 `json:"expires_at"`
+	ExpiredAt      sql.NullInt64 `json:"expired_at"`
+	CreatedAt      int64         `json:"created_at"`
+	UpdatedAt      int64         `json:"updated_at"`
+}
+
+type CreditPurchase struct {
+	ID                      int64  `json:"id"`
+	StripeCheckoutSessionID string `json:"stripe_checkout_session_id"`
+	UserExternalID          string `json:"user_external_id"`
+	PackID                  string `json:"pack_id"`
+	Units                   int64  `json:"units"`
+	Amount                  int64  `json:"amount"`
+	Currency                string `json:"currency"`
+	CreatedAt               int64  `json:"created_at"`
+	UpdatedAt               int64  `json:"updated_at"`
+}
+
 type FreeCredit struct {
-	ID             int64  `json:"id"`
-	UserExternalID string `json:"user_external_id"`
-	Credit         int32  `json:"credit"`
-	CreatedAt      int64  `json:"created_at"`
-	UpdatedAt      int64  `json:"updated_at"`
+	ID             int64         `json:"id"`
+	UserExternalID string        `json:"user_external_id"`
+	Credit         int32         `json:"credit"`
+	ExpiresAt      sql.NullInt64 `json:"expires_at"`
+	RefilledAt     sql.NullInt64 `json:"refilled_at"`
+	CreatedAt      int64         `json:"created_at"`
+	UpdatedAt      int64         `json:"updated_at"`
 }
 
 type InvalidSubscription struct {
@@ -22,19 +100,110 @@ type InvalidSubscription struct {
 	StripeSubscriptionID sql.NullString `json:"stripe_subscription_id"`
 	StripePlanID         sql.NullString `json:"stripe_plan_id"`
 	StripeCustomerID     sql.NullString `json:"stripe_customer_id"`
+	Resolution           sql.NullString `json:"resolution"`
+	ResolvedBy           sql.NullString `json:"resolved_by"`
+	ResolvedAt           sql.NullInt64  `json:"resolved_at"`
 	CreatedAt            int64          `json:"created_at"`
 	UpdatedAt            int64          `json:"updated_at"`
 }
 
-type SpendingUnit struct {
+type Organization struct {
 	ID             int64  `json:"id"`
 	ExternalID     string `json:"external_id"`
 	UserExternalID string `json:"user_external_id"`
-	Amount         int32  `json:"amount"`
 	CreatedAt      int64  `json:"created_at"`
 	UpdatedAt      int64  `json:"updated_at"`
 }
 
+type OrganizationMember struct {
+	ID             int64         `json:"id"`
+	OrganizationID int64         `json:"organization_id"`
+	UserExternalID string        `json:"user_external_id"`
+	Role           string        `json:"role"`
+	SeatAssignedAt sql.NullInt64 `json:"seat_assigned_at"`
+	CreatedAt      int64         `json:"created_at"`
+	UpdatedAt      int64         `json:"updated_at"`
+}
+
+type OveragePeriod struct {
+	ID                   int64          `json:"id"`
+	UserExternalID       string         `json:"user_external_id"`
+	StripeSubscriptionID string         `json:"stripe_subscription_id"`
+	StripeCustomerID     string         `json:"stripe_customer_id"`
+	PeriodStart          int64          `json:"period_start"`
+	PeriodEnd            int64          `json:"period_end"`
+	Allowance            int64          `json:"allowance"`
+	OverageUnitAmount    string         `json:"overage_unit_amount"`
+	Currency             string         `json:"currency"`
+	OverageUnits         int64          `json:"overage_units"`
+	StripeInvoiceItemID  sql.NullString `json:"stripe_invoice_item_id"`
+	InvoicedAt           sql.NullInt64  `json:"invoiced_at"`
+	CreatedAt            int64          `json:"created_at"`
+	UpdatedAt            int64          `json:"updated_at"`
+}
+
+type PlanAllowance struct {
+	ID                  int64          `json:"id"`
+	StripePlanID        string         `json:"stripe_plan_id"`
+	UnitsPerPeriod      sql.NullInt64  `json:"units_per_period"`
+	OverageUnitAmount   sql.NullString `json:"overage_unit_amount"`
+	TrialUnitsPerPeriod sql.NullInt64  `json:"trial_units_per_period"`
+	FeatureLimits       sql.NullString `json:"feature_limits"`
+	FetchedAt           int64          `json:"fetched_at"`
+	CreatedAt           int64          `json:"created_at"`
+	UpdatedAt           int64          `json:"updated_at"`
+}
+
+type PurchasedCredit struct {
+	ID             int64  `json:"id"`
+	UserExternalID string `json:"user_external_id"`
+	Credit         int64  `json:"credit"`
+	CreatedAt      int64  `json:"created_at"`
+	UpdatedAt      int64  `json:"updated_at"`
+}
+
+type SpendingCap struct {
+	ID             int64  `json:"id"`
+	OrganizationID int64  `json:"organization_id"`
+	SubjectType    string `json:"subject_type"`
+	SubjectID      string `json:"subject_id"`
+	CapUnits       int64  `json:"cap_units"`
+	UpdatedBy      string `json:"updated_by"`
+	CreatedAt      int64  `json:"created_at"`
+	UpdatedAt      int64  `json:"updated_at"`
+}
+
+type SpendingUnit struct {
+	ID                      int64           `json:"id"`
+	ExternalID              string          `json:"external_id"`
+	UserExternalID          string          `json:"user_external_id"`
+	Amount                  int32           `json:"amount"`
+	FreeCreditConsumed      int32           `json:"free_credit_consumed"`
+	PurchasedCreditConsumed int32           `json:"purchased_credit_consumed"`
+	RefundOfExternalID      sql.NullString  `json:"refund_of_external_id"`
+	MemberExternalID        sql.NullString  `json:"member_external_id"`
+	ApiKeyID                sql.NullString  `json:"api_key_id"`
+	FeatureKey              sql.NullString  `json:"feature_key"`
+	Labels                  json.RawMessage `json:"labels"`
+	UsageBatch              sql.NullString  `json:"usage_batch"`
+	CreatedAt               int64           `json:"created_at"`
+	UpdatedAt               int64           `json:"updated_at"`
+}
+
+type UsageReport struct {
+	ID                 int64          `json:"id"`
+	SubscriptionItemID string         `json:"subscription_item_id"`
+	UserExternalID     string         `json:"user_external_id"`
+	ReportedUnits      int64          `json:"reported_units"`
+	BatchSeq           int64          `json:"batch_seq"`
+	CarriedUnits       int64          `json:"carried_units"`
+	PendingBatch       sql.NullString `json:"pending_batch"`
+	PendingUnits       sql.NullInt64  `json:"pending_units"`
+	PendingTimestamp   sql.NullInt64  `json:"pending_timestamp"`
+	CreatedAt          int64          `json:"created_at"`
+	UpdatedAt          int64          `json:"updated_at"`
+}
+
 type UserAccount struct {
 	ID                   int64          `json:"id"`
 	UserExternalID       string         `json:"user_external_id"`
@@ -44,3 +213,19 @@ type UserAccount struct {
 	CreatedAt            int64          `json:"created_at"`
 	UpdatedAt            int64          `json:"updated_at"`
 }
+
+type WebhookDelivery struct {
+	ID             int64          `json:"id"`
+	EventID        string         `json:"event_id"`
+	EventType      string         `json:"event_type"`
+	EndpointUrl    string         `json:"endpoint_url"`
+	Payload        string         `json:"payload"`
+	Status         string         `json:"status"`
+	Attempts       int32          `json:"attempts"`
+	NextAttemptAt  int64          `json:"next_attempt_at"`
+	LastStatusCode sql.NullInt32  `json:"last_status_code"`
+	LastError      sql.NullString `json:"last_error"`
+	DeliveredAt    sql.NullInt64  `json:"delivered_at"`
+	CreatedAt      int64          `json:"created_at"`
+	UpdatedAt      int64          `json:"updated_at"`
+}
@@ -10,13 +10,132 @@ import (
 )
 
 type Querier interface {
-	ConsumeFreeCredit(ctx context.Context, arg ConsumeFreeCreditParams) error
-	CountUnitsBetween(ctx context.Context, arg CountUnitsBetweenParams) (interface{}, error)
+	AddPurchasedCredit(ctx context.Context, arg AddPurchasedCreditParams) error
+	// Adds delta (possibly negative) to the balance, clamped at 0, and returns the new balance
+	// along with the change actually applied.
+	AdjustFreeCredit(ctx context.Context, arg AdjustFreeCreditParams) (AdjustFreeCreditRow, error)
+	AssignSeat(ctx context.Context, arg AssignSeatParams) (int64, error)
+	// Claims an alert; a threshold already claimed for the period is left alone.
+	ClaimAllowanceAlert(ctx context.Context, arg ClaimAllowanceAlertParams) (int64, error)
+	// Leases pending deliveries due at now until lease_until, so concurrent workers skip them.
+	ClaimDueWebhookDeliveries(ctx context.Context, arg ClaimDueWebhookDeliveriesParams) ([]ClaimDueWebhookDeliveriesRow, error)
+	// Tags the account's unreported units recorded since `since` (unix ms) with the batch and sums
+	// what they bill. Rows of transactions still in flight are not visible and join a later batch.
+	ClaimUsageBatch(ctx context.Context, arg ClaimUsageBatchParams) (ClaimUsageBatchRow, error)
+	// Only clears for the invoice that put the account in dunning when stripe_invoice_id is given.
+	ClearBillingDunning(ctx context.Context, arg ClearBillingDunningParams) (int64, error)
+	// A batch netting to zero or less is not sent; its deficit is carried into the next batch.
+	CommitUsageReport(ctx context.Context, subscriptionItemID string) error
+	// Returns how much credit was actually consumed (clamped at the remaining balance).
+	ConsumeFreeCredit(ctx context.Context, arg ConsumeFreeCreditParams) (int32, error)
+	// Returns how much credit was actually consumed (clamped at the remaining balance).
+	ConsumePurchasedCredit(ctx context.Context, arg ConsumePurchasedCreditParams) (int32, error)
+	CountSeats(ctx context.Context, organizationID int64) (int32, error)
+	// Units counted against the subscription allowance: those neither free nor purchased credit paid
+	// for. Overage and metered usage bill the same units (see ClaimUsageBatch).
+	CountUnitsBetween(ctx context.Context, arg CountUnitsBetweenParams) (int64, error)
+	CountUserCampaignRedemptions(ctx context.Context, arg CountUserCampaignRedemptionsParams) (int32, error)
+	CountUserReferralRedemptions(ctx context.Context, userExternalID string) (int32, error)
+	DeleteOrganizationMember(ctx context.Context, arg DeleteOrganizationMemberParams) (int64, error)
+	DeleteSpendingCap(ctx context.Context, arg DeleteSpendingCapParams) (int64, error)
+	// Brings the user's active grants back within their free credit balance after it went down,
+	// drawing the excess from the soonest-expiring grants first. Must run in the transaction that
+	// holds the free_credit row lock.
+	DrawDownCreditGrants(ctx context.Context, userExternalID string) error
+	EnsureUsageReport(ctx context.Context, arg EnsureUsageReportParams) error
+	GetBillingStatus(ctx context.Context, userExternalID string) (GetBillingStatusRow, error)
+	GetCampaignByCodeForUpdate(ctx context.Context, code string) (GetCampaignByCodeForUpdateRow, error)
+	GetCampaignRedemptionByKey(ctx context.Context, arg GetCampaignRedemptionByKeyParams) (GetCampaignRedemptionByKeyRow, error)
+	GetInvalidSubscription(ctx context.Context, id int64) (GetInvalidSubscriptionRow, error)
+	GetInvalidSubscriptionBySubscriptionID(ctx context.Context, stripeSubscriptionID sql.NullString) (GetInvalidSubscriptionBySubscriptionIDRow, error)
+	GetMemberOrganization(ctx context.Context, userExternalID string) (GetMemberOrganizationRow, error)
+	GetOrganizationByExternalID(ctx context.Context, externalID string) (GetOrganizationByExternalIDRow, error)
+	GetPendingReferralRedemption(ctx context.Context, userExternalID string) (GetPendingReferralRedemptionRow, error)
+	GetPlanAllowance(ctx context.Context, stripePlanID string) (GetPlanAllowanceRow, error)
+	GetPurchasedCredit(ctx context.Context, userExternalID string) (int64, error)
+	GetSpendingUnitByExternalID(ctx context.Context, externalID string) (GetSpendingUnitByExternalIDRow, error)
 	GetSubscriptionIDByUserExternalID(ctx context.Context, userExternalID string) (sql.NullString, error)
 	GetUserAccount(ctx context.Context, userExternalID string) (GetUserAccountRow, error)
-	InsertInvalidSubscription(ctx context.Context, arg InsertInvalidSubscriptionParams) error
+	GetUserExternalIDBySubscriptionID(ctx context.Context, stripeSubscriptionID sql.NullString) (string, error)
+	IncrementCampaignRedemptions(ctx context.Context, id int64) error
+	InsertCampaign(ctx context.Context, arg InsertCampaignParams) (int64, error)
+	InsertCampaignRedemption(ctx context.Context, arg InsertCampaignRedemptionParams) error
+	InsertCreditGrant(ctx context.Context, arg InsertCreditGrantParams) (int64, error)
+	InsertCreditPurchase(ctx context.Context, arg InsertCreditPurchaseParams) (interface{}, error)
+	// resolution is NULL for duplicates queued for manual review.
+	InsertInvalidSubscription(ctx context.Context, arg InsertInvalidSubscriptionParams) (int64, error)
+	InsertOrganization(ctx context.Context, arg InsertOrganizationParams) (int64, error)
 	InsertSpendingUnit(ctx context.Context, arg InsertSpendingUnitParams) (interface{}, error)
+	// Compensating entries reuse the original created_at so they net out in the same billing period.
+	InsertSpendingUnitRefund(ctx context.Context, arg InsertSpendingUnitRefundParams) (interface{}, error)
+	// Queues an event for one endpoint; an event already queued for it is left alone.
+	InsertWebhookDelivery(ctx context.Context, arg InsertWebhookDeliveryParams) (int64, error)
+	// The organization each hashed user identifier belongs to as a member.
+	ListBilledOrganizations(ctx context.Context, userExternalIds []string) ([]ListBilledOrganizationsRow, error)
+	// Unexpired grants whose expires_at has passed, optionally for a single user.
+	ListDueCreditGrants(ctx context.Context, arg ListDueCreditGrantsParams) ([]ListDueCreditGrantsRow, error)
+	// Keyset-paginated by id; resolved entries are only returned when include_resolved is set.
+	ListInvalidSubscriptions(ctx context.Context, arg ListInvalidSubscriptionsParams) ([]ListInvalidSubscriptionsRow, error)
+	ListOrganizationMembers(ctx context.Context, organizationID int64) ([]ListOrganizationMembersRow, error)
+	ListRecordedSpendingUnits(ctx context.Context, externalIds []string) ([]string, error)
+	ListSeats(ctx context.Context, organizationID int64) ([]ListSeatsRow, error)
+	ListSpendingCaps(ctx context.Context, organizationID int64) ([]ListSpendingCapsRow, error)
+	ListSubscribedUserAccounts(ctx context.Context) ([]ListSubscribedUserAccountsRow, error)
+	ListUninvoicedOveragePeriods(ctx context.Context, periodEnd int64) ([]ListUninvoicedOveragePeriodsRow, error)
+	// What subscription verification reads before calling Stripe, for many accounts at once.
+	// free_credit_current is false when GetFreeCredit would change the balance first: no row yet,
+	// expired credit, a pending monthly refill or an admin grant past its expiry.
+	ListVerifyAccounts(ctx context.Context, arg ListVerifyAccountsParams) ([]ListVerifyAccountsRow, error)
+	// Keyset-paginated by id, newest first; empty filters match everything.
+	ListWebhookDeliveries(ctx context.Context, arg ListWebhookDeliveriesParams) ([]ListWebhookDeliveriesRow, error)
+	// Serializes credit changes for a user within a transaction.
+	LockFreeCredit(ctx context.Context, userExternalID string) (int32, error)
+	// Serializes seat removals and capped spending for an organization within a transaction.
+	LockOrganization(ctx context.Context, id int64) (int64, error)
+	// Serializes batch claims for a subscription item within a transaction.
+	LockUsageReport(ctx context.Context, subscriptionItemID string) (LockUsageReportRow, error)
+	// Records that a claimed alert was queued for delivery.
+	MarkAllowanceAlertNotified(ctx context.Context, arg MarkAllowanceAlertNotifiedParams) error
+	// Events older than the last one applied are ignored (webhooks may arrive out of order).
+	MarkBillingDunning(ctx context.Context, arg MarkBillingDunningParams) (int64, error)
+	MarkCampaignRedemptionRewarded(ctx context.Context, arg MarkCampaignRedemptionRewardedParams) (int64, error)
+	// Returns what was left of the grant; no row when it already expired.
+	MarkCreditGrantExpired(ctx context.Context, arg MarkCreditGrantExpiredParams) (int32, error)
+	MarkOveragePeriodInvoiced(ctx context.Context, arg MarkOveragePeriodInvoicedParams) error
+	// Only while the claim holds: a delivery whose lease ended (and may be claimed again) is left alone.
+	RecordWebhookAttempt(ctx context.Context, arg RecordWebhookAttemptParams) (int64, error)
+	// Same policy as UpsertAndGetFreeCredit, applied to every row that is due.
+	RefreshFreeCredits(ctx context.Context, arg RefreshFreeCreditsParams) (int64, error)
+	// Only entries still queued for review can be resolved.
+	ResolveInvalidSubscription(ctx context.Context, arg ResolveInvalidSubscriptionParams) (int64, error)
+	RestoreFreeCredit(ctx context.Context, arg RestoreFreeCreditParams) error
+	// Moves the account's units from one batch to another, when a pending batch is sent again as a new one.
+	RetagUsageBatch(ctx context.Context, arg RetagUsageBatchParams) error
+	SetSpendingUnitCreditConsumed(ctx context.Context, arg SetSpendingUnitCreditConsumedParams) error
+	SetUsageReportPending(ctx context.Context, arg SetUsageReportPendingParams) error
+	SumUnitsByAPIKeyBetween(ctx context.Context, arg SumUnitsByAPIKeyBetweenParams) ([]SumUnitsByAPIKeyBetweenRow, error)
+	// Units per feature and, when label_key is given, per value of that label. allowance_units leaves out
+	// units paid for with credit, as CountUnitsBetween does.
+	SumUnitsByDimensionBetween(ctx context.Context, arg SumUnitsByDimensionBetweenParams) ([]SumUnitsByDimensionBetweenRow, error)
+	// Units each member spent from a pooled account; member_external_id is empty for units spent directly.
+	SumUnitsByMemberBetween(ctx context.Context, arg SumUnitsByMemberBetweenParams) ([]SumUnitsByMemberBetweenRow, error)
+	UnassignSeat(ctx context.Context, arg UnassignSeatParams) (int64, error)
+	// Leaves the organization's last seat alone. Run it after LockOrganization, so that concurrent
+	// removals count each other's.
+	UnassignSeatUnlessLast(ctx context.Context, arg UnassignSeatUnlessLastParams) (int64, error)
+	// Creates the row with the initial grant, or brings an existing row up to date first:
+	// a pending monthly refill tops the balance up to the refill amount (unexpired credit above it
+	// is kept), otherwise expired credit drops to 0.
+	// Credit still covered by what remains of active admin grants (credit_grant) survives both.
 	UpsertAndGetFreeCredit(ctx context.Context, arg UpsertAndGetFreeCreditParams) (int32, error)
+	// Changes the role of an existing member; users who belong to another organization are left as is.
+	UpsertOrganizationMember(ctx context.Context, arg UpsertOrganizationMemberParams) (int64, error)
+	UpsertOveragePeriod(ctx context.Context, arg UpsertOveragePeriodParams) error
+	UpsertPlanAllowance(ctx context.Context, arg UpsertPlanAllowanceParams) error
+	// Returns the referrer's code, creating it on first use. Rewards follow the current
+	// configuration; pending redemptions keep the referee units recorded when redeemed.
+	UpsertReferralCampaign(ctx context.Context, arg UpsertReferralCampaignParams) (string, error)
+	UpsertSpendingCap(ctx context.Context, arg UpsertSpendingCapParams) error
 	UpsertUserAccount(ctx context.Context, arg UpsertUserAccountParams) error
 }
 
@@ -7,10 +7,14 @@ package sqldb
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/lib/pq"
 )
 
 const countUnitsBetween = `-- name: CountUnitsBetween :one
-SELECT COALESCE(SUM(amount), 0) AS count
+SELECT COALESCE(SUM(amount - free_credit_consumed - purchased_credit_consumed), 0)::bigint AS count
 FROM spending_unit
 WHERE user_external_id = $1
   AND created_at >= $2
@@ -23,22 +27,79 @@ type CountUnitsBetweenParams struct {
 	CreatedAt_2    int64  `json:"created_at_2"`
 }
 
-func (q *Queries) CountUnitsBetween(ctx context.Context, arg CountUnitsBetweenParams) (interface{}, error) {
+// Units counted against the subscription allowance: those neither free nor purchased credit paid
+// for. Overage and metered usage bill the same units (see ClaimUsageBatch).
+func (q *Queries) CountUnitsBetween(ctx context.Context, arg CountUnitsBetweenParams) (int64, error) {
 	row := q.db.QueryRowContext(ctx, countUnitsBetween, arg.UserExternalID, arg.CreatedAt, arg.CreatedAt_2)
-	var count interface{}
+	var count int64
 	err := row.Scan(&count)
 	return count, err
 }
 
+const getSpendingUnitByExternalID = `-- name: GetSpendingUnitByExternalID :one
+SELECT
+  external_id,
+  user_external_id,
+  amount,
+  free_credit_consumed,
+  purchased_credit_consumed,
+  refund_of_external_id,
+  member_external_id,
+  api_key_id,
+  feature_key,
+  labels,
+  created_at
+FROM spending_unit
+WHERE external_id = $1
+FOR UPDATE
+`
+
+type GetSpendingUnitByExternalIDRow struct {
+	ExternalID              string          `json:"external_id"`
+	UserExternalID          string          `json:"user_external_id"`
+	Amount                  int32           `json:"amount"`
+	FreeCreditConsumed      int32           `json:"free_credit_consumed"`
+	PurchasedCreditConsumed int32           `json:"purchased_credit_consumed"`
+	RefundOfExternalID      sql.NullString  `json:"refund_of_external_id"`
+	MemberExternalID        sql.NullString  `json:"member_external_id"`
+	ApiKeyID                sql.NullString  `json:"api_key_id"`
+	FeatureKey              sql.NullString  `json:"feature_key"`
+	Labels                  json.RawMessage `json:"labels"`
+	CreatedAt               int64           `json:"created_at"`
+}
+
+func (q *Queries) GetSpendingUnitByExternalID(ctx context.Context, externalID string) (GetSpendingUnitByExternalIDRow, error) {
+	row := q.db.QueryRowContext(ctx, getSpendingUnitByExternalID, externalID)
+	var i GetSpendingUnitByExternalIDRow
+	err := row.Scan(
+		&i.ExternalID,
+		&i.UserExternalID,
+		&i.Amount,
+		&i.FreeCreditConsumed,
+		&i.PurchasedCreditConsumed,
+		&i.RefundOfExternalID,
+		&i.MemberExternalID,
+		&i.ApiKeyID,
+		&i.FeatureKey,
+		&i.Labels,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
 const insertSpendingUnit = `-- name: InsertSpendingUnit :one
 WITH ins AS (
     INSERT INTO spending_unit (
         external_id,
         user_external_id,
         amount,
+        member_external_id,
+        api_key_id,
+        feature_key,
+        labels,
         created_at,
         updated_at
-    ) VALUES ($1, $2, $3, $4, $4)
+    ) VALUES ($1, $2, $3, $5, $6, $7, $8, $4, $4)
     ON CONFLICT (external_id) DO NOTHING
     RETURNING 1::int AS inserted
 )
@@ -46,10 +107,14 @@ SELECT COALESCE(SUM(inserted), 0) AS inserted FROM ins
 `
 
 type InsertSpendingUnitParams struct {
-	ExternalID     string `json:"external_id"`
-	UserExternalID string `json:"user_external_id"`
-	Amount         int32  `json:"amount"`
-	CreatedAt      int64  `json:"created_at"`
+	ExternalID       string          `json:"external_id"`
+	UserExternalID   string          `json:"user_external_id"`
+	Amount           int32           `json:"amount"`
+	CreatedAt        int64           `json:"created_at"`
+	MemberExternalID sql.NullString  `json:"member_external_id"`
+	ApiKeyID         sql.NullString  `json:"api_key_id"`
+	FeatureKey       sql.NullString  `json:"feature_key"`
+	Labels           json.RawMessage `json:"labels"`
 }
 
 func (q *Queries) InsertSpendingUnit(ctx context.Context, arg InsertSpendingUnitParams) (interface{}, error) {
@@ -58,8 +123,270 @@ func (q *Queries) InsertSpendingUnit(ctx context.Context, arg InsertSpendingUnit
 		arg.UserExternalID,
 		arg.Amount,
 		arg.CreatedAt,
+		arg.MemberExternalID,
+		arg.ApiKeyID,
+		arg.FeatureKey,
+		arg.Labels,
+	)
+	var inserted interface{}
+	err := row.Scan(&inserted)
+	return inserted, err
+}
+
+const insertSpendingUnitRefund = `-- name: InsertSpendingUnitRefund :one
+WITH ins AS (
+    INSERT INTO spending_unit (
+        external_id,
+        user_external_id,
+        amount,
+        free_credit_consumed,
+        purchased_credit_consumed,
+        refund_of_external_id,
+        member_external_id,
+        api_key_id,
+        feature_key,
+        labels,
+        created_at,
+        updated_at
+    ) VALUES ($1, $2, $3, $4, $5, $6, $8, $9, $10, $11, $7, $7)
+    ON CONFLICT DO NOTHING
+    RETURNING 1::int AS inserted
+)
+SELECT COALESCE(SUM(inserted), 0) AS inserted FROM ins
+`
+
+type InsertSpendingUnitRefundParams struct {
+	ExternalID              string          `json:"external_id"`
+	UserExternalID          string          `json:"user_external_id"`
+	Amount                  int32           `json:"amount"`
+	FreeCreditConsumed      int32           `json:"free_credit_consumed"`
+	PurchasedCreditConsumed int32           `json:"purchased_credit_consumed"`
+	RefundOfExternalID      sql.NullString  `json:"refund_of_external_id"`
+	CreatedAt               int64           `json:"created_at"`
+	MemberExternalID        sql.NullString  `json:"member_external_id"`
+	ApiKeyID                sql.NullString  `json:"api_key_id"`
+	FeatureKey              sql.NullString  `json:"feature_key"`
+	Labels                  json.RawMessage `json:"labels"`
+}
+
+// Compensating entries reuse the original created_at so they net out in the same billing period.
+func (q *Queries) InsertSpendingUnitRefund(ctx context.Context, arg InsertSpendingUnitRefundParams) (interface{}, error) {
+	row := q.db.QueryRowContext(ctx, insertSpendingUnitRefund,
+		arg.ExternalID,
+		arg.UserExternalID,
+		arg.Amount,
+		arg.FreeCreditConsumed,
+		arg.PurchasedCreditConsumed,
+		arg.RefundOfExternalID,
+		arg.CreatedAt,
+		arg.MemberExternalID,
+		arg.ApiKeyID,
+		arg.FeatureKey,
+		arg.Labels,
 	)
 	var inserted interface{}
 	err := row.Scan(&inserted)
 	return inserted, err
 }
+
+const listRecordedSpendingUnits = `-- name: ListRecordedSpendingUnits :many
+SELECT external_id
+FROM spending_unit
+WHERE external_id = ANY($1::text[])
+`
+
+func (q *Queries) ListRecordedSpendingUnits(ctx context.Context, externalIds []string) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, listRecordedSpendingUnits, pq.Array(externalIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var external_id string
+		if err := rows.Scan(&external_id); err != nil {
+			return nil, err
+		}
+		items = append(items, external_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setSpendingUnitCreditConsumed = `-- name: SetSpendingUnitCreditConsumed :exec
+UPDATE spending_unit
+SET free_credit_consumed = $2,
+    purchased_credit_consumed = $3
+WHERE external_id = $1
+`
+
+type SetSpendingUnitCreditConsumedParams struct {
+	ExternalID              string `json:"external_id"`
+	FreeCreditConsumed      int32  `json:"free_credit_consumed"`
+	PurchasedCreditConsumed int32  `json:"purchased_credit_consumed"`
+}
+
+func (q *Queries) SetSpendingUnitCreditConsumed(ctx context.Context, arg SetSpendingUnitCreditConsumedParams) error {
+	_, err := q.db.ExecContext(ctx, setSpendingUnitCreditConsumed, arg.ExternalID, arg.FreeCreditConsumed, arg.PurchasedCreditConsumed)
+	return err
+}
+
+const sumUnitsByAPIKeyBetween = `-- name: SumUnitsByAPIKeyBetween :many
+SELECT
+  api_key_id::text AS api_key_id,
+  COALESCE(SUM(amount), 0)::bigint AS units
+FROM spending_unit
+WHERE user_external_id = $1
+  AND api_key_id IS NOT NULL
+  AND created_at >= $2
+  AND created_at <= $3
+GROUP BY api_key_id
+`
+
+type SumUnitsByAPIKeyBetweenParams struct {
+	UserExternalID string `json:"user_external_id"`
+	CreatedAt      int64  `json:"created_at"`
+	CreatedAt_2    int64  `json:"created_at_2"`
+}
+
+type SumUnitsByAPIKeyBetweenRow struct {
+	ApiKeyID string `json:"api_key_id"`
+	Units    int64  `json:"units"`
+}
+
+func (q *Queries) SumUnitsByAPIKeyBetween(ctx context.Context, arg SumUnitsByAPIKeyBetweenParams) ([]SumUnitsByAPIKeyBetweenRow, error) {
+	rows, err := q.db.QueryContext(ctx, sumUnitsByAPIKeyBetween, arg.UserExternalID, arg.CreatedAt, arg.CreatedAt_2)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SumUnitsByAPIKeyBetweenRow
+	for rows.Next() {
+		var i SumUnitsByAPIKeyBetweenRow
+		if err := rows.Scan(&i.ApiKeyID, &i.Units); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const sumUnitsByDimensionBetween = `-- name: SumUnitsByDimensionBetween :many
+SELECT
+  COALESCE(feature_key, '')::text AS feature_key,
+  COALESCE(labels ->> $1::text, '')::text AS label_value,
+  COALESCE(SUM(amount), 0)::bigint AS units,
+  COALESCE(SUM(amount - free_credit_consumed - purchased_credit_consumed), 0)::bigint AS allowance_units
+FROM spending_unit
+WHERE user_external_id = $2
+  AND created_at >= $3
+  AND created_at <= $4
+GROUP BY 1, 2
+ORDER BY 1, 2
+`
+
+type SumUnitsByDimensionBetweenParams struct {
+	LabelKey       string `json:"label_key"`
+	UserExternalID string `json:"user_external_id"`
+	PeriodStart    int64  `json:"period_start"`
+	PeriodEnd      int64  `json:"period_end"`
+}
+
+type SumUnitsByDimensionBetweenRow struct {
+	FeatureKey     string `json:"feature_key"`
+	LabelValue     string `json:"label_value"`
+	Units          int64  `json:"units"`
+	AllowanceUnits int64  `json:"allowance_units"`
+}
+
+// Units per feature and, when label_key is given, per value of that label. allowance_units leaves out
+// units paid for with credit, as CountUnitsBetween does.
+func (q *Queries) SumUnitsByDimensionBetween(ctx context.Context, arg SumUnitsByDimensionBetweenParams) ([]SumUnitsByDimensionBetweenRow, error) {
+	rows, err := q.db.QueryContext(ctx, sumUnitsByDimensionBetween,
+		arg.LabelKey,
+		arg.UserExternalID,
+		arg.PeriodStart,
+		arg.PeriodEnd,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SumUnitsByDimensionBetweenRow
+	for rows.Next() {
+		var i SumUnitsByDimensionBetweenRow
+		if err := rows.Scan(
+			&i.FeatureKey,
+			&i.LabelValue,
+			&i.Units,
+			&i.AllowanceUnits,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const sumUnitsByMemberBetween = `-- name: SumUnitsByMemberBetween :many
+SELECT
+  COALESCE(member_external_id, '')::text AS member_external_id,
+  COALESCE(SUM(amount), 0)::bigint AS units
+FROM spending_unit
+WHERE user_external_id = $1
+  AND created_at >= $2
+  AND created_at <= $3
+GROUP BY member_external_id
+`
+
+type SumUnitsByMemberBetweenParams struct {
+	UserExternalID string `json:"user_external_id"`
+	CreatedAt      int64  `json:"created_at"`
+	CreatedAt_2    int64  `json:"created_at_2"`
+}
+
+type SumUnitsByMemberBetweenRow struct {
+	MemberExternalID string `json:"member_external_id"`
+	Units            int64  `json:"units"`
+}
+
+// Units each member spent from a pooled account; member_external_id is empty for units spent directly.
+func (q *Queries) SumUnitsByMemberBetween(ctx context.Context, arg SumUnitsByMemberBetweenParams) ([]SumUnitsByMemberBetweenRow, error) {
+	rows, err := q.db.QueryContext(ctx, sumUnitsByMemberBetween, arg.UserExternalID, arg.CreatedAt, arg.CreatedAt_2)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SumUnitsByMemberBetweenRow
+	for rows.Next() {
+		var i SumUnitsByMemberBetweenRow
+		if err := rows.Scan(&i.MemberExternalID, &i.Units); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -8,6 +8,8 @@ package sqldb
 import (
 	"context"
 	"database/sql"
+
+	"github.com/lib/pq"
 )
 
 const getSubscriptionIDByUserExternalID = `-- name: GetSubscriptionIDByUserExternalID :one
@@ -58,6 +60,150 @@ func (q *Queries) GetUserAccount(ctx context.Context, userExternalID string) (Ge
 	return i, err
 }
 
+const getUserExternalIDBySubscriptionID = `-- name: GetUserExternalIDBySubscriptionID :one
+SELECT user_external_id
+FROM user_account
+WHERE stripe_subscription_id = $1
+ORDER BY id
+LIMIT 1
+`
+
+func (q *Queries) GetUserExternalIDBySubscriptionID(ctx context.Context, stripeSubscriptionID sql.NullString) (string, error) {
+	row := q.db.QueryRowContext(ctx, getUserExternalIDBySubscriptionID, stripeSubscriptionID)
+	var user_external_id string
+	err := row.Scan(&user_external_id)
+	return user_external_id, err
+}
+
+const listSubscribedUserAccounts = `-- name: ListSubscribedUserAccounts :many
+SELECT
+  user_external_id,
+  stripe_subscription_id,
+  stripe_customer_id
+FROM user_account
+WHERE stripe_subscription_id IS NOT NULL
+  AND stripe_subscription_id <> ''
+ORDER BY id
+`
+
+type ListSubscribedUserAccountsRow struct {
+	UserExternalID       string         `json:"user_external_id"`
+	StripeSubscriptionID sql.NullString `json:"stripe_subscription_id"`
+	StripeCustomerID     sql.NullString `json:"stripe_customer_id"`
+}
+
+func (q *Queries) ListSubscribedUserAccounts(ctx context.Context) ([]ListSubscribedUserAccountsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listSubscribedUserAccounts)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListSubscribedUserAccountsRow
+	for rows.Next() {
+		var i ListSubscribedUserAccountsRow
+		if err := rows.Scan(&i.UserExternalID, &i.StripeSubscriptionID, &i.StripeCustomerID); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listVerifyAccounts = `-- name: ListVerifyAccounts :many
+SELECT
+  ua.user_external_id,
+  ua.stripe_subscription_id,
+  ua.stripe_customer_id,
+  COALESCE(fc.credit, 0)::int AS free_credit,
+  (
+    fc.user_external_id IS NOT NULL
+    AND NOT ($1::int > 0 AND COALESCE(fc.refilled_at, 0) < $2::bigint)
+    AND NOT COALESCE(fc.expires_at <= $3::bigint, false)
+    AND NOT EXISTS (
+      SELECT 1 FROM credit_grant g
+      WHERE g.user_external_id = ua.user_external_id
+        AND g.expired_at IS NULL
+        AND g.amount > 0
+        AND g.expires_at <= $3::bigint
+    )
+  )::bool AS free_credit_current,
+  COALESCE(pc.credit, 0)::bigint AS purchased_credit,
+  COALESCE(bs.in_dunning, false)::bool AS in_dunning,
+  COALESCE(bs.attempt_count, 0)::int AS attempt_count,
+  bs.next_payment_attempt
+FROM user_account ua
+LEFT JOIN free_credit fc ON fc.user_external_id = ua.user_external_id
+LEFT JOIN purchased_credit pc ON pc.user_external_id = ua.user_external_id
+LEFT JOIN billing_status bs ON bs.user_external_id = ua.user_external_id
+WHERE ua.user_external_id = ANY($4::text[])
+`
+
+type ListVerifyAccountsParams struct {
+	Refill          int32    `json:"refill"`
+	PeriodStart     int64    `json:"period_start"`
+	Now             int64    `json:"now"`
+	UserExternalIds []string `json:"user_external_ids"`
+}
+
+type ListVerifyAccountsRow struct {
+	UserExternalID       string         `json:"user_external_id"`
+	StripeSubscriptionID sql.NullString `json:"stripe_subscription_id"`
+	StripeCustomerID     sql.NullString `json:"stripe_customer_id"`
+	FreeCredit           int32          `json:"free_credit"`
+	FreeCreditCurrent    bool           `json:"free_credit_current"`
+	PurchasedCredit      int64          `json:"purchased_credit"`
+	InDunning            bool           `json:"in_dunning"`
+	AttemptCount         int32          `json:"attempt_count"`
+	NextPaymentAttempt   sql.NullInt64  `json:"next_payment_attempt"`
+}
+
+// What subscription verification reads before calling Stripe, for many accounts at once.
+// free_credit_current is false when GetFreeCredit would change the balance first: no row yet,
+// expired credit, a pending monthly refill or an admin grant past its expiry.
+func (q *Queries) ListVerifyAccounts(ctx context.Context, arg ListVerifyAccountsParams) ([]ListVerifyAccountsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listVerifyAccounts,
+		arg.Refill,
+		arg.PeriodStart,
+		arg.Now,
+		pq.Array(arg.UserExternalIds),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListVerifyAccountsRow
+	for rows.Next() {
+		var i ListVerifyAccountsRow
+		if err := rows.Scan(
+			&i.UserExternalID,
+			&i.StripeSubscriptionID,
+			&i.StripeCustomerID,
+			&i.FreeCredit,
+			&i.FreeCreditCurrent,
+			&i.PurchasedCredit,
+			&i.InDunning,
+			&i.AttemptCount,
+			&i.NextPaymentAttempt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const upsertUserAccount = `-- name: UpsertUserAccount :exec
 INSERT INTO user_account (
   user_external_id,
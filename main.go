@@ -17,6 +17,9 @@ import (
 
 	bootstrap "github.com/tbeaudouin05/stripe-trellai/api/bootstrap"
 	cfg "github.com/tbeaudouin05/stripe-trellai/api/config"
+	"github.com/tbeaudouin05/stripe-trellai/api/database"
+	"github.com/tbeaudouin05/stripe-trellai/api/pubsub"
+	"github.com/tbeaudouin05/stripe-trellai/api/scheduler"
 	grpcserver "github.com/tbeaudouin05/stripe-trellai/api/services/stripe/grpc"
 	stripev1 "github.com/tbeaudouin05/stripe-trellai/internal/autogenerated/proto/stripe/v1"
 )
@@ -39,6 +42,19 @@ func grpcLoggingUnaryInterceptor(
 	return resp, err
 }
 
+// grpcLoggingStreamInterceptor logs streaming gRPC calls once they end.
+func grpcLoggingStreamInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	err := handler(srv, ss)
+	dur := time.Since(start)
+	if err != nil {
+		slog.Error("gRPC stream failed", slog.String("method", info.FullMethod), slog.String("duration", dur.String()), slog.String("error", err.Error()))
+	} else {
+		slog.Info("gRPC stream ended", slog.String("method", info.FullMethod), slog.String("duration", dur.String()))
+	}
+	return err
+}
+
 // httpLoggingMiddleware wraps an http.Handler to log requests.
 func httpLoggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -85,6 +101,32 @@ func main() {
 
 	slog.Info("server starting", slog.String("http_port", httpPort), slog.String("grpc_port", grpcPort))
 
+	// Background jobs (each is disabled when its interval is 0)
+	go scheduler.Every(context.Background(), "report-metered-usage", time.Duration(cfg.AppConfig.UsageReportIntervalSeconds)*time.Second, func() error {
+		_, err := stripeSvc.ReportMeteredUsage()
+		return err
+	})
+	go scheduler.Every(context.Background(), "invoice-overages", time.Duration(cfg.AppConfig.OverageInvoiceIntervalSeconds)*time.Second, func() error {
+		_, err := stripeSvc.InvoiceOverages()
+		return err
+	})
+	// outgoing webhooks and allowance alerts are only delivered when there is somewhere to send them
+	deliveryInterval := time.Duration(cfg.AppConfig.WebhookDeliveryIntervalSeconds) * time.Second
+	if len(cfg.SplitList(cfg.AppConfig.OutgoingWebhookURLs)) == 0 && cfg.AppConfig.AlertNotifier == "none" {
+		deliveryInterval = 0
+	}
+	go scheduler.Every(context.Background(), "deliver-webhooks", deliveryInterval, func() error {
+		_, err := stripeSvc.DeliverWebhooks()
+		return err
+	})
+	go scheduler.Every(context.Background(), "refresh-free-credits", time.Duration(cfg.AppConfig.FreeCreditRefreshIntervalSeconds)*time.Second, func() error {
+		_, err := stripeSvc.RefreshFreeCredits()
+		return err
+	})
+
+	// Fan entitlement changes out between instances
+	go pubsub.Listen(context.Background(), cfg.AppConfig.PubSubDatabaseURL, database.GetDB())
+
 	var wg sync.WaitGroup
 	wg.Add(2)
 
@@ -96,7 +138,7 @@ func main() {
 			slog.Error("failed to listen for gRPC", slog.String("error", err.Error()), slog.String("port", grpcPort))
 			os.Exit(1)
 		}
-		g := grpc.NewServer(grpc.UnaryInterceptor(grpcLoggingUnaryInterceptor))
+		g := grpc.NewServer(grpc.UnaryInterceptor(grpcLoggingUnaryInterceptor), grpc.StreamInterceptor(grpcLoggingStreamInterceptor))
 		stripev1.RegisterStripeServiceServer(g, srv)
 		slog.Info("gRPC server listening", slog.String("address", ":"+grpcPort))
 		if err := g.Serve(lis); err != nil {
@@ -177,6 +219,8 @@ func main() {
 			}
 			serveHTMLPage(w, "html/support.html")
 		}))
+		// Entitlement changes as Server-Sent Events (grpc-gateway doesn't stream in-process)
+		root.Handle("/api/entitlements/stream", grpcserver.EntitlementsSSEHandler(stripeSvc))
 		root.Handle("/api/receive-stripe-webhook", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			slog.Info("HTTP HandleWebhook (raw): start")
 			body, err := io.ReadAll(r.Body)
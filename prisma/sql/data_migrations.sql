@@ -0,0 +1,7 @@
+-- Data migrations applied after `prisma db push`. Every statement must be idempotent.
+
+-- free_credit rows created before monthly refills existed have no refilled_at, which would make
+-- every one of them refill at once. Count them as refilled for the current month instead.
+UPDATE free_credit
+SET refilled_at = (EXTRACT(EPOCH FROM date_trunc('month', now() AT TIME ZONE 'UTC')) * 1000)::bigint
+WHERE refilled_at IS NULL;
@@ -44,5 +44,19 @@ SELECT ensure_updated_at_trigger('user_account');
 SELECT ensure_updated_at_trigger('invalid_subscription');
 SELECT ensure_updated_at_trigger('free_credit');
 SELECT ensure_updated_at_trigger('spending_unit');
+SELECT ensure_updated_at_trigger('plan_allowance');
+SELECT ensure_updated_at_trigger('usage_report');
+SELECT ensure_updated_at_trigger('overage_period');
+SELECT ensure_updated_at_trigger('purchased_credit');
+SELECT ensure_updated_at_trigger('credit_purchase');
+SELECT ensure_updated_at_trigger('credit_grant');
+SELECT ensure_updated_at_trigger('campaign');
+SELECT ensure_updated_at_trigger('campaign_redemption');
+SELECT ensure_updated_at_trigger('billing_status');
+SELECT ensure_updated_at_trigger('organization');
+SELECT ensure_updated_at_trigger('organization_member');
+SELECT ensure_updated_at_trigger('spending_cap');
+SELECT ensure_updated_at_trigger('allowance_alert');
+SELECT ensure_updated_at_trigger('webhook_delivery');
 
 COMMIT;
@@ -0,0 +1,14 @@
+-- name: ClaimAllowanceAlert :execrows
+-- Claims an alert; a threshold already claimed for the period is left alone.
+INSERT INTO allowance_alert (user_external_id, period_start, threshold_percent, units, allowance)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (user_external_id, period_start, threshold_percent) DO NOTHING;
+
+-- name: MarkAllowanceAlertNotified :exec
+-- Records that a claimed alert was queued for delivery.
+UPDATE allowance_alert
+SET notified_at = $4
+WHERE user_external_id = $1
+  AND period_start = $2
+  AND threshold_percent = $3;
+
@@ -0,0 +1,39 @@
+-- name: MarkBillingDunning :execrows
+-- Events older than the last one applied are ignored (webhooks may arrive out of order).
+INSERT INTO billing_status (
+  user_external_id,
+  in_dunning,
+  stripe_invoice_id,
+  attempt_count,
+  next_payment_attempt,
+  last_event_at
+) VALUES ($1, true, $2, $3, $4, $5)
+ON CONFLICT (user_external_id) DO UPDATE SET
+  in_dunning = true,
+  stripe_invoice_id = EXCLUDED.stripe_invoice_id,
+  attempt_count = EXCLUDED.attempt_count,
+  next_payment_attempt = EXCLUDED.next_payment_attempt,
+  last_event_at = EXCLUDED.last_event_at
+WHERE billing_status.last_event_at <= EXCLUDED.last_event_at;
+
+-- name: ClearBillingDunning :execrows
+-- Only clears for the invoice that put the account in dunning when stripe_invoice_id is given.
+UPDATE billing_status
+SET
+  in_dunning = false,
+  attempt_count = 0,
+  next_payment_attempt = NULL,
+  last_event_at = sqlc.arg(last_event_at)
+WHERE user_external_id = sqlc.arg(user_external_id)
+  AND (sqlc.narg(stripe_invoice_id)::text IS NULL OR stripe_invoice_id = sqlc.narg(stripe_invoice_id)::text)
+  AND last_event_at <= sqlc.arg(last_event_at);
+
+-- name: GetBillingStatus :one
+SELECT
+  in_dunning,
+  stripe_invoice_id,
+  attempt_count,
+  next_payment_attempt,
+  last_event_at
+FROM billing_status
+WHERE user_external_id = $1;
@@ -0,0 +1,100 @@
+-- name: InsertCampaign :one
+INSERT INTO campaign (
+  code,
+  units,
+  max_redemptions,
+  per_user_limit,
+  starts_at,
+  ends_at
+) VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (code) DO NOTHING
+RETURNING id;
+
+-- name: UpsertReferralCampaign :one
+-- Returns the referrer's code, creating it on first use. Rewards follow the current
+-- configuration; pending redemptions keep the referee units recorded when redeemed.
+INSERT INTO campaign (
+  code,
+  units,
+  referrer_user_external_id,
+  referrer_units
+) VALUES ($1, $2, $3, $4)
+ON CONFLICT (referrer_user_external_id) DO UPDATE SET
+  units = EXCLUDED.units,
+  referrer_units = EXCLUDED.referrer_units
+RETURNING code;
+
+-- name: GetCampaignByCodeForUpdate :one
+SELECT
+  id,
+  code,
+  units,
+  referrer_user_external_id,
+  referrer_units,
+  max_redemptions,
+  per_user_limit,
+  redemptions,
+  starts_at,
+  ends_at
+FROM campaign
+WHERE code = $1
+FOR UPDATE;
+
+-- name: IncrementCampaignRedemptions :exec
+UPDATE campaign
+SET redemptions = redemptions + 1
+WHERE id = $1;
+
+-- name: GetCampaignRedemptionByKey :one
+SELECT
+  id,
+  campaign_id,
+  units,
+  rewarded_at
+FROM campaign_redemption
+WHERE user_external_id = $1
+  AND idempotency_key = $2;
+
+-- name: CountUserCampaignRedemptions :one
+SELECT COUNT(1)::int AS count
+FROM campaign_redemption
+WHERE campaign_id = $1
+  AND user_external_id = $2;
+
+-- name: CountUserReferralRedemptions :one
+SELECT COUNT(1)::int AS count
+FROM campaign_redemption r
+JOIN campaign c ON c.id = r.campaign_id
+WHERE r.user_external_id = $1
+  AND c.referrer_user_external_id IS NOT NULL;
+
+-- name: InsertCampaignRedemption :exec
+INSERT INTO campaign_redemption (
+  campaign_id,
+  user_external_id,
+  idempotency_key,
+  units,
+  rewarded_at
+) VALUES ($1, $2, $3, $4, $5);
+
+-- name: GetPendingReferralRedemption :one
+SELECT
+  r.id,
+  r.units,
+  c.code,
+  c.referrer_user_external_id,
+  c.referrer_units
+FROM campaign_redemption r
+JOIN campaign c ON c.id = r.campaign_id
+WHERE r.user_external_id = $1
+  AND r.rewarded_at IS NULL
+  AND c.referrer_user_external_id IS NOT NULL
+ORDER BY r.id
+LIMIT 1
+FOR UPDATE OF r;
+
+-- name: MarkCampaignRedemptionRewarded :execrows
+UPDATE campaign_redemption
+SET rewarded_at = $2
+WHERE id = $1
+  AND rewarded_at IS NULL;
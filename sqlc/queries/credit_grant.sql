@@ -0,0 +1,64 @@
+-- name: InsertCreditGrant :one
+INSERT INTO credit_grant (
+  user_external_id,
+  amount,
+  remaining,
+  reason,
+  actor,
+  expires_at
+) VALUES ($1, $2, GREATEST($2, 0), $3, $4, $5)
+RETURNING id;
+
+-- name: ListDueCreditGrants :many
+-- Unexpired grants whose expires_at has passed, optionally for a single user.
+SELECT
+  id,
+  user_external_id
+FROM credit_grant
+WHERE expired_at IS NULL
+  AND amount > 0
+  AND expires_at <= sqlc.arg(now)::bigint
+  AND (sqlc.narg(user_external_id)::text IS NULL OR user_external_id = sqlc.narg(user_external_id)::text)
+ORDER BY id;
+
+-- name: MarkCreditGrantExpired :one
+-- Returns what was left of the grant; no row when it already expired.
+WITH prev AS (
+  SELECT cur.id, cur.remaining
+  FROM credit_grant cur
+  WHERE cur.id = sqlc.arg(id)
+    AND cur.expired_at IS NULL
+  FOR UPDATE
+)
+UPDATE credit_grant
+SET expired_at = sqlc.arg(expired_at),
+    remaining = 0
+FROM prev
+WHERE credit_grant.id = prev.id
+RETURNING prev.remaining::int AS remaining;
+
+-- name: DrawDownCreditGrants :exec
+-- Brings the user's active grants back within their free credit balance after it went down,
+-- drawing the excess from the soonest-expiring grants first. Must run in the transaction that
+-- holds the free_credit row lock.
+WITH active AS (
+  SELECT
+    cg.id,
+    cg.remaining,
+    SUM(cg.remaining) OVER (ORDER BY cg.expires_at NULLS LAST, cg.id) - cg.remaining AS drawn_before
+  FROM credit_grant cg
+  WHERE cg.user_external_id = sqlc.arg(user_external_id)
+    AND cg.expired_at IS NULL
+    AND cg.remaining > 0
+), excess AS (
+  SELECT GREATEST(
+    COALESCE((SELECT SUM(remaining) FROM active), 0)
+      - COALESCE((SELECT fc.credit FROM free_credit fc WHERE fc.user_external_id = sqlc.arg(user_external_id)), 0),
+    0
+  ) AS n
+)
+UPDATE credit_grant g
+SET remaining = g.remaining - LEAST(a.remaining, excess.n - a.drawn_before)::int
+FROM active a, excess
+WHERE g.id = a.id
+  AND a.drawn_before < excess.n;
@@ -1,12 +1,110 @@
 -- name: UpsertAndGetFreeCredit :one
+-- Creates the row with the initial grant, or brings an existing row up to date first:
+-- a pending monthly refill tops the balance up to the refill amount (unexpired credit above it
+-- is kept), otherwise expired credit drops to 0.
+-- Credit still covered by what remains of active admin grants (credit_grant) survives both.
 INSERT INTO free_credit (
   user_external_id,
-  credit
-) VALUES ($1, $2)
-ON CONFLICT (user_external_id) DO UPDATE SET user_external_id = EXCLUDED.user_external_id
+  credit,
+  expires_at,
+  refilled_at
+) VALUES (sqlc.arg(user_external_id), sqlc.arg(credit), sqlc.narg(expires_at), sqlc.arg(period_start)::bigint)
+ON CONFLICT (user_external_id) DO UPDATE SET
+  credit = CASE
+    WHEN sqlc.arg(refill)::int > 0 AND COALESCE(free_credit.refilled_at, 0) < sqlc.arg(period_start)::bigint
+      AND (free_credit.expires_at IS NULL OR free_credit.expires_at > sqlc.arg(now)::bigint)
+      THEN GREATEST(free_credit.credit, sqlc.arg(refill)::int + LEAST(free_credit.credit, (
+        SELECT COALESCE(SUM(g.remaining), 0)::int FROM credit_grant g
+        WHERE g.user_external_id = free_credit.user_external_id AND g.remaining > 0 AND g.expired_at IS NULL
+      )))
+    WHEN sqlc.arg(refill)::int > 0 AND COALESCE(free_credit.refilled_at, 0) < sqlc.arg(period_start)::bigint
+      THEN sqlc.arg(refill)::int + LEAST(free_credit.credit, (
+        SELECT COALESCE(SUM(g.remaining), 0)::int FROM credit_grant g
+        WHERE g.user_external_id = free_credit.user_external_id AND g.remaining > 0 AND g.expired_at IS NULL
+      ))
+    WHEN free_credit.expires_at <= sqlc.arg(now)::bigint
+      THEN LEAST(free_credit.credit, (
+        SELECT COALESCE(SUM(g.remaining), 0)::int FROM credit_grant g
+        WHERE g.user_external_id = free_credit.user_external_id AND g.remaining > 0 AND g.expired_at IS NULL
+      ))
+    ELSE free_credit.credit
+  END,
+  expires_at = CASE
+    WHEN sqlc.arg(refill)::int > 0 AND COALESCE(free_credit.refilled_at, 0) < sqlc.arg(period_start)::bigint THEN NULL
+    WHEN free_credit.expires_at <= sqlc.arg(now)::bigint THEN NULL
+    ELSE free_credit.expires_at
+  END,
+  refilled_at = CASE
+    WHEN sqlc.arg(refill)::int > 0 AND COALESCE(free_credit.refilled_at, 0) < sqlc.arg(period_start)::bigint THEN sqlc.arg(period_start)::bigint
+    ELSE free_credit.refilled_at
+  END
 RETURNING credit;
 
--- name: ConsumeFreeCredit :exec
+-- name: ConsumeFreeCredit :one
+-- Returns how much credit was actually consumed (clamped at the remaining balance).
+WITH prev AS (
+  SELECT credit
+  FROM free_credit
+  WHERE user_external_id = sqlc.arg(user_external_id)
+  FOR UPDATE
+)
 UPDATE free_credit
-SET credit = credit - LEAST(credit, $2)
+SET credit = free_credit.credit - LEAST(prev.credit, sqlc.arg(amount)::int)
+FROM prev
+WHERE free_credit.user_external_id = sqlc.arg(user_external_id)
+RETURNING LEAST(prev.credit, sqlc.arg(amount)::int)::int AS consumed;
+
+-- name: RestoreFreeCredit :exec
+UPDATE free_credit
+SET credit = credit + $2
 WHERE user_external_id = $1;
+
+-- name: RefreshFreeCredits :execrows
+-- Same policy as UpsertAndGetFreeCredit, applied to every row that is due.
+UPDATE free_credit
+SET credit = CASE
+      WHEN sqlc.arg(refill)::int > 0 AND COALESCE(refilled_at, 0) < sqlc.arg(period_start)::bigint
+        AND (expires_at IS NULL OR expires_at > sqlc.arg(now)::bigint)
+        THEN GREATEST(credit, sqlc.arg(refill)::int + LEAST(credit, (
+          SELECT COALESCE(SUM(g.remaining), 0)::int FROM credit_grant g
+          WHERE g.user_external_id = free_credit.user_external_id AND g.remaining > 0 AND g.expired_at IS NULL
+        )))
+      WHEN sqlc.arg(refill)::int > 0 AND COALESCE(refilled_at, 0) < sqlc.arg(period_start)::bigint
+        THEN sqlc.arg(refill)::int + LEAST(credit, (
+          SELECT COALESCE(SUM(g.remaining), 0)::int FROM credit_grant g
+          WHERE g.user_external_id = free_credit.user_external_id AND g.remaining > 0 AND g.expired_at IS NULL
+        ))
+      ELSE LEAST(credit, (
+          SELECT COALESCE(SUM(g.remaining), 0)::int FROM credit_grant g
+          WHERE g.user_external_id = free_credit.user_external_id AND g.remaining > 0 AND g.expired_at IS NULL
+        ))
+    END,
+    expires_at = NULL,
+    refilled_at = CASE
+      WHEN sqlc.arg(refill)::int > 0 AND COALESCE(refilled_at, 0) < sqlc.arg(period_start)::bigint THEN sqlc.arg(period_start)::bigint
+      ELSE refilled_at
+    END
+WHERE (sqlc.arg(refill)::int > 0 AND COALESCE(refilled_at, 0) < sqlc.arg(period_start)::bigint)
+   OR expires_at <= sqlc.arg(now)::bigint;
+
+-- name: LockFreeCredit :one
+-- Serializes credit changes for a user within a transaction.
+SELECT credit
+FROM free_credit
+WHERE user_external_id = $1
+FOR UPDATE;
+
+-- name: AdjustFreeCredit :one
+-- Adds delta (possibly negative) to the balance, clamped at 0, and returns the new balance
+-- along with the change actually applied.
+WITH prev AS (
+  SELECT credit
+  FROM free_credit
+  WHERE user_external_id = sqlc.arg(user_external_id)
+  FOR UPDATE
+)
+UPDATE free_credit
+SET credit = GREATEST(prev.credit + sqlc.arg(delta)::int, 0)
+FROM prev
+WHERE free_credit.user_external_id = sqlc.arg(user_external_id)
+RETURNING free_credit.credit AS credit, (free_credit.credit - prev.credit)::int AS applied;
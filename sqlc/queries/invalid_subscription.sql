@@ -1,7 +1,43 @@
--- name: InsertInvalidSubscription :exec
+-- name: InsertInvalidSubscription :one
+-- resolution is NULL for duplicates queued for manual review.
 INSERT INTO invalid_subscription (
   user_external_id,
   stripe_subscription_id,
   stripe_plan_id,
-  stripe_customer_id
-) VALUES ($1, $2, $3, $4);
+  stripe_customer_id,
+  resolution,
+  resolved_by,
+  resolved_at
+) VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING id;
+
+-- name: GetInvalidSubscriptionBySubscriptionID :one
+SELECT id, user_external_id, stripe_subscription_id, stripe_plan_id, stripe_customer_id, resolution, resolved_by, resolved_at, created_at
+FROM invalid_subscription
+WHERE stripe_subscription_id = $1
+ORDER BY id
+LIMIT 1;
+
+-- name: GetInvalidSubscription :one
+SELECT id, user_external_id, stripe_subscription_id, stripe_plan_id, stripe_customer_id, resolution, resolved_by, resolved_at, created_at
+FROM invalid_subscription
+WHERE id = $1;
+
+-- name: ListInvalidSubscriptions :many
+-- Keyset-paginated by id; resolved entries are only returned when include_resolved is set.
+SELECT id, user_external_id, stripe_subscription_id, stripe_plan_id, stripe_customer_id, resolution, resolved_by, resolved_at, created_at
+FROM invalid_subscription
+WHERE id > sqlc.arg(after_id)::bigint
+  AND (sqlc.arg(include_resolved)::boolean OR resolution IS NULL)
+ORDER BY id
+LIMIT sqlc.arg(row_limit)::int;
+
+-- name: ResolveInvalidSubscription :execrows
+-- Only entries still queued for review can be resolved.
+UPDATE invalid_subscription
+SET
+  resolution = $2,
+  resolved_by = $3,
+  resolved_at = $4
+WHERE id = $1
+  AND resolution IS NULL;
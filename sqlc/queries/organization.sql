@@ -0,0 +1,102 @@
+-- name: InsertOrganization :one
+INSERT INTO organization (
+  external_id,
+  user_external_id
+) VALUES ($1, $2)
+ON CONFLICT DO NOTHING
+RETURNING id;
+
+-- name: GetOrganizationByExternalID :one
+SELECT id, external_id, user_external_id, created_at
+FROM organization
+WHERE external_id = $1;
+
+-- name: GetMemberOrganization :one
+SELECT
+  o.id,
+  m.role,
+  m.seat_assigned_at
+FROM organization_member m
+JOIN organization o ON o.id = m.organization_id
+WHERE m.user_external_id = $1;
+
+-- name: UpsertOrganizationMember :execrows
+-- Changes the role of an existing member; users who belong to another organization are left as is.
+INSERT INTO organization_member (
+  organization_id,
+  user_external_id,
+  role
+) VALUES ($1, $2, $3)
+ON CONFLICT (user_external_id) DO UPDATE SET
+  role = EXCLUDED.role
+WHERE organization_member.organization_id = EXCLUDED.organization_id;
+
+-- name: DeleteOrganizationMember :execrows
+DELETE FROM organization_member
+WHERE organization_id = $1
+  AND user_external_id = $2;
+
+-- name: ListOrganizationMembers :many
+SELECT user_external_id, role, created_at
+FROM organization_member
+WHERE organization_id = $1
+ORDER BY id;
+
+-- name: AssignSeat :execrows
+UPDATE organization_member
+SET seat_assigned_at = $3
+WHERE organization_id = $1
+  AND user_external_id = $2
+  AND seat_assigned_at IS NULL;
+
+-- name: UnassignSeat :execrows
+UPDATE organization_member
+SET seat_assigned_at = NULL
+WHERE organization_id = $1
+  AND user_external_id = $2
+  AND seat_assigned_at IS NOT NULL;
+
+-- name: LockOrganization :one
+-- Serializes seat removals and capped spending for an organization within a transaction.
+SELECT id
+FROM organization
+WHERE id = $1
+FOR UPDATE;
+
+-- name: UnassignSeatUnlessLast :execrows
+-- Leaves the organization's last seat alone. Run it after LockOrganization, so that concurrent
+-- removals count each other's.
+UPDATE organization_member
+SET seat_assigned_at = NULL
+WHERE organization_id = $1
+  AND user_external_id = $2
+  AND seat_assigned_at IS NOT NULL
+  AND (
+    SELECT COUNT(*)
+    FROM organization_member s
+    WHERE s.organization_id = $1
+      AND s.seat_assigned_at IS NOT NULL
+  ) > 1;
+
+-- name: CountSeats :one
+SELECT COUNT(*)::int AS seats
+FROM organization_member
+WHERE organization_id = $1
+  AND seat_assigned_at IS NOT NULL;
+
+-- name: ListSeats :many
+SELECT user_external_id, role, seat_assigned_at::bigint AS seat_assigned_at
+FROM organization_member
+WHERE organization_id = $1
+  AND seat_assigned_at IS NOT NULL
+ORDER BY seat_assigned_at, id;
+
+-- name: ListBilledOrganizations :many
+-- The organization each hashed user identifier belongs to as a member.
+SELECT
+  m.user_external_id AS billed_external_id,
+  o.external_id,
+  (m.seat_assigned_at IS NOT NULL)::boolean AS seated
+FROM organization_member m
+JOIN organization o ON o.id = m.organization_id
+WHERE m.user_external_id = ANY(sqlc.arg(user_external_ids)::text[]);
@@ -0,0 +1,42 @@
+-- name: UpsertOveragePeriod :exec
+INSERT INTO overage_period (
+  user_external_id,
+  stripe_subscription_id,
+  stripe_customer_id,
+  period_start,
+  period_end,
+  allowance,
+  overage_unit_amount,
+  currency,
+  overage_units
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+ON CONFLICT (stripe_subscription_id, period_start) DO UPDATE SET
+  period_end = EXCLUDED.period_end,
+  allowance = EXCLUDED.allowance,
+  overage_unit_amount = EXCLUDED.overage_unit_amount,
+  currency = EXCLUDED.currency,
+  overage_units = GREATEST(overage_period.overage_units, EXCLUDED.overage_units)
+WHERE overage_period.invoiced_at IS NULL;
+
+-- name: ListUninvoicedOveragePeriods :many
+SELECT
+  id,
+  user_external_id,
+  stripe_subscription_id,
+  stripe_customer_id,
+  period_start,
+  period_end,
+  allowance,
+  overage_unit_amount,
+  currency
+FROM overage_period
+WHERE invoiced_at IS NULL
+  AND period_end < $1
+ORDER BY period_end;
+
+-- name: MarkOveragePeriodInvoiced :exec
+UPDATE overage_period
+SET overage_units = $2,
+    stripe_invoice_item_id = $3,
+    invoiced_at = $4
+WHERE id = $1;
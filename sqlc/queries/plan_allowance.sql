@@ -0,0 +1,26 @@
+-- name: GetPlanAllowance :one
+SELECT
+  stripe_plan_id,
+  units_per_period,
+  overage_unit_amount,
+  trial_units_per_period,
+  feature_limits,
+  fetched_at
+FROM plan_allowance
+WHERE stripe_plan_id = $1;
+
+-- name: UpsertPlanAllowance :exec
+INSERT INTO plan_allowance (
+  stripe_plan_id,
+  units_per_period,
+  overage_unit_amount,
+  trial_units_per_period,
+  feature_limits,
+  fetched_at
+) VALUES ($1, $2, $3, $4, $6, $5)
+ON CONFLICT (stripe_plan_id) DO UPDATE SET
+  units_per_period = EXCLUDED.units_per_period,
+  overage_unit_amount = EXCLUDED.overage_unit_amount,
+  trial_units_per_period = EXCLUDED.trial_units_per_period,
+  feature_limits = EXCLUDED.feature_limits,
+  fetched_at = EXCLUDED.fetched_at;
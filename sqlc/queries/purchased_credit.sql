@@ -0,0 +1,40 @@
+-- name: GetPurchasedCredit :one
+SELECT credit
+FROM purchased_credit
+WHERE user_external_id = $1;
+
+-- name: AddPurchasedCredit :exec
+INSERT INTO purchased_credit (
+  user_external_id,
+  credit
+) VALUES ($1, $2)
+ON CONFLICT (user_external_id) DO UPDATE SET credit = purchased_credit.credit + EXCLUDED.credit;
+
+-- name: ConsumePurchasedCredit :one
+-- Returns how much credit was actually consumed (clamped at the remaining balance).
+WITH prev AS (
+  SELECT credit
+  FROM purchased_credit
+  WHERE user_external_id = sqlc.arg(user_external_id)
+  FOR UPDATE
+)
+UPDATE purchased_credit
+SET credit = purchased_credit.credit - LEAST(prev.credit, sqlc.arg(amount)::bigint)
+FROM prev
+WHERE purchased_credit.user_external_id = sqlc.arg(user_external_id)
+RETURNING LEAST(prev.credit, sqlc.arg(amount)::bigint)::int AS consumed;
+
+-- name: InsertCreditPurchase :one
+WITH ins AS (
+    INSERT INTO credit_purchase (
+        stripe_checkout_session_id,
+        user_external_id,
+        pack_id,
+        units,
+        amount,
+        currency
+    ) VALUES ($1, $2, $3, $4, $5, $6)
+    ON CONFLICT (stripe_checkout_session_id) DO NOTHING
+    RETURNING 1::int AS inserted
+)
+SELECT COALESCE(SUM(inserted), 0) AS inserted FROM ins;
@@ -0,0 +1,18 @@
+-- name: UpsertSpendingCap :exec
+INSERT INTO spending_cap (organization_id, subject_type, subject_id, cap_units, updated_by)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (organization_id, subject_type, subject_id) DO UPDATE
+SET cap_units = EXCLUDED.cap_units,
+    updated_by = EXCLUDED.updated_by;
+
+-- name: DeleteSpendingCap :execrows
+DELETE FROM spending_cap
+WHERE organization_id = $1
+  AND subject_type = $2
+  AND subject_id = $3;
+
+-- name: ListSpendingCaps :many
+SELECT subject_type, subject_id, cap_units, updated_by, updated_at
+FROM spending_cap
+WHERE organization_id = $1
+ORDER BY subject_type, subject_id;
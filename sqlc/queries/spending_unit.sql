@@ -1,5 +1,7 @@
 -- name: CountUnitsBetween :one
-SELECT COALESCE(SUM(amount), 0) AS count
+-- Units counted against the subscription allowance: those neither free nor purchased credit paid
+-- for. Overage and metered usage bill the same units (see ClaimUsageBatch).
+SELECT COALESCE(SUM(amount - free_credit_consumed - purchased_credit_consumed), 0)::bigint AS count
 FROM spending_unit
 WHERE user_external_id = $1
   AND created_at >= $2
@@ -11,10 +13,101 @@ WITH ins AS (
         external_id,
         user_external_id,
         amount,
+        member_external_id,
+        api_key_id,
+        feature_key,
+        labels,
         created_at,
         updated_at
-    ) VALUES ($1, $2, $3, $4, $4)
+    ) VALUES ($1, $2, $3, $5, $6, $7, $8, $4, $4)
     ON CONFLICT (external_id) DO NOTHING
     RETURNING 1::int AS inserted
 )
 SELECT COALESCE(SUM(inserted), 0) AS inserted FROM ins;
+
+-- name: SetSpendingUnitCreditConsumed :exec
+UPDATE spending_unit
+SET free_credit_consumed = $2,
+    purchased_credit_consumed = $3
+WHERE external_id = $1;
+
+-- name: GetSpendingUnitByExternalID :one
+SELECT
+  external_id,
+  user_external_id,
+  amount,
+  free_credit_consumed,
+  purchased_credit_consumed,
+  refund_of_external_id,
+  member_external_id,
+  api_key_id,
+  feature_key,
+  labels,
+  created_at
+FROM spending_unit
+WHERE external_id = $1
+FOR UPDATE;
+
+-- name: InsertSpendingUnitRefund :one
+-- Compensating entries reuse the original created_at so they net out in the same billing period.
+WITH ins AS (
+    INSERT INTO spending_unit (
+        external_id,
+        user_external_id,
+        amount,
+        free_credit_consumed,
+        purchased_credit_consumed,
+        refund_of_external_id,
+        member_external_id,
+        api_key_id,
+        feature_key,
+        labels,
+        created_at,
+        updated_at
+    ) VALUES ($1, $2, $3, $4, $5, $6, $8, $9, $10, $11, $7, $7)
+    ON CONFLICT DO NOTHING
+    RETURNING 1::int AS inserted
+)
+SELECT COALESCE(SUM(inserted), 0) AS inserted FROM ins;
+
+-- name: ListRecordedSpendingUnits :many
+SELECT external_id
+FROM spending_unit
+WHERE external_id = ANY(sqlc.arg(external_ids)::text[]);
+
+-- name: SumUnitsByMemberBetween :many
+-- Units each member spent from a pooled account; member_external_id is empty for units spent directly.
+SELECT
+  COALESCE(member_external_id, '')::text AS member_external_id,
+  COALESCE(SUM(amount), 0)::bigint AS units
+FROM spending_unit
+WHERE user_external_id = $1
+  AND created_at >= $2
+  AND created_at <= $3
+GROUP BY member_external_id;
+
+-- name: SumUnitsByAPIKeyBetween :many
+SELECT
+  api_key_id::text AS api_key_id,
+  COALESCE(SUM(amount), 0)::bigint AS units
+FROM spending_unit
+WHERE user_external_id = $1
+  AND api_key_id IS NOT NULL
+  AND created_at >= $2
+  AND created_at <= $3
+GROUP BY api_key_id;
+
+-- name: SumUnitsByDimensionBetween :many
+-- Units per feature and, when label_key is given, per value of that label. allowance_units leaves out
+-- units paid for with credit, as CountUnitsBetween does.
+SELECT
+  COALESCE(feature_key, '')::text AS feature_key,
+  COALESCE(labels ->> sqlc.arg(label_key)::text, '')::text AS label_value,
+  COALESCE(SUM(amount), 0)::bigint AS units,
+  COALESCE(SUM(amount - free_credit_consumed - purchased_credit_consumed), 0)::bigint AS allowance_units
+FROM spending_unit
+WHERE user_external_id = sqlc.arg(user_external_id)
+  AND created_at >= sqlc.arg(period_start)
+  AND created_at <= sqlc.arg(period_end)
+GROUP BY 1, 2
+ORDER BY 1, 2;
@@ -0,0 +1,60 @@
+-- name: EnsureUsageReport :exec
+INSERT INTO usage_report (
+  subscription_item_id,
+  user_external_id
+) VALUES ($1, $2)
+ON CONFLICT (subscription_item_id) DO NOTHING;
+
+-- name: LockUsageReport :one
+-- Serializes batch claims for a subscription item within a transaction.
+SELECT
+  batch_seq,
+  carried_units,
+  pending_batch,
+  pending_units,
+  pending_timestamp
+FROM usage_report
+WHERE subscription_item_id = $1
+FOR UPDATE;
+
+-- name: ClaimUsageBatch :one
+-- Tags the account's unreported units recorded since `since` (unix ms) with the batch and sums
+-- what they bill. Rows of transactions still in flight are not visible and join a later batch.
+WITH claimed AS (
+  UPDATE spending_unit
+  SET usage_batch = sqlc.arg(batch)
+  WHERE user_external_id = sqlc.arg(user_external_id)
+    AND usage_batch IS NULL
+    AND created_at >= sqlc.arg(since)::bigint
+  RETURNING amount - free_credit_consumed - purchased_credit_consumed AS units
+)
+SELECT
+  COUNT(*)::bigint AS claimed,
+  COALESCE(SUM(units), 0)::bigint AS units
+FROM claimed;
+
+-- name: RetagUsageBatch :exec
+-- Moves the account's units from one batch to another, when a pending batch is sent again as a new one.
+UPDATE spending_unit
+SET usage_batch = sqlc.arg(new_batch)
+WHERE user_external_id = sqlc.arg(user_external_id)
+  AND usage_batch = sqlc.arg(old_batch);
+
+-- name: SetUsageReportPending :exec
+UPDATE usage_report
+SET batch_seq = $2,
+    pending_batch = $3,
+    pending_units = $4,
+    pending_timestamp = $5
+WHERE subscription_item_id = $1;
+
+-- name: CommitUsageReport :exec
+-- A batch netting to zero or less is not sent; its deficit is carried into the next batch.
+UPDATE usage_report
+SET reported_units = reported_units + GREATEST(pending_units, 0),
+    carried_units = LEAST(pending_units, 0),
+    pending_batch = NULL,
+    pending_units = NULL,
+    pending_timestamp = NULL
+WHERE subscription_item_id = $1
+  AND pending_batch IS NOT NULL;
@@ -25,3 +25,51 @@ SELECT
   updated_at
 FROM user_account
 WHERE user_external_id = $1;
+
+-- name: ListSubscribedUserAccounts :many
+SELECT
+  user_external_id,
+  stripe_subscription_id,
+  stripe_customer_id
+FROM user_account
+WHERE stripe_subscription_id IS NOT NULL
+  AND stripe_subscription_id <> ''
+ORDER BY id;
+
+-- name: GetUserExternalIDBySubscriptionID :one
+SELECT user_external_id
+FROM user_account
+WHERE stripe_subscription_id = $1
+ORDER BY id
+LIMIT 1;
+
+-- name: ListVerifyAccounts :many
+-- What subscription verification reads before calling Stripe, for many accounts at once.
+-- free_credit_current is false when GetFreeCredit would change the balance first: no row yet,
+-- expired credit, a pending monthly refill or an admin grant past its expiry.
+SELECT
+  ua.user_external_id,
+  ua.stripe_subscription_id,
+  ua.stripe_customer_id,
+  COALESCE(fc.credit, 0)::int AS free_credit,
+  (
+    fc.user_external_id IS NOT NULL
+    AND NOT (sqlc.arg(refill)::int > 0 AND COALESCE(fc.refilled_at, 0) < sqlc.arg(period_start)::bigint)
+    AND NOT COALESCE(fc.expires_at <= sqlc.arg(now)::bigint, false)
+    AND NOT EXISTS (
+      SELECT 1 FROM credit_grant g
+      WHERE g.user_external_id = ua.user_external_id
+        AND g.expired_at IS NULL
+        AND g.amount > 0
+        AND g.expires_at <= sqlc.arg(now)::bigint
+    )
+  )::bool AS free_credit_current,
+  COALESCE(pc.credit, 0)::bigint AS purchased_credit,
+  COALESCE(bs.in_dunning, false)::bool AS in_dunning,
+  COALESCE(bs.attempt_count, 0)::int AS attempt_count,
+  bs.next_payment_attempt
+FROM user_account ua
+LEFT JOIN free_credit fc ON fc.user_external_id = ua.user_external_id
+LEFT JOIN purchased_credit pc ON pc.user_external_id = ua.user_external_id
+LEFT JOIN billing_status bs ON bs.user_external_id = ua.user_external_id
+WHERE ua.user_external_id = ANY(sqlc.arg(user_external_ids)::text[]);
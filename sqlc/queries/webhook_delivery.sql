@@ -0,0 +1,43 @@
+-- name: InsertWebhookDelivery :execrows
+-- Queues an event for one endpoint; an event already queued for it is left alone.
+INSERT INTO webhook_delivery (event_id, event_type, endpoint_url, payload, next_attempt_at)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (event_id, endpoint_url) DO NOTHING;
+
+-- name: ClaimDueWebhookDeliveries :many
+-- Leases pending deliveries due at now until lease_until, so concurrent workers skip them.
+UPDATE webhook_delivery
+SET next_attempt_at = sqlc.arg(lease_until)::bigint
+WHERE id IN (
+    SELECT d.id
+    FROM webhook_delivery d
+    WHERE d.status = 'pending'
+      AND d.next_attempt_at <= sqlc.arg(now)::bigint
+    ORDER BY d.next_attempt_at, d.id
+    LIMIT sqlc.arg(row_limit)::int
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, event_id, event_type, endpoint_url, payload, attempts;
+
+-- name: RecordWebhookAttempt :execrows
+-- Only while the claim holds: a delivery whose lease ended (and may be claimed again) is left alone.
+UPDATE webhook_delivery
+SET status = $2,
+    attempts = attempts + 1,
+    next_attempt_at = $3,
+    last_status_code = $4,
+    last_error = $5,
+    delivered_at = $6
+WHERE id = $1
+  AND status = 'pending'
+  AND next_attempt_at = sqlc.arg(lease_until)::bigint;
+
+-- name: ListWebhookDeliveries :many
+-- Keyset-paginated by id, newest first; empty filters match everything.
+SELECT id, event_id, event_type, endpoint_url, status, attempts, next_attempt_at, last_status_code, last_error, delivered_at, created_at
+FROM webhook_delivery
+WHERE (sqlc.arg(before_id)::bigint = 0 OR id < sqlc.arg(before_id)::bigint)
+  AND (sqlc.arg(status)::text = '' OR status = sqlc.arg(status)::text)
+  AND (sqlc.arg(event_type)::text = '' OR event_type = sqlc.arg(event_type)::text)
+ORDER BY id DESC
+LIMIT sqlc.arg(row_limit)::int;
@@ -18,6 +18,9 @@ CREATE TABLE "invalid_subscription" (
     "stripe_subscription_id" VARCHAR(255),
     "stripe_plan_id" VARCHAR(255),
     "stripe_customer_id" VARCHAR(255),
+    "resolution" VARCHAR(64),
+    "resolved_by" TEXT,
+    "resolved_at" BIGINT,
     "created_at" BIGINT NOT NULL DEFAULT ((extract(epoch from now()) * 1000))::bigint,
     "updated_at" BIGINT NOT NULL DEFAULT ((extract(epoch from now()) * 1000))::bigint,
 
@@ -29,6 +32,8 @@ CREATE TABLE "free_credit" (
     "id" BIGSERIAL NOT NULL,
     "user_external_id" TEXT NOT NULL,
     "credit" INTEGER NOT NULL,
+    "expires_at" BIGINT,
+    "refilled_at" BIGINT,
     "created_at" BIGINT NOT NULL DEFAULT ((extract(epoch from now()) * 1000))::bigint,
     "updated_at" BIGINT NOT NULL DEFAULT ((extract(epoch from now()) * 1000))::bigint,
 
@@ -41,30 +46,341 @@ CREATE TABLE "spending_unit" (
     "external_id" TEXT NOT NULL,
     "user_external_id" TEXT NOT NULL,
     "amount" INTEGER NOT NULL DEFAULT 1,
+    "free_credit_consumed" INTEGER NOT NULL DEFAULT 0,
+    "purchased_credit_consumed" INTEGER NOT NULL DEFAULT 0,
+    "refund_of_external_id" TEXT,
+    "member_external_id" TEXT,
+    "api_key_id" TEXT,
+    "feature_key" VARCHAR(64),
+    "labels" JSONB NOT NULL DEFAULT '{}',
+    "usage_batch" VARCHAR(255),
     "created_at" BIGINT NOT NULL DEFAULT ((extract(epoch from now()) * 1000))::bigint,
     "updated_at" BIGINT NOT NULL DEFAULT ((extract(epoch from now()) * 1000))::bigint,
 
     CONSTRAINT "spending_unit_pkey" PRIMARY KEY ("id")
 );
 
+-- CreateTable
+CREATE TABLE "plan_allowance" (
+    "id" BIGSERIAL NOT NULL,
+    "stripe_plan_id" VARCHAR(255) NOT NULL,
+    "units_per_period" BIGINT,
+    "overage_unit_amount" TEXT,
+    "trial_units_per_period" BIGINT,
+    "feature_limits" TEXT,
+    "fetched_at" BIGINT NOT NULL,
+    "created_at" BIGINT NOT NULL DEFAULT ((extract(epoch from now()) * 1000))::bigint,
+    "updated_at" BIGINT NOT NULL DEFAULT ((extract(epoch from now()) * 1000))::bigint,
+
+    CONSTRAINT "plan_allowance_pkey" PRIMARY KEY ("id")
+);
+
+-- CreateTable
+CREATE TABLE "usage_report" (
+    "id" BIGSERIAL NOT NULL,
+    "subscription_item_id" VARCHAR(255) NOT NULL,
+    "user_external_id" TEXT NOT NULL,
+    "reported_units" BIGINT NOT NULL DEFAULT 0,
+    "batch_seq" BIGINT NOT NULL DEFAULT 0,
+    "carried_units" BIGINT NOT NULL DEFAULT 0,
+    "pending_batch" VARCHAR(255),
+    "pending_units" BIGINT,
+    "pending_timestamp" BIGINT,
+    "created_at" BIGINT NOT NULL DEFAULT ((extract(epoch from now()) * 1000))::bigint,
+    "updated_at" BIGINT NOT NULL DEFAULT ((extract(epoch from now()) * 1000))::bigint,
+
+    CONSTRAINT "usage_report_pkey" PRIMARY KEY ("id")
+);
+
+-- CreateTable
+CREATE TABLE "overage_period" (
+    "id" BIGSERIAL NOT NULL,
+    "user_external_id" TEXT NOT NULL,
+    "stripe_subscription_id" VARCHAR(255) NOT NULL,
+    "stripe_customer_id" VARCHAR(255) NOT NULL,
+    "period_start" BIGINT NOT NULL,
+    "period_end" BIGINT NOT NULL,
+    "allowance" BIGINT NOT NULL,
+    "overage_unit_amount" TEXT NOT NULL,
+    "currency" VARCHAR(3) NOT NULL,
+    "overage_units" BIGINT NOT NULL DEFAULT 0,
+    "stripe_invoice_item_id" VARCHAR(255),
+    "invoiced_at" BIGINT,
+    "created_at" BIGINT NOT NULL DEFAULT ((extract(epoch from now()) * 1000))::bigint,
+    "updated_at" BIGINT NOT NULL DEFAULT ((extract(epoch from now()) * 1000))::bigint,
+
+    CONSTRAINT "overage_period_pkey" PRIMARY KEY ("id")
+);
+
+-- CreateTable
+CREATE TABLE "purchased_credit" (
+    "id" BIGSERIAL NOT NULL,
+    "user_external_id" TEXT NOT NULL,
+    "credit" BIGINT NOT NULL DEFAULT 0,
+    "created_at" BIGINT NOT NULL DEFAULT ((extract(epoch from now()) * 1000))::bigint,
+    "updated_at" BIGINT NOT NULL DEFAULT ((extract(epoch from now()) * 1000))::bigint,
+
+    CONSTRAINT "purchased_credit_pkey" PRIMARY KEY ("id")
+);
+
+-- CreateTable
+CREATE TABLE "credit_purchase" (
+    "id" BIGSERIAL NOT NULL,
+    "stripe_checkout_session_id" VARCHAR(255) NOT NULL,
+    "user_external_id" TEXT NOT NULL,
+    "pack_id" TEXT NOT NULL,
+    "units" BIGINT NOT NULL,
+    "amount" BIGINT NOT NULL,
+    "currency" VARCHAR(3) NOT NULL,
+    "created_at" BIGINT NOT NULL DEFAULT ((extract(epoch from now()) * 1000))::bigint,
+    "updated_at" BIGINT NOT NULL DEFAULT ((extract(epoch from now()) * 1000))::bigint,
+
+    CONSTRAINT "credit_purchase_pkey" PRIMARY KEY ("id")
+);
+
+-- CreateTable
+CREATE TABLE "credit_grant" (
+    "id" BIGSERIAL NOT NULL,
+    "user_external_id" TEXT NOT NULL,
+    "amount" INTEGER NOT NULL,
+    "remaining" INTEGER NOT NULL,
+    "reason" TEXT NOT NULL,
+    "actor" TEXT NOT NULL,
+    "expires_at" BIGINT,
+    "expired_at" BIGINT,
+    "created_at" BIGINT NOT NULL DEFAULT ((extract(epoch from now()) * 1000))::bigint,
+    "updated_at" BIGINT NOT NULL DEFAULT ((extract(epoch from now()) * 1000))::bigint,
+
+    CONSTRAINT "credit_grant_pkey" PRIMARY KEY ("id")
+);
+
+-- CreateTable
+CREATE TABLE "campaign" (
+    "id" BIGSERIAL NOT NULL,
+    "code" TEXT NOT NULL,
+    "units" INTEGER NOT NULL,
+    "referrer_user_external_id" TEXT,
+    "referrer_units" INTEGER NOT NULL DEFAULT 0,
+    "max_redemptions" INTEGER,
+    "per_user_limit" INTEGER NOT NULL DEFAULT 1,
+    "redemptions" INTEGER NOT NULL DEFAULT 0,
+    "starts_at" BIGINT,
+    "ends_at" BIGINT,
+    "created_at" BIGINT NOT NULL DEFAULT ((extract(epoch from now()) * 1000))::bigint,
+    "updated_at" BIGINT NOT NULL DEFAULT ((extract(epoch from now()) * 1000))::bigint,
+
+    CONSTRAINT "campaign_pkey" PRIMARY KEY ("id")
+);
+
+-- CreateTable
+CREATE TABLE "campaign_redemption" (
+    "id" BIGSERIAL NOT NULL,
+    "campaign_id" BIGINT NOT NULL,
+    "user_external_id" TEXT NOT NULL,
+    "idempotency_key" TEXT NOT NULL,
+    "units" INTEGER NOT NULL,
+    "rewarded_at" BIGINT,
+    "created_at" BIGINT NOT NULL DEFAULT ((extract(epoch from now()) * 1000))::bigint,
+    "updated_at" BIGINT NOT NULL DEFAULT ((extract(epoch from now()) * 1000))::bigint,
+
+    CONSTRAINT "campaign_redemption_pkey" PRIMARY KEY ("id")
+);
+
+-- CreateTable
+CREATE TABLE "billing_status" (
+    "id" BIGSERIAL NOT NULL,
+    "user_external_id" TEXT NOT NULL,
+    "in_dunning" BOOLEAN NOT NULL DEFAULT false,
+    "stripe_invoice_id" VARCHAR(255),
+    "attempt_count" INTEGER NOT NULL DEFAULT 0,
+    "next_payment_attempt" BIGINT,
+    "last_event_at" BIGINT NOT NULL,
+    "created_at" BIGINT NOT NULL DEFAULT ((extract(epoch from now()) * 1000))::bigint,
+    "updated_at" BIGINT NOT NULL DEFAULT ((extract(epoch from now()) * 1000))::bigint,
+
+    CONSTRAINT "billing_status_pkey" PRIMARY KEY ("id")
+);
+
+-- CreateTable
+CREATE TABLE "organization" (
+    "id" BIGSERIAL NOT NULL,
+    "external_id" TEXT NOT NULL,
+    "user_external_id" TEXT NOT NULL,
+    "created_at" BIGINT NOT NULL DEFAULT ((extract(epoch from now()) * 1000))::bigint,
+    "updated_at" BIGINT NOT NULL DEFAULT ((extract(epoch from now()) * 1000))::bigint,
+
+    CONSTRAINT "organization_pkey" PRIMARY KEY ("id")
+);
+
+-- CreateTable
+CREATE TABLE "organization_member" (
+    "id" BIGSERIAL NOT NULL,
+    "organization_id" BIGINT NOT NULL,
+    "user_external_id" TEXT NOT NULL,
+    "role" VARCHAR(32) NOT NULL,
+    "seat_assigned_at" BIGINT,
+    "created_at" BIGINT NOT NULL DEFAULT ((extract(epoch from now()) * 1000))::bigint,
+    "updated_at" BIGINT NOT NULL DEFAULT ((extract(epoch from now()) * 1000))::bigint,
+
+    CONSTRAINT "organization_member_pkey" PRIMARY KEY ("id")
+);
+
+-- CreateTable
+CREATE TABLE "spending_cap" (
+    "id" BIGSERIAL NOT NULL,
+    "organization_id" BIGINT NOT NULL,
+    "subject_type" VARCHAR(16) NOT NULL,
+    "subject_id" TEXT NOT NULL,
+    "cap_units" BIGINT NOT NULL,
+    "updated_by" TEXT NOT NULL,
+    "created_at" BIGINT NOT NULL DEFAULT ((extract(epoch from now()) * 1000))::bigint,
+    "updated_at" BIGINT NOT NULL DEFAULT ((extract(epoch from now()) * 1000))::bigint,
+
+    CONSTRAINT "spending_cap_pkey" PRIMARY KEY ("id")
+);
+
+-- CreateTable
+CREATE TABLE "allowance_alert" (
+    "id" BIGSERIAL NOT NULL,
+    "user_external_id" TEXT NOT NULL,
+    "period_start" BIGINT NOT NULL,
+    "threshold_percent" INTEGER NOT NULL,
+    "units" BIGINT NOT NULL,
+    "allowance" BIGINT NOT NULL,
+    "notified_at" BIGINT,
+    "created_at" BIGINT NOT NULL DEFAULT ((extract(epoch from now()) * 1000))::bigint,
+    "updated_at" BIGINT NOT NULL DEFAULT ((extract(epoch from now()) * 1000))::bigint,
+
+    CONSTRAINT "allowance_alert_pkey" PRIMARY KEY ("id")
+);
+
+-- CreateTable
+CREATE TABLE "webhook_delivery" (
+    "id" BIGSERIAL NOT NULL,
+    "event_id" VARCHAR(64) NOT NULL,
+    "event_type" VARCHAR(64) NOT NULL,
+    "endpoint_url" TEXT NOT NULL,
+    "payload" TEXT NOT NULL,
+    "status" VARCHAR(16) NOT NULL DEFAULT 'pending',
+    "attempts" INTEGER NOT NULL DEFAULT 0,
+    "next_attempt_at" BIGINT NOT NULL,
+    "last_status_code" INTEGER,
+    "last_error" TEXT,
+    "delivered_at" BIGINT,
+    "created_at" BIGINT NOT NULL DEFAULT ((extract(epoch from now()) * 1000))::bigint,
+    "updated_at" BIGINT NOT NULL DEFAULT ((extract(epoch from now()) * 1000))::bigint,
+
+    CONSTRAINT "webhook_delivery_pkey" PRIMARY KEY ("id")
+);
+
 -- CreateIndex
 CREATE UNIQUE INDEX "user_account_user_external_id_key" ON "user_account"("user_external_id");
 
 -- CreateIndex
 CREATE INDEX "invalid_subscription_user_external_id_idx" ON "invalid_subscription"("user_external_id");
 
+-- CreateIndex
+CREATE INDEX "invalid_subscription_stripe_subscription_id_idx" ON "invalid_subscription"("stripe_subscription_id");
+
 -- CreateIndex
 CREATE UNIQUE INDEX "free_credit_user_external_id_key" ON "free_credit"("user_external_id");
 
 -- CreateIndex
 CREATE UNIQUE INDEX "spending_unit_external_id_key" ON "spending_unit"("external_id");
 
+-- CreateIndex
+CREATE UNIQUE INDEX "spending_unit_refund_of_external_id_key" ON "spending_unit"("refund_of_external_id");
+
 -- CreateIndex
 CREATE INDEX "spending_unit_user_external_id_idx" ON "spending_unit"("user_external_id");
 
+-- CreateIndex
+CREATE INDEX "spending_unit_member_external_id_idx" ON "spending_unit"("member_external_id");
+
+-- CreateIndex
+CREATE INDEX "spending_unit_api_key_id_idx" ON "spending_unit"("api_key_id");
+
+-- CreateIndex
+CREATE INDEX "spending_unit_feature_key_idx" ON "spending_unit"("feature_key");
+
 -- CreateIndex
 CREATE INDEX "spending_unit_created_at_idx" ON "spending_unit"("created_at");
 
+-- CreateIndex
+CREATE INDEX "spending_unit_user_external_id_usage_batch_idx" ON "spending_unit"("user_external_id", "usage_batch");
+
+-- CreateIndex
+CREATE UNIQUE INDEX "plan_allowance_stripe_plan_id_key" ON "plan_allowance"("stripe_plan_id");
+
+-- CreateIndex
+CREATE UNIQUE INDEX "usage_report_subscription_item_id_key" ON "usage_report"("subscription_item_id");
+
+-- CreateIndex
+CREATE INDEX "usage_report_user_external_id_idx" ON "usage_report"("user_external_id");
+
+-- CreateIndex
+CREATE INDEX "overage_period_user_external_id_idx" ON "overage_period"("user_external_id");
+
+-- CreateIndex
+CREATE INDEX "overage_period_period_end_idx" ON "overage_period"("period_end");
+
+-- CreateIndex
+CREATE UNIQUE INDEX "overage_period_stripe_subscription_id_period_start_key" ON "overage_period"("stripe_subscription_id", "period_start");
+
+-- CreateIndex
+CREATE UNIQUE INDEX "purchased_credit_user_external_id_key" ON "purchased_credit"("user_external_id");
+
+-- CreateIndex
+CREATE UNIQUE INDEX "credit_purchase_stripe_checkout_session_id_key" ON "credit_purchase"("stripe_checkout_session_id");
+
+-- CreateIndex
+CREATE INDEX "credit_purchase_user_external_id_idx" ON "credit_purchase"("user_external_id");
+
+-- CreateIndex
+CREATE INDEX "credit_grant_user_external_id_idx" ON "credit_grant"("user_external_id");
+
+-- CreateIndex
+CREATE INDEX "credit_grant_expires_at_idx" ON "credit_grant"("expires_at");
+
+-- CreateIndex
+CREATE UNIQUE INDEX "campaign_code_key" ON "campaign"("code");
+
+-- CreateIndex
+CREATE UNIQUE INDEX "campaign_referrer_user_external_id_key" ON "campaign"("referrer_user_external_id");
+
+-- CreateIndex
+CREATE INDEX "campaign_redemption_campaign_id_idx" ON "campaign_redemption"("campaign_id");
+
+-- CreateIndex
+CREATE UNIQUE INDEX "campaign_redemption_user_external_id_idempotency_key_key" ON "campaign_redemption"("user_external_id", "idempotency_key");
+
+-- CreateIndex
+CREATE UNIQUE INDEX "billing_status_user_external_id_key" ON "billing_status"("user_external_id");
+
+-- CreateIndex
+CREATE UNIQUE INDEX "organization_external_id_key" ON "organization"("external_id");
+
+-- CreateIndex
+CREATE UNIQUE INDEX "organization_user_external_id_key" ON "organization"("user_external_id");
+
+-- CreateIndex
+CREATE UNIQUE INDEX "organization_member_user_external_id_key" ON "organization_member"("user_external_id");
+
+-- CreateIndex
+CREATE INDEX "organization_member_organization_id_idx" ON "organization_member"("organization_id");
+
+-- CreateIndex
+CREATE UNIQUE INDEX "spending_cap_organization_id_subject_type_subject_id_key" ON "spending_cap"("organization_id", "subject_type", "subject_id");
+
+-- CreateIndex
+CREATE UNIQUE INDEX "allowance_alert_user_external_id_period_start_threshold_percent_key" ON "allowance_alert"("user_external_id", "period_start", "threshold_percent");
+
+-- CreateIndex
+CREATE INDEX "webhook_delivery_status_next_attempt_at_idx" ON "webhook_delivery"("status", "next_attempt_at");
+
+-- CreateIndex
+CREATE UNIQUE INDEX "webhook_delivery_event_id_endpoint_url_key" ON "webhook_delivery"("event_id", "endpoint_url");
+
 -- AddForeignKey
 ALTER TABLE "invalid_subscription" ADD CONSTRAINT "invalid_subscription_user_external_id_fkey" FOREIGN KEY ("user_external_id") REFERENCES "user_account"("user_external_id") ON DELETE CASCADE ON UPDATE CASCADE;
 
@@ -74,3 +390,42 @@ ALTER TABLE "free_credit" ADD CONSTRAINT "free_credit_user_external_id_fkey" FOR
 -- AddForeignKey
 ALTER TABLE "spending_unit" ADD CONSTRAINT "spending_unit_user_external_id_fkey" FOREIGN KEY ("user_external_id") REFERENCES "user_account"("user_external_id") ON DELETE CASCADE ON UPDATE CASCADE;
 
+-- AddForeignKey
+ALTER TABLE "usage_report" ADD CONSTRAINT "usage_report_user_external_id_fkey" FOREIGN KEY ("user_external_id") REFERENCES "user_account"("user_external_id") ON DELETE CASCADE ON UPDATE CASCADE;
+
+-- AddForeignKey
+ALTER TABLE "overage_period" ADD CONSTRAINT "overage_period_user_external_id_fkey" FOREIGN KEY ("user_external_id") REFERENCES "user_account"("user_external_id") ON DELETE CASCADE ON UPDATE CASCADE;
+
+-- AddForeignKey
+ALTER TABLE "purchased_credit" ADD CONSTRAINT "purchased_credit_user_external_id_fkey" FOREIGN KEY ("user_external_id") REFERENCES "user_account"("user_external_id") ON DELETE CASCADE ON UPDATE CASCADE;
+
+-- AddForeignKey
+ALTER TABLE "credit_purchase" ADD CONSTRAINT "credit_purchase_user_external_id_fkey" FOREIGN KEY ("user_external_id") REFERENCES "user_account"("user_external_id") ON DELETE CASCADE ON UPDATE CASCADE;
+
+-- AddForeignKey
+ALTER TABLE "credit_grant" ADD CONSTRAINT "credit_grant_user_external_id_fkey" FOREIGN KEY ("user_external_id") REFERENCES "user_account"("user_external_id") ON DELETE CASCADE ON UPDATE CASCADE;
+
+-- AddForeignKey
+ALTER TABLE "campaign" ADD CONSTRAINT "campaign_referrer_user_external_id_fkey" FOREIGN KEY ("referrer_user_external_id") REFERENCES "user_account"("user_external_id") ON DELETE CASCADE ON UPDATE CASCADE;
+
+-- AddForeignKey
+ALTER TABLE "campaign_redemption" ADD CONSTRAINT "campaign_redemption_campaign_id_fkey" FOREIGN KEY ("campaign_id") REFERENCES "campaign"("id") ON DELETE CASCADE ON UPDATE CASCADE;
+
+-- AddForeignKey
+ALTER TABLE "campaign_redemption" ADD CONSTRAINT "campaign_redemption_user_external_id_fkey" FOREIGN KEY ("user_external_id") REFERENCES "user_account"("user_external_id") ON DELETE CASCADE ON UPDATE CASCADE;
+
+-- AddForeignKey
+ALTER TABLE "billing_status" ADD CONSTRAINT "billing_status_user_external_id_fkey" FOREIGN KEY ("user_external_id") REFERENCES "user_account"("user_external_id") ON DELETE CASCADE ON UPDATE CASCADE;
+
+-- AddForeignKey
+ALTER TABLE "organization" ADD CONSTRAINT "organization_user_external_id_fkey" FOREIGN KEY ("user_external_id") REFERENCES "user_account"("user_external_id") ON DELETE CASCADE ON UPDATE CASCADE;
+
+-- AddForeignKey
+ALTER TABLE "organization_member" ADD CONSTRAINT "organization_member_organization_id_fkey" FOREIGN KEY ("organization_id") REFERENCES "organization"("id") ON DELETE CASCADE ON UPDATE CASCADE;
+
+-- AddForeignKey
+ALTER TABLE "spending_cap" ADD CONSTRAINT "spending_cap_organization_id_fkey" FOREIGN KEY ("organization_id") REFERENCES "organization"("id") ON DELETE CASCADE ON UPDATE CASCADE;
+
+-- AddForeignKey
+ALTER TABLE "allowance_alert" ADD CONSTRAINT "allowance_alert_user_external_id_fkey" FOREIGN KEY ("user_external_id") REFERENCES "user_account"("user_external_id") ON DELETE CASCADE ON UPDATE CASCADE;
+